@@ -14,15 +14,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"golang.org/x/crypto/pkcs12"
 
+	"github.com/micromdm/micromdm/mdm/mdm"
 	"github.com/micromdm/micromdm/pkg/crypto/profileutil"
 	"github.com/micromdm/micromdm/platform/blueprint"
 	"github.com/micromdm/micromdm/platform/profile"
+	"github.com/micromdm/micromdm/platform/profile/builder"
 )
 
 type applyCommand struct {
@@ -57,12 +60,22 @@ func (cmd *applyCommand) Run(args []string) error {
 	switch strings.ToLower(args[0]) {
 	case "blueprints":
 		run = cmd.applyBlueprint
+	case "blueprint-to":
+		run = cmd.applyBlueprintTo
+	case "blueprint-release":
+		run = cmd.applyBlueprintRelease
+	case "blueprint-rollback":
+		run = cmd.applyBlueprintRollback
 	case "dep-tokens":
 		run = cmd.applyDEPTokens
+	case "signing-identity":
+		run = cmd.applySigningIdentity
 	case "dep-profiles":
 		run = cmd.applyDEPProfile
 	case "profiles":
 		run = cmd.applyProfile
+	case "profile-rollout":
+		run = cmd.applyProfileRollout
 	case "app":
 		run = cmd.applyApp
 	case "block":
@@ -71,6 +84,14 @@ func (cmd *applyCommand) Run(args []string) error {
 		run = cmd.applyUser
 	case "dep-autoassigner":
 		run = cmd.applyDEPAutoAssigner
+	case "groups":
+		run = cmd.applyGroups
+	case "devices":
+		run = cmd.applyDevices
+	case "device-attributes":
+		run = cmd.applyDeviceAttributes
+	case "device-lifecycle":
+		run = cmd.applyDeviceLifecycle
 	default:
 		cmd.Usage()
 		os.Exit(1)
@@ -85,21 +106,58 @@ Apply a resource.
 Valid resource types:
 
   * blueprints
+  * blueprint-to
+  * blueprint-release
+  * blueprint-rollback
   * profiles
+  * profile-rollout
   * users
   * dep-tokens
+  * signing-identity
   * dep-profiles
   * dep-autoassigner
   * app
   * block
+  * groups
+  * devices
+  * device-attributes
+  * device-lifecycle
 
 Examples:
   # Apply a Blueprint.
   mdmctl apply blueprints -f /path/to/blueprint.json
 
+  # Apply a Blueprint to all enrolled devices, listing the commands first.
+  mdmctl apply blueprint-to -name lab -all -dry-run
+
+  # Send DeviceConfigured to a device held in Setup Assistant.
+  mdmctl apply blueprint-release -udid 564D2F9C-5A4B-4F5E-8A2B-1C0F3D9E7A61
+
+  # Restore an earlier revision of a Blueprint.
+  mdmctl apply blueprint-rollback -name lab -revision 3
+
+  # Send the current version of a profile to a quarter of the devices
+  # which have an older version.
+  mdmctl apply profile-rollout -id com.example.wifi -percent 25
+
+  # Upload the identity the server signs enrollment and other profiles with.
+  mdmctl apply signing-identity -cert identity.p12 -password secret -sign-install-profiles
+
   # Apply a DEP Profile.
   mdmctl apply dep-profiles -f /path/to/dep-profile.json
 
+  # Create a group of devices.
+  mdmctl apply groups -name lab -serials C02ABCDEF,C02GHIJKL
+
+  # Pre-stage devices from purchasing data, showing the changes first.
+  mdmctl apply devices -f /path/to/import.csv -dry-run
+
+  # Set custom attributes on devices.
+  mdmctl apply device-attributes -f /path/to/attributes.csv
+
+  # Retire a device.
+  mdmctl apply device-lifecycle -serial C02ABCDEF -state retired
+
 `
 	fmt.Println(applyUsage)
 	return nil
@@ -124,6 +182,22 @@ func (cmd *applyCommand) applyBlueprint(args []string) error {
 			ProfileIdentifiers: []string{"com.example.my.profile"},
 			UserUUID:           []string{"your-admin-account-uuid"},
 			ApplyAt:            []string{"Enroll"},
+			Scope: &blueprint.Scope{
+				IncludeGroups: []string{"lab"},
+				Models:        []string{"MacBook Pro"},
+			},
+			Commands: []*mdm.Command{
+				{
+					RequestType: "Settings",
+					Settings: &mdm.Settings{Settings: []mdm.Setting{
+						{Item: "Bluetooth", Enabled: boolPtr(true)},
+					}},
+				},
+				{
+					RequestType:          "ScheduleOSUpdateScan",
+					ScheduleOSUpdateScan: &mdm.ScheduleOSUpdateScan{},
+				},
+			},
 		}
 
 		enc := json.NewEncoder(os.Stdout)
@@ -293,6 +367,10 @@ func (cmd *applyCommand) applyProfile(args []string) error {
 		flKeyPass     = flagset.String("password", "", "Password to encrypt/read the signing key(optional) or p12 file.")
 		flKeyPath     = flagset.String("private-key", "", "Path to the signing private key. Don't use with p12 file.")
 		flCertPath    = flagset.String("cert", "", "Path to the signing certificate or p12 file.")
+		flEncrypt     = flagset.Bool("encrypt", false, "Encrypt the PayloadContent to the identity certificate of each device.")
+		flLint        = flagset.Bool("lint", false, "Print the problems the server finds in the profile, without uploading it.")
+		flBuild       = flagset.Bool("build", false, "Build the profile on the server from the typed payloads in a JSON file, see -template.")
+		flTemplate    = flagset.String("template", "", "Print a JSON file to build a profile with -build from a payload type: "+strings.Join(builder.TemplateNames(), ", "))
 	)
 	flagset.Usage = func() {
 		fmt.Fprintf(os.Stderr, "%s\n",
@@ -300,13 +378,47 @@ func (cmd *applyCommand) applyProfile(args []string) error {
 			
 Uploaded profiles can also be specified in a blueprint, which will be applied on device enrollment.
 This command can also be used to replace the enrollment profile.
-Profiles can be signed before upload.
+Profiles can be signed before upload, or by the server with its signing identity,
+see mdmctl apply signing-identity. Signed templates are signed again by the server.
+
+Profiles can be templates, rendered for each device when they are installed:
+{{.UDID}}, {{.SerialNumber}}, {{.DeviceName}}, {{.Model}}, {{.ModelName}},
+{{.ProductName}}, {{.OSVersion}}, {{.IMEI}}, {{.MEID}} and custom device
+attributes such as {{attr "email"}}. Only profiles with one of these
+placeholders are templates. Templates are checked when uploaded.
+Passwords can be referenced as {{secret "wifi/corp"}} instead of being stored
+in the profile. The server resolves them only when it sends the profile, see
+micromdm secrets -h.
+
+Profiles are linted when uploaded, and rejected if devices would fail to
+install them, for example because of duplicate PayloadUUIDs or an invalid
+signature. Use -lint to see the errors and warnings without uploading.
+
+Profiles with secrets such as Wi-Fi passwords can be uploaded with -encrypt. The
+PayloadContent is then encrypted to the identity certificate of each device.
+
+Profiles can also be built by the server from typed payloads, instead of
+writing the mobileconfig by hand. Use -template to print a starting point,
+edit it, and upload it with -build. Set "sign" to true in the file to have
+the server sign the profile with its signing identity. The "content" of the
+certificate and pkcs12 templates is left empty: set it to the base64 encoded
+certificate or PKCS #12 file.
 
 Examples
 
   # Upload a mobileconfig
   mdmctl apply profiles -f /path/to/profile.mobileconfig
 
+  # Check a profile for errors and warnings
+  mdmctl apply profiles -f /path/to/profile.mobileconfig -lint
+
+  # Upload a profile which is encrypted for each device
+  mdmctl apply profiles -f /path/to/wifi.mobileconfig -encrypt
+
+  # Build a Wi-Fi profile from a template
+  mdmctl apply profiles -template wifi > wifi.json
+  mdmctl apply profiles -f wifi.json -build
+
   # Sign and upload
   mdmctl apply profiles -f /path/to/profile.mobileconfig -private-key key.pem -cert certificate.pem -password secret -sign
 
@@ -320,6 +432,9 @@ Examples
 	if err := flagset.Parse(args); err != nil {
 		return err
 	}
+	if *flTemplate != "" {
+		return printProfileTemplate(*flTemplate)
+	}
 	if *flProfilePath == "" {
 		flagset.Usage()
 		return errors.New("bad input: must provide -f parameter. use - for stdin")
@@ -328,6 +443,12 @@ Examples
 	if err != nil {
 		return err
 	}
+	if *flBuild {
+		if *flSign || *flLint {
+			return errors.New("bad input: -sign and -lint can't be used with -build")
+		}
+		return cmd.buildProfile(profileBytes, *flEncrypt)
+	}
 
 	if *flSign {
 		priv, pub, err := loadSigningKey(*flKeyPass, *flKeyPath, *flCertPath)
@@ -349,25 +470,82 @@ Examples
 		profileBytes = signed
 	}
 
+	ctx := context.Background()
+	if *flLint {
+		return cmd.lintProfile(ctx, profileBytes)
+	}
+
 	// TODO: to consider just uploading the Mobileconfig data (without a
 	// Profile struct and doing init server side)
 	var p profile.Profile
 	p.Mobileconfig = profileBytes
+	p.Encrypt = *flEncrypt
 	p.Identifier, err = p.Mobileconfig.GetPayloadIdentifier()
 	if err != nil {
 		return err
 	}
 
-	ctx := context.Background()
-	err = cmd.profilesvc.ApplyProfile(ctx, &p)
+	warnings, err := cmd.profilesvc.ApplyProfile(ctx, &p)
 	if err != nil {
 		return err
 	}
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, w)
+	}
 
 	fmt.Printf("applied profile id %s from %s\n", p.Identifier, *flProfilePath)
 	return nil
 }
 
+func printProfileTemplate(kind string) error {
+	req, err := builder.Template(kind)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(req)
+}
+
+func (cmd *applyCommand) buildProfile(data []byte, encrypt bool) error {
+	var req builder.Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errors.Wrap(err, "decode profile build request")
+	}
+	req.Encrypt = req.Encrypt || encrypt
+	p, err := cmd.buildersvc.BuildProfile(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("built profile id %s with %d payloads\n", p.Identifier, len(req.Payloads))
+	return nil
+}
+
+func (cmd *applyCommand) lintProfile(ctx context.Context, mc profile.Mobileconfig) error {
+	problems, err := cmd.profilesvc.LintProfile(ctx, mc)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Println("no problems found")
+		return nil
+	}
+	var errCount int
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "SEVERITY\tPAYLOAD\tMESSAGE\n")
+	for _, p := range problems {
+		if p.Severity == profile.LintError {
+			errCount++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Severity, p.Payload, p.Message)
+	}
+	w.Flush()
+	if errCount > 0 {
+		return errors.Errorf("profile has %d lint errors", errCount)
+	}
+	return nil
+}
+
 func readBytesFromPath(path string) ([]byte, error) {
 	if path == "-" {
 		return ioutil.ReadAll(os.Stdin)
@@ -377,3 +555,7 @@ func readBytesFromPath(path string) ([]byte, error) {
 	}
 	return ioutil.ReadFile(path)
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
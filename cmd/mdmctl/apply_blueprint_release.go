@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+func (cmd *applyCommand) applyBlueprintRelease(args []string) error {
+	flagset := flag.NewFlagSet("blueprint-release", flag.ExitOnError)
+	var (
+		flIdentifier = flagset.String("udid", "", "device UDID, optionally comma separated")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl apply blueprint-release [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flIdentifier == "" {
+		flagset.Usage()
+		return errors.New("bad input: device UDID must be provided")
+	}
+
+	releases, err := cmd.blueprintsvc.ReleaseDevices(context.Background(), splitList(*flIdentifier))
+	if err != nil {
+		return err
+	}
+	for _, r := range releases {
+		fmt.Printf("device %s %s: %s\n", r.UDID, r.State, r.Reason)
+	}
+	return nil
+}
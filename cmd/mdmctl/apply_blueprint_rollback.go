@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+func (cmd *applyCommand) applyBlueprintRollback(args []string) error {
+	flagset := flag.NewFlagSet("blueprint-rollback", flag.ExitOnError)
+	var (
+		flName     = flagset.String("name", "", "name of blueprint")
+		flRevision = flagset.Int("revision", 0, "revision to restore, see mdmctl get blueprint-revisions")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl apply blueprint-rollback [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flName == "" || *flRevision < 1 {
+		flagset.Usage()
+		return errors.New("bad input: blueprint name and revision must be provided")
+	}
+
+	rev, err := cmd.blueprintsvc.RollbackBlueprint(context.Background(), *flName, *flRevision)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored blueprint %s revision %d as revision %d\n", *flName, *flRevision, rev.Revision)
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/blueprint"
+)
+
+func (cmd *applyCommand) applyBlueprintTo(args []string) error {
+	flagset := flag.NewFlagSet("blueprint-to", flag.ExitOnError)
+	var (
+		flName       = flagset.String("name", "", "name of the blueprint to apply")
+		flIdentifier = flagset.String("udid", "", "device UDID, optionally comma separated")
+		flSerial     = flagset.String("serial", "", "device serial, optionally comma separated")
+		flAll        = flagset.Bool("all", false, "apply to all enrolled devices in the blueprint scope")
+		flDryRun     = flagset.Bool("dry-run", false, "list the commands which would be queued without queueing them")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl apply blueprint-to [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	if *flName == "" {
+		flagset.Usage()
+		return errors.New("bad input: -name must be provided")
+	}
+	if *flIdentifier == "" && *flSerial == "" && !*flAll {
+		flagset.Usage()
+		return errors.New("bad input: device UDID, Serial or -all must be provided")
+	}
+
+	opt := blueprint.ApplyToDevicesOption{
+		UDIDs:   splitList(*flIdentifier),
+		Serials: splitList(*flSerial),
+		All:     *flAll,
+		DryRun:  *flDryRun,
+	}
+	result, err := cmd.blueprintsvc.ApplyBlueprintToDevices(context.Background(), *flName, opt)
+	if result == nil {
+		return err
+	}
+
+	var applied, skipped, commands int
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "UDID\tSerialNumber\tRequestType\tTarget\tCommandUUID\n")
+	for _, d := range result.Devices {
+		if d.Skipped != "" {
+			skipped++
+			fmt.Fprintf(w, "%s\t%s\tskipped: %s\t\t\n", d.UDID, d.SerialNumber, d.Skipped)
+			continue
+		}
+		applied++
+		if len(d.Commands) == 0 {
+			fmt.Fprintf(w, "%s\t%s\tno commands\t\t\n", d.UDID, d.SerialNumber)
+		}
+		for _, c := range d.Commands {
+			commands++
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.UDID, d.SerialNumber, c.RequestType, c.Target, c.CommandUUID)
+		}
+	}
+	w.Flush()
+
+	if result.DryRun {
+		fmt.Printf("\ndry run: %d commands would be queued for %d devices, %d skipped\n", commands, applied, skipped)
+	} else {
+		fmt.Printf("\nqueued %d commands for %d devices, %d skipped\n", commands, applied, skipped)
+	}
+	return err
+}
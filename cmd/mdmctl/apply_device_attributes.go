@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/device"
+)
+
+func (cmd *applyCommand) applyDeviceAttributes(args []string) error {
+	flagset := flag.NewFlagSet("device-attributes", flag.ExitOnError)
+	var (
+		flPath       = flagset.String("f", "", "filename of the CSV file to apply, use - for stdin")
+		flClearEmpty = flagset.Bool("clear-empty", false, "remove attributes with an empty value instead of leaving them unchanged")
+	)
+	flagset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n",
+			`Set custom attributes and notes on devices from a CSV file.
+
+The first row of the file is a header. Devices are matched by the "udid" or
+"serial_number" column, the optional "notes" column replaces the notes of the
+device, and every other column is a custom attribute.
+
+Example CSV:
+
+  serial_number,owner,location,notes
+  C02ABCDEF,jane,nyc,loaner laptop
+  C02GHIJKL,john,sfo,
+
+`)
+		usageFor(flagset, "mdmctl apply device-attributes [flags]")()
+	}
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flPath == "" {
+		flagset.Usage()
+		return errors.New("bad input: must provide -f parameter. use - for stdin")
+	}
+	data, err := readBytesFromPath(*flPath)
+	if err != nil {
+		return err
+	}
+	updates, err := parseDeviceAttributesCSV(bytes.NewReader(data), *flClearEmpty)
+	if err != nil {
+		return err
+	}
+
+	result, err := cmd.devicesvc.ApplyDeviceAttributes(context.Background(), updates)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("updated %d device(s)\n", result.Updated)
+	if len(result.NotFound) > 0 {
+		fmt.Printf("devices not found: %s\n", strings.Join(result.NotFound, ", "))
+	}
+	return nil
+}
+
+func parseDeviceAttributesCSV(r io.Reader, clearEmpty bool) ([]device.DeviceAttributes, error) {
+	header, records, err := readCSV(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read device attributes CSV")
+	}
+	var hasID bool
+	for _, column := range header {
+		switch strings.ToLower(column) {
+		case "udid", "serial_number":
+			hasID = true
+		}
+	}
+	if !hasID {
+		return nil, errors.New("device attributes CSV must have a udid or serial_number column")
+	}
+
+	var updates []device.DeviceAttributes
+	for line, record := range records {
+		var u device.DeviceAttributes
+		for i, value := range record {
+			switch column := header[i]; strings.ToLower(column) {
+			case "udid":
+				u.UDID = value
+			case "serial_number":
+				u.SerialNumber = value
+			case "notes":
+				if value != "" || clearEmpty {
+					notes := value
+					u.Notes = &notes
+				}
+			default:
+				if value == "" && !clearEmpty {
+					continue
+				}
+				if u.Attributes == nil {
+					u.Attributes = make(map[string]string)
+				}
+				u.Attributes[column] = value
+			}
+		}
+		if u.UDID == "" && u.SerialNumber == "" {
+			return nil, errors.Errorf("line %d: missing udid or serial_number", line+2)
+		}
+		updates = append(updates, u)
+	}
+	return updates, nil
+}
+
+// readCSV reads a CSV file with a header row. Surrounding whitespace is
+// trimmed from the header and all values.
+func readCSV(r io.Reader) (header []string, records [][]string, err error) {
+	records, err = csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) < 1 {
+		return nil, nil, errors.New("CSV file is empty")
+	}
+	for _, record := range records {
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+	}
+	return records[0], records[1:], nil
+}
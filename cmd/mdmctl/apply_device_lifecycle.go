@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/device"
+)
+
+func (cmd *applyCommand) applyDeviceLifecycle(args []string) error {
+	flagset := flag.NewFlagSet("device-lifecycle", flag.ExitOnError)
+	var (
+		flIdentifier = flagset.String("udid", "", "device UDID, optionally comma separated")
+		flSerial     = flagset.String("serial", "", "device serial, optionally comma separated")
+		flState      = flagset.String("state", "", "lifecycle state (active, stale, missing, retired, wiped)")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl apply device-lifecycle [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	if *flIdentifier == "" && *flSerial == "" {
+		return errors.New("bad input: device UDID or Serial must be provided")
+	}
+	if *flState == "" {
+		return errors.New("bad input: -state must be provided")
+	}
+
+	opts := device.SetLifecycleOptions{
+		UDIDs:   splitList(*flIdentifier),
+		Serials: splitList(*flSerial),
+		State:   device.LifecycleState(*flState),
+	}
+
+	ctx := context.Background()
+	if err := cmd.devicesvc.SetDeviceLifecycle(ctx, opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("set lifecycle of device(s) %s to %s\n", strings.Join(append(opts.UDIDs, opts.Serials...), ", "), opts.State)
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/device"
+)
+
+func (cmd *applyCommand) applyDevices(args []string) error {
+	flagset := flag.NewFlagSet("devices", flag.ExitOnError)
+	var (
+		flPath   = flagset.String("f", "", "filename of the CSV file to import, use - for stdin")
+		flDryRun = flagset.Bool("dry-run", false, "show what would change without saving the devices")
+	)
+	flagset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n",
+			`Pre-stage devices by serial number before they enroll.
+
+The first row of the file is a header. The "serial_number" column is
+required, the optional "asset_tag", "description" and "notes" columns set
+the matching device fields, and every other column is a custom attribute.
+Unknown serial numbers create placeholder devices, which are merged with
+the device when it enrolls. Empty cells leave the device unchanged.
+
+Example CSV:
+
+  serial_number,asset_tag,description,assigned_user
+  C02ABCDEF,A1001,MacBook Pro 13",jane
+  C02GHIJKL,A1002,MacBook Pro 13",john
+
+`)
+		usageFor(flagset, "mdmctl apply devices [flags]")()
+	}
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flPath == "" {
+		flagset.Usage()
+		return errors.New("bad input: must provide -f parameter. use - for stdin")
+	}
+	data, err := readBytesFromPath(*flPath)
+	if err != nil {
+		return err
+	}
+	devices, err := parseImportDevicesCSV(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	result, err := cmd.devicesvc.ImportDevices(context.Background(), devices, *flDryRun)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "SerialNumber\tAction\tChanges\n")
+	for _, d := range result.Devices {
+		counts[d.Action]++
+		var changes []string
+		for _, c := range d.Changes {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.SerialNumber, d.Action, strings.Join(changes, ", "))
+	}
+	w.Flush()
+
+	created, updated, unchanged := counts[device.ImportCreate], counts[device.ImportUpdate], counts[device.ImportUnchanged]
+	if result.DryRun {
+		fmt.Printf("\ndry run: %d to create, %d to update, %d unchanged\n", created, updated, unchanged)
+	} else {
+		fmt.Printf("\n%d created, %d updated, %d unchanged\n", created, updated, unchanged)
+	}
+	return nil
+}
+
+func parseImportDevicesCSV(r io.Reader) ([]device.ImportDevice, error) {
+	header, records, err := readCSV(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read device import CSV")
+	}
+	var hasSerial bool
+	for _, column := range header {
+		if strings.ToLower(column) == "serial_number" {
+			hasSerial = true
+		}
+	}
+	if !hasSerial {
+		return nil, errors.New("device import CSV must have a serial_number column")
+	}
+
+	var devices []device.ImportDevice
+	for line, record := range records {
+		var d device.ImportDevice
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			switch column := header[i]; strings.ToLower(column) {
+			case "serial_number":
+				d.SerialNumber = value
+			case "asset_tag":
+				d.AssetTag = value
+			case "description":
+				d.Description = value
+			case "notes":
+				notes := value
+				d.Notes = &notes
+			default:
+				if d.Attributes == nil {
+					d.Attributes = make(map[string]string)
+				}
+				d.Attributes[column] = value
+			}
+		}
+		if d.SerialNumber == "" {
+			return nil, errors.Errorf("line %d: missing serial_number", line+2)
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/group"
+)
+
+func (cmd *applyCommand) applyGroups(args []string) error {
+	flagset := flag.NewFlagSet("groups", flag.ExitOnError)
+	var (
+		flGroupPath = flagset.String("f", "", "filename of group JSON to apply")
+		flTemplate  = flagset.Bool("template", false, "print a new group template")
+		flName      = flagset.String("name", "", "name of the group")
+		flUDIDs     = flagset.String("udids", "", "comma separated list of device UDIDs")
+		flSerials   = flagset.String("serials", "", "comma separated list of device serial numbers")
+		flQuery     = flagset.String("query", "", "query which selects the members of a dynamic group")
+		flAdd       = flagset.Bool("add", false, "add the devices to the existing members of the group instead of replacing them")
+	)
+	flagset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n",
+			`Create or update a group of devices.
+
+The members of a static group are listed by UDID or serial number.
+The members of a dynamic group are the devices matching its query, which
+compares device fields, for example model_name, os_version, enrolled or
+dep_profile_status, or custom attributes such as attributes.owner,
+using =, !=, <, <=, >, >= or CONTAINS, combined with AND, OR, NOT and
+parentheses.
+
+Examples
+
+  # Create a group, or replace the members of an existing group
+  mdmctl apply groups -name lab -serials C02ABCDEF,C02GHIJKL
+
+  # Add devices to an existing group
+  mdmctl apply groups -name lab -udids 564D2F9C-5A4B-4F5E-8A2B-1C0F3D9E7A61 -add
+
+  # Create a dynamic group
+  mdmctl apply groups -name outdated-macbooks -query 'model_name = "MacBook Pro" AND os_version < 10.15'
+
+  # Apply a group from a JSON file
+  mdmctl apply groups -f /path/to/group.json
+
+`)
+		usageFor(flagset, "mdmctl apply groups [flags]")()
+	}
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	if *flTemplate {
+		newGroup := &group.Group{
+			Name:    "exampleName",
+			UDIDs:   []string{"564D2F9C-5A4B-4F5E-8A2B-1C0F3D9E7A61"},
+			Serials: []string{"C02ABCDEF"},
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(newGroup); err != nil {
+			return errors.Wrap(err, "encode group template")
+		}
+		return nil
+	}
+
+	var g group.Group
+	switch {
+	case *flGroupPath != "":
+		jsonBytes, err := readBytesFromPath(*flGroupPath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(jsonBytes, &g); err != nil {
+			return errors.Wrap(err, "unmarshal group JSON")
+		}
+	case *flName != "":
+		g = group.Group{
+			Name:    *flName,
+			UDIDs:   splitList(*flUDIDs),
+			Serials: splitList(*flSerials),
+			Query:   *flQuery,
+		}
+	default:
+		flagset.Usage()
+		return errors.New("bad input: must provide -f, -name or -template flag")
+	}
+
+	ctx := context.Background()
+	if *flAdd {
+		members := group.Members{UDIDs: g.UDIDs, Serials: g.Serials}
+		if err := cmd.groupsvc.AddGroupMembers(ctx, g.Name, members); err != nil {
+			return err
+		}
+		fmt.Printf("added %d device(s) to group %s\n", len(g.UDIDs)+len(g.Serials), g.Name)
+		return nil
+	}
+
+	if err := cmd.groupsvc.ApplyGroup(ctx, &g); err != nil {
+		return err
+	}
+	fmt.Println("applied group", g.Name)
+	return nil
+}
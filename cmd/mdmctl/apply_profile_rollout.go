@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+func (cmd *applyCommand) applyProfileRollout(args []string) error {
+	flagset := flag.NewFlagSet("profile-rollout", flag.ExitOnError)
+	var (
+		flIdentifier = flagset.String("id", "", "profile Identifier")
+		flPercent    = flagset.Int("percent", 100, "percent of the devices with the profile which should have the current version")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl apply profile-rollout [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flIdentifier == "" {
+		flagset.Usage()
+		return errors.New("bad input: profile id must be provided")
+	}
+
+	rollout, err := cmd.profilesvc.RolloutProfile(context.Background(), profile.RolloutRequest{
+		Identifier: *flIdentifier,
+		Percent:    *flPercent,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("profile %s version %d: %d of %d devices were sent the current version\n",
+		rollout.Identifier, rollout.Version, rollout.Current, rollout.Devices)
+	if len(rollout.Queued) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "QUEUED UDID\n")
+	for _, udid := range rollout.Queued {
+		fmt.Fprintf(w, "%s\n", udid)
+	}
+	return w.Flush()
+}
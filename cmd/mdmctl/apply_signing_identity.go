@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/config"
+)
+
+func (cmd *applyCommand) applySigningIdentity(args []string) error {
+	flagset := flag.NewFlagSet("signing-identity", flag.ExitOnError)
+	var (
+		flKeyPass            = flagset.String("password", "", "Password to read the signing key or p12 file.")
+		flKeyPath            = flagset.String("private-key", "", "Path to the signing private key. Don't use with p12 file.")
+		flCertPath           = flagset.String("cert", "", "Path to the signing certificate and its chain, or p12 file.")
+		flSignInstallProfile = flagset.Bool("sign-install-profiles", false, "Also sign the profiles of every InstallProfile command.")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl apply signing-identity [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flCertPath == "" {
+		flagset.Usage()
+		return errors.New("bad input: must provide -cert parameter")
+	}
+
+	priv, cert, err := loadSigningKey(*flKeyPass, *flKeyPath, *flCertPath)
+	if err != nil {
+		return errors.Wrap(err, "loading signing certificate and private key")
+	}
+	rsaKey, ok := priv.(*rsa.PrivateKey)
+	if !ok {
+		return errors.New("signing key must be an RSA key")
+	}
+
+	// the PEM certificate file may include the intermediate certificates.
+	certs := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if filepath.Ext(*flCertPath) != ".p12" {
+		if certs, err = ioutil.ReadFile(*flCertPath); err != nil {
+			return err
+		}
+	}
+
+	err = cmd.configsvc.SaveProfileSigningIdentity(context.Background(), &config.ProfileSigningIdentity{
+		Certificates:       certs,
+		PrivateKey:         pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+		SignInstallProfile: *flSignInstallProfile,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("uploaded profile signing identity %s\n", cert.Subject.CommonName)
+	return nil
+}
@@ -8,8 +8,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/micromdm/micromdm/pkg/crypto"
@@ -54,6 +57,8 @@ func (cmd *getCommand) Run(args []string) error {
 	switch strings.ToLower(args[0]) {
 	case "dev", "device", "devices":
 		run = cmd.getDevices
+	case "device-history":
+		run = cmd.getDeviceHistory
 	case "dep-devices":
 		run = cmd.getDEPDevices
 	case "dep-account":
@@ -64,14 +69,26 @@ func (cmd *getCommand) Run(args []string) error {
 		run = cmd.getDepTokens
 	case "blueprints":
 		run = cmd.getBlueprints
+	case "blueprint-drift":
+		run = cmd.getBlueprintDrift
+	case "blueprint-releases":
+		run = cmd.getBlueprintReleases
+	case "blueprint-revisions":
+		run = cmd.getBlueprintRevisions
+	case "blueprint-diff":
+		run = cmd.getBlueprintDiff
 	case "profiles":
 		run = cmd.getProfiles
+	case "profile-devices":
+		run = cmd.getProfileDevices
 	case "users":
 		run = cmd.getUsers
 	case "apps":
 		run = cmd.getApps
 	case "dep-autoassigners":
 		run = cmd.getDEPAutoAssigners
+	case "groups":
+		run = cmd.getGroups
 	default:
 		cmd.Usage()
 		os.Exit(1)
@@ -87,7 +104,12 @@ Display one or many resources.
 Valid resource types:
 
   * devices
+  * device-history
   * blueprints
+  * blueprint-drift
+  * blueprint-releases
+  * blueprint-revisions
+  * blueprint-diff
   * dep-tokens
   * dep-devices
   * dep-account
@@ -95,14 +117,46 @@ Valid resource types:
   * dep-autoassigners
   * users
   * profiles
+  * profile-devices
   * apps
+  * groups
 
 Examples:
   # Get a list of devices
   mdmctl get devices
 
-  # Get a device by serial (TODO implement filtering)
-  mdmctl get devices -serial=C02ABCDEF
+  # Get a device by serial
+  mdmctl get devices -serials=C02ABCDEF
+
+  # Get enrolled devices running macOS 10.14 or later
+  mdmctl get devices -enrolled=true -os-min=10.14
+
+  # Export the inventory of all devices to a spreadsheet
+  mdmctl get devices -o csv > devices.csv
+
+  # Get devices which stopped checking in
+  mdmctl get devices -lifecycle=stale,missing
+
+  # Get devices by custom attribute
+  mdmctl get devices -attributes=location=nyc
+
+  # Get the commands sent to a device and their results
+  mdmctl get device-history -udid=564D2F9C-5A4B-4F5E-8A2B-1C0F3D9E7A61
+
+  # Get devices which drifted from a reconciled blueprint
+  mdmctl get blueprint-drift -name lab -drifted
+
+  # Get devices held in Setup Assistant by a failed blueprint command
+  mdmctl get blueprint-releases -state held
+
+  # Get the changes between two revisions of a blueprint
+  mdmctl get blueprint-diff -name lab -from 3 -to 5
+
+  # Get the profiles with a Wi-Fi payload
+  mdmctl get profiles -payload-type com.apple.wifi.managed
+
+  # Get the version of a profile each device was sent and acknowledged
+  mdmctl get profile-devices -id com.example.wifi
 `
 	fmt.Println(getUsage)
 	return nil
@@ -111,7 +165,7 @@ Examples:
 type devicesTableOutput struct{ w *tabwriter.Writer }
 
 func (out *devicesTableOutput) BasicHeader() {
-	fmt.Fprintf(out.w, "UDID\tSerialNumber\tEnrollmentStatus\tLastSeen\n")
+	fmt.Fprintf(out.w, "UDID\tSerialNumber\tModel\tOSVersion\tEnrollmentStatus\tLifecycle\tLastSeen\n")
 }
 
 func (out *devicesTableOutput) BasicFooter() {
@@ -122,30 +176,96 @@ func (cmd *getCommand) getDevices(args []string) error {
 	flagset := flag.NewFlagSet("devices", flag.ExitOnError)
 	var (
 		flFilterSerials = flagset.String("serials", "", "comma separated list of serials to search")
+		flFilterUDIDs   = flagset.String("udids", "", "comma separated list of UDIDs to search")
+		flFilterModels  = flagset.String("models", "", "comma separated list of models, model names or product names to search")
+		flOSMin         = flagset.String("os-min", "", "minimum OS version, inclusive")
+		flOSMax         = flagset.String("os-max", "", "maximum OS version, inclusive")
+		flEnrolled      = flagset.String("enrolled", "", "only show enrolled (true) or unenrolled (false) devices")
+		flDEPStatus     = flagset.String("dep-status", "", "comma separated list of DEP profile statuses (empty, assigned, pushed, removed)")
+		flSeenWithin    = flagset.Duration("seen-within", 0, "only show devices seen within this duration, ex: 24h")
+		flNotSeenWithin = flagset.Duration("not-seen-within", 0, "only show devices not seen within this duration, ex: 720h")
+		flLifecycle     = flagset.String("lifecycle", "", "comma separated list of lifecycle states (active, stale, missing, retired, wiped)")
+		flAttributes    = flagset.String("attributes", "", "comma separated list of custom attributes to match, ex: owner=jane,location=nyc")
+		flPage          = flagset.Int("page", 0, "page of results to show, requires -per-page")
+		flPerPage       = flagset.Int("per-page", 0, "number of devices per page, 0 shows all devices")
+		flOutput        = flagset.String("o", "", "export the devices with their inventory as csv or json (newline delimited) instead of a table")
+		flColumns       = flagset.String("columns", "", "comma separated list of columns to export with -o, ex: serial_number,os_version,attributes.owner")
 	)
 	flagset.Usage = usageFor(flagset, "mdmctl get devices [flags]")
 	if err := flagset.Parse(args); err != nil {
 		return err
 	}
-	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-	out := &devicesTableOutput{w}
-	out.BasicHeader()
-	defer out.BasicFooter()
-	ctx := context.Background()
 
-	// convert string to []string in case a filter serial is defined
-	// nil serials will return all serials from the database
-	var flFilterSerialsSlice []string
-	if *flFilterSerials != "" {
-		flFilterSerialsSlice = strings.Split(*flFilterSerials, ",")
+	opts := device.ListDevicesOption{
+		Page:               *flPage,
+		PerPage:            *flPerPage,
+		FilterSerial:       splitList(*flFilterSerials),
+		FilterUDID:         splitList(*flFilterUDIDs),
+		FilterModel:        splitList(*flFilterModels),
+		FilterOSVersionMin: *flOSMin,
+		FilterOSVersionMax: *flOSMax,
+	}
+	for _, status := range splitList(*flDEPStatus) {
+		opts.FilterDEPProfileStatus = append(opts.FilterDEPProfileStatus, device.DEPProfileStatus(status))
+	}
+	for _, state := range splitList(*flLifecycle) {
+		opts.FilterLifecycle = append(opts.FilterLifecycle, device.LifecycleState(state))
+	}
+	if *flEnrolled != "" {
+		enrolled, err := strconv.ParseBool(*flEnrolled)
+		if err != nil {
+			return errors.Wrap(err, "parse -enrolled flag")
+		}
+		opts.FilterEnrolled = &enrolled
+	}
+	if *flSeenWithin > 0 {
+		opts.FilterLastSeenAfter = time.Now().Add(-*flSeenWithin)
+	}
+	if *flNotSeenWithin > 0 {
+		opts.FilterLastSeenBefore = time.Now().Add(-*flNotSeenWithin)
+	}
+	for _, attr := range splitList(*flAttributes) {
+		kv := strings.SplitN(attr, "=", 2)
+		if len(kv) != 2 {
+			return errors.Errorf("invalid -attributes value %q, must be key=value", attr)
+		}
+		if opts.FilterAttributes == nil {
+			opts.FilterAttributes = make(map[string]string)
+		}
+		opts.FilterAttributes[kv[0]] = kv[1]
 	}
 
-	devices, err := cmd.devicesvc.ListDevices(ctx, device.ListDevicesOption{FilterSerial: flFilterSerialsSlice})
+	ctx := context.Background()
+	if *flOutput != "" {
+		export := device.ExportDevicesOption{
+			ListDevicesOption: opts,
+			Format:            *flOutput,
+			Columns:           splitList(*flColumns),
+		}
+		if err := export.Validate(); err != nil {
+			return err
+		}
+		return cmd.devicesvc.ExportDevices(ctx, export, os.Stdout)
+	}
+
+	devices, total, err := cmd.devicesvc.ListDevices(ctx, opts)
 	if err != nil {
 		return err
 	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	out := &devicesTableOutput{w}
+	out.BasicHeader()
 	for _, d := range devices {
-		fmt.Fprintf(out.w, "%s\t%s\t%v\t%s\n", d.UDID, d.SerialNumber, d.EnrollmentStatus, d.LastSeen)
+		model := d.ModelName
+		if model == "" {
+			model = d.ProductName
+		}
+		fmt.Fprintf(out.w, "%s\t%s\t%s\t%s\t%v\t%s\t%s\n", d.UDID, d.SerialNumber, model, d.OSVersion, d.EnrollmentStatus, d.Lifecycle, d.LastSeen)
+	}
+	out.BasicFooter()
+	if len(devices) != total {
+		fmt.Printf("\nshowing %d of %d devices\n", len(devices), total)
 	}
 	return nil
 }
@@ -311,31 +431,49 @@ func (cmd *getCommand) getProfiles(args []string) error {
 	var (
 		flProfilePath = flagset.String("f", "-", "filename of profile to write")
 		flIdentifier  = flagset.String("id", "", "profile Identifier")
+		flPayloadType = flagset.String("payload-type", "", "only list profiles with a payload of this PayloadType")
 	)
-	flagset.Usage = usageFor(flagset, "mdmctl get blueprints [flags]")
+	flagset.Usage = usageFor(flagset, "mdmctl get profiles [flags]")
 	if err := flagset.Parse(args); err != nil {
 		return err
 	}
-
-	ctx := context.Background()
-	profiles, err := cmd.profilesvc.GetProfiles(ctx, profile.GetProfilesOption{Identifier: *flIdentifier})
-	if err != nil {
-		return err
+	if *flIdentifier != "" && *flPayloadType != "" {
+		return errors.New("bad input: -payload-type can't be used with -id")
 	}
 
-	if *flIdentifier == "" || len(profiles) < 1 {
+	ctx := context.Background()
+	if *flIdentifier == "" {
+		profiles, err := cmd.profilesvc.QueryProfiles(ctx, profile.QueryProfilesOption{PayloadType: *flPayloadType})
+		if err != nil {
+			return err
+		}
 		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-		fmt.Fprintf(w, "Identifier\tLength\n")
-		for _, p := range profiles {
+		fmt.Fprintf(w, "Identifier\tVersion\tDisplayName\tOrganization\tScope\tPayloads\tSigner\tUploaded\n")
+		for _, m := range profiles {
 			fmt.Fprintf(
 				w,
-				"%s\t%d\n",
-				p.Identifier,
-				len(p.Mobileconfig),
+				"%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				m.Identifier,
+				m.Version,
+				m.DisplayName,
+				m.Organization,
+				m.Scope,
+				formatPayloadTypes(m),
+				m.Signer,
+				formatHistoryTime(m.Uploaded),
 			)
 		}
-		w.Flush()
-	} else if *flProfilePath != "" {
+		return w.Flush()
+	}
+
+	profiles, err := cmd.profilesvc.GetProfiles(ctx, profile.GetProfilesOption{Identifier: *flIdentifier})
+	if err != nil {
+		return err
+	}
+	if len(profiles) < 1 {
+		return fmt.Errorf("profile %s not found", *flIdentifier)
+	}
+	if *flProfilePath != "" {
 		p := profiles[0]
 
 		var output *os.File
@@ -364,3 +502,14 @@ func (cmd *getCommand) getProfiles(args []string) error {
 	}
 	return nil
 }
+
+// formatPayloadTypes lists the payload types of a profile with the number
+// of payloads of each type, such as "2 com.apple.wifi.managed".
+func formatPayloadTypes(m profile.Metadata) string {
+	types := make([]string, 0, len(m.PayloadTypes))
+	for typ, n := range m.PayloadTypes {
+		types = append(types, fmt.Sprintf("%d %s", n, typ))
+	}
+	sort.Strings(types)
+	return strings.Join(types, ", ")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micromdm/micromdm/platform/blueprint"
+)
+
+func (cmd *getCommand) getBlueprintDrift(args []string) error {
+	flagset := flag.NewFlagSet("blueprint-drift", flag.ExitOnError)
+	var (
+		flName    = flagset.String("name", "", "name of a reconciled blueprint")
+		flUDIDs   = flagset.String("udid", "", "device UDID, optionally comma separated")
+		flDrifted = flagset.Bool("drifted", false, "only show devices which drifted from their blueprints")
+		flJSON    = flagset.Bool("json", false, "print the drift as JSON")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl get blueprint-drift [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	drift, err := cmd.blueprintsvc.GetDrift(context.Background(), blueprint.GetDriftOption{
+		FilterUDID:      splitList(*flUDIDs),
+		FilterBlueprint: *flName,
+		Drifted:         *flDrifted,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *flJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(drift)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "UDID\tSerialNumber\tChecked\tBlueprints\tMissingProfiles\tMissingApps\tRemovedProfiles\tUnknownApps\n")
+	for _, d := range drift {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			d.UDID,
+			d.SerialNumber,
+			d.Checked.Local().Format(time.RFC3339),
+			strings.Join(d.Blueprints, ","),
+			strings.Join(d.MissingProfiles, ","),
+			strings.Join(d.MissingApps, ","),
+			strings.Join(d.RemovedProfiles, ","),
+			len(d.UnknownApps),
+		)
+	}
+	return w.Flush()
+}
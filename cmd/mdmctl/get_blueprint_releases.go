@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micromdm/micromdm/platform/blueprint"
+)
+
+func (cmd *getCommand) getBlueprintReleases(args []string) error {
+	flagset := flag.NewFlagSet("blueprint-releases", flag.ExitOnError)
+	var (
+		flUDIDs    = flagset.String("udid", "", "device UDID, optionally comma separated")
+		flState    = flagset.String("state", "", "release state (pending, released, held), optionally comma separated")
+		flCommands = flagset.Bool("commands", false, "list the tracked commands of each device")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl get blueprint-releases [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	opt := blueprint.GetReleasesOption{FilterUDID: splitList(*flUDIDs)}
+	for _, state := range splitList(*flState) {
+		opt.FilterState = append(opt.FilterState, blueprint.ReleaseState(state))
+	}
+	releases, err := cmd.blueprintsvc.GetReleases(context.Background(), opt)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if *flCommands {
+		fmt.Fprintf(w, "UDID\tBlueprint\tRequestType\tTarget\tStatus\tError\n")
+		for _, r := range releases {
+			for _, c := range r.Commands {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.UDID, c.Blueprint, c.RequestType, c.Target, c.Status, c.Error)
+			}
+		}
+		return w.Flush()
+	}
+
+	fmt.Fprintf(w, "UDID\tState\tAcknowledged\tStarted\tReason\n")
+	for _, r := range releases {
+		var acked int
+		for _, c := range r.Commands {
+			if c.Status == blueprint.CommandAcknowledged {
+				acked++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n",
+			r.UDID,
+			r.State,
+			acked,
+			len(r.Commands),
+			r.Started.Local().Format(time.RFC3339),
+			r.Reason,
+		)
+	}
+	return w.Flush()
+}
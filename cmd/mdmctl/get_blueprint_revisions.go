@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/blueprint"
+)
+
+func (cmd *getCommand) getBlueprintRevisions(args []string) error {
+	flagset := flag.NewFlagSet("blueprint-revisions", flag.ExitOnError)
+	var (
+		flName = flagset.String("name", "", "name of blueprint")
+		flJSON = flagset.Bool("json", false, "print the revisions as JSON")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl get blueprint-revisions [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flName == "" {
+		flagset.Usage()
+		return errors.New("bad input: blueprint name must be provided")
+	}
+
+	revisions, err := cmd.blueprintsvc.GetRevisions(context.Background(), *flName)
+	if err != nil {
+		return err
+	}
+
+	if *flJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(revisions)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Revision\tSaved\tChanged\n")
+	var previous *blueprint.Blueprint
+	for i, rev := range revisions {
+		var changed []string
+		if previous != nil {
+			changes, err := blueprint.Diff(previous, &rev.Blueprint)
+			if err != nil {
+				return err
+			}
+			for _, c := range changes {
+				changed = append(changed, c.Field)
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", rev.Revision, rev.Saved.Local().Format(time.RFC3339), strings.Join(changed, ","))
+		previous = &revisions[i].Blueprint
+	}
+	return w.Flush()
+}
+
+func (cmd *getCommand) getBlueprintDiff(args []string) error {
+	flagset := flag.NewFlagSet("blueprint-diff", flag.ExitOnError)
+	var (
+		flName = flagset.String("name", "", "name of blueprint")
+		flFrom = flagset.Int("from", 0, "revision to compare from, defaults to the revision before -to")
+		flTo   = flagset.Int("to", 0, "revision to compare to, defaults to the latest revision")
+		flJSON = flagset.Bool("json", false, "print the changes as JSON")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl get blueprint-diff [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flName == "" {
+		flagset.Usage()
+		return errors.New("bad input: blueprint name must be provided")
+	}
+
+	revisions, err := cmd.blueprintsvc.GetRevisions(context.Background(), *flName)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		return errors.Errorf("blueprint %s has no revisions", *flName)
+	}
+	to := *flTo
+	if to == 0 {
+		to = revisions[len(revisions)-1].Revision
+	}
+	from := *flFrom
+	if from == 0 {
+		from = to - 1
+	}
+	fromRev, toRev := findRevision(revisions, from), findRevision(revisions, to)
+	if fromRev == nil {
+		return errors.Errorf("blueprint %s has no revision %d", *flName, from)
+	}
+	if toRev == nil {
+		return errors.Errorf("blueprint %s has no revision %d", *flName, to)
+	}
+
+	changes, err := blueprint.Diff(&fromRev.Blueprint, &toRev.Blueprint)
+	if err != nil {
+		return err
+	}
+
+	if *flJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+
+	fmt.Printf("blueprint %s revision %d (%s) to %d (%s)\n",
+		*flName,
+		fromRev.Revision, fromRev.Saved.Local().Format(time.RFC3339),
+		toRev.Revision, toRev.Saved.Local().Format(time.RFC3339),
+	)
+	for _, c := range changes {
+		fmt.Printf("%s\n", c.Field)
+		if c.From != nil {
+			fmt.Printf("  - %s\n", diffValue(c.From))
+		}
+		if c.To != nil {
+			fmt.Printf("  + %s\n", diffValue(c.To))
+		}
+	}
+	return nil
+}
+
+func findRevision(revisions []blueprint.Revision, revision int) *blueprint.Revision {
+	for i := range revisions {
+		if revisions[i].Revision == revision {
+			return &revisions[i]
+		}
+	}
+	return nil
+}
+
+func diffValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
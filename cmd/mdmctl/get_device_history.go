@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/pkg/errors"
+)
+
+type deviceHistoryTableOutput struct{ w *tabwriter.Writer }
+
+func (out *deviceHistoryTableOutput) BasicHeader() {
+	fmt.Fprintf(out.w, "CommandUUID\tRequestType\tStatus\tQueued\tLastSent\tAcknowledged\tError\n")
+}
+
+func (out *deviceHistoryTableOutput) BasicFooter() {
+	out.w.Flush()
+}
+
+func (cmd *getCommand) getDeviceHistory(args []string) error {
+	flagset := flag.NewFlagSet("device-history", flag.ExitOnError)
+	var (
+		flUDID        = flagset.String("udid", "", "UDID of the device")
+		flRequestType = flagset.String("request-type", "", "comma separated list of request types to show")
+		flStatus      = flagset.String("status", "", "comma separated list of statuses to show (Queued, Sent, NotNow, Acknowledged, Error, CommandFormatError)")
+		flPage        = flagset.Int("page", 1, "page of results to show")
+		flPerPage     = flagset.Int("per-page", 50, "number of commands per page")
+		flResponse    = flagset.Bool("response", false, "include decoded device responses, output as JSON")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl get device-history -udid UDID [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flUDID == "" {
+		flagset.Usage()
+		return errors.New("bad input: must provide a device UDID")
+	}
+
+	opts := device.DeviceHistoryOption{
+		Page:              *flPage,
+		PerPage:           *flPerPage,
+		FilterRequestType: splitList(*flRequestType),
+		FilterStatus:      splitList(*flStatus),
+		IncludeResponse:   *flResponse,
+	}
+	history, err := cmd.devicesvc.GetDeviceHistory(context.Background(), *flUDID, opts)
+	if err != nil {
+		return err
+	}
+
+	if *flResponse {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(history)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	out := &deviceHistoryTableOutput{w}
+	out.BasicHeader()
+	for _, c := range history.Commands {
+		fmt.Fprintf(out.w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.CommandUUID,
+			c.RequestType,
+			c.Status,
+			formatHistoryTime(c.QueuedAt),
+			formatHistoryTime(c.LastSentAt),
+			formatHistoryTime(c.AcknowledgedAt),
+			formatErrorChain(c.ErrorChain),
+		)
+	}
+	out.BasicFooter()
+	fmt.Printf("\nshowing %d of %d commands\n", len(history.Commands), history.Total)
+	return nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func formatHistoryTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatErrorChain(chain []mdm.ErrorChainItem) string {
+	var errs []string
+	for _, e := range chain {
+		errs = append(errs, fmt.Sprintf("%s (%s %d)", e.USEnglishDescription, e.ErrorDomain, e.ErrorCode))
+	}
+	return strings.Join(errs, "; ")
+}
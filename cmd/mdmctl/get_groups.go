@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/micromdm/micromdm/platform/group"
+)
+
+func (cmd *getCommand) getGroups(args []string) error {
+	flagset := flag.NewFlagSet("groups", flag.ExitOnError)
+	var (
+		flName     = flagset.String("name", "", "name of group")
+		flUDID     = flagset.String("udid", "", "only show groups with the device UDID as a member")
+		flSerial   = flagset.String("serial", "", "only show groups with the device serial number as a member")
+		flJSONName = flagset.String("f", "-", "filename of JSON to save to")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl get groups [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	groups, err := cmd.groupsvc.GetGroups(ctx, group.GetGroupsOption{
+		FilterName:   *flName,
+		FilterUDID:   *flUDID,
+		FilterSerial: *flSerial,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *flName == "" || len(groups) < 1 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Name\tUUID\tUDIDs\tSerials\tQuery\n")
+		for _, g := range groups {
+			query := g.Query
+			if query == "" {
+				query = "(static)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", g.Name, g.UUID, len(g.UDIDs), len(g.Serials), query)
+		}
+		return w.Flush()
+	}
+
+	output := os.Stdout
+	if *flJSONName != "-" {
+		output, err = os.Create(*flJSONName)
+		if err != nil {
+			return err
+		}
+		defer output.Close()
+	}
+	enc := json.NewEncoder(output)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(groups[0]); err != nil {
+		return err
+	}
+	if *flJSONName != "-" {
+		fmt.Printf("wrote group %s to: %s\n", *flName, *flJSONName)
+	}
+	return nil
+}
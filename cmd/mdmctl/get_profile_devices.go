@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+func (cmd *getCommand) getProfileDevices(args []string) error {
+	flagset := flag.NewFlagSet("profile-devices", flag.ExitOnError)
+	var (
+		flIdentifier = flagset.String("id", "", "profile Identifier")
+		flStatus     = flagset.String("status", "", "only list devices with this status, such as Error or RemoveFailed")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl get profile-devices [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flIdentifier == "" {
+		flagset.Usage()
+		return errors.New("bad input: profile id must be provided")
+	}
+
+	devices, err := cmd.profilesvc.GetDeviceProfiles(context.Background(), profile.GetDeviceProfilesOption{
+		Identifier: *flIdentifier,
+		Status:     *flStatus,
+	})
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "UDID\tSTATUS\tSENT VERSION\tSENT\tACKNOWLEDGED VERSION\tACKNOWLEDGED\n")
+	for _, dp := range devices {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\n",
+			dp.UDID,
+			dp.Status,
+			dp.SentVersion,
+			formatHistoryTime(dp.Sent),
+			dp.AcknowledgedVersion,
+			formatHistoryTime(dp.Acknowledged),
+		)
+	}
+	return w.Flush()
+}
@@ -50,6 +50,8 @@ func (cmd *removeCommand) Run(args []string) error {
 		run = cmd.removeBlock
 	case "dep-autoassigner":
 		run = cmd.removeDEPAutoAssigner
+	case "groups":
+		run = cmd.removeGroups
 	default:
 		cmd.Usage()
 		os.Exit(1)
@@ -69,6 +71,14 @@ Valid resource types:
   * profiles
   * block
   * dep-autoassigner
+  * groups
+
+Examples:
+  # Remove a profile from the server and from the devices which have it
+  mdmctl remove profiles -id com.example.wifi -from-devices
+
+  # List the devices where the removal failed
+  mdmctl get profile-devices -id com.example.wifi -status RemoveFailed
 `
 
 	fmt.Println(getUsage)
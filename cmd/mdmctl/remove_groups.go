@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/group"
+)
+
+func (cmd *removeCommand) removeGroups(args []string) error {
+	flagset := flag.NewFlagSet("remove-groups", flag.ExitOnError)
+	var (
+		flName    = flagset.String("name", "", "name of group, optionally comma separated")
+		flUDIDs   = flagset.String("udids", "", "comma separated list of device UDIDs to remove from the group")
+		flSerials = flagset.String("serials", "", "comma separated list of device serial numbers to remove from the group")
+	)
+	flagset.Usage = usageFor(flagset, "mdmctl remove groups [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flName == "" {
+		flagset.Usage()
+		return errors.New("bad input: must provide a group name")
+	}
+
+	ctx := context.Background()
+	if *flUDIDs != "" || *flSerials != "" {
+		members := group.Members{UDIDs: splitList(*flUDIDs), Serials: splitList(*flSerials)}
+		if err := cmd.groupsvc.RemoveGroupMembers(ctx, *flName, members); err != nil {
+			return err
+		}
+		fmt.Printf("removed %d device(s) from group %s\n", len(members.UDIDs)+len(members.Serials), *flName)
+		return nil
+	}
+
+	if err := cmd.groupsvc.RemoveGroups(ctx, strings.Split(*flName, ",")); err != nil {
+		return err
+	}
+	fmt.Printf("removed group(s): %s\n", *flName)
+	return nil
+}
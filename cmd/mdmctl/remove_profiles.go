@@ -4,13 +4,18 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 	"strings"
+	"text/tabwriter"
+
+	"github.com/micromdm/micromdm/platform/profile"
 )
 
 func (cmd *removeCommand) removeProfiles(args []string) error {
 	flagset := flag.NewFlagSet("remove-profiles", flag.ExitOnError)
 	var (
-		flIdentifier = flagset.String("id", "", "profile Identifier, optionally comma separated")
+		flIdentifier  = flagset.String("id", "", "profile Identifier, optionally comma separated")
+		flFromDevices = flagset.Bool("from-devices", false, "also remove the profiles from every device known to have them installed")
 	)
 	flagset.Usage = usageFor(flagset, "mdmctl remove profiles [flags]")
 	if err := flagset.Parse(args); err != nil {
@@ -18,12 +23,26 @@ func (cmd *removeCommand) removeProfiles(args []string) error {
 	}
 
 	ctx := context.Background()
-	err := cmd.profilesvc.RemoveProfiles(ctx, strings.Split(*flIdentifier, ","))
+	removing, err := cmd.profilesvc.RemoveProfiles(ctx, strings.Split(*flIdentifier, ","), profile.RemoveProfilesOption{
+		FromDevices: *flFromDevices,
+	})
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("removed profile(s): %s\n", *flIdentifier)
+	if !*flFromDevices {
+		return nil
+	}
 
-	return nil
+	fmt.Printf("queued RemoveProfile for %d device(s), see mdmctl get profile-devices for the results\n", len(removing))
+	if len(removing) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "IDENTIFIER\tUDID\tCOMMAND UUID\n")
+	for _, dp := range removing {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", dp.Identifier, dp.UDID, dp.CommandUUID)
+	}
+	return w.Flush()
 }
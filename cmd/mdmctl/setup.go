@@ -10,13 +10,16 @@ import (
 	"github.com/micromdm/micromdm/platform/dep"
 	"github.com/micromdm/micromdm/platform/dep/sync"
 	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/group"
 	"github.com/micromdm/micromdm/platform/profile"
+	"github.com/micromdm/micromdm/platform/profile/builder"
 	"github.com/micromdm/micromdm/platform/remove"
 	"github.com/micromdm/micromdm/platform/user"
 )
 
 type remoteServices struct {
 	profilesvc   profile.Service
+	buildersvc   builder.Service
 	blueprintsvc blueprint.Service
 	blocksvc     remove.Service
 	usersvc      user.Service
@@ -25,6 +28,7 @@ type remoteServices struct {
 	appsvc       appstore.Service
 	depsvc       dep.Service
 	depsyncsvc   sync.Service
+	groupsvc     group.Service
 }
 
 func setupClient(logger log.Logger) (*remoteServices, error) {
@@ -40,6 +44,13 @@ func setupClient(logger log.Logger) (*remoteServices, error) {
 		return nil, err
 	}
 
+	buildersvc, err := builder.NewHTTPClient(
+		cfg.ServerURL, cfg.APIToken, logger,
+		httptransport.SetClient(skipVerifyHTTPClient(cfg.SkipVerify)))
+	if err != nil {
+		return nil, err
+	}
+
 	blueprintsvc, err := blueprint.NewHTTPClient(
 		cfg.ServerURL, cfg.APIToken, logger,
 		httptransport.SetClient(skipVerifyHTTPClient(cfg.SkipVerify)))
@@ -96,8 +107,16 @@ func setupClient(logger log.Logger) (*remoteServices, error) {
 		return nil, err
 	}
 
+	groupsvc, err := group.NewHTTPClient(
+		cfg.ServerURL, cfg.APIToken, logger,
+		httptransport.SetClient(skipVerifyHTTPClient(cfg.SkipVerify)))
+	if err != nil {
+		return nil, err
+	}
+
 	return &remoteServices{
 		profilesvc:   profilesvc,
+		buildersvc:   buildersvc,
 		blueprintsvc: blueprintsvc,
 		blocksvc:     blocksvc,
 		usersvc:      usersvc,
@@ -106,5 +125,6 @@ func setupClient(logger log.Logger) (*remoteServices, error) {
 		appsvc:       appsvc,
 		depsvc:       depsvc,
 		depsyncsvc:   depsyncsvc,
+		groupsvc:     groupsvc,
 	}, nil
 }
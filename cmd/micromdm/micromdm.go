@@ -28,6 +28,8 @@ func main() {
 		return
 	case "serve":
 		run = serve
+	case "secrets":
+		run = secretsCmd
 	default:
 		usage()
 		os.Exit(1)
@@ -44,6 +46,7 @@ func usage() error {
 
 Available Commands:
 	serve
+	secrets
 	version
 
 Use micromdm <command> -h for additional usage of each command.
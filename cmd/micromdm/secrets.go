@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/micromdm/go4/env"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/secrets"
+)
+
+// secretsPassphraseEnv is the environment variable with the passphrase of
+// the secrets file. It isn't a flag, so that it doesn't show up in the
+// process list or in serve -print-flags.
+const secretsPassphraseEnv = "MICROMDM_SECRETS_PASSPHRASE"
+
+func secretsCmd(args []string) error {
+	flagset := flag.NewFlagSet("secrets", flag.ExitOnError)
+	var (
+		flPath   = flagset.String("file", env.String("MICROMDM_SECRETS_FILE", ""), "Path to the secrets file")
+		flSet    = flagset.String("set", "", "Name of a secret to create or replace with the value read from stdin, ex: wifi/corp")
+		flDelete = flagset.String("delete", "", "Name of a secret to delete")
+		flList   = flagset.Bool("list", false, "List the names of the secrets, but not their values")
+	)
+	flagset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n", `Manage the encrypted file of the secrets referenced by profiles.
+
+Profiles can reference secrets such as {{secret "wifi/corp"}} instead of
+containing them. The server resolves the references with the secrets file
+passed to micromdm serve -secrets-file only when it creates an InstallProfile
+command. The file is encrypted with the passphrase in `+secretsPassphraseEnv+`.
+
+Examples
+
+  # Create or replace a secret
+  echo -n 'p4ssw0rd' | micromdm secrets -file /var/db/micromdm/secrets -set wifi/corp
+
+  # List the secrets
+  micromdm secrets -file /var/db/micromdm/secrets -list
+`)
+		usageFor(flagset, "micromdm secrets [flags]")()
+	}
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if *flPath == "" {
+		flagset.Usage()
+		return errors.New("bad input: must provide -file parameter")
+	}
+	passphrase := os.Getenv(secretsPassphraseEnv)
+	if passphrase == "" {
+		return errors.Errorf("bad input: %s must be set", secretsPassphraseEnv)
+	}
+
+	stored, err := secrets.ReadFile(*flPath, passphrase)
+	if os.IsNotExist(errors.Cause(err)) {
+		stored, err = make(map[string]string), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *flSet != "":
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && value == "" {
+			return errors.New("bad input: the value of the secret must be read from stdin")
+		}
+		stored[*flSet] = strings.TrimSuffix(value, "\n")
+	case *flDelete != "":
+		if _, ok := stored[*flDelete]; !ok {
+			return errors.Errorf("secret %q not found", *flDelete)
+		}
+		delete(stored, *flDelete)
+	case *flList:
+		var names []string
+		for name := range stored {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	default:
+		flagset.Usage()
+		return errors.New("bad input: must provide -set, -delete or -list")
+	}
+	return secrets.WriteFile(*flPath, passphrase, stored)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/micromdm/micromdm/mdm"
 	"github.com/micromdm/micromdm/mdm/enroll"
 	httputil2 "github.com/micromdm/micromdm/pkg/httputil"
+	"github.com/micromdm/micromdm/pkg/secrets"
 	"github.com/micromdm/micromdm/platform/apns"
 	"github.com/micromdm/micromdm/platform/appstore"
 	appsbuiltin "github.com/micromdm/micromdm/platform/appstore/builtin"
@@ -40,7 +41,12 @@ import (
 	"github.com/micromdm/micromdm/platform/dep/sync"
 	"github.com/micromdm/micromdm/platform/device"
 	devicebuiltin "github.com/micromdm/micromdm/platform/device/builtin"
+	"github.com/micromdm/micromdm/platform/group"
+	groupbuiltin "github.com/micromdm/micromdm/platform/group/builtin"
+	"github.com/micromdm/micromdm/platform/inventory"
 	"github.com/micromdm/micromdm/platform/profile"
+	"github.com/micromdm/micromdm/platform/profile/builder"
+	"github.com/micromdm/micromdm/platform/queue"
 	block "github.com/micromdm/micromdm/platform/remove"
 	"github.com/micromdm/micromdm/platform/user"
 	userbuiltin "github.com/micromdm/micromdm/platform/user/builtin"
@@ -83,7 +89,27 @@ func serve(args []string) error {
 		flCommandWebhookURL  = flagset.String("command-webhook-url", env.String("MICROMDM_WEBHOOK_URL", ""), "URL to send command responses")
 		flHomePage           = flagset.Bool("homepage", env.Bool("MICROMDM_HTTP_HOMEPAGE", true), "Hosts a simple built-in webpage at the / address")
 		flSCEPClientValidity = flagset.Int("scep-client-validity", env.Int("MICROMDM_SCEP_CLIENT_VALIDITY", 365), "Sets the scep certificate validity in days")
+		flResponseRetention  = flagset.Duration("command-response-retention", envDuration("MICROMDM_COMMAND_RESPONSE_RETENTION", queue.DefaultResponseRetention), "How long to keep command responses for the device command history. Kept forever if 0")
 		flPrintArgs          = flagset.Bool("print-flags", false, "Print all flags and their values")
+
+		flInventoryDeviceInfo   = flagset.Duration("inventory-device-information-interval", envDuration("MICROMDM_INVENTORY_DEVICE_INFORMATION_INTERVAL", 0), "How often to send DeviceInformation to enrolled devices. Disabled if 0")
+		flInventoryApps         = flagset.Duration("inventory-installed-apps-interval", envDuration("MICROMDM_INVENTORY_INSTALLED_APPS_INTERVAL", 0), "How often to send InstalledApplicationList to enrolled devices. Disabled if 0")
+		flInventoryProfiles     = flagset.Duration("inventory-profile-list-interval", envDuration("MICROMDM_INVENTORY_PROFILE_LIST_INTERVAL", 0), "How often to send ProfileList to enrolled devices. Disabled if 0")
+		flInventorySecurityInfo = flagset.Duration("inventory-security-info-interval", envDuration("MICROMDM_INVENTORY_SECURITY_INFO_INTERVAL", 0), "How often to send SecurityInfo to enrolled devices. Disabled if 0")
+		flInventoryJitter       = flagset.Duration("inventory-jitter", envDuration("MICROMDM_INVENTORY_JITTER", time.Hour), "Maximum random delay added to each device's inventory schedule")
+
+		flStaleAfter   = flagset.Duration("device-stale-after", envDuration("MICROMDM_DEVICE_STALE_AFTER", 0), "Mark enrolled devices stale when they have not checked in for this long. Disabled if 0")
+		flMissingAfter = flagset.Duration("device-missing-after", envDuration("MICROMDM_DEVICE_MISSING_AFTER", 0), "Mark enrolled devices missing when they have not checked in for this long. Disabled if 0")
+		flPushStale    = flagset.Bool("device-push-stale", env.Bool("MICROMDM_DEVICE_PUSH_STALE", false), "Send an APNs push to devices when they become stale")
+
+		flHoldDeviceConfigured = flagset.Bool("blueprint-await-acks", env.Bool("MICROMDM_BLUEPRINT_AWAIT_ACKS", false), "Send DeviceConfigured only after the required blueprint commands are acknowledged")
+		flAwaitRequestTypes    = flagset.String("blueprint-await-request-types", env.String("MICROMDM_BLUEPRINT_AWAIT_REQUEST_TYPES", strings.Join(blueprint.DefaultRequiredRequestTypes, ",")), "Comma separated blueprint request types which must be acknowledged before DeviceConfigured")
+		flAwaitTimeout         = flagset.Duration("blueprint-await-timeout", envDuration("MICROMDM_BLUEPRINT_AWAIT_TIMEOUT", 30*time.Minute), "How long to wait for the required blueprint commands")
+		flAwaitOnFailure       = flagset.String("blueprint-await-on-failure", env.String("MICROMDM_BLUEPRINT_AWAIT_ON_FAILURE", blueprint.FailureRelease), "When a required blueprint command fails or times out: release sends DeviceConfigured anyway, hold keeps the device in Setup Assistant")
+		flReconcileInterval    = flagset.Duration("blueprint-reconcile-interval", envDuration("MICROMDM_BLUEPRINT_RECONCILE_INTERVAL", 6*time.Hour), "How often to check devices against blueprints with reconcile enabled")
+
+		flSecretsFile      = flagset.String("secrets-file", env.String("MICROMDM_SECRETS_FILE", ""), "Path to a secrets file written with micromdm secrets, for {{secret}} references in profiles. Its passphrase is read from MICROMDM_SECRETS_PASSPHRASE")
+		flSecretsEnvPrefix = flagset.String("secrets-env-prefix", env.String("MICROMDM_SECRETS_ENV_PREFIX", ""), "Resolve {{secret}} references in profiles from environment variables with this prefix, ex: MICROMDM_SECRET_")
 	)
 	flagset.Usage = usageFor(flagset, "micromdm serve [flags]")
 	if err := flagset.Parse(args); err != nil {
@@ -119,6 +145,11 @@ func serve(args []string) error {
 	if err := os.MkdirAll(*flConfigPath, 0755); err != nil {
 		return errors.Wrapf(err, "creating config directory %s", *flConfigPath)
 	}
+
+	secretsProvider, err := setupSecrets(*flSecretsFile, *flSecretsEnvPrefix)
+	if err != nil {
+		return err
+	}
 	sm := &server.Server{
 		ConfigPath:        *flConfigPath,
 		ServerPublicURL:   strings.TrimRight(*flServerURL, "/"),
@@ -126,6 +157,8 @@ func serve(args []string) error {
 		TLSCertPath:       *flTLSCert,
 		CommandWebhookURL: *flCommandWebhookURL,
 
+		CommandResponseRetention: *flResponseRetention,
+
 		WebhooksHTTPClient: &http.Client{Timeout: time.Second * 30},
 
 		// TODO: we have a static SCEP challenge password here to prevent
@@ -134,6 +167,7 @@ func serve(args []string) error {
 		// no less secure and prevents a useless dialog from showing.
 		SCEPChallenge:      "micromdm",
 		SCEPClientValidity: *flSCEPClientValidity,
+		Secrets:            secretsProvider,
 	}
 
 	if err := sm.Setup(logger); err != nil {
@@ -173,16 +207,86 @@ func serve(args []string) error {
 		stdlog.Fatal(err)
 	}
 
+	groupDB, err := groupbuiltin.NewDB(sm.DB)
+	if err != nil {
+		stdlog.Fatal(err)
+	}
+
+	groupEvaluator := group.NewEvaluator(groupDB, devDB, sm.PubClient, log.With(logger, "component", "groups"))
+	go groupEvaluator.Run(context.Background())
+
+	var blueprintOpts []blueprint.WorkerOption
+	if *flHoldDeviceConfigured {
+		if *flAwaitOnFailure != blueprint.FailureRelease && *flAwaitOnFailure != blueprint.FailureHold {
+			stdlog.Fatalf("invalid -blueprint-await-on-failure %q, must be %s or %s", *flAwaitOnFailure, blueprint.FailureRelease, blueprint.FailureHold)
+		}
+		blueprintOpts = append(blueprintOpts, blueprint.WithReleaseGate(
+			blueprint.ReleaseConfig{
+				RequiredRequestTypes: strings.Split(*flAwaitRequestTypes, ","),
+				Timeout:              *flAwaitTimeout,
+				OnFailure:            *flAwaitOnFailure,
+			},
+			bpDB,
+			sm.PubClient,
+		))
+	}
 	blueprintWorker := blueprint.NewWorker(
 		bpDB,
 		userDB,
 		sm.ProfileDB,
+		devDB,
+		groupDB,
 		sm.CommandService,
 		sm.PubClient,
 		logger,
+		blueprintOpts...,
 	)
 	go blueprintWorker.Run(context.Background())
 
+	blueprintReconciler := blueprint.NewReconciler(
+		blueprint.ReconcileConfig{Interval: *flReconcileInterval},
+		bpDB,
+		blueprintWorker,
+		sm.PubClient,
+		log.With(logger, "component", "blueprint_reconciler"),
+	)
+	go blueprintReconciler.Run(context.Background())
+
+	inventoryScheduler := inventory.NewScheduler(
+		inventory.Config{
+			Intervals: map[string]time.Duration{
+				inventory.DeviceInformation:        *flInventoryDeviceInfo,
+				inventory.InstalledApplicationList: *flInventoryApps,
+				inventory.ProfileList:              *flInventoryProfiles,
+				inventory.SecurityInfo:             *flInventorySecurityInfo,
+			},
+			Jitter: *flInventoryJitter,
+		},
+		devDB,
+		sm.CommandQueue,
+		sm.CommandService,
+		sm.PubClient,
+		log.With(logger, "component", "inventory"),
+	)
+	if inventoryScheduler.Enabled() {
+		go inventoryScheduler.Run(context.Background())
+	}
+
+	lifecycleMonitor := device.NewLifecycleMonitor(
+		device.LifecycleConfig{
+			StaleAfter:   *flStaleAfter,
+			MissingAfter: *flMissingAfter,
+			PushStale:    *flPushStale,
+		},
+		devDB,
+		sm.PubClient,
+		sm.APNSPushService,
+		log.With(logger, "component", "lifecycle"),
+	)
+	if lifecycleMonitor.Enabled() {
+		go lifecycleMonitor.Run(context.Background())
+	}
+
 	ctx := context.Background()
 	httpLogger := log.With(logger, "transport", "http")
 
@@ -224,18 +328,32 @@ func serve(args []string) error {
 		apnsEndpoints := apns.MakeServerEndpoints(sm.APNSPushService, basicAuthEndpointMiddleware)
 		apns.RegisterHTTPHandlers(r, apnsEndpoints, options...)
 
-		devicesvc := device.New(devDB)
+		devicesvc := device.New(devDB,
+			device.WithCommandHistory(sm.CommandQueue),
+			device.WithPublisher(sm.PubClient),
+		)
 		deviceEndpoints := device.MakeServerEndpoints(devicesvc, basicAuthEndpointMiddleware)
 		device.RegisterHTTPHandlers(r, deviceEndpoints, options...)
 
-		profilesvc := profile.New(sm.ProfileDB)
+		profilesvc := profile.New(sm.ProfileDB,
+			profile.WithSigner(sm.ProfileSigner),
+			profile.WithCommandService(sm.CommandService),
+		)
 		profileEndpoints := profile.MakeServerEndpoints(profilesvc, basicAuthEndpointMiddleware)
 		profile.RegisterHTTPHandlers(r, profileEndpoints, options...)
 
-		blueprintsvc := blueprint.New(bpDB)
+		buildersvc := builder.New(profilesvc, builder.WithSigner(sm.ProfileSigner))
+		builderEndpoints := builder.MakeServerEndpoints(buildersvc, basicAuthEndpointMiddleware)
+		builder.RegisterHTTPHandlers(r, builderEndpoints, options...)
+
+		blueprintsvc := blueprint.New(bpDB, blueprint.WithWorker(blueprintWorker))
 		blueprintEndpoints := blueprint.MakeServerEndpoints(blueprintsvc, basicAuthEndpointMiddleware)
 		blueprint.RegisterHTTPHandlers(r, blueprintEndpoints, options...)
 
+		groupsvc := group.New(groupDB, group.WithEvaluator(groupEvaluator))
+		groupEndpoints := group.MakeServerEndpoints(groupsvc, basicAuthEndpointMiddleware)
+		group.RegisterHTTPHandlers(r, groupEndpoints, options...)
+
 		blockEndpoints := block.MakeServerEndpoints(removeService, basicAuthEndpointMiddleware)
 		block.RegisterHTTPHandlers(r, blockEndpoints, options...)
 
@@ -329,6 +447,20 @@ func serveOptions(
 	return serveOpts
 }
 
+// envDuration returns the duration set in the environment variable key,
+// or def if the variable is unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	v := env.String(key, "")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
 func boltBackup(db *bolt.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := db.View(func(tx *bolt.Tx) error {
@@ -344,6 +476,26 @@ func boltBackup(db *bolt.DB) http.HandlerFunc {
 	}
 }
 
+// setupSecrets returns the providers of the secrets referenced by profiles,
+// or nil if none are configured.
+func setupSecrets(path, envPrefix string) (secrets.Provider, error) {
+	var providers secrets.Multi
+	if path != "" {
+		file, err := secrets.NewFileProvider(path, os.Getenv(secretsPassphraseEnv))
+		if err != nil {
+			return nil, errors.Wrap(err, "open secrets file")
+		}
+		providers = append(providers, file)
+	}
+	if envPrefix != "" {
+		providers = append(providers, secrets.NewEnvProvider(envPrefix))
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return providers, nil
+}
+
 func printExamples() {
 	const exampleText = `
 		Quickstart:
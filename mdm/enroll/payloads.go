@@ -0,0 +1,115 @@
+package enroll
+
+// Payload types of the typed payloads.
+const (
+	WiFiPayloadType            = "com.apple.wifi.managed"
+	RestrictionsPayloadType    = "com.apple.applicationaccess"
+	PasscodePayloadType        = "com.apple.mobiledevice.passwordpolicy"
+	CertificatePayloadType     = "com.apple.security.pkcs1"
+	RootCertificatePayloadType = "com.apple.security.root"
+	PKCS12PayloadType          = "com.apple.security.pkcs12"
+	SCEPPayloadType            = "com.apple.security.scep"
+	LoginWindowPayloadType     = "com.apple.loginwindow"
+	CustomSettingsPayloadType  = "com.apple.ManagedClient.preferences"
+)
+
+// WiFiPayload configures a Wi-Fi network.
+type WiFiPayload struct {
+	Payload
+	SSID            string `json:"ssid" plist:"SSID_STR"`
+	HiddenNetwork   bool   `json:"hidden_network,omitempty" plist:"HIDDEN_NETWORK,omitempty"`
+	AutoJoin        *bool  `json:"auto_join,omitempty" plist:",omitempty"`
+	EncryptionType  string `json:"encryption_type,omitempty" plist:",omitempty"` // WEP, WPA, WPA2, WPA3, Any or None
+	Password        string `json:"password,omitempty" plist:",omitempty"`
+	ProxyType       string `json:"proxy_type,omitempty" plist:",omitempty"` // None, Manual or Auto
+	ProxyServer     string `json:"proxy_server,omitempty" plist:",omitempty"`
+	ProxyServerPort int    `json:"proxy_server_port,omitempty" plist:",omitempty"`
+	ProxyPACURL     string `json:"proxy_pac_url,omitempty" plist:",omitempty"`
+
+	// PayloadCertificateUUID is the PayloadUUID of a certificate payload in
+	// the same profile, used as the identity for enterprise networks.
+	PayloadCertificateUUID string `json:"certificate_uuid,omitempty" plist:",omitempty"`
+}
+
+// RestrictionsPayload restricts the features of a device. Restrictions
+// which are not set keep the default of the device.
+type RestrictionsPayload struct {
+	Payload
+	AllowAirDrop                 *bool `json:"allow_airdrop,omitempty" plist:"allowAirDrop,omitempty"`
+	AllowAppInstallation         *bool `json:"allow_app_installation,omitempty" plist:"allowAppInstallation,omitempty"`
+	AllowCamera                  *bool `json:"allow_camera,omitempty" plist:"allowCamera,omitempty"`
+	AllowCloudDocumentSync       *bool `json:"allow_cloud_document_sync,omitempty" plist:"allowCloudDocumentSync,omitempty"`
+	AllowEraseContentAndSettings *bool `json:"allow_erase_content_and_settings,omitempty" plist:"allowEraseContentAndSettings,omitempty"`
+	AllowPasswordSharing         *bool `json:"allow_password_sharing,omitempty" plist:"allowPasswordSharing,omitempty"`
+	AllowScreenShot              *bool `json:"allow_screenshot,omitempty" plist:"allowScreenShot,omitempty"`
+	AllowUSBRestrictedMode       *bool `json:"allow_usb_restricted_mode,omitempty" plist:"allowUSBRestrictedMode,omitempty"`
+	ForceEncryptedBackup         *bool `json:"force_encrypted_backup,omitempty" plist:"forceEncryptedBackup,omitempty"`
+}
+
+// PasscodePayload sets the passcode policy of a device.
+type PasscodePayload struct {
+	Payload
+	AllowSimple         *bool `json:"allow_simple,omitempty" plist:"allowSimple,omitempty"`
+	ForcePIN            *bool `json:"force_pin,omitempty" plist:"forcePIN,omitempty"`
+	RequireAlphanumeric *bool `json:"require_alphanumeric,omitempty" plist:"requireAlphanumeric,omitempty"`
+	MinLength           int   `json:"min_length,omitempty" plist:"minLength,omitempty"`
+	MinComplexChars     int   `json:"min_complex_chars,omitempty" plist:"minComplexChars,omitempty"`
+	MaxPINAgeInDays     int   `json:"max_pin_age_in_days,omitempty" plist:"maxPINAgeInDays,omitempty"`
+	MaxInactivity       int   `json:"max_inactivity,omitempty" plist:"maxInactivity,omitempty"` // minutes
+	MaxFailedAttempts   int   `json:"max_failed_attempts,omitempty" plist:"maxFailedAttempts,omitempty"`
+	PinHistory          int   `json:"pin_history,omitempty" plist:"pinHistory,omitempty"`
+}
+
+// CertificatePayload installs a DER or PEM encoded certificate. Use
+// RootCertificatePayloadType as the PayloadType for a root certificate.
+type CertificatePayload struct {
+	Payload
+	PayloadCertificateFileName string `json:"filename,omitempty" plist:",omitempty"`
+	PayloadContent             []byte `json:"content"`
+}
+
+// PKCS12Payload installs an identity from a PKCS #12 file.
+type PKCS12Payload struct {
+	Payload
+	PayloadCertificateFileName string `json:"filename,omitempty" plist:",omitempty"`
+	Password                   string `json:"password,omitempty" plist:",omitempty"`
+	PayloadContent             []byte `json:"content"`
+}
+
+// SCEPPayload requests an identity from a SCEP server.
+type SCEPPayload struct {
+	Payload
+	PayloadContent SCEPPayloadContent `json:"content"`
+}
+
+// LoginWindowPayload configures the login window of a Mac.
+type LoginWindowPayload struct {
+	Payload
+	ShowFullName         bool   `json:"show_full_name,omitempty" plist:"SHOWFULLNAME,omitempty"`
+	HideAdminUsers       bool   `json:"hide_admin_users,omitempty" plist:",omitempty"`
+	DisableConsoleAccess bool   `json:"disable_console_access,omitempty" plist:",omitempty"`
+	LoginwindowText      string `json:"text,omitempty" plist:",omitempty"`
+	AdminHostInfo        string `json:"admin_host_info,omitempty" plist:",omitempty"`
+	RestartDisabled      bool   `json:"restart_disabled,omitempty" plist:",omitempty"`
+	ShutDownDisabled     bool   `json:"shutdown_disabled,omitempty" plist:",omitempty"`
+}
+
+// CustomSettingsPayload forces the preferences of an application, such as
+// the settings in defaults domain com.example.app.
+type CustomSettingsPayload struct {
+	Payload
+	PayloadContent map[string]CustomSettingsDomain `json:"content"`
+}
+
+// NewCustomSettingsDomain forces settings in a preference domain.
+func NewCustomSettingsDomain(settings map[string]interface{}) CustomSettingsDomain {
+	return CustomSettingsDomain{Forced: []CustomSettings{{Settings: settings}}}
+}
+
+type CustomSettingsDomain struct {
+	Forced []CustomSettings `json:"forced" plist:"Forced"`
+}
+
+type CustomSettings struct {
+	Settings map[string]interface{} `json:"settings" plist:"mcx_preference_settings"`
+}
@@ -56,14 +56,14 @@ func NewPayload(payloadType string) *Payload {
 }
 
 type SCEPPayloadContent struct {
-	CAFingerprint []byte `plist:"CAFingerprint,omitempty"` // NSData
-	Challenge     string `plist:"Challenge,omitempty"`
-	Keysize       int
-	KeyType       string `plist:"KeyType"`
-	KeyUsage      int    `plist:"KeyUsage"`
-	Name          string
-	Subject       [][][]string `plist:"Subject,omitempty"`
-	URL           string
+	CAFingerprint []byte       `json:"ca_fingerprint,omitempty" plist:"CAFingerprint,omitempty"` // NSData
+	Challenge     string       `json:"challenge,omitempty" plist:"Challenge,omitempty"`
+	Keysize       int          `json:"keysize"`
+	KeyType       string       `json:"key_type" plist:"KeyType"`
+	KeyUsage      int          `json:"key_usage" plist:"KeyUsage"`
+	Name          string       `json:"name,omitempty"`
+	Subject       [][][]string `json:"subject,omitempty" plist:"Subject,omitempty"`
+	URL           string       `json:"url"`
 }
 
 // AccessRights define the management rights of the MDM server over the device.
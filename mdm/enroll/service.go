@@ -29,7 +29,17 @@ type Service interface {
 	OTAPhase3(ctx context.Context) (profile.Mobileconfig, error)
 }
 
-func NewService(topic TopicProvider, sub pubsub.Subscriber, scepURL, scepChallenge, url, tlsCertPath, scepSubject string, profileDB profile.Store) (Service, error) {
+type Option func(*service)
+
+// WithProfileSigner signs the enrollment and OTA profiles. Profiles are sent
+// unsigned while the signer has no signing identity.
+func WithProfileSigner(signer profile.Signer) Option {
+	return func(svc *service) {
+		svc.signer = signer
+	}
+}
+
+func NewService(topic TopicProvider, sub pubsub.Subscriber, scepURL, scepChallenge, url, tlsCertPath, scepSubject string, profileDB profile.Store, opts ...Option) (Service, error) {
 	var tlsCert []byte
 	var err error
 
@@ -69,6 +79,9 @@ func NewService(topic TopicProvider, sub pubsub.Subscriber, scepURL, scepChallen
 		Topic:         pushTopic,
 		topicProvier:  topic,
 	}
+	for _, opt := range opts {
+		opt(svc)
+	}
 
 	if err := updateTopic(svc, sub); err != nil {
 		return nil, errors.Wrap(err, "enroll: start topic update goroutine")
@@ -113,6 +126,7 @@ type service struct {
 	ProfileDB     profile.Store
 
 	topicProvier TopicProvider
+	signer       profile.Signer
 
 	mu    sync.RWMutex
 	Topic string // APNS Topic for MDM notifications
@@ -150,11 +164,27 @@ func (svc *service) findOrMakeMobileconfig(ctx context.Context, id string, f int
 			if err != nil {
 				return nil, err
 			}
-			return profileOrPayloadToMobileconfig(profile)
+			mc, err := profileOrPayloadToMobileconfig(profile)
+			if err != nil {
+				return nil, err
+			}
+			return svc.sign(mc)
 		}
 		return nil, err
 	}
-	return p.Mobileconfig, nil
+	return svc.sign(p.Mobileconfig)
+}
+
+// sign signs unsigned profiles if the server has a signing identity.
+func (svc *service) sign(mc profile.Mobileconfig) (profile.Mobileconfig, error) {
+	if svc.signer == nil || mc.IsSigned() {
+		return mc, nil
+	}
+	signed, err := svc.signer.Sign(mc)
+	if errors.Cause(err) == config.ErrNoProfileSigningIdentity {
+		return mc, nil
+	}
+	return signed, errors.Wrap(err, "sign enrollment profile")
 }
 
 func (svc *service) Enroll(ctx context.Context) (profile.Mobileconfig, error) {
@@ -0,0 +1,304 @@
+package mdm
+
+import (
+	"time"
+
+	"github.com/groob/plist"
+	"github.com/pkg/errors"
+)
+
+// DeviceInformationResponse is the response body of a DeviceInformation command.
+type DeviceInformationResponse struct {
+	QueryResponses QueryResponses `json:"query_responses"`
+}
+
+// QueryResponses holds the values returned for the DeviceInformation queries.
+// Only the keys requested by the command are set by the device.
+type QueryResponses struct {
+	UDID                             string           `plist:",omitempty" json:"udid,omitempty"`
+	DeviceName                       string           `plist:",omitempty" json:"device_name,omitempty"`
+	OSVersion                        string           `plist:",omitempty" json:"os_version,omitempty"`
+	BuildVersion                     string           `plist:",omitempty" json:"build_version,omitempty"`
+	ModelName                        string           `plist:",omitempty" json:"model_name,omitempty"`
+	Model                            string           `plist:",omitempty" json:"model,omitempty"`
+	ProductName                      string           `plist:",omitempty" json:"product_name,omitempty"`
+	SerialNumber                     string           `plist:",omitempty" json:"serial_number,omitempty"`
+	DeviceCapacity                   float64          `plist:",omitempty" json:"device_capacity,omitempty"`
+	AvailableDeviceCapacity          float64          `plist:",omitempty" json:"available_device_capacity,omitempty"`
+	BatteryLevel                     float64          `plist:",omitempty" json:"battery_level,omitempty"`
+	CellularTechnology               int              `plist:",omitempty" json:"cellular_technology,omitempty"`
+	IMEI                             string           `plist:",omitempty" json:"imei,omitempty"`
+	MEID                             string           `plist:",omitempty" json:"meid,omitempty"`
+	ModemFirmwareVersion             string           `plist:",omitempty" json:"modem_firmware_version,omitempty"`
+	IsSupervised                     bool             `plist:",omitempty" json:"is_supervised,omitempty"`
+	IsDeviceLocatorServiceEnabled    bool             `plist:",omitempty" json:"is_device_locator_service_enabled,omitempty"`
+	IsActivationLockEnabled          bool             `plist:",omitempty" json:"is_activation_lock_enabled,omitempty"`
+	IsDoNotDisturbInEffect           bool             `plist:",omitempty" json:"is_do_not_disturb_in_effect,omitempty"`
+	IsCloudBackupEnabled             bool             `plist:",omitempty" json:"is_cloud_backup_enabled,omitempty"`
+	IsMDMLostModeEnabled             bool             `plist:",omitempty" json:"is_mdm_lost_mode_enabled,omitempty"`
+	IsMultiUser                      bool             `plist:",omitempty" json:"is_multi_user,omitempty"`
+	IsNetworkTethered                bool             `plist:",omitempty" json:"is_network_tethered,omitempty"`
+	ITunesStoreAccountIsActive       bool             `plist:"iTunesStoreAccountIsActive,omitempty" json:"itunes_store_account_is_active,omitempty"`
+	LastCloudBackupDate              time.Time        `plist:",omitempty" json:"last_cloud_backup_date,omitempty"`
+	EASDeviceIdentifier              string           `plist:",omitempty" json:"eas_device_identifier,omitempty"`
+	LocalHostName                    string           `plist:",omitempty" json:"local_host_name,omitempty"`
+	HostName                         string           `plist:",omitempty" json:"host_name,omitempty"`
+	WiFiMAC                          string           `plist:",omitempty" json:"wifi_mac,omitempty"`
+	BluetoothMAC                     string           `plist:",omitempty" json:"bluetooth_mac,omitempty"`
+	EthernetMACs                     []string         `plist:",omitempty" json:"ethernet_macs,omitempty"`
+	PhoneNumber                      string           `plist:",omitempty" json:"phone_number,omitempty"`
+	SystemIntegrityProtectionEnabled bool             `plist:",omitempty" json:"system_integrity_protection_enabled,omitempty"`
+	MaximumResidentUsers             int              `plist:",omitempty" json:"maximum_resident_users,omitempty"`
+	OSUpdateSettings                 OSUpdateSettings `plist:",omitempty" json:"os_update_settings,omitempty"`
+}
+
+type OSUpdateSettings struct {
+	CatalogURL                      string    `plist:",omitempty" json:"catalog_url,omitempty"`
+	IsDefaultCatalog                bool      `plist:",omitempty" json:"is_default_catalog,omitempty"`
+	PreviousScanDate                time.Time `plist:",omitempty" json:"previous_scan_date,omitempty"`
+	PreviousScanResult              string    `plist:",omitempty" json:"previous_scan_result,omitempty"`
+	PerformPeriodicCheck            bool      `plist:",omitempty" json:"perform_periodic_check,omitempty"`
+	AutomaticCheckEnabled           bool      `plist:",omitempty" json:"automatic_check_enabled,omitempty"`
+	BackgroundDownloadEnabled       bool      `plist:",omitempty" json:"background_download_enabled,omitempty"`
+	AutomaticAppInstallationEnabled bool      `plist:",omitempty" json:"automatic_app_installation_enabled,omitempty"`
+	AutomaticOSInstallationEnabled  bool      `plist:",omitempty" json:"automatic_os_installation_enabled,omitempty"`
+	AutomaticSecurityUpdatesEnabled bool      `plist:",omitempty" json:"automatic_security_updates_enabled,omitempty"`
+}
+
+// InstalledApplicationListResponse is the response body of an InstalledApplicationList command.
+type InstalledApplicationListResponse struct {
+	InstalledApplicationList []InstalledApplication `json:"installed_application_list"`
+}
+
+type InstalledApplication struct {
+	Identifier                string `plist:",omitempty" json:"identifier,omitempty"`
+	Name                      string `plist:",omitempty" json:"name,omitempty"`
+	ShortVersion              string `plist:",omitempty" json:"short_version,omitempty"`
+	Version                   string `plist:",omitempty" json:"version,omitempty"`
+	BundleSize                int64  `plist:",omitempty" json:"bundle_size,omitempty"`
+	DynamicSize               int64  `plist:",omitempty" json:"dynamic_size,omitempty"`
+	IsValidated               bool   `plist:",omitempty" json:"is_validated,omitempty"`
+	ExternalVersionIdentifier int64  `plist:",omitempty" json:"external_version_identifier,omitempty"`
+	Installing                bool   `plist:",omitempty" json:"installing,omitempty"`
+	AppStoreVendable          bool   `plist:",omitempty" json:"app_store_vendable,omitempty"`
+	DeviceBasedVPP            bool   `plist:",omitempty" json:"device_based_vpp,omitempty"`
+	BetaApp                   bool   `plist:",omitempty" json:"beta_app,omitempty"`
+	AdHocCodeSigned           bool   `plist:",omitempty" json:"ad_hoc_code_signed,omitempty"`
+	HasUpdateAvailable        bool   `plist:",omitempty" json:"has_update_available,omitempty"`
+}
+
+// ProfileListResponse is the response body of a ProfileList command.
+type ProfileListResponse struct {
+	ProfileList []ProfileListItem `json:"profile_list"`
+}
+
+type ProfileListItem struct {
+	PayloadIdentifier        string               `plist:",omitempty" json:"payload_identifier,omitempty"`
+	PayloadUUID              string               `plist:",omitempty" json:"payload_uuid,omitempty"`
+	PayloadDisplayName       string               `plist:",omitempty" json:"payload_display_name,omitempty"`
+	PayloadDescription       string               `plist:",omitempty" json:"payload_description,omitempty"`
+	PayloadOrganization      string               `plist:",omitempty" json:"payload_organization,omitempty"`
+	PayloadVersion           int                  `plist:",omitempty" json:"payload_version,omitempty"`
+	PayloadRemovalDisallowed bool                 `plist:",omitempty" json:"payload_removal_disallowed,omitempty"`
+	HasRemovalPasscode       bool                 `plist:",omitempty" json:"has_removal_passcode,omitempty"`
+	IsEncrypted              bool                 `plist:",omitempty" json:"is_encrypted,omitempty"`
+	IsManaged                bool                 `plist:",omitempty" json:"is_managed,omitempty"`
+	PayloadContent           []ProfileListPayload `plist:",omitempty" json:"payload_content,omitempty"`
+	SignerCertificates       [][]byte             `plist:",omitempty" json:"signer_certificates,omitempty"`
+}
+
+type ProfileListPayload struct {
+	PayloadIdentifier   string `plist:",omitempty" json:"payload_identifier,omitempty"`
+	PayloadType         string `plist:",omitempty" json:"payload_type,omitempty"`
+	PayloadDisplayName  string `plist:",omitempty" json:"payload_display_name,omitempty"`
+	PayloadDescription  string `plist:",omitempty" json:"payload_description,omitempty"`
+	PayloadOrganization string `plist:",omitempty" json:"payload_organization,omitempty"`
+	PayloadVersion      int    `plist:",omitempty" json:"payload_version,omitempty"`
+}
+
+// CertificateListResponse is the response body of a CertificateList command.
+type CertificateListResponse struct {
+	CertificateList []CertificateListItem `json:"certificate_list"`
+}
+
+type CertificateListItem struct {
+	CommonName string `plist:",omitempty" json:"common_name,omitempty"`
+	Data       []byte `plist:",omitempty" json:"data,omitempty"`
+	IsIdentity bool   `plist:",omitempty" json:"is_identity,omitempty"`
+}
+
+// SecurityInfoResponse is the response body of a SecurityInfo command.
+type SecurityInfoResponse struct {
+	SecurityInfo SecurityInfo `json:"security_info"`
+}
+
+type SecurityInfo struct {
+	HardwareEncryptionCaps           int                    `plist:",omitempty" json:"hardware_encryption_caps,omitempty"`
+	PasscodePresent                  bool                   `plist:",omitempty" json:"passcode_present,omitempty"`
+	PasscodeCompliant                bool                   `plist:",omitempty" json:"passcode_compliant,omitempty"`
+	PasscodeCompliantWithProfiles    bool                   `plist:",omitempty" json:"passcode_compliant_with_profiles,omitempty"`
+	PasscodeLockGracePeriodEnforced  int                    `plist:",omitempty" json:"passcode_lock_grace_period_enforced,omitempty"`
+	FDEEnabled                       bool                   `plist:"FDE_Enabled,omitempty" json:"fde_enabled,omitempty"`
+	FDEHasPersonalRecoveryKey        bool                   `plist:"FDE_HasPersonalRecoveryKey,omitempty" json:"fde_has_personal_recovery_key,omitempty"`
+	FDEHasInstitutionalRecoveryKey   bool                   `plist:"FDE_HasInstitutionalRecoveryKey,omitempty" json:"fde_has_institutional_recovery_key,omitempty"`
+	FDEPersonalRecoveryKeyCMS        []byte                 `plist:"FDE_PersonalRecoveryKeyCMS,omitempty" json:"fde_personal_recovery_key_cms,omitempty"`
+	FDEPersonalRecoveryKeyDeviceKey  string                 `plist:"FDE_PersonalRecoveryKeyDeviceKey,omitempty" json:"fde_personal_recovery_key_device_key,omitempty"`
+	SystemIntegrityProtectionEnabled bool                   `plist:",omitempty" json:"system_integrity_protection_enabled,omitempty"`
+	FirewallSettings                 FirewallSettings       `plist:",omitempty" json:"firewall_settings,omitempty"`
+	FirmwarePasswordStatus           FirmwarePasswordStatus `plist:",omitempty" json:"firmware_password_status,omitempty"`
+	ManagementStatus                 ManagementStatus       `plist:",omitempty" json:"management_status,omitempty"`
+}
+
+type FirewallSettings struct {
+	FirewallEnabled  bool                  `plist:",omitempty" json:"firewall_enabled,omitempty"`
+	BlockAllIncoming bool                  `plist:",omitempty" json:"block_all_incoming,omitempty"`
+	StealthMode      bool                  `plist:",omitempty" json:"stealth_mode,omitempty"`
+	Applications     []FirewallApplication `plist:",omitempty" json:"applications,omitempty"`
+}
+
+type FirewallApplication struct {
+	BundleID string `plist:",omitempty" json:"bundle_id,omitempty"`
+	Allowed  bool   `plist:",omitempty" json:"allowed,omitempty"`
+	Name     string `plist:",omitempty" json:"name,omitempty"`
+}
+
+type FirmwarePasswordStatus struct {
+	PasswordExists bool `plist:",omitempty" json:"password_exists,omitempty"`
+	ChangePending  bool `plist:",omitempty" json:"change_pending,omitempty"`
+	AllowOroms     bool `plist:",omitempty" json:"allow_oroms,omitempty"`
+}
+
+type ManagementStatus struct {
+	EnrolledViaDEP         bool `plist:",omitempty" json:"enrolled_via_dep,omitempty"`
+	UserApprovedEnrollment bool `plist:",omitempty" json:"user_approved_enrollment,omitempty"`
+	IsUserEnrollment       bool `plist:",omitempty" json:"is_user_enrollment,omitempty"`
+}
+
+// UserListResponse is the response body of a UserList command.
+type UserListResponse struct {
+	Users []UserListItem `json:"users"`
+}
+
+type UserListItem struct {
+	UserName       string `plist:",omitempty" json:"username,omitempty"`
+	FullName       string `plist:",omitempty" json:"full_name,omitempty"`
+	UID            int    `plist:",omitempty" json:"uid,omitempty"`
+	UserGUID       string `plist:",omitempty" json:"user_guid,omitempty"`
+	IsLoggedIn     bool   `plist:",omitempty" json:"is_logged_in,omitempty"`
+	HasDataToSync  bool   `plist:",omitempty" json:"has_data_to_sync,omitempty"`
+	HasSecureToken bool   `plist:",omitempty" json:"has_secure_token,omitempty"`
+	MobileAccount  bool   `plist:",omitempty" json:"mobile_account,omitempty"`
+	DataQuota      int64  `plist:",omitempty" json:"data_quota,omitempty"`
+	DataUsed       int64  `plist:",omitempty" json:"data_used,omitempty"`
+}
+
+// DeviceLocationResponse is the response body of a DeviceLocation command.
+type DeviceLocationResponse struct {
+	Latitude           float64 `plist:",omitempty" json:"latitude,omitempty"`
+	Longitude          float64 `plist:",omitempty" json:"longitude,omitempty"`
+	HorizontalAccuracy float64 `plist:",omitempty" json:"horizontal_accuracy,omitempty"`
+	VerticalAccuracy   float64 `plist:",omitempty" json:"vertical_accuracy,omitempty"`
+	Altitude           float64 `plist:",omitempty" json:"altitude,omitempty"`
+	Speed              float64 `plist:",omitempty" json:"speed,omitempty"`
+	Course             float64 `plist:",omitempty" json:"course,omitempty"`
+	Timestamp          string  `plist:",omitempty" json:"timestamp,omitempty"`
+}
+
+// AvailableOSUpdatesResponse is the response body of an AvailableOSUpdates command.
+type AvailableOSUpdatesResponse struct {
+	AvailableOSUpdates []AvailableOSUpdate `json:"available_os_updates"`
+}
+
+type AvailableOSUpdate struct {
+	ProductKey              string   `plist:",omitempty" json:"product_key,omitempty"`
+	HumanReadableName       string   `plist:",omitempty" json:"human_readable_name,omitempty"`
+	HumanReadableNameLocale string   `plist:",omitempty" json:"human_readable_name_locale,omitempty"`
+	ProductName             string   `plist:",omitempty" json:"product_name,omitempty"`
+	Version                 string   `plist:",omitempty" json:"version,omitempty"`
+	Build                   string   `plist:",omitempty" json:"build,omitempty"`
+	DownloadSize            int64    `plist:",omitempty" json:"download_size,omitempty"`
+	InstallSize             int64    `plist:",omitempty" json:"install_size,omitempty"`
+	AppIdentifiersToClose   []string `plist:",omitempty" json:"app_identifiers_to_close,omitempty"`
+	IsCritical              bool     `plist:",omitempty" json:"is_critical,omitempty"`
+	IsConfigDataUpdate      bool     `plist:",omitempty" json:"is_config_data_update,omitempty"`
+	IsFirmwareUpdate        bool     `plist:",omitempty" json:"is_firmware_update,omitempty"`
+	RestartRequired         bool     `plist:",omitempty" json:"restart_required,omitempty"`
+	AllowsInstallLater      bool     `plist:",omitempty" json:"allows_install_later,omitempty"`
+	MetadataURL             string   `plist:",omitempty" json:"metadata_url,omitempty"`
+}
+
+// OSUpdateStatusResponse is the response body of an OSUpdateStatus command.
+type OSUpdateStatusResponse struct {
+	OSUpdateStatus []OSUpdateStatus `json:"os_update_status"`
+}
+
+type OSUpdateStatus struct {
+	ProductKey              string  `plist:",omitempty" json:"product_key,omitempty"`
+	IsDownloaded            bool    `plist:",omitempty" json:"is_downloaded,omitempty"`
+	DownloadPercentComplete float64 `plist:",omitempty" json:"download_percent_complete,omitempty"`
+	Status                  string  `plist:",omitempty" json:"status,omitempty"`
+	MaxDeferrals            int     `plist:",omitempty" json:"max_deferrals,omitempty"`
+	DeferralsRemaining      int     `plist:",omitempty" json:"deferrals_remaining,omitempty"`
+}
+
+// ManagedApplicationListResponse is the response body of a ManagedApplicationList command.
+// The ManagedApplicationList dictionary is keyed by the application bundle identifier.
+type ManagedApplicationListResponse struct {
+	ManagedApplicationList map[string]ManagedApplication `json:"managed_application_list"`
+}
+
+type ManagedApplication struct {
+	Status                    string `plist:",omitempty" json:"status,omitempty"`
+	ManagementFlags           int    `plist:",omitempty" json:"management_flags,omitempty"`
+	UnusedRedemptionCode      string `plist:",omitempty" json:"unused_redemption_code,omitempty"`
+	HasConfiguration          bool   `plist:",omitempty" json:"has_configuration,omitempty"`
+	HasFeedback               bool   `plist:",omitempty" json:"has_feedback,omitempty"`
+	IsValidated               bool   `plist:",omitempty" json:"is_validated,omitempty"`
+	ExternalVersionIdentifier int64  `plist:",omitempty" json:"external_version_identifier,omitempty"`
+}
+
+// ActivationLockBypassCodeResponse is the response body of an ActivationLockBypassCode command.
+type ActivationLockBypassCodeResponse struct {
+	ActivationLockBypassCode string `plist:",omitempty" json:"activation_lock_bypass_code,omitempty"`
+}
+
+// responseTypes maps a command RequestType to a constructor for the
+// typed response of that command.
+var responseTypes = map[string]func() interface{}{
+	"DeviceInformation":        func() interface{} { return new(DeviceInformationResponse) },
+	"InstalledApplicationList": func() interface{} { return new(InstalledApplicationListResponse) },
+	"ProfileList":              func() interface{} { return new(ProfileListResponse) },
+	"CertificateList":          func() interface{} { return new(CertificateListResponse) },
+	"SecurityInfo":             func() interface{} { return new(SecurityInfoResponse) },
+	"UserList":                 func() interface{} { return new(UserListResponse) },
+	"DeviceLocation":           func() interface{} { return new(DeviceLocationResponse) },
+	"AvailableOSUpdates":       func() interface{} { return new(AvailableOSUpdatesResponse) },
+	"OSUpdateStatus":           func() interface{} { return new(OSUpdateStatusResponse) },
+	"ManagedApplicationList":   func() interface{} { return new(ManagedApplicationListResponse) },
+	"ActivationLockBypassCode": func() interface{} { return new(ActivationLockBypassCodeResponse) },
+}
+
+// ErrUnsupportedResponse is returned by DecodeResponse for request types
+// which have no typed response.
+var ErrUnsupportedResponse = errors.New("mdm: unsupported response request type")
+
+// SupportsResponse reports whether DecodeResponse can decode the response of a requestType.
+func SupportsResponse(requestType string) bool {
+	_, ok := responseTypes[requestType]
+	return ok
+}
+
+// DecodeResponse parses the raw plist body the device sent in reply to a
+// command and returns a pointer to the typed response for the requestType,
+// for example *DeviceInformationResponse.
+func DecodeResponse(requestType string, raw []byte) (interface{}, error) {
+	newResponse, ok := responseTypes[requestType]
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedResponse, requestType)
+	}
+	resp := newResponse()
+	if err := plist.Unmarshal(raw, resp); err != nil {
+		return nil, errors.Wrapf(err, "mdm: unmarshal %s response plist", requestType)
+	}
+	return resp, nil
+}
@@ -0,0 +1,198 @@
+package mdm
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	var tests = []struct {
+		requestType string
+		testFn      func(t *testing.T, resp interface{})
+	}{
+		{
+			requestType: "DeviceInformation",
+			testFn: func(t *testing.T, resp interface{}) {
+				qr := resp.(*DeviceInformationResponse).QueryResponses
+				if have, want := qr.SerialNumber, "C02XK1JAJHD3"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+				if have, want := qr.OSVersion, "10.14.6"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+				if have, want := qr.DeviceCapacity, 233.47; have != want {
+					t.Errorf("have %v, want %v", have, want)
+				}
+				if !qr.IsSupervised {
+					t.Error("expected IsSupervised to be true")
+				}
+				if qr.OSUpdateSettings.PreviousScanDate.IsZero() {
+					t.Error("expected OSUpdateSettings.PreviousScanDate to be set")
+				}
+			},
+		},
+		{
+			requestType: "InstalledApplicationList",
+			testFn: func(t *testing.T, resp interface{}) {
+				apps := resp.(*InstalledApplicationListResponse).InstalledApplicationList
+				if have, want := len(apps), 2; have != want {
+					t.Fatalf("have %d apps, want %d", have, want)
+				}
+				if have, want := apps[1].Identifier, "com.microsoft.Word"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+				if have, want := apps[1].BundleSize, int64(283213824); have != want {
+					t.Errorf("have %d, want %d", have, want)
+				}
+			},
+		},
+		{
+			requestType: "ProfileList",
+			testFn: func(t *testing.T, resp interface{}) {
+				profiles := resp.(*ProfileListResponse).ProfileList
+				if have, want := len(profiles), 1; have != want {
+					t.Fatalf("have %d profiles, want %d", have, want)
+				}
+				if have, want := profiles[0].PayloadIdentifier, "com.github.micromdm.micromdm.enroll"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+				if have, want := len(profiles[0].PayloadContent), 2; have != want {
+					t.Fatalf("have %d payloads, want %d", have, want)
+				}
+				if have, want := profiles[0].PayloadContent[0].PayloadType, "com.apple.mdm"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+			},
+		},
+		{
+			requestType: "CertificateList",
+			testFn: func(t *testing.T, resp interface{}) {
+				certs := resp.(*CertificateListResponse).CertificateList
+				if have, want := len(certs), 2; have != want {
+					t.Fatalf("have %d certificates, want %d", have, want)
+				}
+				if !certs[0].IsIdentity || certs[1].IsIdentity {
+					t.Error("expected only the first certificate to be an identity")
+				}
+				if len(certs[0].Data) == 0 {
+					t.Error("expected certificate data")
+				}
+			},
+		},
+		{
+			requestType: "SecurityInfo",
+			testFn: func(t *testing.T, resp interface{}) {
+				info := resp.(*SecurityInfoResponse).SecurityInfo
+				if !info.FDEEnabled {
+					t.Error("expected FDE_Enabled to be true")
+				}
+				if !info.FirewallSettings.StealthMode {
+					t.Error("expected firewall stealth mode to be enabled")
+				}
+				if !info.ManagementStatus.EnrolledViaDEP {
+					t.Error("expected EnrolledViaDEP to be true")
+				}
+			},
+		},
+		{
+			requestType: "UserList",
+			testFn: func(t *testing.T, resp interface{}) {
+				users := resp.(*UserListResponse).Users
+				if have, want := len(users), 1; have != want {
+					t.Fatalf("have %d users, want %d", have, want)
+				}
+				if have, want := users[0].UserName, "labadmin"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+				if have, want := users[0].UID, 501; have != want {
+					t.Errorf("have %d, want %d", have, want)
+				}
+			},
+		},
+		{
+			requestType: "DeviceLocation",
+			testFn: func(t *testing.T, resp interface{}) {
+				loc := resp.(*DeviceLocationResponse)
+				if have, want := loc.Latitude, 40.7484; have != want {
+					t.Errorf("have %v, want %v", have, want)
+				}
+				if have, want := loc.Longitude, -73.9857; have != want {
+					t.Errorf("have %v, want %v", have, want)
+				}
+			},
+		},
+		{
+			requestType: "AvailableOSUpdates",
+			testFn: func(t *testing.T, resp interface{}) {
+				updates := resp.(*AvailableOSUpdatesResponse).AvailableOSUpdates
+				if have, want := len(updates), 1; have != want {
+					t.Fatalf("have %d updates, want %d", have, want)
+				}
+				if have, want := updates[0].ProductKey, "061-26589"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+				if !updates[0].RestartRequired {
+					t.Error("expected RestartRequired to be true")
+				}
+			},
+		},
+		{
+			requestType: "OSUpdateStatus",
+			testFn: func(t *testing.T, resp interface{}) {
+				status := resp.(*OSUpdateStatusResponse).OSUpdateStatus
+				if have, want := len(status), 1; have != want {
+					t.Fatalf("have %d statuses, want %d", have, want)
+				}
+				if have, want := status[0].Status, "Downloading"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+				if have, want := status[0].DownloadPercentComplete, 0.42; have != want {
+					t.Errorf("have %v, want %v", have, want)
+				}
+			},
+		},
+		{
+			requestType: "ManagedApplicationList",
+			testFn: func(t *testing.T, resp interface{}) {
+				apps := resp.(*ManagedApplicationListResponse).ManagedApplicationList
+				app, ok := apps["com.example.SelfService"]
+				if !ok {
+					t.Fatal("expected com.example.SelfService in managed application list")
+				}
+				if have, want := app.Status, "Managed"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+			},
+		},
+		{
+			requestType: "ActivationLockBypassCode",
+			testFn: func(t *testing.T, resp interface{}) {
+				code := resp.(*ActivationLockBypassCodeResponse).ActivationLockBypassCode
+				if have, want := code, "J0KFX-7FQ3M-R8C2W-4HKD6-T9E1G-QJ5A"; have != want {
+					t.Errorf("have %s, want %s", have, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requestType, func(t *testing.T) {
+			if !SupportsResponse(tt.requestType) {
+				t.Fatalf("expected %s to have a typed response", tt.requestType)
+			}
+			data := mustLoadFile(t, filepath.Join("responses", tt.requestType+".plist"))
+			resp, err := DecodeResponse(tt.requestType, data)
+			if err != nil {
+				t.Fatalf("decode %s response: %s", tt.requestType, err)
+			}
+			tt.testFn(t, resp)
+		})
+	}
+}
+
+func TestDecodeResponse_Unsupported(t *testing.T) {
+	_, err := DecodeResponse("RestartDevice", []byte{})
+	if err == nil {
+		t.Fatal("expected error for request type without a typed response")
+	}
+}
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS devices_udid_idx ON devices (udid);
+CREATE INDEX IF NOT EXISTS devices_serial_number_idx ON devices (serial_number);
+CREATE INDEX IF NOT EXISTS devices_model_idx ON devices (model);
+CREATE INDEX IF NOT EXISTS devices_model_name_idx ON devices (model_name);
+CREATE INDEX IF NOT EXISTS devices_product_name_idx ON devices (product_name);
+-- os_version_key is written by the server, see device.OSVersionKey.
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS os_version_key TEXT COLLATE "C" DEFAULT '';
+UPDATE devices SET os_version_key = regexp_replace(COALESCE((
+    SELECT string_agg(lpad(COALESCE(substring(part FROM '^[0-9]{1,10}$'), '0'), 10, '0'), '' ORDER BY n)
+    FROM unnest(string_to_array(os_version, '.')) WITH ORDINALITY AS parts(part, n)
+), ''), '(0{10})+$', '');
+CREATE INDEX IF NOT EXISTS devices_os_version_key_idx ON devices (os_version_key);
+CREATE INDEX IF NOT EXISTS devices_enrolled_idx ON devices (enrolled);
+CREATE INDEX IF NOT EXISTS devices_dep_profile_status_idx ON devices (dep_profile_status);
+CREATE INDEX IF NOT EXISTS devices_last_seen_idx ON devices (last_seen);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS devices_udid_idx;
+DROP INDEX IF EXISTS devices_serial_number_idx;
+DROP INDEX IF EXISTS devices_model_idx;
+DROP INDEX IF EXISTS devices_model_name_idx;
+DROP INDEX IF EXISTS devices_product_name_idx;
+DROP INDEX IF EXISTS devices_os_version_key_idx;
+ALTER TABLE devices DROP COLUMN IF EXISTS os_version_key;
+DROP INDEX IF EXISTS devices_enrolled_idx;
+DROP INDEX IF EXISTS devices_dep_profile_status_idx;
+DROP INDEX IF EXISTS devices_last_seen_idx;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS attributes JSONB DEFAULT '{}';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS notes TEXT DEFAULT '';
+CREATE INDEX IF NOT EXISTS devices_attributes_idx ON devices USING GIN (attributes);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS devices_attributes_idx;
+ALTER TABLE devices DROP COLUMN IF EXISTS notes;
+ALTER TABLE devices DROP COLUMN IF EXISTS attributes;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS lifecycle TEXT DEFAULT 'active';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS lifecycle_changed TIMESTAMP DEFAULT '1970-01-01 00:00:00';
+CREATE INDEX IF NOT EXISTS devices_lifecycle_idx ON devices (lifecycle);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS devices_lifecycle_idx;
+ALTER TABLE devices DROP COLUMN IF EXISTS lifecycle_changed;
+ALTER TABLE devices DROP COLUMN IF EXISTS lifecycle;
@@ -0,0 +1,44 @@
+package profileutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fullsailor/pkcs7"
+
+	"github.com/micromdm/micromdm/pkg/crypto"
+)
+
+func TestEncrypt(t *testing.T) {
+	key, cert, err := crypto.SimpleSelfSignedRSAKeypair("device", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range [][]byte{
+		[]byte("<array><dict></dict></array>"),
+		bytes.Repeat([]byte{'a'}, 16),
+	} {
+		encrypted, err := Encrypt(content, cert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p7, err := pkcs7.Parse(encrypted)
+		if err != nil {
+			t.Fatalf("parse enveloped data: %s", err)
+		}
+		decrypted, err := p7.Decrypt(cert, key)
+		if err != nil {
+			t.Fatalf("decrypt enveloped data: %s", err)
+		}
+		if !bytes.Equal(decrypted, content) {
+			t.Errorf("have %q, want %q", decrypted, content)
+		}
+	}
+}
+
+func TestEncryptNoRecipients(t *testing.T) {
+	if _, err := Encrypt([]byte("content")); err == nil {
+		t.Error("expected an error without recipients")
+	}
+}
@@ -10,7 +10,8 @@ import (
 )
 
 // Sign takes an unsigned payload and signs it with the provided private key and certificate.
-func Sign(key crypto.PrivateKey, cert *x509.Certificate, mobileconfig []byte) ([]byte, error) {
+// Intermediate certificates of the signing certificate are added to the signed data.
+func Sign(key crypto.PrivateKey, cert *x509.Certificate, mobileconfig []byte, intermediates ...*x509.Certificate) ([]byte, error) {
 	sd, err := pkcs7.NewSignedData(mobileconfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "create signed data for mobileconfig")
@@ -19,6 +20,9 @@ func Sign(key crypto.PrivateKey, cert *x509.Certificate, mobileconfig []byte) ([
 	if err := sd.AddSigner(cert, key, pkcs7.SignerInfoConfig{}); err != nil {
 		return nil, errors.Wrap(err, "add crypto signer to mobileconfig signed data")
 	}
+	for _, c := range intermediates {
+		sd.AddCertificate(c)
+	}
 
 	signedMobileconfig, err := sd.Finish()
 	return signedMobileconfig, errors.Wrap(err, "complete mobileconfig signing")
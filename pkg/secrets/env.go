@@ -0,0 +1,44 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// EnvProvider looks up secrets in environment variables. The variable of a
+// secret is its name in upper case with every character other than a
+// letter or a digit replaced by '_', after the prefix. With the prefix
+// MICROMDM_SECRET_, wifi/corp is read from MICROMDM_SECRET_WIFI_CORP.
+type EnvProvider struct {
+	prefix string
+	lookup func(string) (string, bool)
+}
+
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix, lookup: os.LookupEnv}
+}
+
+func (p *EnvProvider) Secret(ctx context.Context, name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	value, ok := p.lookup(p.Variable(name))
+	if !ok {
+		return "", &notFound{name: name}
+	}
+	return value, nil
+}
+
+// Variable returns the environment variable of the secret.
+func (p *EnvProvider) Variable(name string) string {
+	return p.prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,190 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The secrets file is the salt of the key, followed by the nonce and the
+// secretbox sealed JSON object of the secrets. The key is derived from a
+// passphrase with scrypt.
+const (
+	saltSize  = 16
+	nonceSize = 24
+	keySize   = 32
+)
+
+var fileMagic = []byte("micromdm-secrets-v1\n")
+
+// FileProvider looks up secrets in a file encrypted with a passphrase. The
+// file is read again for every secret, so it can be changed with WriteFile
+// while the server is running.
+type FileProvider struct {
+	path       string
+	passphrase []byte
+
+	mu   sync.Mutex
+	salt []byte
+	key  *[keySize]byte
+}
+
+// NewFileProvider returns a provider for the secrets file at path, and
+// checks that it can be decrypted with passphrase.
+func NewFileProvider(path, passphrase string) (*FileProvider, error) {
+	p := &FileProvider{path: path, passphrase: []byte(passphrase)}
+	if _, err := p.read(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *FileProvider) Secret(ctx context.Context, name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	secrets, err := p.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", &notFound{name: name}
+	}
+	return value, nil
+}
+
+func (p *FileProvider) read() (map[string]string, error) {
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read secrets file")
+	}
+	salt, err := fileSalt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	if !bytes.Equal(salt, p.salt) {
+		key, err := deriveKey(p.passphrase, salt)
+		if err != nil {
+			p.mu.Unlock()
+			return nil, err
+		}
+		p.salt, p.key = salt, key
+	}
+	key := p.key
+	p.mu.Unlock()
+
+	return open(data, key)
+}
+
+// ReadFile decrypts the secrets file at path.
+func ReadFile(path, passphrase string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read secrets file")
+	}
+	salt, err := fileSalt(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey([]byte(passphrase), salt)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, key)
+}
+
+// WriteFile encrypts secrets with passphrase and replaces the secrets file
+// at path with them.
+func WriteFile(path, passphrase string, secrets map[string]string) error {
+	for name := range secrets {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return errors.Wrap(err, "marshal secrets")
+	}
+
+	salt := make([]byte, saltSize)
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return errors.Wrap(err, "generate salt")
+	}
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return errors.Wrap(err, "generate nonce")
+	}
+	key, err := deriveKey([]byte(passphrase), salt)
+	if err != nil {
+		return err
+	}
+
+	data := append([]byte{}, fileMagic...)
+	data = append(data, salt...)
+	data = append(data, nonce[:]...)
+	data = secretbox.Seal(data, plaintext, &nonce, key)
+
+	// write a temporary file and rename it, so the server never reads a
+	// partially written file.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".secrets")
+	if err != nil {
+		return errors.Wrap(err, "create secrets file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "write secrets file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "write secrets file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "replace secrets file")
+}
+
+func fileSalt(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, fileMagic) || len(data) < len(fileMagic)+saltSize+nonceSize+secretbox.Overhead {
+		return nil, errors.New("not a secrets file")
+	}
+	return data[len(fileMagic) : len(fileMagic)+saltSize], nil
+}
+
+func open(data []byte, key *[keySize]byte) (map[string]string, error) {
+	var nonce [nonceSize]byte
+	data = data[len(fileMagic)+saltSize:]
+	copy(nonce[:], data[:nonceSize])
+	plaintext, ok := secretbox.Open(nil, data[nonceSize:], &nonce, key)
+	if !ok {
+		return nil, errors.New("decrypt secrets file: wrong passphrase or corrupt file")
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, errors.New("decrypt secrets file: invalid content")
+	}
+	return secrets, nil
+}
+
+func deriveKey(passphrase, salt []byte) (*[keySize]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("secrets file passphrase must not be empty")
+	}
+	k, err := scrypt.Key(passphrase, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive secrets file key")
+	}
+	var key [keySize]byte
+	copy(key[:], k)
+	return &key, nil
+}
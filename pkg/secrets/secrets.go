@@ -0,0 +1,67 @@
+// Package secrets resolves the secrets referenced by profiles, such as
+// {{secret "wifi/corp"}}, so that passwords don't have to be stored in
+// the profiles themselves.
+package secrets
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Provider looks up the value of a secret by name. Implementations must
+// not log the values they return, or include them in errors.
+type Provider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*(/[A-Za-z0-9_][A-Za-z0-9_.-]*)*$`)
+
+// ValidateName returns an error if name is not a valid secret name. Names
+// are made of letters, digits, '_', '.' and '-', in parts separated by '/'
+// which start with a letter, a digit or '_'.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return errors.Errorf("invalid secret name %q", name)
+	}
+	return nil
+}
+
+type notFound struct {
+	name string
+}
+
+func (e *notFound) Error() string {
+	return "secret not found: " + e.name
+}
+
+func (e *notFound) NotFound() bool {
+	return true
+}
+
+// IsNotFound reports whether err is returned for a secret which doesn't
+// exist.
+func IsNotFound(err error) bool {
+	type notFoundError interface {
+		error
+		NotFound() bool
+	}
+	e, ok := errors.Cause(err).(notFoundError)
+	return ok && e.NotFound()
+}
+
+// Multi looks up secrets in each of the providers in order, and returns
+// the first secret found.
+type Multi []Provider
+
+func (m Multi) Secret(ctx context.Context, name string) (string, error) {
+	for _, p := range m {
+		value, err := p.Secret(ctx, name)
+		if IsNotFound(err) {
+			continue
+		}
+		return value, err
+	}
+	return "", &notFound{name: name}
+}
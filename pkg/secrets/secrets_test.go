@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets")
+
+	if err := WriteFile(path, "passphrase", map[string]string{"wifi/corp": "p4ssw0rd"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "p4ssw0rd") || strings.Contains(string(data), "wifi/corp") {
+		t.Error("secrets file is not encrypted")
+	}
+
+	ctx := context.Background()
+	p, err := NewFileProvider(path, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := p.Secret(ctx, "wifi/corp"); err != nil || value != "p4ssw0rd" {
+		t.Errorf("have secret %q, err %v", value, err)
+	}
+	if _, err := p.Secret(ctx, "wifi/guest"); !IsNotFound(err) {
+		t.Errorf("want not found error, have %v", err)
+	}
+
+	// the file is read again after it is replaced.
+	if err := WriteFile(path, "passphrase", map[string]string{"wifi/guest": "guest"}); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := p.Secret(ctx, "wifi/guest"); err != nil || value != "guest" {
+		t.Errorf("have secret %q, err %v", value, err)
+	}
+
+	if _, err := NewFileProvider(path, "wrong"); err == nil || strings.Contains(err.Error(), "guest") {
+		t.Errorf("want decryption error without the secrets, have %v", err)
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	env := map[string]string{"MICROMDM_SECRET_WIFI_CORP": "p4ssw0rd"}
+	p := NewEnvProvider("MICROMDM_SECRET_")
+	p.lookup = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	ctx := context.Background()
+	if value, err := p.Secret(ctx, "wifi/corp"); err != nil || value != "p4ssw0rd" {
+		t.Errorf("have secret %q, err %v", value, err)
+	}
+	if _, err := p.Secret(ctx, "wifi/guest"); !IsNotFound(err) {
+		t.Errorf("want not found error, have %v", err)
+	}
+	if _, err := p.Secret(ctx, "../wifi"); err == nil || IsNotFound(err) {
+		t.Errorf("want invalid name error, have %v", err)
+	}
+
+	multi := Multi{NewEnvProvider("UNSET_PREFIX_"), p}
+	if value, err := multi.Secret(ctx, "wifi/corp"); err != nil || value != "p4ssw0rd" {
+		t.Errorf("have secret %q from Multi, err %v", value, err)
+	}
+}
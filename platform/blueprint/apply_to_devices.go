@@ -0,0 +1,248 @@
+package blueprint
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log/level"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/group"
+)
+
+// ApplyToDevicesOption selects the enrolled devices a blueprint is applied to.
+type ApplyToDevicesOption struct {
+	UDIDs   []string `json:"udids,omitempty"`
+	Serials []string `json:"serials,omitempty"`
+	All     bool     `json:"all,omitempty"`
+
+	// DryRun lists the commands which would be queued without queueing them.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Command summarizes a command queued by a blueprint. Target is the
+// user short name, manifest URL or profile identifier of the command.
+type Command struct {
+	RequestType string `json:"request_type"`
+	Target      string `json:"target,omitempty"`
+	CommandUUID string `json:"command_uuid,omitempty"`
+}
+
+// DeviceApplyResult is the result of applying a blueprint to one device.
+// Devices which are skipped have a Skipped reason and no commands.
+type DeviceApplyResult struct {
+	UDID         string    `json:"udid,omitempty"`
+	SerialNumber string    `json:"serial_number,omitempty"`
+	Skipped      string    `json:"skipped,omitempty"`
+	Commands     []Command `json:"commands,omitempty"`
+}
+
+type ApplyToDevicesResult struct {
+	Blueprint string              `json:"blueprint"`
+	DryRun    bool                `json:"dry_run"`
+	Devices   []DeviceApplyResult `json:"devices"`
+}
+
+// Skip reasons.
+const (
+	SkippedNotFound    = "device not found"
+	SkippedNotEnrolled = "device not enrolled"
+	SkippedNotInScope  = "device not in blueprint scope"
+)
+
+// ApplyToDevices queues the commands of the blueprint for the selected
+// enrolled devices. Devices outside of the blueprint scope are skipped.
+// Enrollment params are only known when a device enrolls, so a scope with
+// EnrollmentParams matches no devices here.
+//
+// If a command can't be queued, the result of the devices applied so far is
+// returned with the error, because their commands were already queued.
+func (w *Worker) ApplyToDevices(ctx context.Context, bp Blueprint, opt ApplyToDevicesOption) (*ApplyToDevicesResult, error) {
+	devices, missing, err := w.selectDevices(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	var groups []group.Group
+	if bp.Scope.UsesGroups() {
+		if groups, err = w.groupDB.List(); err != nil {
+			return nil, errors.Wrap(err, "list groups for blueprint scope")
+		}
+	}
+
+	result := &ApplyToDevicesResult{Blueprint: bp.Name, DryRun: opt.DryRun}
+	result.Devices = append(result.Devices, missing...)
+	for _, dev := range devices {
+		res := DeviceApplyResult{UDID: dev.UDID, SerialNumber: dev.SerialNumber}
+		switch {
+		case !dev.Enrolled:
+			res.Skipped = SkippedNotEnrolled
+		case !bp.Scope.Match(deviceTarget(dev, groups)):
+			res.Skipped = SkippedNotInScope
+		}
+		if res.Skipped != "" {
+			result.Devices = append(result.Devices, res)
+			continue
+		}
+
+		for _, c := range w.commands(ctx, bp, dev.UDID) {
+			if !opt.DryRun {
+				payload, err := w.cmdsvc.NewCommand(ctx, c.request)
+				if err != nil {
+					result.Devices = append(result.Devices, res)
+					return result, errors.Wrapf(err, "create new command from blueprint for udid %s", dev.UDID)
+				}
+				c.summary.CommandUUID = payload.CommandUUID
+			}
+			res.Commands = append(res.Commands, c.summary)
+		}
+		level.Debug(w.logger).Log(
+			"msg", "applied blueprint on demand",
+			"blueprint_name", bp.Name,
+			"device_udid", dev.UDID,
+			"commands", len(res.Commands),
+			"dry_run", opt.DryRun,
+		)
+		result.Devices = append(result.Devices, res)
+	}
+	return result, nil
+}
+
+// selectDevices returns the devices selected by opt, and skipped results
+// for the UDIDs and serials which were not found.
+func (w *Worker) selectDevices(ctx context.Context, opt ApplyToDevicesOption) ([]device.Device, []DeviceApplyResult, error) {
+	if opt.All {
+		enrolled := true
+		devices, err := w.deviceDB.List(ctx, device.ListDevicesOption{FilterEnrolled: &enrolled})
+		return devices, nil, errors.Wrap(err, "list enrolled devices")
+	}
+
+	var (
+		devices []device.Device
+		missing []DeviceApplyResult
+		seen    = make(map[string]bool)
+	)
+	add := func(notFound DeviceApplyResult, dev *device.Device, err error) error {
+		if isNotFound(err) {
+			notFound.Skipped = SkippedNotFound
+			missing = append(missing, notFound)
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "get device %s%s", notFound.UDID, notFound.SerialNumber)
+		}
+		if !seen[dev.UDID] {
+			seen[dev.UDID] = true
+			devices = append(devices, *dev)
+		}
+		return nil
+	}
+	for _, udid := range opt.UDIDs {
+		dev, err := w.deviceDB.DeviceByUDID(ctx, udid)
+		if err := add(DeviceApplyResult{UDID: udid}, dev, err); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, serial := range opt.Serials {
+		dev, err := w.deviceDB.DeviceBySerial(ctx, serial)
+		if err := add(DeviceApplyResult{SerialNumber: serial}, dev, err); err != nil {
+			return nil, nil, err
+		}
+	}
+	return devices, missing, nil
+}
+
+// ApplyBlueprintToDevices applies the named blueprint to enrolled devices.
+func (svc *BlueprintService) ApplyBlueprintToDevices(ctx context.Context, name string, opt ApplyToDevicesOption) (*ApplyToDevicesResult, error) {
+	if svc.worker == nil {
+		return nil, errors.New("blueprints can't be applied to devices by this server")
+	}
+	if !opt.All && len(opt.UDIDs) == 0 && len(opt.Serials) == 0 {
+		return nil, errors.New("no devices selected, set UDIDs, serials or all")
+	}
+	bp, err := svc.store.BlueprintByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return svc.worker.ApplyToDevices(ctx, *bp, opt)
+}
+
+type applyToDevicesRequest struct {
+	Name string `json:"-"`
+	ApplyToDevicesOption
+}
+
+type applyToDevicesResponse struct {
+	Result *ApplyToDevicesResult `json:"result,omitempty"`
+
+	// PartialErr is the error which stopped the blueprint from being
+	// applied to all devices. Result has the devices applied before it.
+	PartialErr string `json:"partial_err,omitempty"`
+	Err        error  `json:"err,omitempty"`
+}
+
+func (r applyToDevicesResponse) Failed() error { return r.Err }
+
+func decodeApplyToDevicesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	name, ok := mux.Vars(r)["name"]
+	if !ok {
+		return nil, errors.New("bad route")
+	}
+	req := applyToDevicesRequest{Name: name}
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func encodeApplyToDevicesRequest(ctx context.Context, r *http.Request, request interface{}) error {
+	req := request.(applyToDevicesRequest)
+	r.URL.Path = "/v1/blueprints/" + req.Name + "/apply"
+	return httptransport.EncodeJSONRequest(ctx, r, request)
+}
+
+func decodeApplyToDevicesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp applyToDevicesResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeApplyToDevicesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(applyToDevicesRequest)
+		result, err := svc.ApplyBlueprintToDevices(ctx, req.Name, req.ApplyToDevicesOption)
+		if err != nil && result != nil {
+			return applyToDevicesResponse{Result: result, PartialErr: err.Error()}, nil
+		}
+		return applyToDevicesResponse{
+			Result: result,
+			Err:    err,
+		}, nil
+	}
+}
+
+func (e Endpoints) ApplyBlueprintToDevices(ctx context.Context, name string, opt ApplyToDevicesOption) (*ApplyToDevicesResult, error) {
+	request := applyToDevicesRequest{Name: name, ApplyToDevicesOption: opt}
+	response, err := e.ApplyToDevicesEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(applyToDevicesResponse)
+	if resp.PartialErr != "" {
+		return resp.Result, errors.New(resp.PartialErr)
+	}
+	return resp.Result, resp.Err
+}
+
+func isNotFound(err error) bool {
+	err = errors.Cause(err)
+	type notFoundErr interface {
+		error
+		NotFound() bool
+	}
+
+	e, ok := err.(notFoundErr)
+	return ok && e.NotFound()
+}
@@ -1,9 +1,10 @@
 package blueprint
 
 import (
-	"errors"
-
 	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/mdm/mdm"
 	"github.com/micromdm/micromdm/platform/blueprint/internal/blueprintproto"
 )
 
@@ -25,16 +26,59 @@ type Blueprint struct {
 	SkipPrimarySetupAccountCreation     bool     `json:"skip_primary_setup_account_creation"`
 	SetPrimarySetupAccountAsRegularUser bool     `json:"set_primary_setup_account_as_regular_user"`
 	ApplyAt                             []string `json:"apply_at"`
+
+	// Scope restricts the devices the blueprint is applied to.
+	// A blueprint without a scope is applied to every device.
+	Scope *Scope `json:"scope,omitempty"`
+
+	// Reconcile periodically checks the profiles and applications of the
+	// devices in scope, and reinstalls what is missing. See Reconciler.
+	Reconcile bool `json:"reconcile,omitempty"`
+
+	// Commands are sent in order after the accounts, applications and
+	// profiles of the blueprint, for example Settings to set the device
+	// name. They use the JSON format of the mdm package.
+	Commands []*mdm.Command `json:"commands,omitempty"`
 }
 
 func (bp *Blueprint) Verify() error {
 	if bp.Name == "" || bp.UUID == "" {
 		return errors.New("Blueprint must have Name and UUID")
 	}
+	for i, c := range bp.Commands {
+		if err := verifyCommand(c); err != nil {
+			return errors.Wrapf(err, "Blueprint command %d", i+1)
+		}
+	}
 	return nil
 }
 
+// verifyCommand checks that the command survives a JSON round trip
+// through the mdm package.
+func verifyCommand(c *mdm.Command) error {
+	if c == nil || c.RequestType == "" {
+		return errors.New("command must have a request_type")
+	}
+	if c.RequestType == "DeviceConfigured" {
+		return errors.New("DeviceConfigured is sent when the blueprints are applied, and can't be a blueprint command")
+	}
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	var check mdm.Command
+	return check.UnmarshalJSON(data)
+}
+
 func MarshalBlueprint(bp *Blueprint) ([]byte, error) {
+	protobp, err := blueprintToProto(bp)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(protobp)
+}
+
+func blueprintToProto(bp *Blueprint) (*blueprintproto.Blueprint, error) {
 	protobp := blueprintproto.Blueprint{
 		Uuid:                                bp.UUID,
 		Name:                                bp.Name,
@@ -43,9 +87,18 @@ func MarshalBlueprint(bp *Blueprint) ([]byte, error) {
 		UserUuid:                            bp.UserUUID,
 		SkipPrimarySetupAccountCreation:     bp.SkipPrimarySetupAccountCreation,
 		SetPrimarySetupAccountAsRegularUser: bp.SetPrimarySetupAccountAsRegularUser,
-		ApplyAt: bp.ApplyAt,
+		ApplyAt:                             bp.ApplyAt,
+		Scope:                               scopeToProto(bp.Scope),
+		Reconcile:                           bp.Reconcile,
 	}
-	return proto.Marshal(&protobp)
+	for _, c := range bp.Commands {
+		data, err := c.MarshalJSON()
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal %s command", c.RequestType)
+		}
+		protobp.Commands = append(protobp.Commands, data)
+	}
+	return &protobp, nil
 }
 
 func UnmarshalBlueprint(data []byte, bp *Blueprint) error {
@@ -53,6 +106,10 @@ func UnmarshalBlueprint(data []byte, bp *Blueprint) error {
 	if err := proto.Unmarshal(data, &pb); err != nil {
 		return err
 	}
+	return blueprintFromProto(&pb, bp)
+}
+
+func blueprintFromProto(pb *blueprintproto.Blueprint, bp *Blueprint) error {
 	bp.UUID = pb.GetUuid()
 	bp.Name = pb.GetName()
 	bp.ApplicationURLs = pb.GetManifestUrls()
@@ -61,5 +118,15 @@ func UnmarshalBlueprint(data []byte, bp *Blueprint) error {
 	bp.UserUUID = pb.GetUserUuid()
 	bp.SkipPrimarySetupAccountCreation = pb.GetSkipPrimarySetupAccountCreation()
 	bp.SetPrimarySetupAccountAsRegularUser = pb.GetSetPrimarySetupAccountAsRegularUser()
+	bp.Scope = scopeFromProto(pb.GetScope())
+	bp.Reconcile = pb.GetReconcile()
+	bp.Commands = nil
+	for _, data := range pb.GetCommands() {
+		var c mdm.Command
+		if err := c.UnmarshalJSON(data); err != nil {
+			return errors.Wrap(err, "unmarshal blueprint command")
+		}
+		bp.Commands = append(bp.Commands, &c)
+	}
 	return nil
 }
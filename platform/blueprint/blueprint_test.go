@@ -0,0 +1,79 @@
+package blueprint
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/micromdm/micromdm/mdm/mdm"
+)
+
+func TestBlueprintCommands(t *testing.T) {
+	data := []byte(`{
+		"uuid": "a-b-c-d",
+		"name": "first-boot",
+		"commands": [
+			{"request_type": "Settings", "settings": [{"item": "DeviceName", "device_name": "lab-01"}]},
+			{"request_type": "DeviceInformation", "queries": ["OSVersion"]},
+			{"request_type": "ScheduleOSUpdateScan", "force": true}
+		]
+	}`)
+	var bp Blueprint
+	if err := json.Unmarshal(data, &bp); err != nil {
+		t.Fatal(err)
+	}
+	if err := bp.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	var have []string
+	for _, c := range bp.Commands {
+		have = append(have, c.RequestType)
+	}
+	if want := []string{"Settings", "DeviceInformation", "ScheduleOSUpdateScan"}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("have commands %v, want %v", have, want)
+	}
+
+	proto, err := MarshalBlueprint(&bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Blueprint
+	if err := UnmarshalBlueprint(proto, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored.Commands, bp.Commands) {
+		t.Errorf("have stored commands %+v, want %+v", stored.Commands, bp.Commands)
+	}
+	if name := *stored.Commands[0].Settings.Settings[0].DeviceName; name != "lab-01" {
+		t.Errorf("have device name %q, want lab-01", name)
+	}
+}
+
+func TestVerifyCommands(t *testing.T) {
+	var tests = []struct {
+		name     string
+		commands []*mdm.Command
+		ok       bool
+	}{
+		{"valid", []*mdm.Command{{RequestType: "ProfileList"}}, true},
+		{"nil command", []*mdm.Command{nil}, false},
+		{"no request type", []*mdm.Command{{}}, false},
+		{"unknown request type", []*mdm.Command{{RequestType: "MakeCoffee"}}, false},
+		{"device configured", []*mdm.Command{{RequestType: "DeviceConfigured"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp := Blueprint{UUID: "a-b-c-d", Name: "test", Commands: tt.commands}
+			if err := bp.Verify(); (err == nil) != tt.ok {
+				t.Errorf("have err %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+
+	data := []byte(`{"uuid": "a-b-c-d", "name": "test", "commands": [{"request_type": "MakeCoffee"}]}`)
+	var bp Blueprint
+	if err := json.Unmarshal(data, &bp); err == nil {
+		t.Error("expected unknown request type to fail JSON unmarshalling")
+	}
+}
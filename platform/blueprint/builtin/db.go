@@ -2,8 +2,10 @@ package builtin
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/pkg/errors"
@@ -15,6 +17,12 @@ import (
 const (
 	BlueprintBucket      = "mdm.Blueprint"
 	blueprintIndexBucket = "mdm.BlueprintIdx"
+	DriftBucket          = "mdm.BlueprintDrift"
+	ReleaseBucket        = "mdm.BlueprintRelease"
+
+	// RevisionBucket has a bucket of revisions for each blueprint name,
+	// keyed by revision number.
+	RevisionBucket = "mdm.BlueprintRevision"
 )
 
 type DB struct {
@@ -31,6 +39,18 @@ func NewDB(
 		if err != nil {
 			return err
 		}
+		_, err = tx.CreateBucketIfNotExists([]byte(DriftBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(ReleaseBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(RevisionBucket))
+		if err != nil {
+			return err
+		}
 		_, err = tx.CreateBucketIfNotExists([]byte(BlueprintBucket))
 		return err
 	})
@@ -88,6 +108,7 @@ func (db *DB) Save(bp *blueprint.Blueprint) error {
 	if err != nil {
 		return errors.Wrap(err, "begin transaction")
 	}
+	defer tx.Rollback()
 	bkt := tx.Bucket([]byte(BlueprintBucket))
 	if bkt == nil {
 		return fmt.Errorf("bucket %q not found!", BlueprintBucket)
@@ -109,9 +130,78 @@ func (db *DB) Save(bp *blueprint.Blueprint) error {
 	if err := bkt.Put(key, bpproto); err != nil {
 		return errors.Wrap(err, "put blueprint to boltdb")
 	}
+	if err := saveRevision(tx, bp); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
+// saveRevision adds bp as the newest revision of the blueprint. Revisions
+// are kept when a blueprint is removed, so a blueprint created again with
+// the same name continues its revision numbers.
+func saveRevision(tx *bolt.Tx, bp *blueprint.Blueprint) error {
+	revs, err := tx.Bucket([]byte(RevisionBucket)).CreateBucketIfNotExists([]byte(bp.Name))
+	if err != nil {
+		return errors.Wrapf(err, "create revision bucket for blueprint %s", bp.Name)
+	}
+	seq, err := revs.NextSequence()
+	if err != nil {
+		return errors.Wrap(err, "next blueprint revision")
+	}
+	data, err := blueprint.MarshalRevision(&blueprint.Revision{
+		Revision:  int(seq),
+		Saved:     time.Now().UTC(),
+		Blueprint: *bp,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshalling blueprint revision")
+	}
+	return errors.Wrap(revs.Put(revisionKey(int(seq)), data), "put blueprint revision to boltdb")
+}
+
+func revisionKey(revision int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(revision))
+	return key
+}
+
+func (db *DB) BlueprintRevisions(name string) ([]blueprint.Revision, error) {
+	var revisions []blueprint.Revision
+	err := db.View(func(tx *bolt.Tx) error {
+		revs := tx.Bucket([]byte(RevisionBucket)).Bucket([]byte(name))
+		if revs == nil {
+			return &notFound{"Revision", fmt.Sprintf("blueprint %s", name)}
+		}
+		return revs.ForEach(func(k, v []byte) error {
+			var r blueprint.Revision
+			if err := blueprint.UnmarshalRevision(v, &r); err != nil {
+				return err
+			}
+			revisions = append(revisions, r)
+			return nil
+		})
+	})
+	return revisions, err
+}
+
+func (db *DB) BlueprintRevision(name string, revision int) (*blueprint.Revision, error) {
+	var r blueprint.Revision
+	err := db.View(func(tx *bolt.Tx) error {
+		var v []byte
+		if revs := tx.Bucket([]byte(RevisionBucket)).Bucket([]byte(name)); revs != nil && revision > 0 {
+			v = revs.Get(revisionKey(revision))
+		}
+		if v == nil {
+			return &notFound{"Revision", fmt.Sprintf("blueprint %s revision %d", name, revision)}
+		}
+		return blueprint.UnmarshalRevision(v, &r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 func (db *DB) BlueprintByName(name string) (*blueprint.Blueprint, error) {
 	var bp blueprint.Blueprint
 	err := db.View(func(tx *bolt.Tx) error {
@@ -182,6 +272,88 @@ func (db *DB) Delete(name string) error {
 	return err
 }
 
+func (db *DB) SaveDeviceDrift(d *blueprint.DeviceDrift) error {
+	data, err := blueprint.MarshalDeviceDrift(d)
+	if err != nil {
+		return errors.Wrap(err, "marshalling device drift")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(DriftBucket)).Put([]byte(d.UDID), data)
+	})
+	return errors.Wrap(err, "put device drift to boltdb")
+}
+
+func (db *DB) DeviceDrift(udid string) (*blueprint.DeviceDrift, error) {
+	var d blueprint.DeviceDrift
+	err := db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(DriftBucket)).Get([]byte(udid))
+		if v == nil {
+			return &notFound{"DeviceDrift", fmt.Sprintf("udid %s", udid)}
+		}
+		return blueprint.UnmarshalDeviceDrift(v, &d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (db *DB) ListDeviceDrift() ([]blueprint.DeviceDrift, error) {
+	var drift []blueprint.DeviceDrift
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(DriftBucket)).ForEach(func(k, v []byte) error {
+			var d blueprint.DeviceDrift
+			if err := blueprint.UnmarshalDeviceDrift(v, &d); err != nil {
+				return err
+			}
+			drift = append(drift, d)
+			return nil
+		})
+	})
+	return drift, err
+}
+
+func (db *DB) SaveDeviceRelease(r *blueprint.DeviceRelease) error {
+	data, err := blueprint.MarshalDeviceRelease(r)
+	if err != nil {
+		return errors.Wrap(err, "marshalling device release")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(ReleaseBucket)).Put([]byte(r.UDID), data)
+	})
+	return errors.Wrap(err, "put device release to boltdb")
+}
+
+func (db *DB) DeviceRelease(udid string) (*blueprint.DeviceRelease, error) {
+	var r blueprint.DeviceRelease
+	err := db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(ReleaseBucket)).Get([]byte(udid))
+		if v == nil {
+			return &notFound{"DeviceRelease", fmt.Sprintf("udid %s", udid)}
+		}
+		return blueprint.UnmarshalDeviceRelease(v, &r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (db *DB) ListDeviceReleases() ([]blueprint.DeviceRelease, error) {
+	var releases []blueprint.DeviceRelease
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(ReleaseBucket)).ForEach(func(k, v []byte) error {
+			var r blueprint.DeviceRelease
+			if err := blueprint.UnmarshalDeviceRelease(v, &r); err != nil {
+				return err
+			}
+			releases = append(releases, r)
+			return nil
+		})
+	})
+	return releases, err
+}
+
 type notFound struct {
 	ResourceType string
 	Message      string
@@ -191,6 +363,10 @@ func (e *notFound) Error() string {
 	return fmt.Sprintf("not found: %s %s", e.ResourceType, e.Message)
 }
 
+func (e *notFound) NotFound() bool {
+	return true
+}
+
 func isNotFound(err error) bool {
 	if _, ok := err.(*notFound); ok {
 		return true
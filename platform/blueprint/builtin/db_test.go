@@ -116,6 +116,42 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestDeviceDrift(t *testing.T) {
+	db := setupDB(t)
+
+	if _, err := db.DeviceDrift("UDID-1"); !isNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	drift := &blueprint.DeviceDrift{
+		UDID:            "UDID-1",
+		Blueprints:      []string{"lab"},
+		MissingProfiles: []string{"com.example.wifi"},
+	}
+	if err := db.SaveDeviceDrift(drift); err != nil {
+		t.Fatalf("saving device drift: %s", err)
+	}
+	if err := db.SaveDeviceDrift(&blueprint.DeviceDrift{UDID: "UDID-2"}); err != nil {
+		t.Fatalf("saving device drift: %s", err)
+	}
+
+	have, err := db.DeviceDrift("UDID-1")
+	if err != nil {
+		t.Fatalf("getting device drift: %s", err)
+	}
+	if !have.Drifted() || have.MissingProfiles[0] != "com.example.wifi" {
+		t.Errorf("have drift %+v, want %+v", have, drift)
+	}
+
+	all, err := db.ListDeviceDrift()
+	if err != nil {
+		t.Fatalf("listing device drift: %s", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("have %d device drift records, want 2", len(all))
+	}
+}
+
 func setupDB(t *testing.T) *DB {
 	f, _ := ioutil.TempFile("", "bolt-")
 	f.Close()
@@ -136,3 +172,53 @@ func setupDB(t *testing.T) *DB {
 	}
 	return blueprintDB
 }
+
+func TestRevisions(t *testing.T) {
+	db := setupDB(t)
+	bp := &blueprint.Blueprint{
+		UUID:    "a-b-c-d",
+		Name:    "blueprint",
+		ApplyAt: []string{"Enroll"},
+	}
+	if err := db.Save(bp); err != nil {
+		t.Fatalf("saving blueprint to datastore: %s", err)
+	}
+	bp.ApplyAt = nil
+	if err := db.Save(bp); err != nil {
+		t.Fatalf("saving blueprint to datastore: %s", err)
+	}
+
+	revisions, err := db.BlueprintRevisions("blueprint")
+	if err != nil {
+		t.Fatalf("listing blueprint revisions: %s", err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[1].Revision != 2 {
+		t.Fatalf("have revisions %+v, want 1 and 2", revisions)
+	}
+	if revisions[0].Saved.IsZero() || len(revisions[0].Blueprint.ApplyAt) != 1 {
+		t.Errorf("have revision 1 %+v, want the first saved blueprint", revisions[0])
+	}
+
+	if err := db.Delete("blueprint"); err != nil {
+		t.Fatalf("deleting blueprint in datastore: %s", err)
+	}
+	svc := blueprint.New(db)
+	rev, err := svc.RollbackBlueprint(context.Background(), "blueprint", 1)
+	if err != nil {
+		t.Fatalf("rolling back blueprint: %s", err)
+	}
+	if rev.Revision != 3 {
+		t.Errorf("have revision %d after rollback, want 3", rev.Revision)
+	}
+	restored, err := db.BlueprintByName("blueprint")
+	if err != nil {
+		t.Fatalf("getting restored blueprint: %s", err)
+	}
+	if len(restored.ApplyAt) != 1 {
+		t.Errorf("have ApplyAt %v, want the ApplyAt of revision 1", restored.ApplyAt)
+	}
+
+	if _, err := db.BlueprintRevision("blueprint", 4); !isNotFound(err) {
+		t.Errorf("have err %v for a missing revision, want not found", err)
+	}
+}
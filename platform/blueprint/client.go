@@ -48,9 +48,81 @@ func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransp
 		).Endpoint()
 	}
 
+	var applyToDevicesEndpoint endpoint.Endpoint
+	{
+		applyToDevicesEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, ""), // empty path, modified by the encodeRequest func
+			httputil.EncodeRequestWithToken(token, encodeApplyToDevicesRequest),
+			decodeApplyToDevicesResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var getDriftEndpoint endpoint.Endpoint
+	{
+		getDriftEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/blueprints/drift"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeGetDriftResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var getReleasesEndpoint endpoint.Endpoint
+	{
+		getReleasesEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/blueprints/releases"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeReleasesResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var releaseDevicesEndpoint endpoint.Endpoint
+	{
+		releaseDevicesEndpoint = httptransport.NewClient(
+			"PUT",
+			httputil.CopyURL(u, "/v1/blueprints/releases"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeReleasesResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var getRevisionsEndpoint endpoint.Endpoint
+	{
+		getRevisionsEndpoint = httptransport.NewClient(
+			"GET",
+			httputil.CopyURL(u, ""), // empty path, modified by the encodeRequest func
+			httputil.EncodeRequestWithToken(token, encodeGetRevisionsRequest),
+			decodeGetRevisionsResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var rollbackEndpoint endpoint.Endpoint
+	{
+		rollbackEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, ""), // empty path, modified by the encodeRequest func
+			httputil.EncodeRequestWithToken(token, encodeRollbackRequest),
+			decodeRollbackResponse,
+			opts...,
+		).Endpoint()
+	}
+
 	return Endpoints{
 		ApplyBlueprintEndpoint:   applyBlueprintEndpoint,
 		GetBlueprintsEndpoint:    getBlueprintsEndpoint,
 		RemoveBlueprintsEndpoint: removeBlueprintsEndpoint,
+		ApplyToDevicesEndpoint:   applyToDevicesEndpoint,
+		GetDriftEndpoint:         getDriftEndpoint,
+		GetReleasesEndpoint:      getReleasesEndpoint,
+		ReleaseDevicesEndpoint:   releaseDevicesEndpoint,
+		GetRevisionsEndpoint:     getRevisionsEndpoint,
+		RollbackEndpoint:         rollbackEndpoint,
 	}, nil
 }
@@ -0,0 +1,170 @@
+package blueprint
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+	"github.com/micromdm/micromdm/platform/blueprint/internal/blueprintproto"
+)
+
+// DeviceDrift is the difference between a device and the reconciled
+// blueprints in its scope, as of the last reconcile.
+type DeviceDrift struct {
+	UDID         string    `json:"udid"`
+	SerialNumber string    `json:"serial_number,omitempty"`
+	Checked      time.Time `json:"checked"`
+	Blueprints   []string  `json:"blueprints,omitempty"`
+
+	// MissingProfiles are profile identifiers and MissingApps are
+	// manifest URLs of blueprints which were not installed.
+	MissingProfiles []string `json:"missing_profiles,omitempty"`
+	MissingApps     []string `json:"missing_apps,omitempty"`
+
+	// RemovedProfiles were installed by a blueprint, and removed from
+	// the device because they are no longer part of it.
+	RemovedProfiles []string `json:"removed_profiles,omitempty"`
+
+	// UnknownApps are manifest URLs which could not be checked because
+	// the bundle identifier of the manifest is unknown.
+	UnknownApps []string `json:"unknown_apps,omitempty"`
+
+	// Queued are the commands queued to correct the drift.
+	Queued []Command `json:"queued,omitempty"`
+
+	// ManagedProfiles are the profiles the reconciler installed on the
+	// device, used to find the profiles removed from a blueprint.
+	ManagedProfiles []string `json:"managed_profiles,omitempty"`
+}
+
+// Drifted reports whether the device did not match its blueprints.
+func (d DeviceDrift) Drifted() bool {
+	return len(d.MissingProfiles) > 0 || len(d.MissingApps) > 0 || len(d.RemovedProfiles) > 0
+}
+
+func MarshalDeviceDrift(d *DeviceDrift) ([]byte, error) {
+	pb := blueprintproto.DeviceDrift{
+		Udid:            d.UDID,
+		SerialNumber:    d.SerialNumber,
+		Checked:         timeToNano(d.Checked),
+		Blueprints:      d.Blueprints,
+		MissingProfiles: d.MissingProfiles,
+		MissingApps:     d.MissingApps,
+		RemovedProfiles: d.RemovedProfiles,
+		UnknownApps:     d.UnknownApps,
+		ManagedProfiles: d.ManagedProfiles,
+	}
+	for _, c := range d.Queued {
+		pb.Queued = append(pb.Queued, &blueprintproto.Command{
+			RequestType: c.RequestType,
+			Target:      c.Target,
+			CommandUuid: c.CommandUUID,
+		})
+	}
+	return proto.Marshal(&pb)
+}
+
+func UnmarshalDeviceDrift(data []byte, d *DeviceDrift) error {
+	var pb blueprintproto.DeviceDrift
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return errors.Wrap(err, "unmarshal proto to DeviceDrift")
+	}
+	d.UDID = pb.GetUdid()
+	d.SerialNumber = pb.GetSerialNumber()
+	d.Checked = timeFromNano(pb.GetChecked())
+	d.Blueprints = pb.GetBlueprints()
+	d.MissingProfiles = pb.GetMissingProfiles()
+	d.MissingApps = pb.GetMissingApps()
+	d.RemovedProfiles = pb.GetRemovedProfiles()
+	d.UnknownApps = pb.GetUnknownApps()
+	d.ManagedProfiles = pb.GetManagedProfiles()
+	d.Queued = nil
+	for _, c := range pb.GetQueued() {
+		d.Queued = append(d.Queued, Command{
+			RequestType: c.GetRequestType(),
+			Target:      c.GetTarget(),
+			CommandUUID: c.GetCommandUuid(),
+		})
+	}
+	return nil
+}
+
+type GetDriftOption struct {
+	FilterUDID      []string `json:"filter_udid,omitempty"`
+	FilterBlueprint string   `json:"filter_blueprint,omitempty"`
+
+	// Drifted returns only the devices which did not match their blueprints.
+	Drifted bool `json:"drifted,omitempty"`
+}
+
+func (opt GetDriftOption) match(d DeviceDrift) bool {
+	if len(opt.FilterUDID) > 0 && !contains(opt.FilterUDID, d.UDID) {
+		return false
+	}
+	if opt.FilterBlueprint != "" && !contains(d.Blueprints, opt.FilterBlueprint) {
+		return false
+	}
+	return !opt.Drifted || d.Drifted()
+}
+
+func (svc *BlueprintService) GetDrift(ctx context.Context, opt GetDriftOption) ([]DeviceDrift, error) {
+	all, err := svc.store.ListDeviceDrift()
+	if err != nil {
+		return nil, err
+	}
+	var drift []DeviceDrift
+	for _, d := range all {
+		if opt.match(d) {
+			drift = append(drift, d)
+		}
+	}
+	return drift, nil
+}
+
+type getDriftRequest struct {
+	Opts GetDriftOption `json:"opts"`
+}
+
+type getDriftResponse struct {
+	Drift []DeviceDrift `json:"drift"`
+	Err   error         `json:"err,omitempty"`
+}
+
+func (r getDriftResponse) Failed() error { return r.Err }
+
+func decodeGetDriftRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req getDriftRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeGetDriftResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp getDriftResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeGetDriftEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getDriftRequest)
+		drift, err := svc.GetDrift(ctx, req.Opts)
+		return getDriftResponse{
+			Drift: drift,
+			Err:   err,
+		}, nil
+	}
+}
+
+func (e Endpoints) GetDrift(ctx context.Context, opt GetDriftOption) ([]DeviceDrift, error) {
+	response, err := e.GetDriftEndpoint(ctx, getDriftRequest{Opts: opt})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(getDriftResponse)
+	return resp.Drift, resp.Err
+}
@@ -1,15 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // source: blueprint.proto
 
-/*
-Package blueprintproto is a generated protocol buffer package.
-
-It is generated from these files:
-	blueprint.proto
-
-It has these top-level messages:
-	Blueprint
-*/
 package blueprintproto
 
 import proto "github.com/golang/protobuf/proto"
@@ -28,20 +19,45 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Blueprint struct {
-	Uuid                                string   `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
-	Name                                string   `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
-	ManifestUrls                        []string `protobuf:"bytes,3,rep,name=manifest_urls,json=manifestUrls" json:"manifest_urls,omitempty"`
-	ProfileIds                          []string `protobuf:"bytes,5,rep,name=profile_ids,json=profileIds" json:"profile_ids,omitempty"`
-	ApplyAt                             []string `protobuf:"bytes,6,rep,name=apply_at,json=applyAt" json:"apply_at,omitempty"`
-	UserUuid                            []string `protobuf:"bytes,7,rep,name=user_uuid,json=userUuid" json:"user_uuid,omitempty"`
-	SkipPrimarySetupAccountCreation     bool     `protobuf:"varint,8,opt,name=skip_primary_setup_account_creation,json=skipPrimarySetupAccountCreation" json:"skip_primary_setup_account_creation,omitempty"`
-	SetPrimarySetupAccountAsRegularUser bool     `protobuf:"varint,9,opt,name=set_primary_setup_account_as_regular_user,json=setPrimarySetupAccountAsRegularUser" json:"set_primary_setup_account_as_regular_user,omitempty"`
+	Uuid                                string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Name                                string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ManifestUrls                        []string `protobuf:"bytes,3,rep,name=manifest_urls,json=manifestUrls,proto3" json:"manifest_urls,omitempty"`
+	ProfileIds                          []string `protobuf:"bytes,5,rep,name=profile_ids,json=profileIds,proto3" json:"profile_ids,omitempty"`
+	ApplyAt                             []string `protobuf:"bytes,6,rep,name=apply_at,json=applyAt,proto3" json:"apply_at,omitempty"`
+	UserUuid                            []string `protobuf:"bytes,7,rep,name=user_uuid,json=userUuid,proto3" json:"user_uuid,omitempty"`
+	SkipPrimarySetupAccountCreation     bool     `protobuf:"varint,8,opt,name=skip_primary_setup_account_creation,json=skipPrimarySetupAccountCreation,proto3" json:"skip_primary_setup_account_creation,omitempty"`
+	SetPrimarySetupAccountAsRegularUser bool     `protobuf:"varint,9,opt,name=set_primary_setup_account_as_regular_user,json=setPrimarySetupAccountAsRegularUser,proto3" json:"set_primary_setup_account_as_regular_user,omitempty"`
+	Scope                               *Scope   `protobuf:"bytes,10,opt,name=scope,proto3" json:"scope,omitempty"`
+	Reconcile                           bool     `protobuf:"varint,11,opt,name=reconcile,proto3" json:"reconcile,omitempty"`
+	Commands                            [][]byte `protobuf:"bytes,12,rep,name=commands,proto3" json:"commands,omitempty"`
+	XXX_NoUnkeyedLiteral                struct{} `json:"-"`
+	XXX_unrecognized                    []byte   `json:"-"`
+	XXX_sizecache                       int32    `json:"-"`
+}
+
+func (m *Blueprint) Reset()         { *m = Blueprint{} }
+func (m *Blueprint) String() string { return proto.CompactTextString(m) }
+func (*Blueprint) ProtoMessage()    {}
+func (*Blueprint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blueprint_9000fb8cb0e3e8cb, []int{0}
+}
+func (m *Blueprint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Blueprint.Unmarshal(m, b)
+}
+func (m *Blueprint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Blueprint.Marshal(b, m, deterministic)
+}
+func (dst *Blueprint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Blueprint.Merge(dst, src)
+}
+func (m *Blueprint) XXX_Size() int {
+	return xxx_messageInfo_Blueprint.Size(m)
+}
+func (m *Blueprint) XXX_DiscardUnknown() {
+	xxx_messageInfo_Blueprint.DiscardUnknown(m)
 }
 
-func (m *Blueprint) Reset()                    { *m = Blueprint{} }
-func (m *Blueprint) String() string            { return proto.CompactTextString(m) }
-func (*Blueprint) ProtoMessage()               {}
-func (*Blueprint) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+var xxx_messageInfo_Blueprint proto.InternalMessageInfo
 
 func (m *Blueprint) GetUuid() string {
 	if m != nil {
@@ -99,30 +115,573 @@ func (m *Blueprint) GetSetPrimarySetupAccountAsRegularUser() bool {
 	return false
 }
 
+func (m *Blueprint) GetScope() *Scope {
+	if m != nil {
+		return m.Scope
+	}
+	return nil
+}
+
+func (m *Blueprint) GetReconcile() bool {
+	if m != nil {
+		return m.Reconcile
+	}
+	return false
+}
+
+func (m *Blueprint) GetCommands() [][]byte {
+	if m != nil {
+		return m.Commands
+	}
+	return nil
+}
+
+type Scope struct {
+	IncludeSerials       []string          `protobuf:"bytes,1,rep,name=include_serials,json=includeSerials,proto3" json:"include_serials,omitempty"`
+	IncludeUdids         []string          `protobuf:"bytes,2,rep,name=include_udids,json=includeUdids,proto3" json:"include_udids,omitempty"`
+	IncludeGroups        []string          `protobuf:"bytes,3,rep,name=include_groups,json=includeGroups,proto3" json:"include_groups,omitempty"`
+	ExcludeSerials       []string          `protobuf:"bytes,4,rep,name=exclude_serials,json=excludeSerials,proto3" json:"exclude_serials,omitempty"`
+	ExcludeUdids         []string          `protobuf:"bytes,5,rep,name=exclude_udids,json=excludeUdids,proto3" json:"exclude_udids,omitempty"`
+	ExcludeGroups        []string          `protobuf:"bytes,6,rep,name=exclude_groups,json=excludeGroups,proto3" json:"exclude_groups,omitempty"`
+	DepProfileUuids      []string          `protobuf:"bytes,7,rep,name=dep_profile_uuids,json=depProfileUuids,proto3" json:"dep_profile_uuids,omitempty"`
+	Models               []string          `protobuf:"bytes,8,rep,name=models,proto3" json:"models,omitempty"`
+	EnrollmentParams     map[string]string `protobuf:"bytes,9,rep,name=enrollment_params,json=enrollmentParams,proto3" json:"enrollment_params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Scope) Reset()         { *m = Scope{} }
+func (m *Scope) String() string { return proto.CompactTextString(m) }
+func (*Scope) ProtoMessage()    {}
+func (*Scope) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blueprint_9000fb8cb0e3e8cb, []int{1}
+}
+func (m *Scope) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Scope.Unmarshal(m, b)
+}
+func (m *Scope) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Scope.Marshal(b, m, deterministic)
+}
+func (dst *Scope) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Scope.Merge(dst, src)
+}
+func (m *Scope) XXX_Size() int {
+	return xxx_messageInfo_Scope.Size(m)
+}
+func (m *Scope) XXX_DiscardUnknown() {
+	xxx_messageInfo_Scope.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Scope proto.InternalMessageInfo
+
+func (m *Scope) GetIncludeSerials() []string {
+	if m != nil {
+		return m.IncludeSerials
+	}
+	return nil
+}
+
+func (m *Scope) GetIncludeUdids() []string {
+	if m != nil {
+		return m.IncludeUdids
+	}
+	return nil
+}
+
+func (m *Scope) GetIncludeGroups() []string {
+	if m != nil {
+		return m.IncludeGroups
+	}
+	return nil
+}
+
+func (m *Scope) GetExcludeSerials() []string {
+	if m != nil {
+		return m.ExcludeSerials
+	}
+	return nil
+}
+
+func (m *Scope) GetExcludeUdids() []string {
+	if m != nil {
+		return m.ExcludeUdids
+	}
+	return nil
+}
+
+func (m *Scope) GetExcludeGroups() []string {
+	if m != nil {
+		return m.ExcludeGroups
+	}
+	return nil
+}
+
+func (m *Scope) GetDepProfileUuids() []string {
+	if m != nil {
+		return m.DepProfileUuids
+	}
+	return nil
+}
+
+func (m *Scope) GetModels() []string {
+	if m != nil {
+		return m.Models
+	}
+	return nil
+}
+
+func (m *Scope) GetEnrollmentParams() map[string]string {
+	if m != nil {
+		return m.EnrollmentParams
+	}
+	return nil
+}
+
+type DeviceDrift struct {
+	Udid                 string     `protobuf:"bytes,1,opt,name=udid,proto3" json:"udid,omitempty"`
+	SerialNumber         string     `protobuf:"bytes,2,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	Checked              int64      `protobuf:"varint,3,opt,name=checked,proto3" json:"checked,omitempty"`
+	Blueprints           []string   `protobuf:"bytes,4,rep,name=blueprints,proto3" json:"blueprints,omitempty"`
+	MissingProfiles      []string   `protobuf:"bytes,5,rep,name=missing_profiles,json=missingProfiles,proto3" json:"missing_profiles,omitempty"`
+	MissingApps          []string   `protobuf:"bytes,6,rep,name=missing_apps,json=missingApps,proto3" json:"missing_apps,omitempty"`
+	RemovedProfiles      []string   `protobuf:"bytes,7,rep,name=removed_profiles,json=removedProfiles,proto3" json:"removed_profiles,omitempty"`
+	UnknownApps          []string   `protobuf:"bytes,8,rep,name=unknown_apps,json=unknownApps,proto3" json:"unknown_apps,omitempty"`
+	Queued               []*Command `protobuf:"bytes,9,rep,name=queued,proto3" json:"queued,omitempty"`
+	ManagedProfiles      []string   `protobuf:"bytes,10,rep,name=managed_profiles,json=managedProfiles,proto3" json:"managed_profiles,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *DeviceDrift) Reset()         { *m = DeviceDrift{} }
+func (m *DeviceDrift) String() string { return proto.CompactTextString(m) }
+func (*DeviceDrift) ProtoMessage()    {}
+func (*DeviceDrift) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blueprint_9000fb8cb0e3e8cb, []int{2}
+}
+func (m *DeviceDrift) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeviceDrift.Unmarshal(m, b)
+}
+func (m *DeviceDrift) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeviceDrift.Marshal(b, m, deterministic)
+}
+func (dst *DeviceDrift) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeviceDrift.Merge(dst, src)
+}
+func (m *DeviceDrift) XXX_Size() int {
+	return xxx_messageInfo_DeviceDrift.Size(m)
+}
+func (m *DeviceDrift) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeviceDrift.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeviceDrift proto.InternalMessageInfo
+
+func (m *DeviceDrift) GetUdid() string {
+	if m != nil {
+		return m.Udid
+	}
+	return ""
+}
+
+func (m *DeviceDrift) GetSerialNumber() string {
+	if m != nil {
+		return m.SerialNumber
+	}
+	return ""
+}
+
+func (m *DeviceDrift) GetChecked() int64 {
+	if m != nil {
+		return m.Checked
+	}
+	return 0
+}
+
+func (m *DeviceDrift) GetBlueprints() []string {
+	if m != nil {
+		return m.Blueprints
+	}
+	return nil
+}
+
+func (m *DeviceDrift) GetMissingProfiles() []string {
+	if m != nil {
+		return m.MissingProfiles
+	}
+	return nil
+}
+
+func (m *DeviceDrift) GetMissingApps() []string {
+	if m != nil {
+		return m.MissingApps
+	}
+	return nil
+}
+
+func (m *DeviceDrift) GetRemovedProfiles() []string {
+	if m != nil {
+		return m.RemovedProfiles
+	}
+	return nil
+}
+
+func (m *DeviceDrift) GetUnknownApps() []string {
+	if m != nil {
+		return m.UnknownApps
+	}
+	return nil
+}
+
+func (m *DeviceDrift) GetQueued() []*Command {
+	if m != nil {
+		return m.Queued
+	}
+	return nil
+}
+
+func (m *DeviceDrift) GetManagedProfiles() []string {
+	if m != nil {
+		return m.ManagedProfiles
+	}
+	return nil
+}
+
+type Command struct {
+	RequestType          string   `protobuf:"bytes,1,opt,name=request_type,json=requestType,proto3" json:"request_type,omitempty"`
+	Target               string   `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	CommandUuid          string   `protobuf:"bytes,3,opt,name=command_uuid,json=commandUuid,proto3" json:"command_uuid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Command) Reset()         { *m = Command{} }
+func (m *Command) String() string { return proto.CompactTextString(m) }
+func (*Command) ProtoMessage()    {}
+func (*Command) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blueprint_9000fb8cb0e3e8cb, []int{3}
+}
+func (m *Command) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Command.Unmarshal(m, b)
+}
+func (m *Command) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Command.Marshal(b, m, deterministic)
+}
+func (dst *Command) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Command.Merge(dst, src)
+}
+func (m *Command) XXX_Size() int {
+	return xxx_messageInfo_Command.Size(m)
+}
+func (m *Command) XXX_DiscardUnknown() {
+	xxx_messageInfo_Command.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Command proto.InternalMessageInfo
+
+func (m *Command) GetRequestType() string {
+	if m != nil {
+		return m.RequestType
+	}
+	return ""
+}
+
+func (m *Command) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *Command) GetCommandUuid() string {
+	if m != nil {
+		return m.CommandUuid
+	}
+	return ""
+}
+
+type DeviceRelease struct {
+	Udid                 string            `protobuf:"bytes,1,opt,name=udid,proto3" json:"udid,omitempty"`
+	State                string            `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Reason               string            `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Started              int64             `protobuf:"varint,4,opt,name=started,proto3" json:"started,omitempty"`
+	Updated              int64             `protobuf:"varint,5,opt,name=updated,proto3" json:"updated,omitempty"`
+	Commands             []*TrackedCommand `protobuf:"bytes,6,rep,name=commands,proto3" json:"commands,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *DeviceRelease) Reset()         { *m = DeviceRelease{} }
+func (m *DeviceRelease) String() string { return proto.CompactTextString(m) }
+func (*DeviceRelease) ProtoMessage()    {}
+func (*DeviceRelease) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blueprint_9000fb8cb0e3e8cb, []int{4}
+}
+func (m *DeviceRelease) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeviceRelease.Unmarshal(m, b)
+}
+func (m *DeviceRelease) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeviceRelease.Marshal(b, m, deterministic)
+}
+func (dst *DeviceRelease) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeviceRelease.Merge(dst, src)
+}
+func (m *DeviceRelease) XXX_Size() int {
+	return xxx_messageInfo_DeviceRelease.Size(m)
+}
+func (m *DeviceRelease) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeviceRelease.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeviceRelease proto.InternalMessageInfo
+
+func (m *DeviceRelease) GetUdid() string {
+	if m != nil {
+		return m.Udid
+	}
+	return ""
+}
+
+func (m *DeviceRelease) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *DeviceRelease) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *DeviceRelease) GetStarted() int64 {
+	if m != nil {
+		return m.Started
+	}
+	return 0
+}
+
+func (m *DeviceRelease) GetUpdated() int64 {
+	if m != nil {
+		return m.Updated
+	}
+	return 0
+}
+
+func (m *DeviceRelease) GetCommands() []*TrackedCommand {
+	if m != nil {
+		return m.Commands
+	}
+	return nil
+}
+
+type TrackedCommand struct {
+	CommandUuid          string   `protobuf:"bytes,1,opt,name=command_uuid,json=commandUuid,proto3" json:"command_uuid,omitempty"`
+	RequestType          string   `protobuf:"bytes,2,opt,name=request_type,json=requestType,proto3" json:"request_type,omitempty"`
+	Target               string   `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	Blueprint            string   `protobuf:"bytes,4,opt,name=blueprint,proto3" json:"blueprint,omitempty"`
+	Status               string   `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TrackedCommand) Reset()         { *m = TrackedCommand{} }
+func (m *TrackedCommand) String() string { return proto.CompactTextString(m) }
+func (*TrackedCommand) ProtoMessage()    {}
+func (*TrackedCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blueprint_9000fb8cb0e3e8cb, []int{5}
+}
+func (m *TrackedCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrackedCommand.Unmarshal(m, b)
+}
+func (m *TrackedCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrackedCommand.Marshal(b, m, deterministic)
+}
+func (dst *TrackedCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrackedCommand.Merge(dst, src)
+}
+func (m *TrackedCommand) XXX_Size() int {
+	return xxx_messageInfo_TrackedCommand.Size(m)
+}
+func (m *TrackedCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrackedCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrackedCommand proto.InternalMessageInfo
+
+func (m *TrackedCommand) GetCommandUuid() string {
+	if m != nil {
+		return m.CommandUuid
+	}
+	return ""
+}
+
+func (m *TrackedCommand) GetRequestType() string {
+	if m != nil {
+		return m.RequestType
+	}
+	return ""
+}
+
+func (m *TrackedCommand) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *TrackedCommand) GetBlueprint() string {
+	if m != nil {
+		return m.Blueprint
+	}
+	return ""
+}
+
+func (m *TrackedCommand) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *TrackedCommand) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type Revision struct {
+	Revision             int64      `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	Saved                int64      `protobuf:"varint,2,opt,name=saved,proto3" json:"saved,omitempty"`
+	Blueprint            *Blueprint `protobuf:"bytes,3,opt,name=blueprint,proto3" json:"blueprint,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *Revision) Reset()         { *m = Revision{} }
+func (m *Revision) String() string { return proto.CompactTextString(m) }
+func (*Revision) ProtoMessage()    {}
+func (*Revision) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blueprint_9000fb8cb0e3e8cb, []int{6}
+}
+func (m *Revision) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Revision.Unmarshal(m, b)
+}
+func (m *Revision) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Revision.Marshal(b, m, deterministic)
+}
+func (dst *Revision) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Revision.Merge(dst, src)
+}
+func (m *Revision) XXX_Size() int {
+	return xxx_messageInfo_Revision.Size(m)
+}
+func (m *Revision) XXX_DiscardUnknown() {
+	xxx_messageInfo_Revision.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Revision proto.InternalMessageInfo
+
+func (m *Revision) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *Revision) GetSaved() int64 {
+	if m != nil {
+		return m.Saved
+	}
+	return 0
+}
+
+func (m *Revision) GetBlueprint() *Blueprint {
+	if m != nil {
+		return m.Blueprint
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Blueprint)(nil), "blueprintproto.Blueprint")
+	proto.RegisterType((*Scope)(nil), "blueprintproto.Scope")
+	proto.RegisterMapType((map[string]string)(nil), "blueprintproto.Scope.EnrollmentParamsEntry")
+	proto.RegisterType((*DeviceDrift)(nil), "blueprintproto.DeviceDrift")
+	proto.RegisterType((*Command)(nil), "blueprintproto.Command")
+	proto.RegisterType((*DeviceRelease)(nil), "blueprintproto.DeviceRelease")
+	proto.RegisterType((*TrackedCommand)(nil), "blueprintproto.TrackedCommand")
+	proto.RegisterType((*Revision)(nil), "blueprintproto.Revision")
 }
 
-func init() { proto.RegisterFile("blueprint.proto", fileDescriptor0) }
-
-var fileDescriptor0 = []byte{
-	// 282 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x90, 0xbd, 0x4e, 0x2b, 0x31,
-	0x10, 0x85, 0x95, 0xff, 0x5d, 0xdf, 0x1b, 0x40, 0xae, 0x8c, 0x28, 0x12, 0x91, 0x26, 0x34, 0x34,
-	0x3c, 0x41, 0xa0, 0x02, 0x51, 0xa0, 0x45, 0xa1, 0xb5, 0xbc, 0xbb, 0xb3, 0x91, 0x85, 0xd7, 0xb6,
-	0x66, 0xec, 0x22, 0xef, 0xcc, 0x43, 0x20, 0x7b, 0x13, 0x2a, 0xe8, 0x66, 0xbe, 0xf9, 0x74, 0x7c,
-	0x64, 0x76, 0x59, 0x9b, 0x08, 0x1e, 0xb5, 0x0d, 0xf7, 0x1e, 0x5d, 0x70, 0xfc, 0xe2, 0x07, 0xe4,
-	0xfd, 0xf6, 0x6b, 0xcc, 0xca, 0xc7, 0x33, 0xe2, 0x9c, 0x4d, 0x63, 0xd4, 0xad, 0x18, 0xad, 0x47,
-	0xdb, 0xb2, 0xca, 0x73, 0x62, 0x56, 0xf5, 0x20, 0xc6, 0x03, 0x4b, 0x33, 0xdf, 0xb0, 0x65, 0xaf,
-	0xac, 0xee, 0x80, 0x82, 0x8c, 0x68, 0x48, 0x4c, 0xd6, 0x93, 0x6d, 0x59, 0xfd, 0x3f, 0xc3, 0x3d,
-	0x1a, 0xe2, 0x2b, 0xf6, 0xcf, 0xa3, 0xeb, 0xb4, 0x01, 0xa9, 0x5b, 0x12, 0xb3, 0xac, 0xb0, 0x13,
-	0x7a, 0x6e, 0x89, 0x5f, 0xb3, 0x42, 0x79, 0x6f, 0x8e, 0x52, 0x05, 0x31, 0xcf, 0xd7, 0x45, 0xde,
-	0x77, 0x81, 0xdf, 0xb0, 0x32, 0x12, 0xa0, 0xcc, 0x6d, 0x16, 0xf9, 0x56, 0x24, 0xb0, 0x4f, 0x8d,
-	0x5e, 0xd9, 0x86, 0x3e, 0xb5, 0x97, 0x1e, 0x75, 0xaf, 0xf0, 0x28, 0x09, 0x42, 0xf4, 0x52, 0x35,
-	0x8d, 0x8b, 0x36, 0xc8, 0x06, 0x41, 0x05, 0xed, 0xac, 0x28, 0xd6, 0xa3, 0x6d, 0x51, 0xad, 0x92,
-	0xfa, 0x36, 0x98, 0xef, 0x49, 0xdc, 0x0d, 0xde, 0xd3, 0x49, 0xe3, 0x1f, 0xec, 0x8e, 0x20, 0xfc,
-	0x11, 0xa6, 0x48, 0x22, 0x1c, 0xa2, 0x51, 0x28, 0xd3, 0xf3, 0xa2, 0xcc, 0x99, 0x1b, 0x82, 0xf0,
-	0x4b, 0xe4, 0x8e, 0xaa, 0xc1, 0xdd, 0x13, 0xe0, 0xcb, 0xb4, 0x98, 0x5e, 0xcd, 0xaa, 0x65, 0xef,
-	0x6a, 0x6d, 0xa0, 0x71, 0xb6, 0xd3, 0x07, 0xaa, 0xe7, 0xf9, 0xd7, 0x1f, 0xbe, 0x03, 0x00, 0x00,
-	0xff, 0xff, 0x22, 0x48, 0x96, 0x88, 0x98, 0x01, 0x00, 0x00,
+func init() { proto.RegisterFile("blueprint.proto", fileDescriptor_blueprint_9000fb8cb0e3e8cb) }
+
+var fileDescriptor_blueprint_9000fb8cb0e3e8cb = []byte{
+	// 901 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x55, 0x5d, 0x6f, 0x1b, 0x45,
+	0x14, 0xd5, 0x7a, 0x6d, 0xc7, 0x7b, 0xed, 0x7c, 0x74, 0xd4, 0xc2, 0x36, 0x54, 0xad, 0x71, 0x84,
+	0x70, 0xa9, 0x14, 0xa4, 0xf2, 0x00, 0xea, 0x5b, 0x48, 0x2b, 0x04, 0x42, 0x28, 0xda, 0x34, 0x88,
+	0xb7, 0xd5, 0x64, 0xf7, 0xc6, 0x8c, 0xb2, 0x3b, 0x3b, 0x9d, 0x0f, 0x63, 0xff, 0x35, 0xde, 0xf8,
+	0x0d, 0xf0, 0x63, 0x78, 0x44, 0xf3, 0xb1, 0x1b, 0xdb, 0x09, 0x7d, 0xdb, 0x7b, 0xe6, 0xcc, 0xbd,
+	0x77, 0xce, 0xb9, 0x33, 0x0b, 0x87, 0xd7, 0x95, 0x41, 0x21, 0x19, 0xd7, 0xa7, 0x42, 0x36, 0xba,
+	0x21, 0x07, 0x1d, 0xe0, 0xe2, 0xd9, 0xdf, 0x31, 0x24, 0xdf, 0xb7, 0x10, 0x21, 0xd0, 0x37, 0x86,
+	0x95, 0x69, 0x34, 0x8d, 0xe6, 0x49, 0xe6, 0xbe, 0x2d, 0xc6, 0x69, 0x8d, 0x69, 0xcf, 0x63, 0xf6,
+	0x9b, 0x9c, 0xc0, 0x7e, 0x4d, 0x39, 0xbb, 0x41, 0xa5, 0x73, 0x23, 0x2b, 0x95, 0xc6, 0xd3, 0x78,
+	0x9e, 0x64, 0x93, 0x16, 0xbc, 0x92, 0x95, 0x22, 0x2f, 0x60, 0x2c, 0x64, 0x73, 0xc3, 0x2a, 0xcc,
+	0x59, 0xa9, 0xd2, 0x81, 0xa3, 0x40, 0x80, 0x7e, 0x2c, 0x15, 0x79, 0x0a, 0x23, 0x2a, 0x44, 0xb5,
+	0xce, 0xa9, 0x4e, 0x87, 0x6e, 0x75, 0xcf, 0xc5, 0x67, 0x9a, 0x7c, 0x06, 0x89, 0x51, 0x28, 0x73,
+	0xd7, 0xcd, 0x9e, 0x5b, 0x1b, 0x59, 0xe0, 0xca, 0x76, 0xf4, 0x33, 0x9c, 0xa8, 0x5b, 0x26, 0x72,
+	0x21, 0x59, 0x4d, 0xe5, 0x3a, 0x57, 0xa8, 0x8d, 0xc8, 0x69, 0x51, 0x34, 0x86, 0xeb, 0xbc, 0x90,
+	0x48, 0x35, 0x6b, 0x78, 0x3a, 0x9a, 0x46, 0xf3, 0x51, 0xf6, 0xc2, 0x52, 0x2f, 0x3c, 0xf3, 0xd2,
+	0x12, 0xcf, 0x3c, 0xef, 0x3c, 0xd0, 0xc8, 0xaf, 0xf0, 0x52, 0xa1, 0xfe, 0x9f, 0x64, 0x54, 0xe5,
+	0x12, 0x17, 0xa6, 0xa2, 0x32, 0xb7, 0xe5, 0xd3, 0xc4, 0xe5, 0x3c, 0x51, 0xa8, 0x1f, 0x48, 0x79,
+	0xa6, 0x32, 0xcf, 0xbd, 0x52, 0x28, 0xc9, 0x2b, 0x18, 0xa8, 0xa2, 0x11, 0x98, 0xc2, 0x34, 0x9a,
+	0x8f, 0x5f, 0x3f, 0x39, 0xdd, 0x56, 0xfe, 0xf4, 0xd2, 0x2e, 0x66, 0x9e, 0x43, 0x9e, 0x41, 0x22,
+	0xb1, 0x68, 0x78, 0xc1, 0x2a, 0x4c, 0xc7, 0xae, 0xc8, 0x1d, 0x40, 0x8e, 0x61, 0x54, 0x34, 0x75,
+	0x4d, 0x79, 0xa9, 0xd2, 0xc9, 0x34, 0x9e, 0x4f, 0xb2, 0x2e, 0xfe, 0xa9, 0x3f, 0xea, 0x1f, 0x0d,
+	0xb2, 0xfd, 0xba, 0xb9, 0x66, 0x95, 0xdd, 0x70, 0xc3, 0x16, 0x6a, 0xf6, 0x4f, 0x0c, 0x03, 0x97,
+	0x9f, 0x7c, 0x09, 0x87, 0x8c, 0x17, 0x95, 0x29, 0x31, 0x57, 0x28, 0x19, 0xad, 0x54, 0x1a, 0x39,
+	0x39, 0x0f, 0x02, 0x7c, 0xe9, 0x51, 0x6b, 0x69, 0x4b, 0x34, 0xa5, 0xf5, 0xab, 0xe7, 0x2d, 0x0d,
+	0xe0, 0x95, 0xc5, 0xc8, 0x17, 0xd0, 0x6e, 0xcb, 0x17, 0xb2, 0x31, 0xa2, 0x35, 0xbe, 0xdd, 0xfa,
+	0x83, 0x03, 0x6d, 0x51, 0x5c, 0x6d, 0x17, 0xed, 0xfb, 0xa2, 0xb8, 0xda, 0x2d, 0x8a, 0xab, 0xcd,
+	0xa2, 0x7e, 0x48, 0x26, 0xb8, 0xda, 0x2e, 0x8a, 0xab, 0xad, 0xa2, 0x7e, 0x58, 0xda, 0xad, 0xa1,
+	0xe8, 0x57, 0xf0, 0xa8, 0x44, 0x91, 0x87, 0xf9, 0x72, 0x93, 0xa3, 0xc2, 0xe8, 0x1c, 0x96, 0x28,
+	0x2e, 0x3c, 0x6e, 0x07, 0x48, 0x91, 0x4f, 0x60, 0x58, 0x37, 0x25, 0x56, 0x2a, 0x1d, 0x39, 0x42,
+	0x88, 0xc8, 0x6f, 0xf0, 0x08, 0xb9, 0x6c, 0xaa, 0xaa, 0x46, 0xae, 0x73, 0x41, 0x25, 0xad, 0x55,
+	0x9a, 0x4c, 0xe3, 0xf9, 0xf8, 0xf5, 0xab, 0x07, 0xfd, 0x3b, 0x7d, 0xd7, 0xd1, 0x2f, 0x1c, 0xfb,
+	0x1d, 0xd7, 0x72, 0x9d, 0x1d, 0xe1, 0x0e, 0x7c, 0x7c, 0x0e, 0x4f, 0x1e, 0xa4, 0x92, 0x23, 0x88,
+	0x6f, 0x71, 0x1d, 0x6e, 0x9c, 0xfd, 0x24, 0x8f, 0x61, 0xb0, 0xa4, 0x95, 0x69, 0x6f, 0x9c, 0x0f,
+	0xde, 0xf4, 0xbe, 0x8b, 0x66, 0xff, 0xf6, 0x60, 0xfc, 0x16, 0x97, 0xac, 0xc0, 0xb7, 0x92, 0xdd,
+	0xf8, 0xeb, 0x5a, 0x6e, 0x5c, 0xd7, 0x92, 0x95, 0x56, 0x52, 0xaf, 0x79, 0xce, 0x4d, 0x7d, 0x8d,
+	0x32, 0x64, 0x99, 0x78, 0xf0, 0x17, 0x87, 0x91, 0x14, 0xf6, 0x8a, 0xdf, 0xb1, 0xb8, 0xc5, 0x32,
+	0x8d, 0xa7, 0xd1, 0x3c, 0xce, 0xda, 0x90, 0x3c, 0x07, 0xe8, 0xce, 0xd9, 0xba, 0xb6, 0x81, 0x90,
+	0x97, 0x70, 0x54, 0x33, 0xa5, 0x18, 0x5f, 0xb4, 0x4a, 0xb7, 0xa6, 0x1d, 0x06, 0x3c, 0x08, 0xad,
+	0xc8, 0xe7, 0x30, 0x69, 0xa9, 0x54, 0x74, 0xae, 0x8d, 0x03, 0x76, 0x26, 0x84, 0xcb, 0x26, 0xb1,
+	0x6e, 0x96, 0x58, 0xde, 0x65, 0x0b, 0x96, 0x05, 0x7c, 0x33, 0x9b, 0xe1, 0xb7, 0xbc, 0xf9, 0x83,
+	0xfb, 0x6c, 0xde, 0xb8, 0x71, 0xc0, 0x5c, 0xb6, 0xaf, 0x61, 0xf8, 0xc1, 0xa0, 0xc1, 0x32, 0x58,
+	0xf6, 0xe9, 0xae, 0x65, 0xe7, 0xfe, 0xd2, 0x64, 0x81, 0xe6, 0x0e, 0x43, 0x39, 0x5d, 0x6c, 0x96,
+	0x87, 0x70, 0x18, 0x8f, 0xb7, 0xe5, 0x67, 0x0b, 0xd8, 0x0b, 0xbb, 0x6d, 0x27, 0x12, 0x3f, 0x18,
+	0xfb, 0xf6, 0xe9, 0xb5, 0xc0, 0xa0, 0xfe, 0x38, 0x60, 0xef, 0xd7, 0x02, 0xed, 0x7c, 0x69, 0x2a,
+	0x17, 0xa8, 0x83, 0xfa, 0x21, 0xb2, 0x5b, 0xc3, 0xc5, 0xf5, 0x2f, 0x5b, 0xec, 0xb7, 0x06, 0xcc,
+	0xce, 0xe6, 0xec, 0xaf, 0x08, 0xf6, 0xbd, 0xc7, 0x19, 0x56, 0x48, 0x15, 0x3e, 0xe8, 0xf2, 0x63,
+	0x18, 0x28, 0x4d, 0x75, 0x37, 0x23, 0x2e, 0xb0, 0x65, 0x25, 0x52, 0xd5, 0xf0, 0x90, 0x38, 0x44,
+	0xd6, 0x6e, 0xa5, 0xa9, 0xd4, 0x58, 0xa6, 0x7d, 0x6f, 0x77, 0x08, 0xed, 0x8a, 0x11, 0x25, 0xb5,
+	0x2b, 0x03, 0xbf, 0x12, 0x42, 0xf2, 0x66, 0xe3, 0xcd, 0x19, 0x3a, 0x39, 0x9f, 0xef, 0xca, 0xf9,
+	0x5e, 0x52, 0x3b, 0x33, 0xad, 0xaa, 0x1d, 0x7f, 0xf6, 0x67, 0x04, 0x07, 0xdb, 0x8b, 0xf7, 0x4e,
+	0x1e, 0xdd, 0x3b, 0xf9, 0x3d, 0x5d, 0x7b, 0x1f, 0xd3, 0x35, 0xde, 0xd2, 0xf5, 0x19, 0x24, 0x5d,
+	0x6f, 0xee, 0x88, 0x49, 0x76, 0x07, 0xd8, 0x5d, 0x56, 0x1f, 0xa3, 0xdc, 0x19, 0x93, 0x2c, 0x44,
+	0x56, 0x44, 0x94, 0xb2, 0x91, 0xe9, 0xd0, 0x8b, 0xe8, 0x82, 0x99, 0x81, 0x51, 0x86, 0x4b, 0xa6,
+	0xec, 0xbf, 0xe1, 0x18, 0x46, 0x32, 0x7c, 0xbb, 0x8e, 0xe3, 0xac, 0x8b, 0x9d, 0x05, 0x74, 0x89,
+	0xa5, 0xeb, 0x33, 0xce, 0x7c, 0x40, 0xbe, 0xdd, 0xec, 0x24, 0x76, 0x2f, 0xff, 0xd3, 0x5d, 0xdd,
+	0xba, 0xff, 0xed, 0x46, 0x93, 0xd7, 0x43, 0xb7, 0xf6, 0xcd, 0x7f, 0x03, 0x00, 0xbc, 0xfb, 0x37,
+	0x5e, 0xb2, 0x07, 0x00, 0x00,
 }
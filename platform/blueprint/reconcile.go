@@ -0,0 +1,519 @@
+package blueprint
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/groob/plist"
+	"github.com/pkg/errors"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/mdm/appmanifest"
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/group"
+	"github.com/micromdm/micromdm/platform/pubsub"
+)
+
+const defaultReconcileInterval = 6 * time.Hour
+
+// ReconcileConfig configures the Reconciler.
+type ReconcileConfig struct {
+	// Interval is how often devices are checked. Defaults to 6 hours.
+	Interval time.Duration
+}
+
+type ReconcileStore interface {
+	List() ([]Blueprint, error)
+	DeviceDrift(udid string) (*DeviceDrift, error)
+	SaveDeviceDrift(*DeviceDrift) error
+}
+
+// Reconciler keeps devices in the state described by the blueprints which
+// have Reconcile set.
+//
+// On every interval the Reconciler sends ProfileList, and
+// InstalledApplicationList if the blueprints have applications, to the
+// enrolled devices in scope. When a device responds, the missing profiles
+// and applications are installed again, and profiles which were removed
+// from the blueprints since the last reconcile are removed from the device.
+// The result is saved as the DeviceDrift of the device.
+//
+// Applications are matched by the bundle identifier of their manifest.
+// Enrollment params are only known when a device enrolls, so blueprints
+// scoped by EnrollmentParams are not reconciled.
+type Reconciler struct {
+	config ReconcileConfig
+	store  ReconcileStore
+	worker *Worker
+	sub    pubsub.Subscriber
+	logger log.Logger
+
+	now           func() time.Time
+	fetchManifest func(ctx context.Context, url string) (*appmanifest.Manifest, error)
+
+	mtx       sync.Mutex
+	pending   map[string]pendingQuery     // by command UUID
+	inventory map[string]*deviceInventory // by device UDID
+	manifests map[string]manifestBundleID // by manifest URL
+}
+
+type pendingQuery struct {
+	udid        string
+	requestType string
+	queued      time.Time
+}
+
+// deviceInventory collects the responses of a device to the queries of
+// a reconcile.
+type deviceInventory struct {
+	profiles     map[string]bool
+	apps         map[string]bool
+	haveProfiles bool
+	haveApps     bool
+	needApps     bool
+}
+
+func (inv *deviceInventory) complete() bool {
+	return inv.haveProfiles && (inv.haveApps || !inv.needApps)
+}
+
+type manifestBundleID struct {
+	bundleID string
+	fetched  time.Time
+}
+
+// NewReconciler creates a Reconciler, which queues commands and looks up
+// devices with the worker.
+func NewReconciler(config ReconcileConfig, store ReconcileStore, worker *Worker, sub pubsub.Subscriber, logger log.Logger) *Reconciler {
+	if config.Interval <= 0 {
+		config.Interval = defaultReconcileInterval
+	}
+	return &Reconciler{
+		config:        config,
+		store:         store,
+		worker:        worker,
+		sub:           sub,
+		logger:        logger,
+		now:           time.Now,
+		fetchManifest: fetchManifest,
+		pending:       make(map[string]pendingQuery),
+		inventory:     make(map[string]*deviceInventory),
+		manifests:     make(map[string]manifestBundleID),
+	}
+}
+
+func (r *Reconciler) Run(ctx context.Context) error {
+	const subscription = "blueprint_reconciler"
+	connectEvents, err := r.sub.Subscribe(ctx, subscription, mdmsvc.ConnectTopic)
+	if err != nil {
+		return errors.Wrapf(err, "subscribing %s to %s", subscription, mdmsvc.ConnectTopic)
+	}
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-connectEvents:
+			var udid string
+			var inv *deviceInventory
+			udid, inv, err = r.handleAcknowledge(ev.Message)
+			if inv != nil {
+				// reconcile may fetch application manifests, which must not
+				// block the responses of other devices.
+				go r.reconcileDevice(ctx, udid, inv)
+			}
+		case <-ticker.C:
+			err = r.check(ctx)
+		}
+		if err != nil {
+			level.Info(r.logger).Log(
+				"msg", "reconcile blueprints",
+				"err", err,
+			)
+		}
+	}
+}
+
+// reconciled returns the blueprints which have Reconcile set.
+func (r *Reconciler) reconciled() ([]Blueprint, []group.Group, error) {
+	all, err := r.store.List()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "list blueprints to reconcile")
+	}
+	var bps []Blueprint
+	var usesGroups bool
+	for _, bp := range all {
+		if bp.Reconcile {
+			bps = append(bps, bp)
+			usesGroups = usesGroups || bp.Scope.UsesGroups()
+		}
+	}
+	if !usesGroups {
+		return bps, nil, nil
+	}
+	groups, err := r.worker.groupDB.List()
+	return bps, groups, errors.Wrap(err, "list groups for blueprint scope")
+}
+
+func inScope(bps []Blueprint, dev device.Device, groups []group.Group) []Blueprint {
+	target := deviceTarget(dev, groups)
+	var matched []Blueprint
+	for _, bp := range bps {
+		if bp.Scope.Match(target) {
+			matched = append(matched, bp)
+		}
+	}
+	return matched
+}
+
+// check queues the inventory queries for the devices in scope of the
+// reconciled blueprints.
+func (r *Reconciler) check(ctx context.Context) error {
+	bps, groups, err := r.reconciled()
+	if err != nil || len(bps) == 0 {
+		return err
+	}
+	enrolled := true
+	devices, err := r.worker.deviceDB.List(ctx, device.ListDevicesOption{FilterEnrolled: &enrolled})
+	if err != nil {
+		return errors.Wrap(err, "list devices to reconcile")
+	}
+
+	r.expirePending()
+	for _, dev := range devices {
+		matched := inScope(bps, dev, groups)
+		if len(matched) == 0 || r.isPending(dev.UDID) {
+			continue
+		}
+		inv := &deviceInventory{}
+		for _, bp := range matched {
+			inv.needApps = inv.needApps || len(bp.ApplicationURLs) > 0
+		}
+		r.mtx.Lock()
+		r.inventory[dev.UDID] = inv
+		r.mtx.Unlock()
+
+		if err := r.query(ctx, dev.UDID, "ProfileList"); err != nil {
+			return err
+		}
+		if inv.needApps {
+			if err := r.query(ctx, dev.UDID, "InstalledApplicationList"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) query(ctx context.Context, udid, requestType string) error {
+	request := &mdm.CommandRequest{
+		UDID:    udid,
+		Command: &mdm.Command{RequestType: requestType},
+	}
+	if requestType == "InstalledApplicationList" {
+		request.Command.InstalledApplicationList = &mdm.InstalledApplicationList{}
+	}
+	payload, err := r.worker.cmdsvc.NewCommand(ctx, request)
+	if err != nil {
+		return errors.Wrapf(err, "queue %s command for udid %s", requestType, udid)
+	}
+	r.mtx.Lock()
+	r.pending[payload.CommandUUID] = pendingQuery{udid: udid, requestType: requestType, queued: r.now()}
+	r.mtx.Unlock()
+	return nil
+}
+
+func (r *Reconciler) isPending(udid string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, q := range r.pending {
+		if q.udid == udid {
+			return true
+		}
+	}
+	return false
+}
+
+// expirePending forgets the queries of devices which did not respond
+// within an interval, so that they are queried again.
+func (r *Reconciler) expirePending() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for id, q := range r.pending {
+		if r.now().Sub(q.queued) >= r.config.Interval {
+			delete(r.pending, id)
+			delete(r.inventory, q.udid)
+		}
+	}
+}
+
+// handleAcknowledge collects the responses to the queries of a reconcile,
+// and returns the inventory of the device once it is complete.
+func (r *Reconciler) handleAcknowledge(message []byte) (string, *deviceInventory, error) {
+	var ev mdmsvc.AcknowledgeEvent
+	if err := mdmsvc.UnmarshalAcknowledgeEvent(message, &ev); err != nil {
+		return "", nil, errors.Wrap(err, "unmarshal acknowledge event")
+	}
+	if ev.Response.Status == "NotNow" {
+		return "", nil, nil
+	}
+
+	r.mtx.Lock()
+	q, ok := r.pending[ev.Response.CommandUUID]
+	delete(r.pending, ev.Response.CommandUUID)
+	inv := r.inventory[q.udid]
+	r.mtx.Unlock()
+	if !ok || inv == nil {
+		return "", nil, nil
+	}
+	if ev.Response.Status != "Acknowledged" {
+		r.mtx.Lock()
+		delete(r.inventory, q.udid)
+		r.mtx.Unlock()
+		return "", nil, errors.Errorf("%s for udid %s returned status %s", q.requestType, q.udid, ev.Response.Status)
+	}
+
+	resp, err := mdm.DecodeResponse(q.requestType, ev.Raw)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "decode %s response", q.requestType)
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	switch resp := resp.(type) {
+	case *mdm.ProfileListResponse:
+		inv.profiles = make(map[string]bool)
+		for _, p := range resp.ProfileList {
+			inv.profiles[p.PayloadIdentifier] = true
+		}
+		inv.haveProfiles = true
+	case *mdm.InstalledApplicationListResponse:
+		inv.apps = make(map[string]bool)
+		for _, app := range resp.InstalledApplicationList {
+			inv.apps[app.Identifier] = true
+		}
+		inv.haveApps = true
+	}
+	if !inv.complete() {
+		return "", nil, nil
+	}
+	delete(r.inventory, q.udid)
+	return q.udid, inv, nil
+}
+
+func (r *Reconciler) reconcileDevice(ctx context.Context, udid string, inv *deviceInventory) {
+	if err := r.reconcile(ctx, udid, inv); err != nil {
+		level.Info(r.logger).Log(
+			"msg", "reconcile blueprints",
+			"device_udid", udid,
+			"err", err,
+		)
+	}
+}
+
+// reconcile compares the inventory of the device with its blueprints and
+// queues the commands which correct the drift.
+func (r *Reconciler) reconcile(ctx context.Context, udid string, inv *deviceInventory) error {
+	bps, groups, err := r.reconciled()
+	if err != nil {
+		return err
+	}
+	dev, err := r.worker.deviceDB.DeviceByUDID(ctx, udid)
+	if err != nil {
+		return errors.Wrapf(err, "get device %s to reconcile", udid)
+	}
+	prev, err := r.store.DeviceDrift(udid)
+	if err != nil && !isNotFound(err) {
+		return errors.Wrapf(err, "get drift of device %s", udid)
+	}
+
+	// managed are the profiles the reconciler installed on the device,
+	// which it removes once they are no longer part of a blueprint.
+	managed := make(map[string]bool)
+	if prev != nil {
+		for _, pid := range prev.ManagedProfiles {
+			managed[pid] = true
+		}
+	}
+
+	drift := &DeviceDrift{UDID: udid, SerialNumber: dev.SerialNumber, Checked: r.now()}
+	desired := make(map[string]bool)
+	seenApps := make(map[string]bool)
+	for _, bp := range inScope(bps, *dev, groups) {
+		drift.Blueprints = append(drift.Blueprints, bp.Name)
+		fix := Blueprint{Name: bp.Name}
+		for _, pid := range bp.ProfileIdentifiers {
+			if desired[pid] {
+				continue
+			}
+			desired[pid] = true
+			if managed[pid] {
+				drift.ManagedProfiles = append(drift.ManagedProfiles, pid)
+			}
+			if !inv.profiles[pid] {
+				fix.ProfileIdentifiers = append(fix.ProfileIdentifiers, pid)
+			}
+		}
+		for _, url := range bp.ApplicationURLs {
+			if seenApps[url] {
+				continue
+			}
+			seenApps[url] = true
+			bundleID := r.bundleID(ctx, url)
+			switch {
+			case bundleID == "":
+				drift.UnknownApps = append(drift.UnknownApps, url)
+			case !inv.apps[bundleID]:
+				fix.ApplicationURLs = append(fix.ApplicationURLs, url)
+			}
+		}
+		drift.MissingProfiles = append(drift.MissingProfiles, fix.ProfileIdentifiers...)
+		drift.MissingApps = append(drift.MissingApps, fix.ApplicationURLs...)
+
+		for _, c := range r.worker.commands(ctx, fix, udid) {
+			payload, err := r.worker.cmdsvc.NewCommand(ctx, c.request)
+			if err != nil {
+				return errors.Wrapf(err, "queue %s for udid %s", c.summary.RequestType, udid)
+			}
+			c.summary.CommandUUID = payload.CommandUUID
+			drift.Queued = append(drift.Queued, c.summary)
+			if c.summary.RequestType == "InstallProfile" && !managed[c.summary.Target] {
+				managed[c.summary.Target] = true
+				drift.ManagedProfiles = append(drift.ManagedProfiles, c.summary.Target)
+			}
+		}
+	}
+
+	if prev != nil {
+		// profiles which another blueprint in scope installs, including
+		// blueprints which are not reconciled, are kept.
+		wanted, err := r.wanted(*dev, groups)
+		if err != nil {
+			return err
+		}
+		for _, pid := range prev.ManagedProfiles {
+			if desired[pid] || wanted[pid] || !inv.profiles[pid] {
+				continue
+			}
+			payload, err := r.worker.cmdsvc.NewCommand(ctx, &mdm.CommandRequest{
+				UDID: udid,
+				Command: &mdm.Command{
+					RequestType:   "RemoveProfile",
+					RemoveProfile: &mdm.RemoveProfile{Identifier: pid},
+				},
+			})
+			if err != nil {
+				return errors.Wrapf(err, "queue RemoveProfile for udid %s", udid)
+			}
+			drift.RemovedProfiles = append(drift.RemovedProfiles, pid)
+			drift.Queued = append(drift.Queued, Command{
+				RequestType: "RemoveProfile",
+				Target:      pid,
+				CommandUUID: payload.CommandUUID,
+			})
+		}
+	}
+
+	if drift.Drifted() {
+		level.Info(r.logger).Log(
+			"msg", "device drifted from blueprints",
+			"device_udid", udid,
+			"missing_profiles", len(drift.MissingProfiles),
+			"missing_apps", len(drift.MissingApps),
+			"removed_profiles", len(drift.RemovedProfiles),
+		)
+	}
+	return errors.Wrapf(r.store.SaveDeviceDrift(drift), "save drift of device %s", udid)
+}
+
+// wanted returns the profile identifiers of all the blueprints in scope of
+// the device.
+func (r *Reconciler) wanted(dev device.Device, groups []group.Group) (map[string]bool, error) {
+	all, err := r.store.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "list blueprints in scope")
+	}
+	for _, bp := range all {
+		if bp.Scope.UsesGroups() && groups == nil {
+			if groups, err = r.worker.groupDB.List(); err != nil {
+				return nil, errors.Wrap(err, "list groups for blueprint scope")
+			}
+			break
+		}
+	}
+	wanted := make(map[string]bool)
+	for _, bp := range inScope(all, dev, groups) {
+		for _, pid := range bp.ProfileIdentifiers {
+			wanted[pid] = true
+		}
+	}
+	return wanted, nil
+}
+
+// bundleID returns the bundle identifier of the application in the
+// manifest, or an empty string if it is unknown. Manifests are fetched
+// at most once per interval.
+func (r *Reconciler) bundleID(ctx context.Context, url string) string {
+	r.mtx.Lock()
+	m, ok := r.manifests[url]
+	r.mtx.Unlock()
+	if ok && r.now().Sub(m.fetched) < r.config.Interval {
+		return m.bundleID
+	}
+
+	manifest, err := r.fetchManifest(ctx, url)
+	if err != nil {
+		level.Info(r.logger).Log(
+			"msg", "fetch application manifest to reconcile",
+			"manifest_url", url,
+			"err", err,
+		)
+	}
+	m = manifestBundleID{fetched: r.now()}
+	if manifest != nil {
+		for _, item := range manifest.ManifestItems {
+			if item.Metadata == nil {
+				continue
+			}
+			m.bundleID = item.Metadata.BundleIdentifier
+			if m.bundleID == "" && len(item.Metadata.Items) > 0 {
+				m.bundleID = item.Metadata.Items[0].BundleIdentifier
+			}
+			if m.bundleID != "" {
+				break
+			}
+		}
+	}
+	r.mtx.Lock()
+	r.manifests[url] = m
+	r.mtx.Unlock()
+	return m.bundleID
+}
+
+var manifestClient = &http.Client{Timeout: 30 * time.Second}
+
+func fetchManifest(ctx context.Context, url string) (*appmanifest.Manifest, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create manifest request")
+	}
+	resp, err := manifestClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "get manifest")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get manifest: %s", resp.Status)
+	}
+	var manifest appmanifest.Manifest
+	err = plist.NewDecoder(resp.Body).Decode(&manifest)
+	return &manifest, errors.Wrap(err, "decode manifest")
+}
@@ -0,0 +1,185 @@
+package blueprint
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/groob/plist"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/mdm/appmanifest"
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+type reconcileStore struct {
+	Store
+	blueprints []Blueprint
+	drift      map[string]*DeviceDrift
+}
+
+func (s *reconcileStore) List() ([]Blueprint, error) { return s.blueprints, nil }
+
+func (s *reconcileStore) DeviceDrift(udid string) (*DeviceDrift, error) {
+	d, ok := s.drift[udid]
+	if !ok {
+		return nil, notFound(udid)
+	}
+	return d, nil
+}
+
+func (s *reconcileStore) SaveDeviceDrift(d *DeviceDrift) error {
+	s.drift[d.UDID] = d
+	return nil
+}
+
+type mockProfiles map[string]bool
+
+func (m mockProfiles) ProfileById(ctx context.Context, id string) (*profile.Profile, error) {
+	if !m[id] {
+		return nil, notFound(id)
+	}
+	return &profile.Profile{Identifier: id, Mobileconfig: []byte(id)}, nil
+}
+
+func TestReconcile(t *testing.T) {
+	store := &reconcileStore{
+		blueprints: []Blueprint{
+			{
+				Name:               "lab",
+				Reconcile:          true,
+				ProfileIdentifiers: []string{"com.example.wifi", "com.example.vpn"},
+				ApplicationURLs:    []string{"https://example.com/app.plist", "https://example.com/unknown.plist"},
+			},
+			// profiles of blueprints which are not reconciled are kept.
+			{Name: "once", ProfileIdentifiers: []string{"com.example.once", "com.example.kept"}},
+		},
+		drift: map[string]*DeviceDrift{
+			"UDID-1": {UDID: "UDID-1", ManagedProfiles: []string{"com.example.wifi", "com.example.old", "com.example.kept"}},
+		},
+	}
+	devices := mockDevices{"UDID-1": {UDID: "UDID-1", SerialNumber: "C02LAB", Enrolled: true}}
+	profiles := mockProfiles{"com.example.wifi": true, "com.example.vpn": true}
+	cmds := new(mockCommands)
+	w := NewWorker(nil, nil, profiles, devices, nil, cmds, nil, log.NewNopLogger())
+	r := NewReconciler(ReconcileConfig{Interval: time.Hour}, store, w, nil, log.NewNopLogger())
+	r.fetchManifest = func(ctx context.Context, url string) (*appmanifest.Manifest, error) {
+		manifest := new(appmanifest.Manifest)
+		if url == "https://example.com/app.plist" {
+			manifest.ManifestItems = []appmanifest.Item{{Metadata: &appmanifest.Metadata{
+				BundleInfo: appmanifest.BundleInfo{BundleIdentifier: "com.example.app"},
+			}}}
+		}
+		return manifest, nil
+	}
+
+	ctx := context.Background()
+	if err := r.check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds.requests) != 2 {
+		t.Fatalf("have %d queries queued, want 2", len(cmds.requests))
+	}
+	// a device with pending queries is not queried again.
+	if err := r.check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds.requests) != 2 {
+		t.Fatalf("have %d queries queued after second check, want 2", len(cmds.requests))
+	}
+
+	responses := map[string]interface{}{
+		"ProfileList": map[string]interface{}{
+			"ProfileList": []map[string]interface{}{
+				{"PayloadIdentifier": "com.example.vpn"},
+				{"PayloadIdentifier": "com.example.old"},
+				{"PayloadIdentifier": "com.example.kept"},
+			},
+		},
+		"InstalledApplicationList": map[string]interface{}{
+			"InstalledApplicationList": []map[string]interface{}{
+				{"Identifier": "com.apple.Safari"},
+			},
+		},
+	}
+	for i, req := range cmds.requests[:2] {
+		raw, err := plist.Marshal(responses[req.Command.RequestType])
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := mdmsvc.MarshalAcknowledgeEvent(&mdmsvc.AcknowledgeEvent{
+			Response: mdmsvc.Response{
+				UDID:        "UDID-1",
+				Status:      "Acknowledged",
+				CommandUUID: cmds.payloads[i].CommandUUID,
+			},
+			Raw: raw,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		udid, inv, err := r.handleAcknowledge(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inv == nil {
+			continue
+		}
+		if err := r.reconcile(ctx, udid, inv); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	drift := store.drift["UDID-1"]
+	for i := range drift.Queued {
+		drift.Queued[i].CommandUUID = ""
+	}
+	want := &DeviceDrift{
+		UDID:            "UDID-1",
+		SerialNumber:    "C02LAB",
+		Checked:         drift.Checked,
+		Blueprints:      []string{"lab"},
+		MissingProfiles: []string{"com.example.wifi"},
+		MissingApps:     []string{"https://example.com/app.plist"},
+		RemovedProfiles: []string{"com.example.old"},
+		UnknownApps:     []string{"https://example.com/unknown.plist"},
+		// com.example.vpn is already installed, and wasn't installed by the
+		// reconciler.
+		ManagedProfiles: []string{"com.example.wifi"},
+		Queued: []Command{
+			{RequestType: "InstallApplication", Target: "https://example.com/app.plist"},
+			{RequestType: "InstallProfile", Target: "com.example.wifi"},
+			{RequestType: "RemoveProfile", Target: "com.example.old"},
+		},
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("have drift\n%+v\nwant\n%+v", drift, want)
+	}
+	if len(cmds.requests) != 5 {
+		t.Errorf("have %d commands queued, want 5", len(cmds.requests))
+	}
+}
+
+func TestMarshalDeviceDrift(t *testing.T) {
+	d := &DeviceDrift{
+		UDID:            "UDID-1",
+		Checked:         time.Now().UTC().Truncate(time.Second),
+		Blueprints:      []string{"lab"},
+		MissingProfiles: []string{"com.example.wifi"},
+		Queued:          []Command{{RequestType: "InstallProfile", Target: "com.example.wifi", CommandUUID: "a-b-c"}},
+		ManagedProfiles: []string{"com.example.wifi"},
+	}
+	data, err := MarshalDeviceDrift(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have DeviceDrift
+	if err := UnmarshalDeviceDrift(data, &have); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&have, d) {
+		t.Errorf("have %+v, want %+v", have, d)
+	}
+}
@@ -0,0 +1,370 @@
+package blueprint
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/groob/plist"
+	"github.com/pkg/errors"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/blueprint/internal/blueprintproto"
+	"github.com/micromdm/micromdm/platform/pubsub"
+)
+
+// ReleaseHeldTopic is the topic of the DeviceRelease published when a
+// device is held in Setup Assistant.
+const ReleaseHeldTopic = "mdm.BlueprintReleaseHeld"
+
+// ReleaseState is the state of the DeviceConfigured command of a device
+// which enrolled while awaiting configuration.
+type ReleaseState string
+
+const (
+	// ReleasePending devices wait for their required commands.
+	ReleasePending ReleaseState = "pending"
+	// ReleaseReleased devices were sent DeviceConfigured.
+	ReleaseReleased ReleaseState = "released"
+	// ReleaseHeld devices had a failed or timed out required command,
+	// and wait for an administrator to release them.
+	ReleaseHeld ReleaseState = "held"
+)
+
+// Failure policies for required commands which fail or time out.
+const (
+	FailureRelease = "release"
+	FailureHold    = "hold"
+)
+
+// Command statuses of a TrackedCommand. Other statuses are the status
+// returned by the device, such as Error.
+const (
+	CommandPending      = "Pending"
+	CommandAcknowledged = "Acknowledged"
+)
+
+// DefaultRequiredRequestTypes are the blueprint commands which must be
+// acknowledged before a device is released, unless configured otherwise.
+var DefaultRequiredRequestTypes = []string{"AccountConfiguration", "InstallProfile"}
+
+const (
+	defaultReleaseTimeout       = 30 * time.Minute
+	defaultReleaseCheckInterval = time.Minute
+)
+
+// ReleaseConfig configures when DeviceConfigured is sent to devices which
+// are awaiting configuration.
+type ReleaseConfig struct {
+	// RequiredRequestTypes are the blueprint commands which must be
+	// acknowledged. Defaults to DefaultRequiredRequestTypes.
+	RequiredRequestTypes []string
+
+	// Timeout is how long to wait for the required commands.
+	// Defaults to 30 minutes.
+	Timeout time.Duration
+
+	// OnFailure is the policy for a required command which fails or
+	// times out, FailureRelease or FailureHold. Defaults to FailureRelease.
+	OnFailure string
+}
+
+// TrackedCommand is a required command sent by a blueprint.
+type TrackedCommand struct {
+	CommandUUID string `json:"command_uuid"`
+	RequestType string `json:"request_type"`
+	Target      string `json:"target,omitempty"`
+	Blueprint   string `json:"blueprint"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// DeviceRelease tracks the required commands of a device, and whether
+// the device was released from Setup Assistant.
+type DeviceRelease struct {
+	UDID     string           `json:"udid"`
+	State    ReleaseState     `json:"state"`
+	Reason   string           `json:"reason,omitempty"`
+	Started  time.Time        `json:"started"`
+	Updated  time.Time        `json:"updated"`
+	Commands []TrackedCommand `json:"commands,omitempty"`
+}
+
+func (r *DeviceRelease) acknowledged() bool {
+	for _, c := range r.Commands {
+		if c.Status != CommandAcknowledged {
+			return false
+		}
+	}
+	return true
+}
+
+type ReleaseStore interface {
+	SaveDeviceRelease(*DeviceRelease) error
+	DeviceRelease(udid string) (*DeviceRelease, error)
+	ListDeviceReleases() ([]DeviceRelease, error)
+}
+
+type WorkerOption func(*Worker)
+
+// WithReleaseGate holds DeviceConfigured until the required blueprint
+// commands are acknowledged. Held devices are published to ReleaseHeldTopic.
+func WithReleaseGate(config ReleaseConfig, store ReleaseStore, pub pubsub.Publisher) WorkerOption {
+	if len(config.RequiredRequestTypes) == 0 {
+		config.RequiredRequestTypes = DefaultRequiredRequestTypes
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultReleaseTimeout
+	}
+	if config.OnFailure == "" {
+		config.OnFailure = FailureRelease
+	}
+	return func(w *Worker) {
+		w.release = &releaseGate{config: config, store: store, pub: pub}
+	}
+}
+
+type releaseGate struct {
+	config ReleaseConfig
+	store  ReleaseStore
+	pub    pubsub.Publisher
+}
+
+func (g *releaseGate) required(requestType string) bool {
+	return contains(g.config.RequiredRequestTypes, requestType)
+}
+
+// configure sends DeviceConfigured to a device after its blueprints were
+// applied, or starts tracking the required commands which were sent.
+func (w *Worker) configure(ctx context.Context, udid string, sent []TrackedCommand) error {
+	if w.release == nil || len(sent) == 0 {
+		level.Debug(w.logger).Log(
+			"msg", "sending DeviceConfigured at the end of blueprint",
+			"device_udid", udid,
+		)
+		return w.sendDeviceConfigured(ctx, udid)
+	}
+	now := w.now()
+	rel := &DeviceRelease{
+		UDID:     udid,
+		State:    ReleasePending,
+		Started:  now,
+		Updated:  now,
+		Commands: sent,
+	}
+	level.Debug(w.logger).Log(
+		"msg", "holding DeviceConfigured until blueprint commands are acknowledged",
+		"device_udid", udid,
+		"commands", len(sent),
+	)
+	return errors.Wrap(w.release.store.SaveDeviceRelease(rel), "save device release")
+}
+
+func (w *Worker) sendDeviceConfigured(ctx context.Context, udid string) error {
+	_, err := w.cmdsvc.NewCommand(ctx, &mdm.CommandRequest{
+		Command: &mdm.Command{RequestType: "DeviceConfigured"},
+		UDID:    udid,
+	})
+	return errors.Wrap(err, "send DeviceConfigured")
+}
+
+// handleReleaseAcknowledge updates the tracked command of an acknowledge
+// event, and releases or holds the device when all commands completed.
+func (w *Worker) handleReleaseAcknowledge(ctx context.Context, message []byte) error {
+	var ev mdmsvc.AcknowledgeEvent
+	if err := mdmsvc.UnmarshalAcknowledgeEvent(message, &ev); err != nil {
+		return errors.Wrap(err, "unmarshal acknowledge event")
+	}
+	switch ev.Response.Status {
+	case "Acknowledged", "Error", "CommandFormatError":
+	default:
+		return nil
+	}
+	if ev.Response.UserID != nil || ev.Response.EnrollmentID != nil {
+		return nil
+	}
+
+	rel, err := w.release.store.DeviceRelease(ev.Response.UDID)
+	if isNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "get release of device %s", ev.Response.UDID)
+	}
+	if rel.State != ReleasePending {
+		return nil
+	}
+	var tracked *TrackedCommand
+	for i := range rel.Commands {
+		if rel.Commands[i].CommandUUID == ev.Response.CommandUUID {
+			tracked = &rel.Commands[i]
+		}
+	}
+	if tracked == nil {
+		return nil
+	}
+
+	tracked.Status = ev.Response.Status
+	rel.Updated = w.now()
+	if ev.Response.Status != "Acknowledged" {
+		tracked.Error = errorChainDescription(ev.Raw)
+		return w.releaseFailed(ctx, rel, tracked.RequestType+" failed with status "+tracked.Status)
+	}
+	if rel.acknowledged() {
+		return w.releaseDevice(ctx, rel, "required commands acknowledged")
+	}
+	return errors.Wrap(w.release.store.SaveDeviceRelease(rel), "save device release")
+}
+
+// errorChainDescription returns the first error description in the
+// response of a failed command.
+func errorChainDescription(raw []byte) string {
+	var resp struct {
+		ErrorChain []mdmsvc.ErrorChainItem
+	}
+	if err := plist.Unmarshal(raw, &resp); err != nil || len(resp.ErrorChain) == 0 {
+		return ""
+	}
+	return resp.ErrorChain[0].USEnglishDescription
+}
+
+// checkReleaseTimeouts applies the failure policy to pending devices
+// whose commands were not acknowledged in time.
+func (w *Worker) checkReleaseTimeouts(ctx context.Context) error {
+	releases, err := w.release.store.ListDeviceReleases()
+	if err != nil {
+		return errors.Wrap(err, "list device releases")
+	}
+	for i := range releases {
+		rel := &releases[i]
+		if rel.State != ReleasePending || w.now().Sub(rel.Started) < w.release.config.Timeout {
+			continue
+		}
+		rel.Updated = w.now()
+		if err := w.releaseFailed(ctx, rel, "timed out waiting for required commands"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseFailed applies the failure policy to the device.
+func (w *Worker) releaseFailed(ctx context.Context, rel *DeviceRelease, reason string) error {
+	if w.release.config.OnFailure != FailureHold {
+		return w.releaseDevice(ctx, rel, reason)
+	}
+	rel.State = ReleaseHeld
+	rel.Reason = reason
+	if err := w.release.store.SaveDeviceRelease(rel); err != nil {
+		return errors.Wrap(err, "save device release")
+	}
+	level.Info(w.logger).Log(
+		"msg", "holding device in Setup Assistant",
+		"device_udid", rel.UDID,
+		"reason", reason,
+	)
+	if w.release.pub == nil {
+		return nil
+	}
+	msg, err := MarshalDeviceRelease(rel)
+	if err != nil {
+		return errors.Wrap(err, "marshal device release")
+	}
+	return errors.Wrap(w.release.pub.Publish(ctx, ReleaseHeldTopic, msg), "publish held device")
+}
+
+func (w *Worker) releaseDevice(ctx context.Context, rel *DeviceRelease, reason string) error {
+	if err := w.sendDeviceConfigured(ctx, rel.UDID); err != nil {
+		return err
+	}
+	rel.State = ReleaseReleased
+	rel.Reason = reason
+	rel.Updated = w.now()
+	level.Debug(w.logger).Log(
+		"msg", "sent DeviceConfigured",
+		"device_udid", rel.UDID,
+		"reason", reason,
+	)
+	return errors.Wrap(w.release.store.SaveDeviceRelease(rel), "save device release")
+}
+
+// ReleaseDevices sends DeviceConfigured to pending or held devices.
+func (w *Worker) ReleaseDevices(ctx context.Context, udids []string) ([]DeviceRelease, error) {
+	if w.release == nil {
+		return nil, errors.New("DeviceConfigured is not held by this server")
+	}
+	var released []DeviceRelease
+	for _, udid := range udids {
+		rel, err := w.release.store.DeviceRelease(udid)
+		if err != nil {
+			return released, errors.Wrapf(err, "get release of device %s", udid)
+		}
+		if rel.State != ReleaseReleased {
+			if err := w.releaseDevice(ctx, rel, "released by administrator"); err != nil {
+				return released, err
+			}
+		}
+		released = append(released, *rel)
+	}
+	return released, nil
+}
+
+func MarshalDeviceRelease(r *DeviceRelease) ([]byte, error) {
+	pb := blueprintproto.DeviceRelease{
+		Udid:    r.UDID,
+		State:   string(r.State),
+		Reason:  r.Reason,
+		Started: timeToNano(r.Started),
+		Updated: timeToNano(r.Updated),
+	}
+	for _, c := range r.Commands {
+		pb.Commands = append(pb.Commands, &blueprintproto.TrackedCommand{
+			CommandUuid: c.CommandUUID,
+			RequestType: c.RequestType,
+			Target:      c.Target,
+			Blueprint:   c.Blueprint,
+			Status:      c.Status,
+			Error:       c.Error,
+		})
+	}
+	return proto.Marshal(&pb)
+}
+
+func UnmarshalDeviceRelease(data []byte, r *DeviceRelease) error {
+	var pb blueprintproto.DeviceRelease
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return errors.Wrap(err, "unmarshal proto to DeviceRelease")
+	}
+	r.UDID = pb.GetUdid()
+	r.State = ReleaseState(pb.GetState())
+	r.Reason = pb.GetReason()
+	r.Started = timeFromNano(pb.GetStarted())
+	r.Updated = timeFromNano(pb.GetUpdated())
+	r.Commands = nil
+	for _, c := range pb.GetCommands() {
+		r.Commands = append(r.Commands, TrackedCommand{
+			CommandUUID: c.GetCommandUuid(),
+			RequestType: c.GetRequestType(),
+			Target:      c.GetTarget(),
+			Blueprint:   c.GetBlueprint(),
+			Status:      c.GetStatus(),
+			Error:       c.GetError(),
+		})
+	}
+	return nil
+}
+
+func timeToNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func timeFromNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
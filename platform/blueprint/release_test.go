@@ -0,0 +1,186 @@
+package blueprint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+)
+
+type releaseStore map[string]DeviceRelease
+
+func (s releaseStore) SaveDeviceRelease(r *DeviceRelease) error {
+	s[r.UDID] = *r
+	return nil
+}
+
+func (s releaseStore) DeviceRelease(udid string) (*DeviceRelease, error) {
+	r, ok := s[udid]
+	if !ok {
+		return nil, notFound(udid)
+	}
+	return &r, nil
+}
+
+func (s releaseStore) ListDeviceReleases() ([]DeviceRelease, error) {
+	var releases []DeviceRelease
+	for _, r := range s {
+		releases = append(releases, r)
+	}
+	return releases, nil
+}
+
+type mockPublisher []string
+
+func (p *mockPublisher) Publish(ctx context.Context, topic string, msg []byte) error {
+	*p = append(*p, topic)
+	return nil
+}
+
+func enrollAwaitingConfiguration(t *testing.T, w *Worker, udid string) {
+	t.Helper()
+	msg, err := mdmsvc.MarshalCheckinEvent(&mdmsvc.CheckinEvent{
+		Command: mdmsvc.CheckinCommand{MessageType: "TokenUpdate", UDID: udid, AwaitingConfiguration: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.handleTokenUpdateEvent(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func acknowledge(t *testing.T, w *Worker, udid, commandUUID, status string, raw []byte) {
+	t.Helper()
+	msg, err := mdmsvc.MarshalAcknowledgeEvent(&mdmsvc.AcknowledgeEvent{
+		Response: mdmsvc.Response{UDID: udid, Status: status, CommandUUID: commandUUID},
+		Raw:      raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.handleReleaseAcknowledge(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sentDeviceConfigured(cmds *mockCommands) bool {
+	for _, r := range cmds.requests {
+		if r.Command.RequestType == "DeviceConfigured" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReleaseGate(t *testing.T) {
+	bps := mockBlueprints{{
+		Name:               "setup",
+		ApplicationURLs:    []string{"https://example.com/app.plist"},
+		ProfileIdentifiers: []string{"com.example.wifi", "com.example.vpn"},
+	}}
+	profiles := mockProfiles{"com.example.wifi": true, "com.example.vpn": true}
+	errorResponse := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>ErrorChain</key>
+	<array>
+		<dict>
+			<key>ErrorCode</key>
+			<integer>4001</integer>
+			<key>USEnglishDescription</key>
+			<string>The profile is invalid.</string>
+		</dict>
+	</array>
+</dict>
+</plist>`)
+
+	t.Run("acknowledged", func(t *testing.T) {
+		store, cmds := make(releaseStore), new(mockCommands)
+		w := NewWorker(bps, nil, profiles, nil, nil, cmds, nil, log.NewNopLogger(),
+			WithReleaseGate(ReleaseConfig{}, store, nil))
+		enrollAwaitingConfiguration(t, w, "UDID-1")
+
+		if sentDeviceConfigured(cmds) {
+			t.Fatal("DeviceConfigured sent before the profiles were acknowledged")
+		}
+		rel := store["UDID-1"]
+		if rel.State != ReleasePending || len(rel.Commands) != 2 {
+			t.Fatalf("have release %+v, want pending with 2 commands", rel)
+		}
+
+		acknowledge(t, w, "UDID-1", rel.Commands[0].CommandUUID, "Acknowledged", nil)
+		if sentDeviceConfigured(cmds) || store["UDID-1"].State != ReleasePending {
+			t.Fatal("device released with an unacknowledged profile")
+		}
+		acknowledge(t, w, "UDID-1", rel.Commands[1].CommandUUID, "Acknowledged", nil)
+		if !sentDeviceConfigured(cmds) {
+			t.Fatal("DeviceConfigured not sent after the profiles were acknowledged")
+		}
+		if have := store["UDID-1"].State; have != ReleaseReleased {
+			t.Errorf("have state %s, want %s", have, ReleaseReleased)
+		}
+	})
+
+	t.Run("hold failed", func(t *testing.T) {
+		store, cmds, pub := make(releaseStore), new(mockCommands), new(mockPublisher)
+		w := NewWorker(bps, nil, profiles, nil, nil, cmds, nil, log.NewNopLogger(),
+			WithReleaseGate(ReleaseConfig{OnFailure: FailureHold}, store, pub))
+		enrollAwaitingConfiguration(t, w, "UDID-1")
+
+		acknowledge(t, w, "UDID-1", store["UDID-1"].Commands[0].CommandUUID, "Error", errorResponse)
+		rel := store["UDID-1"]
+		if rel.State != ReleaseHeld || sentDeviceConfigured(cmds) {
+			t.Fatalf("have release %+v, want held", rel)
+		}
+		if have, want := rel.Commands[0].Error, "The profile is invalid."; have != want {
+			t.Errorf("have error %q, want %q", have, want)
+		}
+		if len(*pub) != 1 || (*pub)[0] != ReleaseHeldTopic {
+			t.Errorf("have published %v, want %s", *pub, ReleaseHeldTopic)
+		}
+
+		if _, err := w.ReleaseDevices(context.Background(), []string{"UDID-1"}); err != nil {
+			t.Fatal(err)
+		}
+		if store["UDID-1"].State != ReleaseReleased || !sentDeviceConfigured(cmds) {
+			t.Errorf("held device was not released")
+		}
+	})
+
+	t.Run("release on timeout", func(t *testing.T) {
+		store, cmds := make(releaseStore), new(mockCommands)
+		w := NewWorker(bps, nil, profiles, nil, nil, cmds, nil, log.NewNopLogger(),
+			WithReleaseGate(ReleaseConfig{Timeout: time.Minute}, store, nil))
+		enrollAwaitingConfiguration(t, w, "UDID-1")
+
+		if err := w.checkReleaseTimeouts(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if sentDeviceConfigured(cmds) {
+			t.Fatal("DeviceConfigured sent before the timeout")
+		}
+		w.now = func() time.Time { return time.Now().Add(time.Hour) }
+		if err := w.checkReleaseTimeouts(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if store["UDID-1"].State != ReleaseReleased || !sentDeviceConfigured(cmds) {
+			t.Errorf("device not released after the timeout")
+		}
+	})
+
+	t.Run("no required commands", func(t *testing.T) {
+		store, cmds := make(releaseStore), new(mockCommands)
+		appsOnly := mockBlueprints{{Name: "apps", ApplicationURLs: []string{"https://example.com/app.plist"}}}
+		w := NewWorker(appsOnly, nil, nil, nil, nil, cmds, nil, log.NewNopLogger(),
+			WithReleaseGate(ReleaseConfig{}, store, nil))
+		enrollAwaitingConfiguration(t, w, "UDID-1")
+		if !sentDeviceConfigured(cmds) {
+			t.Error("DeviceConfigured not sent without required commands")
+		}
+	})
+}
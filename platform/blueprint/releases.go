@@ -0,0 +1,131 @@
+package blueprint
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+type GetReleasesOption struct {
+	FilterUDID  []string       `json:"filter_udid,omitempty"`
+	FilterState []ReleaseState `json:"filter_state,omitempty"`
+}
+
+func (opt GetReleasesOption) match(r DeviceRelease) bool {
+	if len(opt.FilterUDID) > 0 && !contains(opt.FilterUDID, r.UDID) {
+		return false
+	}
+	if len(opt.FilterState) == 0 {
+		return true
+	}
+	for _, state := range opt.FilterState {
+		if r.State == state {
+			return true
+		}
+	}
+	return false
+}
+
+// GetReleases returns the DeviceConfigured state of devices which enrolled
+// while awaiting configuration.
+func (svc *BlueprintService) GetReleases(ctx context.Context, opt GetReleasesOption) ([]DeviceRelease, error) {
+	all, err := svc.store.ListDeviceReleases()
+	if err != nil {
+		return nil, err
+	}
+	var releases []DeviceRelease
+	for _, r := range all {
+		if opt.match(r) {
+			releases = append(releases, r)
+		}
+	}
+	return releases, nil
+}
+
+// ReleaseDevices sends DeviceConfigured to devices which are pending or held.
+func (svc *BlueprintService) ReleaseDevices(ctx context.Context, udids []string) ([]DeviceRelease, error) {
+	if svc.worker == nil {
+		return nil, errors.New("devices can't be released by this server")
+	}
+	if len(udids) == 0 {
+		return nil, errors.New("no devices selected")
+	}
+	return svc.worker.ReleaseDevices(ctx, udids)
+}
+
+type getReleasesRequest struct {
+	Opts GetReleasesOption `json:"opts"`
+}
+
+type releasesResponse struct {
+	Releases []DeviceRelease `json:"releases"`
+	Err      error           `json:"err,omitempty"`
+}
+
+func (r releasesResponse) Failed() error { return r.Err }
+
+func decodeGetReleasesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req getReleasesRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeReleasesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp releasesResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeGetReleasesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getReleasesRequest)
+		releases, err := svc.GetReleases(ctx, req.Opts)
+		return releasesResponse{
+			Releases: releases,
+			Err:      err,
+		}, nil
+	}
+}
+
+func (e Endpoints) GetReleases(ctx context.Context, opt GetReleasesOption) ([]DeviceRelease, error) {
+	response, err := e.GetReleasesEndpoint(ctx, getReleasesRequest{Opts: opt})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(releasesResponse)
+	return resp.Releases, resp.Err
+}
+
+type releaseDevicesRequest struct {
+	UDIDs []string `json:"udids"`
+}
+
+func decodeReleaseDevicesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req releaseDevicesRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func MakeReleaseDevicesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(releaseDevicesRequest)
+		releases, err := svc.ReleaseDevices(ctx, req.UDIDs)
+		return releasesResponse{
+			Releases: releases,
+			Err:      err,
+		}, nil
+	}
+}
+
+func (e Endpoints) ReleaseDevices(ctx context.Context, udids []string) ([]DeviceRelease, error) {
+	response, err := e.ReleaseDevicesEndpoint(ctx, releaseDevicesRequest{UDIDs: udids})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(releasesResponse)
+	return resp.Releases, resp.Err
+}
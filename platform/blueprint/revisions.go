@@ -0,0 +1,284 @@
+package blueprint
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+	"github.com/micromdm/micromdm/platform/blueprint/internal/blueprintproto"
+)
+
+// Revision is a saved version of a blueprint. The revisions of a blueprint
+// are numbered from 1 in the order they were saved.
+type Revision struct {
+	Revision  int       `json:"revision"`
+	Saved     time.Time `json:"saved"`
+	Blueprint Blueprint `json:"blueprint"`
+}
+
+func MarshalRevision(r *Revision) ([]byte, error) {
+	bp, err := blueprintToProto(&r.Blueprint)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&blueprintproto.Revision{
+		Revision:  int64(r.Revision),
+		Saved:     timeToNano(r.Saved),
+		Blueprint: bp,
+	})
+}
+
+func UnmarshalRevision(data []byte, r *Revision) error {
+	var pb blueprintproto.Revision
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return errors.Wrap(err, "unmarshal proto to Revision")
+	}
+	r.Revision = int(pb.GetRevision())
+	r.Saved = timeFromNano(pb.GetSaved())
+	r.Blueprint = Blueprint{}
+	if pb.GetBlueprint() == nil {
+		return nil
+	}
+	return blueprintFromProto(pb.GetBlueprint(), &r.Blueprint)
+}
+
+// Change is a field which differs between two versions of a blueprint.
+// Field is the JSON path of the field, for example scope.include_serials.
+// From is nil for a field which was added, and To for one which was removed.
+type Change struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
+
+// Diff returns the fields which differ between two blueprints, sorted by
+// field. Empty fields are treated as unset.
+func Diff(from, to *Blueprint) ([]Change, error) {
+	a, err := toJSONValue(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toJSONValue(to)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	diffValues("", a, b, &changes)
+	return changes, nil
+}
+
+func toJSONValue(bp *Blueprint) (interface{}, error) {
+	data, err := json.Marshal(bp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal blueprint %s", bp.Name)
+	}
+	var v interface{}
+	err = json.Unmarshal(data, &v)
+	return v, errors.Wrapf(err, "unmarshal blueprint %s", bp.Name)
+}
+
+func diffValues(field string, a, b interface{}, changes *[]Change) {
+	am, aok := a.(map[string]interface{})
+	bm, bok := b.(map[string]interface{})
+	if aok && bok {
+		keys := make(map[string]bool)
+		for k := range am {
+			keys[k] = true
+		}
+		for k := range bm {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			path := k
+			if field != "" {
+				path = field + "." + k
+			}
+			diffValues(path, am[k], bm[k], changes)
+		}
+		return
+	}
+	if isEmptyValue(a) {
+		a = nil
+	}
+	if isEmptyValue(b) {
+		b = nil
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Field: field, From: a, To: b})
+	}
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// GetRevisions returns the saved revisions of a blueprint, oldest first.
+func (svc *BlueprintService) GetRevisions(ctx context.Context, name string) ([]Revision, error) {
+	return svc.store.BlueprintRevisions(name)
+}
+
+// RollbackBlueprint saves an earlier revision of a blueprint as its newest
+// revision. A blueprint which was removed is restored.
+func (svc *BlueprintService) RollbackBlueprint(ctx context.Context, name string, revision int) (*Revision, error) {
+	rev, err := svc.store.BlueprintRevision(name, revision)
+	if err != nil {
+		return nil, err
+	}
+	bp := rev.Blueprint
+	current, err := svc.store.BlueprintByName(name)
+	switch {
+	case err == nil:
+		// keep the UUID in case the blueprint was removed and created again.
+		bp.UUID = current.UUID
+	case !isNotFound(err):
+		return nil, errors.Wrapf(err, "get blueprint %s", name)
+	}
+	if err := svc.store.Save(&bp); err != nil {
+		return nil, errors.Wrapf(err, "rollback blueprint %s to revision %d", name, revision)
+	}
+	revisions, err := svc.store.BlueprintRevisions(name)
+	if err != nil {
+		return nil, err
+	}
+	return &revisions[len(revisions)-1], nil
+}
+
+type getRevisionsRequest struct {
+	Name string `json:"-"`
+}
+
+type getRevisionsResponse struct {
+	Revisions []Revision `json:"revisions"`
+	Err       error      `json:"err,omitempty"`
+}
+
+func (r getRevisionsResponse) Failed() error { return r.Err }
+
+func decodeGetRevisionsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	name, ok := mux.Vars(r)["name"]
+	if !ok {
+		return nil, errors.New("bad route")
+	}
+	return getRevisionsRequest{Name: name}, nil
+}
+
+func encodeGetRevisionsRequest(ctx context.Context, r *http.Request, request interface{}) error {
+	req := request.(getRevisionsRequest)
+	r.URL.Path = "/v1/blueprints/" + req.Name + "/revisions"
+	return nil
+}
+
+func decodeGetRevisionsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp getRevisionsResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeGetRevisionsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getRevisionsRequest)
+		revisions, err := svc.GetRevisions(ctx, req.Name)
+		return getRevisionsResponse{
+			Revisions: revisions,
+			Err:       err,
+		}, nil
+	}
+}
+
+func (e Endpoints) GetRevisions(ctx context.Context, name string) ([]Revision, error) {
+	response, err := e.GetRevisionsEndpoint(ctx, getRevisionsRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(getRevisionsResponse)
+	return resp.Revisions, resp.Err
+}
+
+type rollbackRequest struct {
+	Name     string `json:"-"`
+	Revision int    `json:"revision"`
+}
+
+type rollbackResponse struct {
+	Revision *Revision `json:"revision,omitempty"`
+	Err      error     `json:"err,omitempty"`
+}
+
+func (r rollbackResponse) Failed() error { return r.Err }
+
+func decodeRollbackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	name, ok := mux.Vars(r)["name"]
+	if !ok {
+		return nil, errors.New("bad route")
+	}
+	req := rollbackRequest{Name: name}
+	if err := httputil.DecodeJSONRequest(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Revision < 1 {
+		return nil, errors.Errorf("invalid revision %d", req.Revision)
+	}
+	return req, nil
+}
+
+func encodeRollbackRequest(ctx context.Context, r *http.Request, request interface{}) error {
+	req := request.(rollbackRequest)
+	r.URL.Path = "/v1/blueprints/" + req.Name + "/rollback"
+	return httptransport.EncodeJSONRequest(ctx, r, request)
+}
+
+func decodeRollbackResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp rollbackResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeRollbackEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(rollbackRequest)
+		rev, err := svc.RollbackBlueprint(ctx, req.Name, req.Revision)
+		return rollbackResponse{
+			Revision: rev,
+			Err:      err,
+		}, nil
+	}
+}
+
+func (e Endpoints) RollbackBlueprint(ctx context.Context, name string, revision int) (*Revision, error) {
+	request := rollbackRequest{Name: name, Revision: revision}
+	response, err := e.RollbackEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(rollbackResponse)
+	return resp.Revision, resp.Err
+}
@@ -0,0 +1,60 @@
+package blueprint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	from := &Blueprint{
+		UUID:               "a-b-c-d",
+		Name:               "lab",
+		ProfileIdentifiers: []string{"com.example.wifi"},
+		ApplyAt:            []string{"Enroll"},
+		Scope:              &Scope{IncludeSerials: []string{"C02ABC"}},
+	}
+	to := &Blueprint{
+		UUID:               "a-b-c-d",
+		Name:               "lab",
+		ProfileIdentifiers: []string{"com.example.wifi", "com.example.vpn"},
+		ApplyAt:            []string{},
+		Scope:              &Scope{IncludeSerials: []string{"C02ABC"}, Models: []string{"MacBookPro15,1"}},
+		Reconcile:          true,
+	}
+
+	changes, err := Diff(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Field: "apply_at", From: []interface{}{"Enroll"}},
+		{Field: "profile_ids", From: []interface{}{"com.example.wifi"}, To: []interface{}{"com.example.wifi", "com.example.vpn"}},
+		{Field: "reconcile", To: true},
+		{Field: "scope.models", To: []interface{}{"MacBookPro15,1"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("have changes\n%+v\nwant\n%+v", changes, want)
+	}
+
+	if changes, _ := Diff(to, to); len(changes) != 0 {
+		t.Errorf("have changes %+v for the same blueprint, want none", changes)
+	}
+}
+
+func TestMarshalRevision(t *testing.T) {
+	rev := &Revision{
+		Revision:  3,
+		Blueprint: Blueprint{UUID: "a-b-c-d", Name: "lab", Reconcile: true},
+	}
+	data, err := MarshalRevision(rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have Revision
+	if err := UnmarshalRevision(data, &have); err != nil {
+		t.Fatal(err)
+	}
+	if have.Revision != 3 || have.Blueprint.Name != "lab" || !have.Blueprint.Reconcile {
+		t.Errorf("have %+v, want %+v", have, rev)
+	}
+}
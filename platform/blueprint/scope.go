@@ -0,0 +1,169 @@
+package blueprint
+
+import (
+	"strings"
+
+	"github.com/micromdm/micromdm/platform/blueprint/internal/blueprintproto"
+)
+
+// Scope restricts the devices a Blueprint is applied to. An empty Scope
+// matches every device.
+//
+// A device is excluded if it matches any of the exclude lists. Otherwise
+// every criterion which is set must match: the device must be in one of
+// the include lists, have one of the DEP profile UUIDs and one of the
+// models, and have all of the enrollment params.
+type Scope struct {
+	IncludeSerials []string `json:"include_serials,omitempty"`
+	IncludeUDIDs   []string `json:"include_udids,omitempty"`
+	IncludeGroups  []string `json:"include_groups,omitempty"`
+
+	ExcludeSerials []string `json:"exclude_serials,omitempty"`
+	ExcludeUDIDs   []string `json:"exclude_udids,omitempty"`
+	ExcludeGroups  []string `json:"exclude_groups,omitempty"`
+
+	DEPProfileUUIDs []string `json:"dep_profile_uuids,omitempty"`
+
+	// Models match the model, model name or product name of the device,
+	// for example "MacBookPro15,1", "MacBook Pro" or "iPad8,1".
+	Models []string `json:"models,omitempty"`
+
+	// EnrollmentParams match the query parameters of the check-in URL
+	// the device enrolled with, as in /mdm/checkin?site=lab.
+	EnrollmentParams map[string]string `json:"enrollment_params,omitempty"`
+}
+
+// Target is the device a Scope is matched against.
+type Target struct {
+	UDID           string
+	SerialNumber   string
+	DEPProfileUUID string
+	Model          string
+	ModelName      string
+	ProductName    string
+	Params         map[string]string
+
+	// Groups are the names of the device groups the device is a member of.
+	Groups []string
+}
+
+// Empty reports whether the scope has no rules.
+func (s *Scope) Empty() bool {
+	return s == nil || (len(s.IncludeSerials) == 0 &&
+		len(s.IncludeUDIDs) == 0 &&
+		len(s.IncludeGroups) == 0 &&
+		len(s.ExcludeSerials) == 0 &&
+		len(s.ExcludeUDIDs) == 0 &&
+		len(s.ExcludeGroups) == 0 &&
+		len(s.DEPProfileUUIDs) == 0 &&
+		len(s.Models) == 0 &&
+		len(s.EnrollmentParams) == 0)
+}
+
+// UsesGroups reports whether the scope has group rules, which require the
+// group membership of the Target.
+func (s *Scope) UsesGroups() bool {
+	return s != nil && (len(s.IncludeGroups) > 0 || len(s.ExcludeGroups) > 0)
+}
+
+// Match reports whether the blueprint should be applied to the device.
+func (s *Scope) Match(t Target) bool {
+	if s.Empty() {
+		return true
+	}
+	if contains(s.ExcludeSerials, t.SerialNumber) ||
+		contains(s.ExcludeUDIDs, t.UDID) ||
+		containsAny(s.ExcludeGroups, t.Groups) {
+		return false
+	}
+
+	hasIncludes := len(s.IncludeSerials) > 0 || len(s.IncludeUDIDs) > 0 || len(s.IncludeGroups) > 0
+	if hasIncludes &&
+		!contains(s.IncludeSerials, t.SerialNumber) &&
+		!contains(s.IncludeUDIDs, t.UDID) &&
+		!containsAny(s.IncludeGroups, t.Groups) {
+		return false
+	}
+	if len(s.DEPProfileUUIDs) > 0 && !contains(s.DEPProfileUUIDs, t.DEPProfileUUID) {
+		return false
+	}
+	if len(s.Models) > 0 &&
+		!containsFold(s.Models, t.Model) &&
+		!containsFold(s.Models, t.ModelName) &&
+		!containsFold(s.Models, t.ProductName) {
+		return false
+	}
+	for k, v := range s.EnrollmentParams {
+		if have, ok := t.Params[k]; !ok || have != v {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		if contains(list, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeToProto(s *Scope) *blueprintproto.Scope {
+	if s.Empty() {
+		return nil
+	}
+	return &blueprintproto.Scope{
+		IncludeSerials:   s.IncludeSerials,
+		IncludeUdids:     s.IncludeUDIDs,
+		IncludeGroups:    s.IncludeGroups,
+		ExcludeSerials:   s.ExcludeSerials,
+		ExcludeUdids:     s.ExcludeUDIDs,
+		ExcludeGroups:    s.ExcludeGroups,
+		DepProfileUuids:  s.DEPProfileUUIDs,
+		Models:           s.Models,
+		EnrollmentParams: s.EnrollmentParams,
+	}
+}
+
+func scopeFromProto(pb *blueprintproto.Scope) *Scope {
+	if pb == nil {
+		return nil
+	}
+	return &Scope{
+		IncludeSerials:   pb.GetIncludeSerials(),
+		IncludeUDIDs:     pb.GetIncludeUdids(),
+		IncludeGroups:    pb.GetIncludeGroups(),
+		ExcludeSerials:   pb.GetExcludeSerials(),
+		ExcludeUDIDs:     pb.GetExcludeUdids(),
+		ExcludeGroups:    pb.GetExcludeGroups(),
+		DEPProfileUUIDs:  pb.GetDepProfileUuids(),
+		Models:           pb.GetModels(),
+		EnrollmentParams: pb.GetEnrollmentParams(),
+	}
+}
@@ -0,0 +1,76 @@
+package blueprint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopeMatch(t *testing.T) {
+	target := Target{
+		UDID:           "UDID-1",
+		SerialNumber:   "C02LAB001",
+		DEPProfileUUID: "DEP-LAB",
+		ModelName:      "MacBook Pro",
+		ProductName:    "MacBookPro15,1",
+		Params:         map[string]string{"site": "lab"},
+		Groups:         []string{"lab"},
+	}
+
+	var tests = []struct {
+		name  string
+		scope *Scope
+		want  bool
+	}{
+		{"nil scope", nil, true},
+		{"empty scope", &Scope{}, true},
+		{"include serial", &Scope{IncludeSerials: []string{"C02LAB001"}}, true},
+		{"include other serial", &Scope{IncludeSerials: []string{"C02STAFF"}}, false},
+		{"include by udid", &Scope{IncludeSerials: []string{"C02STAFF"}, IncludeUDIDs: []string{"UDID-1"}}, true},
+		{"include group", &Scope{IncludeGroups: []string{"staff", "lab"}}, true},
+		{"exclude serial", &Scope{ExcludeSerials: []string{"C02LAB001"}}, false},
+		{"exclude wins", &Scope{IncludeGroups: []string{"lab"}, ExcludeUDIDs: []string{"UDID-1"}}, false},
+		{"exclude group", &Scope{ExcludeGroups: []string{"lab"}}, false},
+		{"dep profile", &Scope{DEPProfileUUIDs: []string{"DEP-LAB"}}, true},
+		{"other dep profile", &Scope{DEPProfileUUIDs: []string{"DEP-STAFF"}}, false},
+		{"model name", &Scope{Models: []string{"macbook pro"}}, true},
+		{"product name", &Scope{Models: []string{"MacBookPro15,1"}}, true},
+		{"other model", &Scope{Models: []string{"iMac"}}, false},
+		{"params", &Scope{EnrollmentParams: map[string]string{"site": "lab"}}, true},
+		{"other params", &Scope{EnrollmentParams: map[string]string{"site": "staff"}}, false},
+		{"missing param", &Scope{EnrollmentParams: map[string]string{"building": "1"}}, false},
+		{"all criteria", &Scope{IncludeGroups: []string{"lab"}, Models: []string{"MacBook Pro"}, DEPProfileUUIDs: []string{"DEP-LAB"}}, true},
+		{"one criterion fails", &Scope{IncludeGroups: []string{"lab"}, Models: []string{"iMac"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if have := tt.scope.Match(target); have != tt.want {
+				t.Errorf("have %v, want %v", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalScope(t *testing.T) {
+	bp := &Blueprint{
+		UUID: "a-b-c-d",
+		Name: "lab",
+		Scope: &Scope{
+			IncludeGroups:    []string{"lab"},
+			ExcludeSerials:   []string{"C02STAFF"},
+			Models:           []string{"MacBook Pro"},
+			EnrollmentParams: map[string]string{"site": "lab"},
+		},
+	}
+	data, err := MarshalBlueprint(bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have Blueprint
+	if err := UnmarshalBlueprint(data, &have); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(have.Scope, bp.Scope) {
+		t.Errorf("have scope %+v, want %+v", have.Scope, bp.Scope)
+	}
+}
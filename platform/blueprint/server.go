@@ -11,6 +11,12 @@ type Endpoints struct {
 	ApplyBlueprintEndpoint   endpoint.Endpoint
 	GetBlueprintsEndpoint    endpoint.Endpoint
 	RemoveBlueprintsEndpoint endpoint.Endpoint
+	ApplyToDevicesEndpoint   endpoint.Endpoint
+	GetDriftEndpoint         endpoint.Endpoint
+	GetReleasesEndpoint      endpoint.Endpoint
+	ReleaseDevicesEndpoint   endpoint.Endpoint
+	GetRevisionsEndpoint     endpoint.Endpoint
+	RollbackEndpoint         endpoint.Endpoint
 }
 
 func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoint.Middleware) Endpoints {
@@ -18,6 +24,12 @@ func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoin
 		GetBlueprintsEndpoint:    endpoint.Chain(outer, others...)(MakeGetBlueprintsEndpoint(s)),
 		ApplyBlueprintEndpoint:   endpoint.Chain(outer, others...)(MakeApplyBlueprintEndpoint(s)),
 		RemoveBlueprintsEndpoint: endpoint.Chain(outer, others...)(MakeRemoveBlueprintsEndpoint(s)),
+		ApplyToDevicesEndpoint:   endpoint.Chain(outer, others...)(MakeApplyToDevicesEndpoint(s)),
+		GetDriftEndpoint:         endpoint.Chain(outer, others...)(MakeGetDriftEndpoint(s)),
+		GetReleasesEndpoint:      endpoint.Chain(outer, others...)(MakeGetReleasesEndpoint(s)),
+		ReleaseDevicesEndpoint:   endpoint.Chain(outer, others...)(MakeReleaseDevicesEndpoint(s)),
+		GetRevisionsEndpoint:     endpoint.Chain(outer, others...)(MakeGetRevisionsEndpoint(s)),
+		RollbackEndpoint:         endpoint.Chain(outer, others...)(MakeRollbackEndpoint(s)),
 	}
 }
 
@@ -25,6 +37,12 @@ func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.S
 	// PUT     /v1/blueprints			create or replace a blueprint on the server
 	// POST    /v1/blueprints			get a list of blueprints managed by the server
 	// DELETE  /v1/blueprints			remove one or more blueprints from the server
+	// POST    /v1/blueprints/{name}/apply	apply a blueprint to enrolled devices
+	// POST    /v1/blueprints/drift		get the drift of devices from reconciled blueprints
+	// POST    /v1/blueprints/releases		get the DeviceConfigured state of enrolling devices
+	// PUT     /v1/blueprints/releases		send DeviceConfigured to pending or held devices
+	// GET     /v1/blueprints/{name}/revisions	get the saved revisions of a blueprint
+	// POST    /v1/blueprints/{name}/rollback	restore an earlier revision of a blueprint
 
 	r.Methods("PUT").Path("/v1/blueprints").Handler(httptransport.NewServer(
 		e.ApplyBlueprintEndpoint,
@@ -46,4 +64,46 @@ func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.S
 		httputil.EncodeJSONResponse,
 		options...,
 	))
+
+	r.Methods("POST").Path("/v1/blueprints/{name}/apply").Handler(httptransport.NewServer(
+		e.ApplyToDevicesEndpoint,
+		decodeApplyToDevicesRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/blueprints/drift").Handler(httptransport.NewServer(
+		e.GetDriftEndpoint,
+		decodeGetDriftRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/blueprints/releases").Handler(httptransport.NewServer(
+		e.GetReleasesEndpoint,
+		decodeGetReleasesRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("PUT").Path("/v1/blueprints/releases").Handler(httptransport.NewServer(
+		e.ReleaseDevicesEndpoint,
+		decodeReleaseDevicesRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("GET").Path("/v1/blueprints/{name}/revisions").Handler(httptransport.NewServer(
+		e.GetRevisionsEndpoint,
+		decodeGetRevisionsRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/blueprints/{name}/rollback").Handler(httptransport.NewServer(
+		e.RollbackEndpoint,
+		decodeRollbackRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
 }
@@ -12,6 +12,12 @@ type Service interface {
 	ApplyBlueprint(ctx context.Context, bp *Blueprint) error
 	GetBlueprints(ctx context.Context, opt GetBlueprintsOption) ([]Blueprint, error)
 	RemoveBlueprints(ctx context.Context, names []string) error
+	ApplyBlueprintToDevices(ctx context.Context, name string, opt ApplyToDevicesOption) (*ApplyToDevicesResult, error)
+	GetDrift(ctx context.Context, opt GetDriftOption) ([]DeviceDrift, error)
+	GetReleases(ctx context.Context, opt GetReleasesOption) ([]DeviceRelease, error)
+	ReleaseDevices(ctx context.Context, udids []string) ([]DeviceRelease, error)
+	GetRevisions(ctx context.Context, name string) ([]Revision, error)
+	RollbackBlueprint(ctx context.Context, name string, revision int) (*Revision, error)
 }
 
 type Store interface {
@@ -19,12 +25,38 @@ type Store interface {
 	BlueprintByName(name string) (*Blueprint, error)
 	List() ([]Blueprint, error)
 	Delete(string) error
+
+	// Save keeps every saved version of a blueprint as a revision.
+	BlueprintRevisions(name string) ([]Revision, error)
+	BlueprintRevision(name string, revision int) (*Revision, error)
+
+	SaveDeviceDrift(*DeviceDrift) error
+	DeviceDrift(udid string) (*DeviceDrift, error)
+	ListDeviceDrift() ([]DeviceDrift, error)
+
+	SaveDeviceRelease(*DeviceRelease) error
+	DeviceRelease(udid string) (*DeviceRelease, error)
+	ListDeviceReleases() ([]DeviceRelease, error)
 }
 
 type BlueprintService struct {
-	store Store
+	store  Store
+	worker *Worker
+}
+
+type Option func(*BlueprintService)
+
+// WithWorker enables applying blueprints to enrolled devices on demand.
+func WithWorker(w *Worker) Option {
+	return func(svc *BlueprintService) {
+		svc.worker = w
+	}
 }
 
-func New(store Store) *BlueprintService {
-	return &BlueprintService{store: store}
+func New(store Store, opts ...Option) *BlueprintService {
+	svc := &BlueprintService{store: store}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
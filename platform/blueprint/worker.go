@@ -2,6 +2,7 @@ package blueprint
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -11,6 +12,7 @@ import (
 	"github.com/micromdm/micromdm/mdm/mdm"
 	"github.com/micromdm/micromdm/platform/command"
 	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/group"
 	"github.com/micromdm/micromdm/platform/profile"
 	"github.com/micromdm/micromdm/platform/pubsub"
 	"github.com/micromdm/micromdm/platform/user"
@@ -28,31 +30,55 @@ type ProfileStore interface {
 	ProfileById(ctx context.Context, id string) (*profile.Profile, error)
 }
 
+type DeviceStore interface {
+	DeviceByUDID(ctx context.Context, udid string) (*device.Device, error)
+	DeviceBySerial(ctx context.Context, serial string) (*device.Device, error)
+	List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error)
+}
+
+type GroupStore interface {
+	List() ([]group.Group, error)
+}
+
 func NewWorker(
 	db BlueprintWorkerStore,
 	userDB UserStore,
 	profileDB ProfileStore,
+	deviceDB DeviceStore,
+	groupDB GroupStore,
 	cmdsvc command.Service,
 	sub pubsub.Subscriber,
 	logger log.Logger,
+	opts ...WorkerOption,
 ) *Worker {
-	return &Worker{
+	w := &Worker{
 		db:        db,
 		userDB:    userDB,
 		profileDB: profileDB,
+		deviceDB:  deviceDB,
+		groupDB:   groupDB,
 		ps:        sub,
 		cmdsvc:    cmdsvc,
 		logger:    logger,
+		now:       time.Now,
 	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
 type Worker struct {
 	db        BlueprintWorkerStore
 	userDB    UserStore
 	profileDB ProfileStore
+	deviceDB  DeviceStore
+	groupDB   GroupStore
 	ps        pubsub.Subscriber
 	cmdsvc    command.Service
 	logger    log.Logger
+	release   *releaseGate
+	now       func() time.Time
 }
 
 func (w *Worker) Run(ctx context.Context) error {
@@ -61,6 +87,21 @@ func (w *Worker) Run(ctx context.Context) error {
 		return errors.Wrapf(err, "subscribing devices to %s topic", device.DeviceEnrolledTopic)
 	}
 
+	// acknowledgements and timeouts are only needed to release devices.
+	var (
+		connectEvents <-chan pubsub.Event
+		releaseChecks <-chan time.Time
+	)
+	if w.release != nil {
+		connectEvents, err = w.ps.Subscribe(ctx, "blueprintRelease", mdmsvc.ConnectTopic)
+		if err != nil {
+			return errors.Wrapf(err, "subscribing to %s topic", mdmsvc.ConnectTopic)
+		}
+		ticker := time.NewTicker(defaultReleaseCheckInterval)
+		defer ticker.Stop()
+		releaseChecks = ticker.C
+	}
+
 	for {
 		var err error
 		select {
@@ -68,6 +109,10 @@ func (w *Worker) Run(ctx context.Context) error {
 			return ctx.Err()
 		case ev := <-tokenUpdateEvents:
 			err = w.handleTokenUpdateEvent(ctx, ev.Message)
+		case ev := <-connectEvents:
+			err = w.handleReleaseAcknowledge(ctx, ev.Message)
+		case <-releaseChecks:
+			err = w.checkReleaseTimeouts(ctx)
 		}
 
 		if err != nil {
@@ -107,6 +152,12 @@ func (w *Worker) handleTokenUpdateEvent(ctx context.Context, message []byte) err
 		return errors.Wrap(err, "get blueprints by ApplyAtEnroll")
 	}
 
+	target, err := w.target(ctx, ev, bps)
+	if err != nil {
+		return err
+	}
+	bps = w.inScope(bps, target)
+
 	// if there are no blueprints exit early. This will ensure that DeviceConfigured is not sent.
 	if len(bps) == 0 {
 		level.Debug(w.logger).Log(
@@ -116,6 +167,7 @@ func (w *Worker) handleTokenUpdateEvent(ctx context.Context, message []byte) err
 		return nil
 	}
 
+	var required []TrackedCommand
 	for _, bp := range bps {
 		level.Debug(w.logger).Log(
 			"msg", "applying blueprint",
@@ -123,31 +175,119 @@ func (w *Worker) handleTokenUpdateEvent(ctx context.Context, message []byte) err
 			"blueprint_name", bp.Name,
 		)
 
-		if err := w.applyToDevice(ctx, bp, ev.Command.UDID); err != nil {
+		sent, err := w.applyToDevice(ctx, bp, ev.Command.UDID)
+		if err != nil {
 			return errors.Wrapf(err, "apply blueprint to udid name=%s, udid=%s", bp.Name, ev.Command.UDID)
 		}
+		for _, c := range sent {
+			if w.release != nil && w.release.required(c.RequestType) {
+				required = append(required, TrackedCommand{
+					CommandUUID: c.CommandUUID,
+					RequestType: c.RequestType,
+					Target:      c.Target,
+					Blueprint:   bp.Name,
+					Status:      CommandPending,
+				})
+			}
+		}
 	}
 
 	if ev.Command.AwaitingConfiguration {
-		level.Debug(w.logger).Log(
-			"msg", "sending DeviceConfigured at the end of blueprint",
-			"device_udid", ev.Command.UDID,
-		)
-		_, err := w.cmdsvc.NewCommand(ctx, &mdm.CommandRequest{
-			Command: &mdm.Command{RequestType: "DeviceConfigured"},
-			UDID:    ev.Command.UDID,
-		})
-		if err != nil {
-			return errors.Wrap(err, "send DeviceConfigured")
-		}
+		return w.configure(ctx, ev.Command.UDID, required)
 	}
 
 	return nil
 
 }
 
-func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) error {
-	var requests []*mdm.CommandRequest
+// target returns the enrolling device to match blueprint scopes against.
+func (w *Worker) target(ctx context.Context, ev mdmsvc.CheckinEvent, bps []Blueprint) (Target, error) {
+	t := Target{UDID: ev.Command.UDID, Params: ev.Params}
+	var scoped, usesGroups bool
+	for _, bp := range bps {
+		scoped = scoped || !bp.Scope.Empty()
+		usesGroups = usesGroups || bp.Scope.UsesGroups()
+	}
+	if !scoped {
+		return t, nil
+	}
+
+	dev, err := w.deviceDB.DeviceByUDID(ctx, ev.Command.UDID)
+	if err != nil {
+		return t, errors.Wrapf(err, "get device %s for blueprint scope", ev.Command.UDID)
+	}
+	var groups []group.Group
+	if usesGroups {
+		if groups, err = w.groupDB.List(); err != nil {
+			return t, errors.Wrap(err, "list groups for blueprint scope")
+		}
+	}
+	target := deviceTarget(*dev, groups)
+	target.Params = ev.Params
+	return target, nil
+}
+
+// deviceTarget returns the Target for a device which is a member of any of
+// the groups.
+func deviceTarget(dev device.Device, groups []group.Group) Target {
+	t := Target{
+		UDID:           dev.UDID,
+		SerialNumber:   dev.SerialNumber,
+		DEPProfileUUID: dev.DEPProfileUUID,
+		Model:          dev.Model,
+		ModelName:      dev.ModelName,
+		ProductName:    dev.ProductName,
+	}
+	for _, g := range groups {
+		if g.HasDevice(t.UDID, t.SerialNumber) {
+			t.Groups = append(t.Groups, g.Name)
+		}
+	}
+	return t
+}
+
+// inScope returns the blueprints whose scope matches the target.
+func (w *Worker) inScope(bps []Blueprint, t Target) []Blueprint {
+	var matched []Blueprint
+	for _, bp := range bps {
+		if !bp.Scope.Match(t) {
+			level.Debug(w.logger).Log(
+				"msg", "device not in blueprint scope",
+				"device_udid", t.UDID,
+				"blueprint_name", bp.Name,
+			)
+			continue
+		}
+		matched = append(matched, bp)
+	}
+	return matched
+}
+
+// applyToDevice queues the commands of the blueprint and returns them.
+func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) ([]Command, error) {
+	var sent []Command
+	for _, c := range w.commands(ctx, bp, udid) {
+		payload, err := w.cmdsvc.NewCommand(ctx, c.request)
+		if err != nil {
+			return sent, errors.Wrap(err, "create new command from blueprint")
+		}
+		c.summary.CommandUUID = payload.CommandUUID
+		sent = append(sent, c.summary)
+	}
+	return sent, nil
+}
+
+// blueprintCommand is a command request created from a blueprint, with a
+// summary of what it does.
+type blueprintCommand struct {
+	request *mdm.CommandRequest
+	summary Command
+}
+
+// commands returns the commands which apply the blueprint to the device.
+// Users and profiles which can't be found are logged and skipped.
+func (w *Worker) commands(ctx context.Context, bp Blueprint, udid string) []blueprintCommand {
+	var requests []blueprintCommand
 	for _, uuid := range bp.UserUUID {
 		level.Debug(w.logger).Log(
 			"msg", "creating mdm command request from blueprint",
@@ -169,7 +309,10 @@ func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) e
 			continue
 		}
 
-		requests = append(requests, &mdm.CommandRequest{
+		requests = append(requests, blueprintCommand{summary: Command{
+			RequestType: "AccountConfiguration",
+			Target:      usr.UserShortname,
+		}, request: &mdm.CommandRequest{
 			UDID: udid,
 			Command: &mdm.Command{
 				RequestType: "AccountConfiguration",
@@ -186,7 +329,7 @@ func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) e
 					},
 				},
 			},
-		})
+		}})
 	}
 
 	for _, appURL := range bp.ApplicationURLs {
@@ -198,7 +341,11 @@ func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) e
 			"device_udid", udid,
 		)
 
-		requests = append(requests, &mdm.CommandRequest{
+		appURL := appURL
+		requests = append(requests, blueprintCommand{summary: Command{
+			RequestType: "InstallApplication",
+			Target:      appURL,
+		}, request: &mdm.CommandRequest{
 			UDID: udid,
 			Command: &mdm.Command{
 				RequestType: "InstallApplication",
@@ -207,7 +354,7 @@ func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) e
 					ManagementFlags: intPtr(1),
 				},
 			},
-		})
+		}})
 	}
 
 	for _, pid := range bp.ProfileIdentifiers {
@@ -230,7 +377,10 @@ func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) e
 			continue
 		}
 
-		requests = append(requests, &mdm.CommandRequest{
+		requests = append(requests, blueprintCommand{summary: Command{
+			RequestType: "InstallProfile",
+			Target:      pid,
+		}, request: &mdm.CommandRequest{
 			UDID: udid,
 			Command: &mdm.Command{
 				RequestType: "InstallProfile",
@@ -238,15 +388,22 @@ func (w *Worker) applyToDevice(ctx context.Context, bp Blueprint, udid string) e
 					Payload: foundProfile.Mobileconfig,
 				},
 			},
-		})
+		}})
 	}
 
-	for _, r := range requests {
-		if _, err := w.cmdsvc.NewCommand(ctx, r); err != nil {
-			return errors.Wrap(err, "create new command from blueprint")
-		}
+	for _, c := range bp.Commands {
+		level.Debug(w.logger).Log(
+			"msg", "creating mdm command request from blueprint",
+			"request_type", c.RequestType,
+			"blueprint_name", bp.Name,
+			"device_udid", udid,
+		)
+		requests = append(requests, blueprintCommand{
+			summary: Command{RequestType: c.RequestType},
+			request: &mdm.CommandRequest{UDID: udid, Command: c},
+		})
 	}
-	return nil
+	return requests
 }
 
 func intPtr(i int) *int {
@@ -0,0 +1,276 @@
+package blueprint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/group"
+)
+
+type mockBlueprints []Blueprint
+
+func (m mockBlueprints) BlueprintsByApplyAt(ctx context.Context, action string) ([]Blueprint, error) {
+	return m, nil
+}
+
+type notFound string
+
+func (e notFound) Error() string  { return fmt.Sprintf("device %s not found", string(e)) }
+func (e notFound) NotFound() bool { return true }
+
+type mockDevices map[string]*device.Device
+
+func (m mockDevices) DeviceByUDID(ctx context.Context, udid string) (*device.Device, error) {
+	dev, ok := m[udid]
+	if !ok {
+		return nil, notFound(udid)
+	}
+	return dev, nil
+}
+
+func (m mockDevices) DeviceBySerial(ctx context.Context, serial string) (*device.Device, error) {
+	for _, dev := range m {
+		if dev.SerialNumber == serial {
+			return dev, nil
+		}
+	}
+	return nil, notFound(serial)
+}
+
+func (m mockDevices) List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error) {
+	var devices []device.Device
+	for _, dev := range m {
+		if opt.Match(*dev) {
+			devices = append(devices, *dev)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].UDID < devices[j].UDID })
+	return devices, nil
+}
+
+type mockGroups []group.Group
+
+func (m mockGroups) List() ([]group.Group, error) { return m, nil }
+
+type mockCommands struct {
+	requests []*mdm.CommandRequest
+	payloads []*mdm.CommandPayload
+}
+
+func (m *mockCommands) NewCommand(ctx context.Context, req *mdm.CommandRequest) (*mdm.CommandPayload, error) {
+	payload, err := mdm.NewCommandPayload(req)
+	if err != nil {
+		return nil, err
+	}
+	m.requests = append(m.requests, req)
+	m.payloads = append(m.payloads, payload)
+	return payload, nil
+}
+
+func TestScopedBlueprints(t *testing.T) {
+	bps := mockBlueprints{
+		{Name: "everyone", ApplicationURLs: []string{"https://example.com/everyone.plist"}},
+		{
+			Name:            "lab",
+			ApplicationURLs: []string{"https://example.com/lab.plist"},
+			Scope:           &Scope{IncludeGroups: []string{"lab"}},
+		},
+		{
+			Name:            "staff",
+			ApplicationURLs: []string{"https://example.com/staff.plist"},
+			Scope:           &Scope{EnrollmentParams: map[string]string{"site": "staff"}},
+		},
+	}
+	devices := mockDevices{
+		"UDID-LAB":   {UDID: "UDID-LAB", SerialNumber: "C02LAB"},
+		"UDID-STAFF": {UDID: "UDID-STAFF", SerialNumber: "C02STAFF"},
+	}
+	groups := mockGroups{{Name: "lab", Serials: []string{"C02LAB"}}}
+
+	var tests = []struct {
+		udid   string
+		params map[string]string
+		want   []string
+	}{
+		{"UDID-LAB", nil, []string{"everyone", "lab"}},
+		{"UDID-STAFF", map[string]string{"site": "staff"}, []string{"everyone", "staff"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.udid, func(t *testing.T) {
+			cmds := new(mockCommands)
+			w := NewWorker(bps, nil, nil, devices, groups, cmds, nil, log.NewNopLogger())
+
+			ev := mdmsvc.CheckinEvent{
+				Command: mdmsvc.CheckinCommand{MessageType: "TokenUpdate", UDID: tt.udid},
+				Params:  tt.params,
+			}
+			msg, err := mdmsvc.MarshalCheckinEvent(&ev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.handleTokenUpdateEvent(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+
+			var have []string
+			for _, r := range cmds.requests {
+				url := *r.Command.InstallApplication.ManifestURL
+				have = append(have, url[len("https://example.com/"):len(url)-len(".plist")])
+			}
+			sort.Strings(have)
+			if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+				t.Errorf("have blueprints %v, want %v", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyToDevices(t *testing.T) {
+	bp := Blueprint{
+		Name:            "lab",
+		ApplicationURLs: []string{"https://example.com/a.plist", "https://example.com/b.plist"},
+		Scope:           &Scope{ExcludeSerials: []string{"C02STAFF"}},
+	}
+	devices := mockDevices{
+		"UDID-LAB":   {UDID: "UDID-LAB", SerialNumber: "C02LAB", Enrolled: true},
+		"UDID-STAFF": {UDID: "UDID-STAFF", SerialNumber: "C02STAFF", Enrolled: true},
+		"UDID-OLD":   {UDID: "UDID-OLD", SerialNumber: "C02OLD"},
+	}
+
+	var tests = []struct {
+		name     string
+		opt      ApplyToDevicesOption
+		want     []DeviceApplyResult
+		commands int
+	}{
+		{
+			name:     "all",
+			opt:      ApplyToDevicesOption{All: true},
+			commands: 2,
+			want: []DeviceApplyResult{
+				{UDID: "UDID-LAB", SerialNumber: "C02LAB", Commands: []Command{
+					{RequestType: "InstallApplication", Target: "https://example.com/a.plist"},
+					{RequestType: "InstallApplication", Target: "https://example.com/b.plist"},
+				}},
+				{UDID: "UDID-STAFF", SerialNumber: "C02STAFF", Skipped: SkippedNotInScope},
+			},
+		},
+		{
+			name: "dry run",
+			opt:  ApplyToDevicesOption{Serials: []string{"C02LAB", "C02OLD", "C02NONE"}, DryRun: true},
+			want: []DeviceApplyResult{
+				{SerialNumber: "C02NONE", Skipped: SkippedNotFound},
+				{UDID: "UDID-LAB", SerialNumber: "C02LAB", Commands: []Command{
+					{RequestType: "InstallApplication", Target: "https://example.com/a.plist"},
+					{RequestType: "InstallApplication", Target: "https://example.com/b.plist"},
+				}},
+				{UDID: "UDID-OLD", SerialNumber: "C02OLD", Skipped: SkippedNotEnrolled},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := new(mockCommands)
+			w := NewWorker(nil, nil, nil, devices, nil, cmds, nil, log.NewNopLogger())
+			result, err := w.ApplyToDevices(context.Background(), bp, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cmds.requests) != tt.commands {
+				t.Fatalf("have %d commands queued, want %d", len(cmds.requests), tt.commands)
+			}
+			for i, r := range cmds.requests {
+				if have, want := *r.Command.InstallApplication.ManifestURL, bp.ApplicationURLs[i]; have != want {
+					t.Errorf("have manifest URL %s, want %s", have, want)
+				}
+			}
+			for i := range result.Devices {
+				for j := range result.Devices[i].Commands {
+					c := &result.Devices[i].Commands[j]
+					if tt.opt.DryRun != (c.CommandUUID == "") {
+						t.Errorf("have command UUID %q with dry run %v", c.CommandUUID, tt.opt.DryRun)
+					}
+					c.CommandUUID = ""
+				}
+			}
+			if !reflect.DeepEqual(result.Devices, tt.want) {
+				t.Errorf("have results\n%+v\nwant\n%+v", result.Devices, tt.want)
+			}
+		})
+	}
+}
+
+// failingCommands fails to queue commands after limit commands.
+type failingCommands struct {
+	mockCommands
+	limit int
+}
+
+func (m *failingCommands) NewCommand(ctx context.Context, req *mdm.CommandRequest) (*mdm.CommandPayload, error) {
+	if len(m.requests) >= m.limit {
+		return nil, errors.New("queue unavailable")
+	}
+	return m.mockCommands.NewCommand(ctx, req)
+}
+
+func TestApplyToDevicesPartial(t *testing.T) {
+	bp := Blueprint{
+		Name:            "lab",
+		ApplicationURLs: []string{"https://example.com/a.plist", "https://example.com/b.plist"},
+	}
+	devices := mockDevices{
+		"UDID-1": {UDID: "UDID-1", SerialNumber: "C021", Enrolled: true},
+		"UDID-2": {UDID: "UDID-2", SerialNumber: "C022", Enrolled: true},
+	}
+	cmds := &failingCommands{limit: 3}
+	w := NewWorker(nil, nil, nil, devices, nil, cmds, nil, log.NewNopLogger())
+	result, err := w.ApplyToDevices(context.Background(), bp, ApplyToDevicesOption{All: true})
+	if err == nil {
+		t.Fatal("want error when a command can't be queued")
+	}
+	if result == nil {
+		t.Fatal("want the partial result with the error")
+	}
+	if len(result.Devices) != 2 {
+		t.Fatalf("have %d devices in the result, want 2", len(result.Devices))
+	}
+	if have := len(result.Devices[0].Commands); have != 2 {
+		t.Errorf("have %d commands for %s, want 2", have, result.Devices[0].UDID)
+	}
+	if have := result.Devices[1].Commands; len(have) != 1 || have[0].CommandUUID != cmds.payloads[2].CommandUUID {
+		t.Errorf("have commands %+v for %s, want the one queued command", have, result.Devices[1].UDID)
+	}
+}
+
+func TestApplyCommandsInOrder(t *testing.T) {
+	bp := Blueprint{
+		Name:            "first-boot",
+		ApplicationURLs: []string{"https://example.com/app.plist"},
+		Commands: []*mdm.Command{
+			{RequestType: "ScheduleOSUpdateScan", ScheduleOSUpdateScan: &mdm.ScheduleOSUpdateScan{}},
+			{RequestType: "ProfileList"},
+		},
+	}
+	cmds := new(mockCommands)
+	w := NewWorker(nil, nil, nil, nil, nil, cmds, nil, log.NewNopLogger())
+	if _, err := w.applyToDevice(context.Background(), bp, "UDID-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var have []string
+	for _, r := range cmds.requests {
+		have = append(have, r.Command.RequestType)
+	}
+	if want := []string{"InstallApplication", "ScheduleOSUpdateScan", "ProfileList"}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have commands %v, want %v", have, want)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"net/http"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 
 	"github.com/micromdm/micromdm/mdm/mdm"
 	"github.com/micromdm/micromdm/pkg/httputil"
+	"github.com/micromdm/micromdm/platform/profile"
 )
 
 const (
@@ -20,6 +22,11 @@ func (svc *CommandService) NewCommand(ctx context.Context, request *mdm.CommandR
 	if request == nil {
 		return nil, errors.New("empty CommandRequest")
 	}
+	original := request
+	request, err := svc.prepareProfile(ctx, request)
+	if err != nil {
+		return nil, err
+	}
 	payload, err := mdm.NewCommandPayload(request)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating mdm payload")
@@ -29,10 +36,65 @@ func (svc *CommandService) NewCommand(ctx context.Context, request *mdm.CommandR
 	if err != nil {
 		return nil, errors.Wrap(err, "marshalling mdm command event")
 	}
+	// the command is tracked before it is published, so the tracker knows
+	// the command when the device acknowledges it. The tracker is passed
+	// the profile as requested, which it compares with the stored profile.
+	if svc.tracker != nil {
+		requested := &mdm.CommandPayload{CommandUUID: payload.CommandUUID, Command: original.Command}
+		if err := svc.tracker.CommandQueued(ctx, request.UDID, requested); err != nil {
+			level.Info(svc.logger).Log(
+				"msg", "track queued profile command",
+				"device_udid", request.UDID,
+				"command_uuid", payload.CommandUUID,
+				"err", err,
+			)
+		}
+	}
 	if err := svc.publisher.Publish(context.TODO(), CommandTopic, msg); err != nil {
 		return nil, errors.Wrapf(err, "publish mdm command on topic: %s", CommandTopic)
 	}
-	return payload, nil
+	return redactSecrets(original, payload), nil
+}
+
+// redactSecrets returns a copy of the payload of an InstallProfile command
+// with the profile as requested, if the profile references secrets. The
+// profile rendered with the values of the secrets is only sent to the
+// device.
+func redactSecrets(request *mdm.CommandRequest, payload *mdm.CommandPayload) *mdm.CommandPayload {
+	if request.Command == nil || request.InstallProfile == nil || payload.Command == nil ||
+		!profile.Mobileconfig(request.InstallProfile.Payload).HasSecrets() {
+		return payload
+	}
+	cmd := *payload.Command
+	cmd.InstallProfile = &mdm.InstallProfile{Payload: request.InstallProfile.Payload}
+	return &mdm.CommandPayload{CommandUUID: payload.CommandUUID, Command: &cmd}
+}
+
+// prepareProfile returns a copy of an InstallProfile request with the
+// profile rendered for the device if it is a template, and signed if the
+// signer signs InstallProfile commands.
+func (svc *CommandService) prepareProfile(ctx context.Context, request *mdm.CommandRequest) (*mdm.CommandRequest, error) {
+	if request.Command == nil || request.InstallProfile == nil {
+		return request, nil
+	}
+	payload := request.InstallProfile.Payload
+	if svc.renderer != nil {
+		rendered, err := svc.renderer.Render(ctx, request.UDID, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = rendered
+	}
+	if svc.signer != nil && svc.signer.SignInstallProfile() && !profile.Mobileconfig(payload).IsSigned() {
+		signed, err := svc.signer.Sign(payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "sign InstallProfile payload")
+		}
+		payload = signed
+	}
+	cmd := *request.Command
+	cmd.InstallProfile = &mdm.InstallProfile{Payload: payload}
+	return &mdm.CommandRequest{UDID: request.UDID, Command: &cmd}, nil
 }
 
 type newCommandRequest struct {
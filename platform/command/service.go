@@ -2,7 +2,10 @@
 package command
 
 import (
+	"github.com/go-kit/kit/log"
+
 	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/profile"
 	"github.com/micromdm/micromdm/platform/pubsub"
 	"golang.org/x/net/context"
 )
@@ -13,11 +16,59 @@ type Service interface {
 
 type CommandService struct {
 	publisher pubsub.Publisher
+	renderer  *profile.Renderer
+	signer    ProfileSigner
+	tracker   *profile.Tracker
+	logger    log.Logger
+}
+
+// ProfileSigner signs the profiles of InstallProfile commands when
+// SignInstallProfile is true.
+type ProfileSigner interface {
+	profile.Signer
+	SignInstallProfile() bool
 }
 
-func New(pub pubsub.Publisher) (*CommandService, error) {
+type Option func(*CommandService)
+
+// WithProfileRenderer renders the templated profiles of InstallProfile
+// commands for the device each command is sent to.
+func WithProfileRenderer(r *profile.Renderer) Option {
+	return func(svc *CommandService) {
+		svc.renderer = r
+	}
+}
+
+// WithProfileSigner signs the unsigned profiles of InstallProfile commands.
+func WithProfileSigner(s ProfileSigner) Option {
+	return func(svc *CommandService) {
+		svc.signer = s
+	}
+}
+
+// WithProfileTracker records the profiles each InstallProfile and
+// RemoveProfile command installs on or removes from a device.
+func WithProfileTracker(t *profile.Tracker) Option {
+	return func(svc *CommandService) {
+		svc.tracker = t
+	}
+}
+
+// WithLogger logs the errors of the profile tracker, which don't fail the
+// commands.
+func WithLogger(logger log.Logger) Option {
+	return func(svc *CommandService) {
+		svc.logger = logger
+	}
+}
+
+func New(pub pubsub.Publisher, opts ...Option) (*CommandService, error) {
 	svc := CommandService{
 		publisher: pub,
+		logger:    log.NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(&svc)
 	}
 	return &svc, nil
 }
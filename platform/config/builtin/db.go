@@ -17,6 +17,8 @@ import (
 
 const (
 	ConfigBucket = "mdm.ServerConfig"
+
+	signingIdentityKey = "profile_signing_identity"
 )
 
 // DB stores server configuration in BoltDB
@@ -64,6 +66,35 @@ func (db *DB) SavePushCertificate(cert, key []byte) error {
 	return err
 }
 
+// SaveProfileSigningIdentity stores the profile signing identity under its
+// own key in the config bucket, so it is kept when the push certificate is
+// replaced.
+func (db *DB) SaveProfileSigningIdentity(id *config.ProfileSigningIdentity) error {
+	data, err := config.MarshalProfileSigningIdentity(id)
+	if err != nil {
+		return err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(ConfigBucket)).Put([]byte(signingIdentityKey), data)
+	})
+	return errors.Wrap(err, "save profile signing identity in bucket")
+}
+
+func (db *DB) ProfileSigningIdentity() (*config.ProfileSigningIdentity, error) {
+	var id config.ProfileSigningIdentity
+	err := db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte(ConfigBucket)).Get([]byte(signingIdentityKey))
+		if data == nil {
+			return &notFound{"ProfileSigningIdentity", "no profile signing identity found in boltdb"}
+		}
+		return config.UnmarshalProfileSigningIdentity(data, &id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
 func (db *DB) serverConfig() (*config.ServerConfig, error) {
 	var conf config.ServerConfig
 	err := db.View(func(tx *bolt.Tx) error {
@@ -141,3 +172,7 @@ type notFound struct {
 func (e *notFound) Error() string {
 	return fmt.Sprintf("not found: %s %s", e.ResourceType, e.Message)
 }
+
+func (e *notFound) NotFound() bool {
+	return true
+}
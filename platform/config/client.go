@@ -48,9 +48,33 @@ func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransp
 		).Endpoint()
 	}
 
+	var saveSigningIdentityEndpoint endpoint.Endpoint
+	{
+		saveSigningIdentityEndpoint = httptransport.NewClient(
+			"PUT",
+			httputil.CopyURL(u, "/v1/config/signing-identity"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeSaveSigningIdentityResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var getSigningIdentityEndpoint endpoint.Endpoint
+	{
+		getSigningIdentityEndpoint = httptransport.NewClient(
+			"GET",
+			httputil.CopyURL(u, "/v1/config/signing-identity"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeGetSigningIdentityResponse,
+			opts...,
+		).Endpoint()
+	}
+
 	return Endpoints{
 		SavePushCertificateEndpoint: saveEndpoint,
 		ApplyDEPTokensEndpoint:      applyDEPTokensEndpoint,
 		GetDEPTokensEndpoint:        getDEPTokensEndpoint,
+		SaveSigningIdentityEndpoint: saveSigningIdentityEndpoint,
+		GetSigningIdentityEndpoint:  getSigningIdentityEndpoint,
 	}, nil
 }
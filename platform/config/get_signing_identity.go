@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+// GetProfileSigningIdentity returns the certificates of the signing
+// identity, without the private key.
+func (svc *ConfigService) GetProfileSigningIdentity(ctx context.Context) (*ProfileSigningIdentity, error) {
+	id, err := svc.store.ProfileSigningIdentity()
+	if err != nil {
+		return nil, errors.Wrap(err, "get profile signing identity")
+	}
+	id.PrivateKey = nil
+	return id, nil
+}
+
+type getSigningIdentityResponse struct {
+	Identity *ProfileSigningIdentity `json:"identity,omitempty"`
+	Err      error                   `json:"err,omitempty"`
+}
+
+func (r getSigningIdentityResponse) Failed() error { return r.Err }
+
+func decodeGetSigningIdentityRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeGetSigningIdentityResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp getSigningIdentityResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeGetSigningIdentityEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		id, err := svc.GetProfileSigningIdentity(ctx)
+		return getSigningIdentityResponse{
+			Identity: id,
+			Err:      err,
+		}, nil
+	}
+}
+
+func (e Endpoints) GetProfileSigningIdentity(ctx context.Context) (*ProfileSigningIdentity, error) {
+	response, err := e.GetSigningIdentityEndpoint(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(getSigningIdentityResponse)
+	return resp.Identity, resp.Err
+}
@@ -1,15 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // source: config.proto
 
-/*
-Package configproto is a generated protocol buffer package.
-
-It is generated from these files:
-	config.proto
-
-It has these top-level messages:
-	ServerConfig
-*/
 package configproto
 
 import proto "github.com/golang/protobuf/proto"
@@ -28,14 +19,36 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type ServerConfig struct {
-	PushCertificate    []byte `protobuf:"bytes,1,opt,name=push_certificate,json=pushCertificate,proto3" json:"push_certificate,omitempty"`
-	PushCertificateKey []byte `protobuf:"bytes,2,opt,name=push_certificate_key,json=pushCertificateKey,proto3" json:"push_certificate_key,omitempty"`
+	PushCertificate      []byte   `protobuf:"bytes,1,opt,name=push_certificate,json=pushCertificate,proto3" json:"push_certificate,omitempty"`
+	PushCertificateKey   []byte   `protobuf:"bytes,2,opt,name=push_certificate_key,json=pushCertificateKey,proto3" json:"push_certificate_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ServerConfig) Reset()         { *m = ServerConfig{} }
+func (m *ServerConfig) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig) ProtoMessage()    {}
+func (*ServerConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_e09981728bb0e8aa, []int{0}
+}
+func (m *ServerConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServerConfig.Unmarshal(m, b)
+}
+func (m *ServerConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServerConfig.Marshal(b, m, deterministic)
+}
+func (dst *ServerConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServerConfig.Merge(dst, src)
+}
+func (m *ServerConfig) XXX_Size() int {
+	return xxx_messageInfo_ServerConfig.Size(m)
+}
+func (m *ServerConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServerConfig.DiscardUnknown(m)
 }
 
-func (m *ServerConfig) Reset()                    { *m = ServerConfig{} }
-func (m *ServerConfig) String() string            { return proto.CompactTextString(m) }
-func (*ServerConfig) ProtoMessage()               {}
-func (*ServerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+var xxx_messageInfo_ServerConfig proto.InternalMessageInfo
 
 func (m *ServerConfig) GetPushCertificate() []byte {
 	if m != nil {
@@ -51,20 +64,80 @@ func (m *ServerConfig) GetPushCertificateKey() []byte {
 	return nil
 }
 
+type ProfileSigningIdentity struct {
+	Certificates         []byte   `protobuf:"bytes,1,opt,name=certificates,proto3" json:"certificates,omitempty"`
+	PrivateKey           []byte   `protobuf:"bytes,2,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"`
+	SignInstallProfile   bool     `protobuf:"varint,3,opt,name=sign_install_profile,json=signInstallProfile,proto3" json:"sign_install_profile,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProfileSigningIdentity) Reset()         { *m = ProfileSigningIdentity{} }
+func (m *ProfileSigningIdentity) String() string { return proto.CompactTextString(m) }
+func (*ProfileSigningIdentity) ProtoMessage()    {}
+func (*ProfileSigningIdentity) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_e09981728bb0e8aa, []int{1}
+}
+func (m *ProfileSigningIdentity) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProfileSigningIdentity.Unmarshal(m, b)
+}
+func (m *ProfileSigningIdentity) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProfileSigningIdentity.Marshal(b, m, deterministic)
+}
+func (dst *ProfileSigningIdentity) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProfileSigningIdentity.Merge(dst, src)
+}
+func (m *ProfileSigningIdentity) XXX_Size() int {
+	return xxx_messageInfo_ProfileSigningIdentity.Size(m)
+}
+func (m *ProfileSigningIdentity) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProfileSigningIdentity.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProfileSigningIdentity proto.InternalMessageInfo
+
+func (m *ProfileSigningIdentity) GetCertificates() []byte {
+	if m != nil {
+		return m.Certificates
+	}
+	return nil
+}
+
+func (m *ProfileSigningIdentity) GetPrivateKey() []byte {
+	if m != nil {
+		return m.PrivateKey
+	}
+	return nil
+}
+
+func (m *ProfileSigningIdentity) GetSignInstallProfile() bool {
+	if m != nil {
+		return m.SignInstallProfile
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*ServerConfig)(nil), "configproto.ServerConfig")
+	proto.RegisterType((*ProfileSigningIdentity)(nil), "configproto.ProfileSigningIdentity")
 }
 
-func init() { proto.RegisterFile("config.proto", fileDescriptor0) }
+func init() { proto.RegisterFile("config.proto", fileDescriptor_config_e09981728bb0e8aa) }
 
-var fileDescriptor0 = []byte{
-	// 115 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x49, 0xce, 0xcf, 0x4b,
-	0xcb, 0x4c, 0xd7, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x86, 0xf0, 0xc0, 0x1c, 0xa5, 0x6c,
-	0x2e, 0x9e, 0xe0, 0xd4, 0xa2, 0xb2, 0xd4, 0x22, 0x67, 0xb0, 0xa0, 0x90, 0x26, 0x97, 0x40, 0x41,
-	0x69, 0x71, 0x46, 0x7c, 0x72, 0x6a, 0x51, 0x49, 0x66, 0x5a, 0x66, 0x72, 0x62, 0x49, 0xaa, 0x04,
-	0xa3, 0x02, 0xa3, 0x06, 0x4f, 0x10, 0x3f, 0x48, 0xdc, 0x19, 0x21, 0x2c, 0x64, 0xc0, 0x25, 0x82,
-	0xae, 0x34, 0x3e, 0x3b, 0xb5, 0x52, 0x82, 0x09, 0xac, 0x5c, 0x08, 0x4d, 0xb9, 0x77, 0x6a, 0x65,
-	0x12, 0x1b, 0xd8, 0x4e, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0xea, 0xa7, 0xb0, 0xbc, 0x90,
-	0x00, 0x00, 0x00,
+var fileDescriptor_config_e09981728bb0e8aa = []byte{
+	// 199 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0xc1, 0x4a, 0xc6, 0x30,
+	0x10, 0x84, 0x89, 0x82, 0xc8, 0xfe, 0x01, 0x25, 0x88, 0xf4, 0x66, 0xe9, 0xa9, 0x5e, 0x44, 0xf0,
+	0x11, 0x7a, 0x2a, 0xbd, 0x48, 0xfb, 0x00, 0xa1, 0xd6, 0x6d, 0x5c, 0x5a, 0x92, 0x90, 0xc4, 0x42,
+	0x9e, 0xc2, 0x57, 0x96, 0xa6, 0x05, 0x6d, 0x8f, 0xf3, 0xcd, 0xb0, 0x33, 0x2c, 0xf0, 0xc1, 0xe8,
+	0x91, 0xd4, 0x8b, 0x75, 0x26, 0x18, 0x71, 0xd9, 0x54, 0x12, 0xc5, 0x04, 0xbc, 0x43, 0xb7, 0xa0,
+	0xab, 0x12, 0x14, 0xcf, 0x70, 0x6f, 0xbf, 0xfd, 0x97, 0x1c, 0xd0, 0x05, 0x1a, 0x69, 0xe8, 0x03,
+	0x66, 0x2c, 0x67, 0x25, 0x6f, 0xef, 0x56, 0x5e, 0xfd, 0x61, 0xf1, 0x0a, 0x0f, 0xe7, 0xa8, 0x9c,
+	0x30, 0x66, 0x57, 0x29, 0x2e, 0x4e, 0xf1, 0x06, 0x63, 0xf1, 0xc3, 0xe0, 0xf1, 0xdd, 0x99, 0x91,
+	0x66, 0xec, 0x48, 0x69, 0xd2, 0xaa, 0xfe, 0x44, 0x1d, 0x28, 0x44, 0x51, 0x00, 0xff, 0x77, 0xc7,
+	0xef, 0x9d, 0x07, 0x26, 0x9e, 0xe0, 0x62, 0x1d, 0x2d, 0xc7, 0x1e, 0xd8, 0x51, 0x83, 0x71, 0x5d,
+	0xe4, 0x49, 0x69, 0x49, 0xda, 0x87, 0x7e, 0x9e, 0xa5, 0xdd, 0xba, 0xb2, 0xeb, 0x9c, 0x95, 0xb7,
+	0xad, 0x58, 0xbd, 0x7a, 0xb3, 0xf6, 0x15, 0x1f, 0x37, 0xe9, 0x0b, 0x6f, 0xbf, 0x03, 0x00, 0xcd,
+	0xea, 0x94, 0x93, 0x22, 0x01, 0x00, 0x00,
 }
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+func (svc *ConfigService) SaveProfileSigningIdentity(ctx context.Context, id *ProfileSigningIdentity) error {
+	if id == nil {
+		return errors.New("empty profile signing identity")
+	}
+	if _, _, err := id.Parse(); err != nil {
+		return err
+	}
+	err := svc.store.SaveProfileSigningIdentity(id)
+	return errors.Wrap(err, "save profile signing identity")
+}
+
+type saveSigningIdentityRequest struct {
+	Identity *ProfileSigningIdentity `json:"identity"`
+}
+
+type saveSigningIdentityResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r saveSigningIdentityResponse) Failed() error { return r.Err }
+
+func decodeSaveSigningIdentityRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req saveSigningIdentityRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeSaveSigningIdentityResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp saveSigningIdentityResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeSaveSigningIdentityEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(saveSigningIdentityRequest)
+		err = svc.SaveProfileSigningIdentity(ctx, req.Identity)
+		return saveSigningIdentityResponse{Err: err}, nil
+	}
+}
+
+func (e Endpoints) SaveProfileSigningIdentity(ctx context.Context, id *ProfileSigningIdentity) error {
+	response, err := e.SaveSigningIdentityEndpoint(ctx, saveSigningIdentityRequest{Identity: id})
+	if err != nil {
+		return err
+	}
+	return response.(saveSigningIdentityResponse).Err
+}
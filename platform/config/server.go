@@ -12,6 +12,8 @@ type Endpoints struct {
 	GetPushCertificateEndpoint  endpoint.Endpoint
 	ApplyDEPTokensEndpoint      endpoint.Endpoint
 	GetDEPTokensEndpoint        endpoint.Endpoint
+	SaveSigningIdentityEndpoint endpoint.Endpoint
+	GetSigningIdentityEndpoint  endpoint.Endpoint
 }
 
 func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoint.Middleware) Endpoints {
@@ -20,6 +22,8 @@ func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoin
 		GetPushCertificateEndpoint:  endpoint.Chain(outer, others...)(MakeGetPushCertificateEndpoint(s)),
 		ApplyDEPTokensEndpoint:      endpoint.Chain(outer, others...)(MakeApplyDEPTokensEndpoint(s)),
 		GetDEPTokensEndpoint:        endpoint.Chain(outer, others...)(MakeGetDEPTokensEndpoint(s)),
+		SaveSigningIdentityEndpoint: endpoint.Chain(outer, others...)(MakeSaveSigningIdentityEndpoint(s)),
+		GetSigningIdentityEndpoint:  endpoint.Chain(outer, others...)(MakeGetSigningIdentityEndpoint(s)),
 	}
 }
 
@@ -28,6 +32,8 @@ func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.S
 	// GET     /v1/config/certificate		retrieve the MDM Push Certificate
 	// PUT     /v1/dep-tokens				create or replace a DEP OAuth token
 	// GET     /v1/dep-tokens				get the OAuth Token used for the DEP client
+	// PUT     /v1/config/signing-identity		create or replace the profile signing identity
+	// GET     /v1/config/signing-identity		retrieve the profile signing certificates
 
 	r.Methods("PUT").Path("/v1/config/certificate").Handler(httptransport.NewServer(
 		e.SavePushCertificateEndpoint,
@@ -56,4 +62,18 @@ func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.S
 		httputil.EncodeJSONResponse,
 		options...,
 	))
+
+	r.Methods("PUT").Path("/v1/config/signing-identity").Handler(httptransport.NewServer(
+		e.SaveSigningIdentityEndpoint,
+		decodeSaveSigningIdentityRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("GET").Path("/v1/config/signing-identity").Handler(httptransport.NewServer(
+		e.GetSigningIdentityEndpoint,
+		decodeGetSigningIdentityRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
 }
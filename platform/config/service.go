@@ -12,6 +12,8 @@ type Service interface {
 	GetPushCertificate(ctx context.Context) ([]byte, error)
 	ApplyDEPToken(ctx context.Context, P7MContent []byte) error
 	GetDEPTokens(ctx context.Context) ([]DEPToken, []byte, error)
+	SaveProfileSigningIdentity(ctx context.Context, id *ProfileSigningIdentity) error
+	GetProfileSigningIdentity(ctx context.Context) (*ProfileSigningIdentity, error)
 }
 
 type Store interface {
@@ -22,6 +24,8 @@ type Store interface {
 	DEPKeypair() (key *rsa.PrivateKey, cert *x509.Certificate, err error)
 	AddToken(consumerKey string, json []byte) error
 	DEPTokens() ([]DEPToken, error)
+	SaveProfileSigningIdentity(id *ProfileSigningIdentity) error
+	ProfileSigningIdentity() (*ProfileSigningIdentity, error)
 }
 
 type ConfigService struct {
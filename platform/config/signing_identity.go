@@ -0,0 +1,163 @@
+package config
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/crypto/profileutil"
+	"github.com/micromdm/micromdm/platform/config/internal/configproto"
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+// ErrNoProfileSigningIdentity is returned when profiles are signed before
+// a signing identity is uploaded.
+var ErrNoProfileSigningIdentity = errors.New("no profile signing identity uploaded to the server")
+
+// ProfileSigningIdentity is the private key and certificate chain the server
+// signs profiles with. The enrollment and OTA profiles are always signed.
+type ProfileSigningIdentity struct {
+	// Certificates is the PEM encoded certificate chain, starting with
+	// the signing certificate.
+	Certificates []byte `json:"certificates"`
+
+	// PrivateKey is the PEM encoded RSA private key. It is never returned
+	// by the API.
+	PrivateKey []byte `json:"private_key,omitempty"`
+
+	// SignInstallProfile also signs the unsigned profiles of every
+	// InstallProfile command.
+	SignInstallProfile bool `json:"sign_install_profile"`
+}
+
+// Parse returns the private key and the certificate chain of the identity.
+func (id *ProfileSigningIdentity) Parse() (*rsa.PrivateKey, []*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	rest := id.Certificates
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "parse profile signing certificate")
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, nil, errors.New("no PEM certificates in profile signing identity")
+	}
+
+	keyBlock, _ := pem.Decode(id.PrivateKey)
+	if keyBlock == nil {
+		return nil, nil, errors.New("decode profile signing key PEM")
+	}
+	var key *rsa.PrivateKey
+	switch keyBlock.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "parse profile signing key")
+		}
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, errors.New("profile signing key must be an RSA key")
+		}
+		key = rsaKey
+	default:
+		rsaKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "parse profile signing key")
+		}
+		key = rsaKey
+	}
+
+	pub, ok := chain[0].PublicKey.(*rsa.PublicKey)
+	if !ok || pub.N.Cmp(key.N) != 0 || pub.E != key.E {
+		return nil, nil, errors.New("profile signing key does not match the first certificate")
+	}
+	return key, chain, nil
+}
+
+func MarshalProfileSigningIdentity(id *ProfileSigningIdentity) ([]byte, error) {
+	pb := configproto.ProfileSigningIdentity{
+		Certificates:       id.Certificates,
+		PrivateKey:         id.PrivateKey,
+		SignInstallProfile: id.SignInstallProfile,
+	}
+	data, err := proto.Marshal(&pb)
+	return data, errors.Wrap(err, "marshal profile signing identity to proto")
+}
+
+func UnmarshalProfileSigningIdentity(data []byte, id *ProfileSigningIdentity) error {
+	var pb configproto.ProfileSigningIdentity
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return errors.Wrap(err, "unmarshal profile signing identity from proto")
+	}
+	id.Certificates = pb.GetCertificates()
+	id.PrivateKey = pb.GetPrivateKey()
+	id.SignInstallProfile = pb.GetSignInstallProfile()
+	return nil
+}
+
+type ProfileSigningIdentityStore interface {
+	ProfileSigningIdentity() (*ProfileSigningIdentity, error)
+}
+
+// ProfileSigner signs profiles with the signing identity in the config
+// store. The identity is read for every profile, so an uploaded identity
+// is used without restarting the server.
+type ProfileSigner struct {
+	store ProfileSigningIdentityStore
+}
+
+func NewProfileSigner(store ProfileSigningIdentityStore) *ProfileSigner {
+	return &ProfileSigner{store: store}
+}
+
+func (s *ProfileSigner) identity() (*ProfileSigningIdentity, error) {
+	id, err := s.store.ProfileSigningIdentity()
+	if isNotFound(err) {
+		return nil, ErrNoProfileSigningIdentity
+	}
+	return id, err
+}
+
+// Sign signs the mobileconfig. It returns ErrNoProfileSigningIdentity if
+// no identity was uploaded.
+func (s *ProfileSigner) Sign(mc profile.Mobileconfig) (profile.Mobileconfig, error) {
+	id, err := s.identity()
+	if err != nil {
+		return nil, err
+	}
+	key, chain, err := id.Parse()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := profileutil.Sign(key, chain[0], mc, chain[1:]...)
+	return signed, errors.Wrap(err, "sign profile")
+}
+
+// SignInstallProfile reports whether the profiles of InstallProfile
+// commands should be signed.
+func (s *ProfileSigner) SignInstallProfile() bool {
+	id, err := s.identity()
+	return err == nil && id.SignInstallProfile
+}
+
+func isNotFound(err error) bool {
+	type notFoundError interface {
+		error
+		NotFound() bool
+	}
+	e, ok := errors.Cause(err).(notFoundError)
+	return ok && e.NotFound()
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+type signingIdentityStore struct {
+	id *ProfileSigningIdentity
+}
+
+type notFoundErr struct{}
+
+func (notFoundErr) Error() string  { return "not found" }
+func (notFoundErr) NotFound() bool { return true }
+
+func (s signingIdentityStore) ProfileSigningIdentity() (*ProfileSigningIdentity, error) {
+	if s.id == nil {
+		return nil, notFoundErr{}
+	}
+	return s.id, nil
+}
+
+func newSigningIdentity(t *testing.T) *ProfileSigningIdentity {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Profile Signing"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ProfileSigningIdentity{
+		Certificates: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		PrivateKey:   pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}
+}
+
+func TestProfileSigner(t *testing.T) {
+	mc := []byte(`<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict/></plist>`)
+
+	signer := NewProfileSigner(signingIdentityStore{})
+	if _, err := signer.Sign(mc); err != ErrNoProfileSigningIdentity {
+		t.Fatalf("have err %v without an identity, want %v", err, ErrNoProfileSigningIdentity)
+	}
+	if signer.SignInstallProfile() {
+		t.Error("SignInstallProfile is true without an identity")
+	}
+
+	id := newSigningIdentity(t)
+	id.SignInstallProfile = true
+	signer = NewProfileSigner(signingIdentityStore{id: id})
+	signed, err := signer.Sign(mc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7, err := pkcs7.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p7.Verify(); err != nil {
+		t.Fatalf("verify signed profile: %s", err)
+	}
+	if string(p7.Content) != string(mc) {
+		t.Errorf("signed content changed:\n%s", p7.Content)
+	}
+	if !signer.SignInstallProfile() {
+		t.Error("SignInstallProfile is false")
+	}
+}
+
+func TestParseSigningIdentityKeyMismatch(t *testing.T) {
+	id, other := newSigningIdentity(t), newSigningIdentity(t)
+	id.PrivateKey = other.PrivateKey
+	if _, _, err := id.Parse(); err == nil {
+		t.Error("parsed a signing identity with the key of another certificate")
+	}
+}
@@ -0,0 +1,166 @@
+package device
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+// DeviceAttributes updates the custom attributes and notes of a device,
+// which is found by UDID or serial number.
+type DeviceAttributes struct {
+	UDID         string `json:"udid,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+
+	// Attributes are merged with the existing attributes of the device.
+	// An attribute with an empty value is removed.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Notes replace the notes of the device if set.
+	Notes *string `json:"notes,omitempty"`
+}
+
+type ApplyAttributesResult struct {
+	Updated int `json:"updated"`
+
+	// NotFound lists the UDIDs or serial numbers of unknown devices.
+	NotFound []string `json:"not_found,omitempty"`
+}
+
+func (svc *DeviceService) ApplyDeviceAttributes(ctx context.Context, updates []DeviceAttributes) (*ApplyAttributesResult, error) {
+	for _, u := range updates {
+		if u.UDID == "" && u.SerialNumber == "" {
+			return nil, errors.New("device attributes must have a UDID or serial number")
+		}
+		for key := range u.Attributes {
+			if err := validAttributeKey(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := new(ApplyAttributesResult)
+	for _, u := range updates {
+		id := u.UDID
+		if id == "" {
+			id = u.SerialNumber
+		}
+		dev, err := svc.deviceByUDIDOrSerial(ctx, u.UDID, u.SerialNumber)
+		if isNotFound(err) {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		apply := func(dev *Device) error {
+			for key, value := range u.Attributes {
+				if value == "" {
+					delete(dev.Attributes, key)
+					continue
+				}
+				if dev.Attributes == nil {
+					dev.Attributes = make(Attributes)
+				}
+				dev.Attributes[key] = value
+			}
+			if u.Notes != nil {
+				dev.Notes = *u.Notes
+			}
+			return nil
+		}
+		if dev.UDID != "" {
+			// the device worker updates enrolled devices concurrently.
+			dev, err = svc.store.UpdateByUDID(ctx, dev.UDID, apply)
+		} else {
+			apply(dev)
+			err = svc.store.Save(ctx, dev)
+		}
+		if isNotFound(err) {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "save attributes for device %s", id)
+		}
+		if svc.publisher != nil {
+			if err := publishDeviceUpdated(ctx, svc.publisher, dev); err != nil {
+				return nil, err
+			}
+		}
+		result.Updated++
+	}
+	return result, nil
+}
+
+func (svc *DeviceService) deviceByUDIDOrSerial(ctx context.Context, udid, serial string) (*Device, error) {
+	if udid != "" {
+		dev, err := svc.store.DeviceByUDID(ctx, udid)
+		if err == nil || !isNotFound(err) || serial == "" {
+			return dev, err
+		}
+	}
+	return svc.store.DeviceBySerial(ctx, serial)
+}
+
+// validAttributeKey checks that the key can be stored in the search
+// index, which separates the key and value with "=".
+func validAttributeKey(key string) error {
+	if key == "" {
+		return errors.New("device attribute key must not be empty")
+	}
+	if strings.ContainsAny(key, "=\x00") {
+		return errors.Errorf("device attribute key %q must not contain \"=\"", key)
+	}
+	return nil
+}
+
+type applyDeviceAttributesRequest struct {
+	Devices []DeviceAttributes `json:"devices"`
+}
+
+type applyDeviceAttributesResponse struct {
+	Result *ApplyAttributesResult `json:"result,omitempty"`
+	Err    error                  `json:"err,omitempty"`
+}
+
+func (r applyDeviceAttributesResponse) Failed() error { return r.Err }
+
+func decodeApplyDeviceAttributesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req applyDeviceAttributesRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeApplyDeviceAttributesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp applyDeviceAttributesResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeApplyDeviceAttributesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(applyDeviceAttributesRequest)
+		result, err := svc.ApplyDeviceAttributes(ctx, req.Devices)
+		return applyDeviceAttributesResponse{
+			Result: result,
+			Err:    err,
+		}, nil
+	}
+}
+
+func (e Endpoints) ApplyDeviceAttributes(ctx context.Context, updates []DeviceAttributes) (*ApplyAttributesResult, error) {
+	request := applyDeviceAttributesRequest{Devices: updates}
+	response, err := e.ApplyDeviceAttributesEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(applyDeviceAttributesResponse)
+	return resp.Result, resp.Err
+}
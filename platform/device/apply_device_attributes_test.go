@@ -0,0 +1,60 @@
+package device
+
+import (
+	"context"
+	"testing"
+)
+
+// racingStore updates the OS version of the stored device after it is read,
+// like the device worker updating the device from a check-in.
+type racingStore struct {
+	memStore
+}
+
+func (s racingStore) DeviceByUDID(ctx context.Context, udid string) (*Device, error) {
+	dev, err := s.memStore.DeviceByUDID(ctx, udid)
+	if err == nil {
+		s.devices[dev.SerialNumber].OSVersion = "10.15"
+	}
+	return dev, err
+}
+
+func (s racingStore) DeviceBySerial(ctx context.Context, serial string) (*Device, error) {
+	dev, err := s.memStore.DeviceBySerial(ctx, serial)
+	if err == nil {
+		s.devices[serial].OSVersion = "10.15"
+	}
+	return dev, err
+}
+
+func TestApplyDeviceAttributes(t *testing.T) {
+	store := racingStore{memStore{devices: map[string]*Device{
+		"C02ENROLLED": {UUID: "1", UDID: "UDID-1", SerialNumber: "C02ENROLLED", OSVersion: "10.14.6"},
+		"C02DEP":      {UUID: "2", SerialNumber: "C02DEP"},
+	}}}
+	svc := New(store)
+
+	notes := "loaner"
+	result, err := svc.ApplyDeviceAttributes(context.Background(), []DeviceAttributes{
+		{UDID: "UDID-1", Attributes: map[string]string{"owner": "jane"}},
+		{SerialNumber: "C02DEP", Notes: &notes},
+		{SerialNumber: "C02NONE", Notes: &notes},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 2 || len(result.NotFound) != 1 || result.NotFound[0] != "C02NONE" {
+		t.Errorf("have result %+v, want 2 updated and C02NONE not found", result)
+	}
+
+	enrolled := store.devices["C02ENROLLED"]
+	if enrolled.Attributes["owner"] != "jane" {
+		t.Errorf("have attributes %v, want owner set", enrolled.Attributes)
+	}
+	if enrolled.OSVersion != "10.15" {
+		t.Errorf("have os version %s, want the concurrent update kept", enrolled.OSVersion)
+	}
+	if store.devices["C02DEP"].Notes != notes {
+		t.Errorf("have notes %q for the device without a UDID, want %q", store.devices["C02DEP"].Notes, notes)
+	}
+}
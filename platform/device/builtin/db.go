@@ -20,15 +20,46 @@ const (
 	// The udidCertAuthBucket stores a simple mapping from UDID to
 	// sha256 hash of the device identity certificate for future validation
 	udidCertAuthBucket = "mdm.UDIDCertAuth"
+
+	// The udidCertBucket stores the DER encoded identity certificate of
+	// each UDID, which profiles are encrypted to.
+	udidCertBucket = "mdm.UDIDCert"
+
+	// The deviceSearchIndexBucket holds one nested bucket per searchable
+	// field. See search.go. The name changes with the encoding of the
+	// indexed values, so that existing indexes are rebuilt.
+	deviceSearchIndexBucket = "mdm.DeviceSearchIdx.v2"
 )
 
+// oldDeviceSearchIndexBuckets are the replaced search index buckets,
+// deleted on startup.
+var oldDeviceSearchIndexBuckets = []string{"mdm.DeviceSearchIdx"}
+
 type DB struct {
 	*bolt.DB
 }
 
 func NewDB(db *bolt.DB) (*DB, error) {
+	var reindex bool
 	err := db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(deviceIndexBucket))
+		for _, name := range oldDeviceSearchIndexBuckets {
+			if tx.Bucket([]byte(name)) != nil {
+				if err := tx.DeleteBucket([]byte(name)); err != nil {
+					return err
+				}
+			}
+		}
+		root, err := tx.CreateBucketIfNotExists([]byte(deviceSearchIndexBucket))
+		if err != nil {
+			return err
+		}
+		// rebuild the indexes when a new one is added.
+		for _, name := range searchIndexes {
+			if root.Bucket([]byte(name)) == nil {
+				reindex = true
+			}
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(deviceIndexBucket))
 		if err != nil {
 			return err
 		}
@@ -37,46 +68,144 @@ func NewDB(db *bolt.DB) (*DB, error) {
 			return err
 		}
 		_, err = tx.CreateBucketIfNotExists([]byte(udidCertAuthBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(udidCertBucket))
 		return err
 	})
 	if err != nil {
 		return nil, errors.Wrapf(err, "creating %s bucket", DeviceBucket)
 	}
 	datastore := &DB{DB: db}
+	if reindex {
+		if err := datastore.reindex(); err != nil {
+			return nil, errors.Wrap(err, "build device search index")
+		}
+	}
 	return datastore, nil
 }
 
+// List returns the devices matching the filters in opt, ordered by UUID.
 func (db *DB) List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error) {
 	var devices []device.Device
 	err := db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(DeviceBucket))
-		// TODO optimize by implemting Seek() and bytes.HasPrefix() so we don't
-		// hit all keys in the database if we dont have to.
-		return b.ForEach(func(k, v []byte) error {
-			var dev device.Device
-			if err := device.UnmarshalDevice(v, &dev); err != nil {
-				return err
-			}
-			if len(opt.FilterSerial) == 0 {
-				devices = append(devices, dev)
+		matches, err := search(tx, opt)
+		if err != nil {
+			return err
+		}
+		matches = paginate(matches, opt)
+		devices = make([]device.Device, 0, len(matches))
+		for _, dev := range matches {
+			devices = append(devices, *dev)
+		}
+		return nil
+	})
+	return devices, err
+}
+
+// errStopWalk ends a walk early without an error.
+var errStopWalk = errors.New("stop walk")
+
+// forEachPageSize is the number of devices ForEach reads in a transaction.
+const forEachPageSize = 100
+
+// ForEach calls fn with each device matching opt, ordered by UUID. Devices
+// are read in pages, each in its own transaction, and fn is called outside
+// of the transaction so a slow fn doesn't hold the database open. An error
+// returned by fn stops the iteration and is returned.
+func (db *DB) ForEach(ctx context.Context, opt device.ListDevicesOption, fn func(device.Device) error) error {
+	var (
+		n     int
+		after string
+		start = opt.Offset()
+	)
+	for {
+		var (
+			page []device.Device
+			more bool
+		)
+		err := db.View(func(tx *bolt.Tx) error {
+			return walk(tx, opt, after, func(dev *device.Device) error {
+				after = dev.UUID
+				n++
+				if n <= start {
+					return nil
+				}
+				if opt.PerPage > 0 && n > start+opt.PerPage {
+					return errStopWalk
+				}
+				page = append(page, *dev)
+				if len(page) == forEachPageSize {
+					more = true
+					return errStopWalk
+				}
 				return nil
+			})
+		})
+		if err != nil && err != errStopWalk {
+			return err
+		}
+		for _, dev := range page {
+			if err := ctx.Err(); err != nil {
+				return err
 			}
-			for _, fs := range opt.FilterSerial {
-				if fs == dev.SerialNumber {
-					devices = append(devices, dev)
-				}
+			if err := fn(dev); err != nil {
+				return err
 			}
+		}
+		if !more {
 			return nil
-		})
+		}
+	}
+}
+
+// Count returns the number of devices matching the filters in opt,
+// ignoring pagination.
+func (db *DB) Count(ctx context.Context, opt device.ListDevicesOption) (int, error) {
+	var count int
+	err := db.View(func(tx *bolt.Tx) error {
+		matches, err := search(tx, opt)
+		count = len(matches)
+		return err
 	})
-	return devices, err
+	return count, err
 }
 
 func (db *DB) Save(ctx context.Context, dev *device.Device) error {
-	tx, err := db.DB.Begin(true)
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		return saveDevice(tx, dev)
+	})
+}
+
+// UpdateByUDID reads the device with udid, calls fn with it and saves the
+// device in a single transaction.
+func (db *DB) UpdateByUDID(ctx context.Context, udid string, fn func(*device.Device) error) (*device.Device, error) {
+	var dev device.Device
+	err := db.DB.Update(func(tx *bolt.Tx) error {
+		idx := tx.Bucket([]byte(deviceIndexBucket)).Get([]byte(udid))
+		if idx == nil {
+			return &notFound{"Device", fmt.Sprintf("key %s", udid)}
+		}
+		v := tx.Bucket([]byte(DeviceBucket)).Get(idx)
+		if v == nil {
+			return &notFound{"Device", fmt.Sprintf("uuid %s", string(idx))}
+		}
+		if err := device.UnmarshalDevice(v, &dev); err != nil {
+			return err
+		}
+		if err := fn(&dev); err != nil {
+			return err
+		}
+		return saveDevice(tx, &dev)
+	})
 	if err != nil {
-		return errors.Wrap(err, "begin transaction")
+		return nil, err
 	}
+	return &dev, nil
+}
+
+func saveDevice(tx *bolt.Tx, dev *device.Device) error {
 	bkt := tx.Bucket([]byte(DeviceBucket))
 	if bkt == nil {
 		return fmt.Errorf("bucket %q not found!", DeviceBucket)
@@ -104,10 +233,19 @@ func (db *DB) Save(ctx context.Context, dev *device.Device) error {
 	}
 
 	key := []byte(dev.UUID)
-	if err := bkt.Put(key, devproto); err != nil {
-		return errors.Wrap(err, "put device to boltdb")
+	if prev := bkt.Get(key); prev != nil {
+		var prevDev device.Device
+		if err := device.UnmarshalDevice(prev, &prevDev); err != nil {
+			return errors.Wrap(err, "unmarshal previous device")
+		}
+		if err := removeSearchIndexes(tx, &prevDev); err != nil {
+			return errors.Wrap(err, "remove device search index")
+		}
 	}
-	return tx.Commit()
+	if err := addSearchIndexes(tx, dev); err != nil {
+		return errors.Wrap(err, "add device search index")
+	}
+	return errors.Wrap(bkt.Put(key, devproto), "put device to boltdb")
 }
 
 func (db *DB) DeleteByUDID(ctx context.Context, udid string) error {
@@ -141,6 +279,9 @@ func (db *DB) deleteByIndex(key string) error {
 	if err := idxBucket.Delete([]byte(device.SerialNumber)); err != nil {
 		return errors.Wrapf(err, "delete device index for serial %s", device.SerialNumber)
 	}
+	if err := removeSearchIndexes(tx, device); err != nil {
+		return errors.Wrapf(err, "delete device search index for key %s", key)
+	}
 
 	return tx.Commit()
 }
@@ -217,3 +358,30 @@ func (db *DB) GetUDIDCertHash(udid []byte) ([]byte, error) {
 	})
 	return certHash, err
 }
+
+func (db *DB) SaveUDIDCert(udid, cert []byte) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(udidCertBucket))
+		if bkt == nil {
+			return fmt.Errorf("bucket %q not found!", udidCertBucket)
+		}
+		return errors.Wrap(bkt.Put(udid, cert), "put udid cert to boltdb")
+	})
+}
+
+func (db *DB) GetUDIDCert(udid []byte) ([]byte, error) {
+	var cert []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(udidCertBucket))
+		if b == nil {
+			return fmt.Errorf("bucket %q not found!", udidCertBucket)
+		}
+		v := b.Get(udid)
+		if v == nil {
+			return &notFound{"UDID", fmt.Sprintf("udid %s", string(udid))}
+		}
+		cert = append([]byte(nil), v...)
+		return nil
+	})
+	return cert, err
+}
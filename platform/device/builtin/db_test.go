@@ -2,9 +2,11 @@ package builtin
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/boltdb/bolt"
 
@@ -115,6 +117,206 @@ func TestDeleteBySerial(t *testing.T) {
 	}
 }
 
+func TestList(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	devices := []*device.Device{
+		{UUID: "1", UDID: "UDID-1", SerialNumber: "SERIAL1", ModelName: "MacBook Pro", OSVersion: "10.14.6", Enrolled: true, LastSeen: now, Attributes: device.Attributes{"location": "nyc", "owner": "jane"}},
+		{UUID: "2", UDID: "UDID-2", SerialNumber: "SERIAL2", ModelName: "MacBook Pro", OSVersion: "10.9.5", Enrolled: true, LastSeen: now.Add(-72 * time.Hour), Lifecycle: device.LifecycleStale},
+		{UUID: "3", UDID: "UDID-3", SerialNumber: "SERIAL3", ProductName: "iPad8,1", OSVersion: "12.4", DEPProfileStatus: device.ASSIGNED, Attributes: device.Attributes{"location": "nyc"}},
+	}
+	for _, dev := range devices {
+		if err := db.Save(ctx, dev); err != nil {
+			t.Fatalf("saving device in datastore: %s", err)
+		}
+	}
+
+	// update a device to verify stale index entries are removed.
+	devices[1].OSVersion = "10.13.6"
+	if err := db.Save(ctx, devices[1]); err != nil {
+		t.Fatalf("saving device in datastore: %s", err)
+	}
+
+	enrolled := true
+	var tests = []struct {
+		name string
+		opt  device.ListDevicesOption
+		want []string
+	}{
+		{"all", device.ListDevicesOption{}, []string{"1", "2", "3"}},
+		{"udid", device.ListDevicesOption{FilterUDID: []string{"UDID-2"}}, []string{"2"}},
+		{"serial", device.ListDevicesOption{FilterSerial: []string{"SERIAL1", "SERIAL3"}}, []string{"1", "3"}},
+		{"model", device.ListDevicesOption{FilterModel: []string{"MacBook Pro"}}, []string{"1", "2"}},
+		{"os_version_min", device.ListDevicesOption{FilterOSVersionMin: "10.10"}, []string{"1", "2", "3"}},
+		{"os_version_range", device.ListDevicesOption{FilterOSVersionMin: "10.14", FilterOSVersionMax: "10.14.6"}, []string{"1"}},
+		{"os_version_stale_index", device.ListDevicesOption{FilterOSVersionMax: "10.10"}, nil},
+		{"os_version_trailing_zero", device.ListDevicesOption{FilterOSVersionMin: "12.4.0", FilterOSVersionMax: "12.4"}, []string{"3"}},
+		{"os_version_zero", device.ListDevicesOption{FilterOSVersionMax: "0"}, nil},
+		{"enrolled", device.ListDevicesOption{FilterEnrolled: &enrolled}, []string{"1", "2"}},
+		{"dep_profile_status", device.ListDevicesOption{FilterDEPProfileStatus: []device.DEPProfileStatus{device.ASSIGNED}}, []string{"3"}},
+		{"last_seen_after", device.ListDevicesOption{FilterLastSeenAfter: now.Add(-time.Hour)}, []string{"1"}},
+		{"combined", device.ListDevicesOption{FilterEnrolled: &enrolled, FilterLastSeenBefore: now.Add(-time.Hour)}, []string{"2"}},
+		{"attributes", device.ListDevicesOption{FilterAttributes: map[string]string{"location": "nyc"}}, []string{"1", "3"}},
+		{"attributes_all", device.ListDevicesOption{FilterAttributes: map[string]string{"location": "nyc", "owner": "jane"}}, []string{"1"}},
+		{"lifecycle_active", device.ListDevicesOption{FilterLifecycle: []device.LifecycleState{device.LifecycleActive}}, []string{"1", "3"}},
+		{"lifecycle", device.ListDevicesOption{FilterLifecycle: []device.LifecycleState{device.LifecycleStale, device.LifecycleMissing}}, []string{"2"}},
+		{"page", device.ListDevicesOption{Page: 2, PerPage: 2}, []string{"3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := db.List(ctx, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var have []string
+			for _, dev := range found {
+				have = append(have, dev.UUID)
+			}
+			if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+				t.Errorf("have %v, want %v", have, tt.want)
+			}
+		})
+	}
+
+	count, err := db.Count(ctx, device.ListDevicesOption{Page: 2, PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := count, 3; have != want {
+		t.Errorf("have count %d, want %d", have, want)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+	for _, uuid := range []string{"3", "1", "2", "4"} {
+		dev := &device.Device{UUID: uuid, UDID: "UDID-" + uuid, OSVersion: "10.14." + uuid}
+		if err := db.Save(ctx, dev); err != nil {
+			t.Fatalf("saving device in datastore: %s", err)
+		}
+	}
+
+	var tests = []struct {
+		name string
+		opt  device.ListDevicesOption
+		want []string
+	}{
+		{"all", device.ListDevicesOption{}, []string{"1", "2", "3", "4"}},
+		{"filtered", device.ListDevicesOption{FilterOSVersionMin: "10.14.2"}, []string{"2", "3", "4"}},
+		{"page", device.ListDevicesOption{Page: 2, PerPage: 2, FilterOSVersionMin: "10.14.2"}, []string{"4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var have []string
+			err := db.ForEach(ctx, tt.opt, func(dev device.Device) error {
+				have = append(have, dev.UUID)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+				t.Errorf("have %v, want %v", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestReindexOldBucket(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+	if err := db.Save(ctx, &device.Device{UUID: "1", UDID: "UDID-1", OSVersion: "10.14.6"}); err != nil {
+		t.Fatalf("saving device in datastore: %s", err)
+	}
+	// replace the index with the bucket of a previous version.
+	err := db.DB.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(deviceSearchIndexBucket)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte(oldDeviceSearchIndexBuckets[0]))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = NewDB(db.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := db.List(ctx, device.ListDevicesOption{FilterOSVersionMin: "10.14"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 {
+		t.Errorf("have %d devices, want the reindexed device", len(found))
+	}
+	err = db.DB.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(oldDeviceSearchIndexBuckets[0])) != nil {
+			t.Error("want the old index bucket deleted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForEachPages(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+	const total = 2*forEachPageSize + 5
+	err := db.DB.Update(func(tx *bolt.Tx) error {
+		for i := 0; i < total; i++ {
+			dev := &device.Device{UUID: fmt.Sprintf("%04d", i), UDID: fmt.Sprintf("UDID-%04d", i)}
+			if err := saveDevice(tx, dev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fn saves devices, which would deadlock in a read transaction.
+	var have []string
+	err = db.ForEach(ctx, device.ListDevicesOption{}, func(dev device.Device) error {
+		have = append(have, dev.UUID)
+		dev.Notes = "exported"
+		return db.Save(ctx, &dev)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(have) != total {
+		t.Fatalf("have %d devices, want %d", len(have), total)
+	}
+	for i, uuid := range have {
+		if want := fmt.Sprintf("%04d", i); uuid != want {
+			t.Fatalf("have device %s at %d, want %s", uuid, i, want)
+		}
+	}
+
+	have = nil
+	opt := device.ListDevicesOption{Page: 2, PerPage: forEachPageSize + 1}
+	err = db.ForEach(ctx, opt, func(dev device.Device) error {
+		have = append(have, dev.UUID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(have) != forEachPageSize+1 || have[0] != fmt.Sprintf("%04d", forEachPageSize+1) {
+		t.Errorf("have %d devices starting at %v, want %d starting at %04d",
+			len(have), have[:1], forEachPageSize+1, forEachPageSize+1)
+	}
+}
+
 func setupDB(t *testing.T) *DB {
 	f, _ := ioutil.TempFile("", "bolt-")
 	f.Close()
@@ -130,3 +332,20 @@ func setupDB(t *testing.T) *DB {
 	}
 	return devDB
 }
+
+func TestUDIDCert(t *testing.T) {
+	db := setupDB(t)
+	if _, err := db.GetUDIDCert([]byte("UDID-FOO")); err == nil {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if err := db.SaveUDIDCert([]byte("UDID-FOO"), []byte("der")); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := db.GetUDIDCert([]byte("UDID-FOO"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := string(cert), "der"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+}
@@ -0,0 +1,364 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/device"
+)
+
+// Secondary indexes are stored in nested buckets of deviceSearchIndexBucket,
+// one per field. Each key is the indexed value, a zero byte and the device
+// UUID, so all devices with a value, or a range of values, are found with a
+// cursor seek. Values are encoded to sort in the same order as the field.
+const (
+	modelIndex            = "model"
+	osVersionIndex        = "os_version"
+	enrolledIndex         = "enrolled"
+	depProfileStatusIndex = "dep_profile_status"
+	lastSeenIndex         = "last_seen"
+	attributesIndex       = "attributes"
+	lifecycleIndex        = "lifecycle"
+)
+
+var searchIndexes = []string{
+	modelIndex,
+	osVersionIndex,
+	enrolledIndex,
+	depProfileStatusIndex,
+	lastSeenIndex,
+	attributesIndex,
+	lifecycleIndex,
+}
+
+func indexValues(dev *device.Device) map[string][]string {
+	lifecycle := dev.Lifecycle
+	if lifecycle == "" {
+		lifecycle = device.LifecycleActive
+	}
+	values := map[string][]string{
+		osVersionIndex:        {device.OSVersionKey(dev.OSVersion)},
+		enrolledIndex:         {strconv.FormatBool(dev.Enrolled)},
+		depProfileStatusIndex: {string(dev.DEPProfileStatus)},
+		lastSeenIndex:         {encodeTime(dev.LastSeen)},
+		lifecycleIndex:        {string(lifecycle)},
+	}
+	for key, value := range dev.Attributes {
+		values[attributesIndex] = append(values[attributesIndex], attributeIndexValue(key, value))
+	}
+	for _, model := range []string{dev.Model, dev.ModelName, dev.ProductName} {
+		if model != "" && !containsString(values[modelIndex], model) {
+			values[modelIndex] = append(values[modelIndex], model)
+		}
+	}
+	return values
+}
+
+func attributeIndexValue(key, value string) string {
+	return key + "=" + value
+}
+
+func indexKey(value, uuid string) []byte {
+	return []byte(value + "\x00" + uuid)
+}
+
+func splitIndexKey(key []byte) (value, uuid string) {
+	i := bytes.IndexByte(key, 0)
+	if i < 0 {
+		return string(key), ""
+	}
+	return string(key[:i]), string(key[i+1:])
+}
+
+func addSearchIndexes(tx *bolt.Tx, dev *device.Device) error {
+	if dev.UUID == "" {
+		return nil
+	}
+	root := tx.Bucket([]byte(deviceSearchIndexBucket))
+	if root == nil {
+		return fmt.Errorf("bucket %q not found!", deviceSearchIndexBucket)
+	}
+	for name, values := range indexValues(dev) {
+		b, err := root.CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return errors.Wrapf(err, "create %s index bucket", name)
+		}
+		for _, v := range values {
+			if err := b.Put(indexKey(v, dev.UUID), []byte{}); err != nil {
+				return errors.Wrapf(err, "put %s index", name)
+			}
+		}
+	}
+	return nil
+}
+
+func removeSearchIndexes(tx *bolt.Tx, dev *device.Device) error {
+	root := tx.Bucket([]byte(deviceSearchIndexBucket))
+	if root == nil {
+		return fmt.Errorf("bucket %q not found!", deviceSearchIndexBucket)
+	}
+	for name, values := range indexValues(dev) {
+		b := root.Bucket([]byte(name))
+		if b == nil {
+			continue
+		}
+		for _, v := range values {
+			if err := b.Delete(indexKey(v, dev.UUID)); err != nil {
+				return errors.Wrapf(err, "delete %s index", name)
+			}
+		}
+	}
+	return nil
+}
+
+// reindex rebuilds the search indexes from the stored devices.
+func (db *DB) reindex() error {
+	return db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket([]byte(deviceSearchIndexBucket))
+		for _, name := range searchIndexes {
+			if root.Bucket([]byte(name)) != nil {
+				if err := root.DeleteBucket([]byte(name)); err != nil {
+					return err
+				}
+			}
+			if _, err := root.CreateBucket([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket([]byte(DeviceBucket)).ForEach(func(k, v []byte) error {
+			var dev device.Device
+			if err := device.UnmarshalDevice(v, &dev); err != nil {
+				return err
+			}
+			return addSearchIndexes(tx, &dev)
+		})
+	})
+}
+
+// uuidSet is a set of device UUIDs. A nil set means no constraint.
+type uuidSet map[string]struct{}
+
+func intersect(a, b uuidSet) uuidSet {
+	if a == nil {
+		return b
+	}
+	out := make(uuidSet)
+	for k := range a {
+		if _, ok := b[k]; ok {
+			out[k] = struct{}{}
+		}
+	}
+	return out
+}
+
+// scanIndex collects the UUIDs with a value between from and to, inclusive.
+// Empty bounds are open.
+func scanIndex(tx *bolt.Tx, name, from, to string) uuidSet {
+	set := make(uuidSet)
+	root := tx.Bucket([]byte(deviceSearchIndexBucket))
+	b := root.Bucket([]byte(name))
+	if b == nil {
+		return set
+	}
+	c := b.Cursor()
+	for k, _ := c.Seek([]byte(from)); k != nil; k, _ = c.Next() {
+		value, uuid := splitIndexKey(k)
+		if to != "" && value > to {
+			break
+		}
+		set[uuid] = struct{}{}
+	}
+	return set
+}
+
+// lookupIndex collects the UUIDs with one of the given values.
+func lookupIndex(tx *bolt.Tx, name string, values []string) uuidSet {
+	set := make(uuidSet)
+	root := tx.Bucket([]byte(deviceSearchIndexBucket))
+	b := root.Bucket([]byte(name))
+	if b == nil {
+		return set
+	}
+	c := b.Cursor()
+	for _, v := range values {
+		prefix := []byte(v + "\x00")
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			_, uuid := splitIndexKey(k)
+			set[uuid] = struct{}{}
+		}
+	}
+	return set
+}
+
+// lookupUUIDs resolves UDIDs or serial numbers using the device index bucket.
+func lookupUUIDs(tx *bolt.Tx, keys []string) uuidSet {
+	set := make(uuidSet)
+	ib := tx.Bucket([]byte(deviceIndexBucket))
+	for _, key := range keys {
+		if uuid := ib.Get([]byte(key)); uuid != nil {
+			set[string(uuid)] = struct{}{}
+		}
+	}
+	return set
+}
+
+// search returns the devices matching opt, ordered by UUID.
+func search(tx *bolt.Tx, opt device.ListDevicesOption) ([]*device.Device, error) {
+	var devices []*device.Device
+	err := walk(tx, opt, "", func(dev *device.Device) error {
+		devices = append(devices, dev)
+		return nil
+	})
+	return devices, err
+}
+
+// walk calls fn with each device matching opt with a UUID after after,
+// ordered by UUID. Only one device is decoded at a time, so the matching
+// devices are never all held in memory.
+func walk(tx *bolt.Tx, opt device.ListDevicesOption, after string, fn func(*device.Device) error) error {
+	var candidates uuidSet
+	if len(opt.FilterUDID) > 0 {
+		candidates = intersect(candidates, lookupUUIDs(tx, opt.FilterUDID))
+	}
+	if len(opt.FilterSerial) > 0 {
+		candidates = intersect(candidates, lookupUUIDs(tx, opt.FilterSerial))
+	}
+	if len(opt.FilterModel) > 0 {
+		candidates = intersect(candidates, lookupIndex(tx, modelIndex, opt.FilterModel))
+	}
+	if len(opt.FilterDEPProfileStatus) > 0 {
+		var statuses []string
+		for _, s := range opt.FilterDEPProfileStatus {
+			statuses = append(statuses, string(s))
+		}
+		candidates = intersect(candidates, lookupIndex(tx, depProfileStatusIndex, statuses))
+	}
+	if opt.FilterEnrolled != nil {
+		candidates = intersect(candidates, lookupIndex(tx, enrolledIndex, []string{strconv.FormatBool(*opt.FilterEnrolled)}))
+	}
+	if len(opt.FilterLifecycle) > 0 {
+		var states []string
+		for _, s := range opt.FilterLifecycle {
+			states = append(states, string(s))
+		}
+		candidates = intersect(candidates, lookupIndex(tx, lifecycleIndex, states))
+	}
+	if opt.FilterOSVersionMin != "" || opt.FilterOSVersionMax != "" {
+		var from, to string
+		if opt.FilterOSVersionMin != "" {
+			from = device.OSVersionKey(opt.FilterOSVersionMin)
+		}
+		if opt.FilterOSVersionMax != "" {
+			to = device.OSVersionKey(opt.FilterOSVersionMax)
+		}
+		if opt.FilterOSVersionMax != "" && to == "" {
+			// the key of version 0 is empty, which scanIndex treats as open.
+			candidates = intersect(candidates, lookupIndex(tx, osVersionIndex, []string{""}))
+		} else {
+			candidates = intersect(candidates, scanIndex(tx, osVersionIndex, from, to))
+		}
+	}
+	if !opt.FilterLastSeenAfter.IsZero() || !opt.FilterLastSeenBefore.IsZero() {
+		var from, to string
+		if !opt.FilterLastSeenAfter.IsZero() {
+			from = encodeTime(opt.FilterLastSeenAfter)
+		}
+		if !opt.FilterLastSeenBefore.IsZero() {
+			to = encodeTime(opt.FilterLastSeenBefore)
+		}
+		candidates = intersect(candidates, scanIndex(tx, lastSeenIndex, from, to))
+	}
+	for key, value := range opt.FilterAttributes {
+		candidates = intersect(candidates, lookupIndex(tx, attributesIndex, []string{attributeIndexValue(key, value)}))
+	}
+
+	b := tx.Bucket([]byte(DeviceBucket))
+	match := func(v []byte) error {
+		var dev device.Device
+		if err := device.UnmarshalDevice(v, &dev); err != nil {
+			return err
+		}
+		// the indexes narrow down the candidates, but the device
+		// record is the source of truth.
+		if !opt.Match(dev) {
+			return nil
+		}
+		return fn(&dev)
+	}
+
+	if candidates == nil {
+		c := b.Cursor()
+		k, v := c.Seek([]byte(after))
+		if k != nil && after != "" && string(k) == after {
+			k, v = c.Next()
+		}
+		for ; k != nil; k, v = c.Next() {
+			if err := match(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	uuids := make([]string, 0, len(candidates))
+	for uuid := range candidates {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	for _, uuid := range uuids {
+		if after != "" && uuid <= after {
+			continue
+		}
+		v := b.Get([]byte(uuid))
+		if v == nil {
+			continue
+		}
+		if err := match(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func paginate(devices []*device.Device, opt device.ListDevicesOption) []*device.Device {
+	if opt.PerPage <= 0 {
+		return devices
+	}
+	start := opt.Offset()
+	if start >= len(devices) {
+		return nil
+	}
+	end := start + opt.PerPage
+	if end > len(devices) {
+		end = len(devices)
+	}
+	return devices[start:end]
+}
+
+// encodeTime encodes t as fixed width hex of the unix time, so that
+// times sort lexically. The zero time is encoded as 0.
+func encodeTime(t time.Time) string {
+	var n int64
+	if !t.IsZero() {
+		n = t.Unix()
+	}
+	if n < 0 {
+		n = 0
+	}
+	return fmt.Sprintf("%016x", n)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -38,9 +38,69 @@ func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransp
 		).Endpoint()
 	}
 
+	var getDeviceHistoryEndpoint endpoint.Endpoint
+	{
+		getDeviceHistoryEndpoint = httptransport.NewClient(
+			"GET",
+			httputil.CopyURL(u, ""), // empty path, modified by the encodeRequest func
+			httputil.EncodeRequestWithToken(token, encodeGetDeviceHistoryRequest),
+			decodeGetDeviceHistoryResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var applyDeviceAttributesEndpoint endpoint.Endpoint
+	{
+		applyDeviceAttributesEndpoint = httptransport.NewClient(
+			"PUT",
+			httputil.CopyURL(u, "/v1/devices/attributes"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeApplyDeviceAttributesResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var setDeviceLifecycleEndpoint endpoint.Endpoint
+	{
+		setDeviceLifecycleEndpoint = httptransport.NewClient(
+			"PUT",
+			httputil.CopyURL(u, "/v1/devices/lifecycle"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeSetDeviceLifecycleResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var exportDevicesEndpoint endpoint.Endpoint
+	{
+		exportDevicesEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/devices/export"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeExportDevicesResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var importDevicesEndpoint endpoint.Endpoint
+	{
+		importDevicesEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/devices/import"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeImportDevicesResponse,
+			opts...,
+		).Endpoint()
+	}
+
 	return Endpoints{
-		ListDevicesEndpoint:   listDevicesEndpoint,
-		RemoveDevicesEndpoint: removeDevicesEndpoint,
+		ListDevicesEndpoint:           listDevicesEndpoint,
+		RemoveDevicesEndpoint:         removeDevicesEndpoint,
+		GetDeviceHistoryEndpoint:      getDeviceHistoryEndpoint,
+		ApplyDeviceAttributesEndpoint: applyDeviceAttributesEndpoint,
+		SetDeviceLifecycleEndpoint:    setDeviceLifecycleEndpoint,
+		ExportDevicesEndpoint:         exportDevicesEndpoint,
+		ImportDevicesEndpoint:         importDevicesEndpoint,
 	}, nil
 
 }
@@ -1,6 +1,8 @@
 package device
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -11,6 +13,10 @@ import (
 
 const DeviceEnrolledTopic = "mdm.DeviceEnrolled"
 
+// DeviceUpdatedTopic is the topic of the messages published by the Worker
+// every time it saves a device. The message is the marshaled Device.
+const DeviceUpdatedTopic = "mdm.DeviceUpdated"
+
 type Device struct {
 	UUID                   string           `db:"uuid"`
 	UDID                   string           `db:"udid"`
@@ -38,6 +44,44 @@ type Device struct {
 	DEPProfileAssignedDate time.Time        `db:"dep_profile_assigned_date"`
 	DEPProfileAssignedBy   string           `db:"dep_profile_assigned_by"`
 	LastSeen               time.Time        `db:"last_seen"`
+
+	// Attributes and Notes are set by administrators through the API,
+	// for example to record the owner or location of a device.
+	Attributes Attributes `db:"attributes"`
+	Notes      string     `db:"notes"`
+
+	// Lifecycle is updated by the LifecycleMonitor when the device stops
+	// checking in, or set through the API for retired and wiped devices.
+	Lifecycle        LifecycleState `db:"lifecycle"`
+	LifecycleChanged time.Time      `db:"lifecycle_changed"`
+}
+
+// Attributes are custom key/value attributes of a device.
+type Attributes map[string]string
+
+// Value implements driver.Valuer, storing the attributes as JSON.
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner for attributes stored as JSON.
+func (a *Attributes) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.Errorf("unsupported type %T for device attributes", src)
+	}
+	return json.Unmarshal(data, a)
 }
 
 // DEPProfileStatus is the status of the DEP Profile
@@ -80,6 +124,10 @@ func MarshalDevice(dev *Device) ([]byte, error) {
 		DepProfileAssignedDate: timeToNano(dev.DEPProfileAssignedDate),
 		DepProfileAssignedBy:   dev.DEPProfileAssignedBy,
 		LastSeen:               timeToNano(dev.LastSeen),
+		Attributes:             dev.Attributes,
+		Notes:                  dev.Notes,
+		Lifecycle:              string(dev.Lifecycle),
+		LifecycleChanged:       timeToNano(dev.LifecycleChanged),
 	}
 	return proto.Marshal(&protodev)
 }
@@ -115,6 +163,14 @@ func UnmarshalDevice(data []byte, dev *Device) error {
 	dev.DEPProfileAssignedDate = timeFromNano(pb.GetDepProfileAssignedDate())
 	dev.DEPProfileAssignedBy = pb.GetDepProfileAssignedBy()
 	dev.LastSeen = timeFromNano(pb.GetLastSeen())
+	dev.Attributes = pb.GetAttributes()
+	dev.Notes = pb.GetNotes()
+	dev.Lifecycle = LifecycleState(pb.GetLifecycle())
+	if dev.Lifecycle == "" {
+		// devices saved before lifecycle states were tracked.
+		dev.Lifecycle = LifecycleActive
+	}
+	dev.LifecycleChanged = timeFromNano(pb.GetLifecycleChanged())
 	return nil
 }
 
@@ -0,0 +1,304 @@
+package device
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+// Export formats.
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json" // newline delimited JSON, one device per line
+)
+
+// ExportDevicesOption selects the devices, format and columns of an export.
+type ExportDevicesOption struct {
+	ListDevicesOption
+
+	Format string `json:"format"`
+
+	// Columns are the exported fields, in order. The default is
+	// ExportColumns. Custom attributes are selected individually with
+	// the attributes. prefix, as in attributes.owner.
+	Columns []string `json:"columns,omitempty"`
+}
+
+type exportColumn struct {
+	name  string
+	value func(Device) interface{}
+}
+
+var exportColumns = []exportColumn{
+	{"uuid", func(d Device) interface{} { return d.UUID }},
+	{"udid", func(d Device) interface{} { return d.UDID }},
+	{"serial_number", func(d Device) interface{} { return d.SerialNumber }},
+	{"device_name", func(d Device) interface{} { return d.DeviceName }},
+	{"model", func(d Device) interface{} { return d.Model }},
+	{"model_name", func(d Device) interface{} { return d.ModelName }},
+	{"product_name", func(d Device) interface{} { return d.ProductName }},
+	{"os_version", func(d Device) interface{} { return d.OSVersion }},
+	{"build_version", func(d Device) interface{} { return d.BuildVersion }},
+	{"imei", func(d Device) interface{} { return d.IMEI }},
+	{"meid", func(d Device) interface{} { return d.MEID }},
+	{"enrolled", func(d Device) interface{} { return d.Enrolled }},
+	{"awaiting_configuration", func(d Device) interface{} { return d.AwaitingConfiguration }},
+	{"lifecycle", func(d Device) interface{} { return string(d.Lifecycle) }},
+	{"lifecycle_changed", func(d Device) interface{} { return exportTime(d.LifecycleChanged) }},
+	{"last_seen", func(d Device) interface{} { return exportTime(d.LastSeen) }},
+	{"description", func(d Device) interface{} { return d.Description }},
+	{"color", func(d Device) interface{} { return d.Color }},
+	{"asset_tag", func(d Device) interface{} { return d.AssetTag }},
+	{"dep_profile_status", func(d Device) interface{} { return string(d.DEPProfileStatus) }},
+	{"dep_profile_uuid", func(d Device) interface{} { return d.DEPProfileUUID }},
+	{"dep_profile_assign_time", func(d Device) interface{} { return exportTime(d.DEPProfileAssignTime) }},
+	{"dep_profile_push_time", func(d Device) interface{} { return exportTime(d.DEPProfilePushTime) }},
+	{"dep_profile_assigned_date", func(d Device) interface{} { return exportTime(d.DEPProfileAssignedDate) }},
+	{"dep_profile_assigned_by", func(d Device) interface{} { return d.DEPProfileAssignedBy }},
+	{"notes", func(d Device) interface{} { return d.Notes }},
+	{"attributes", func(d Device) interface{} { return d.Attributes }},
+}
+
+// ExportColumns are the names of the columns which can be exported.
+var ExportColumns = func() []string {
+	var names []string
+	for _, c := range exportColumns {
+		names = append(names, c.name)
+	}
+	return names
+}()
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func lookupExportColumn(name string) (exportColumn, error) {
+	if key := strings.TrimPrefix(name, "attributes."); key != name && key != "" {
+		return exportColumn{name, func(d Device) interface{} { return d.Attributes[key] }}, nil
+	}
+	for _, c := range exportColumns {
+		if c.name == name {
+			return c, nil
+		}
+	}
+	return exportColumn{}, errors.Errorf("unknown export column %q", name)
+}
+
+func (opt ExportDevicesOption) columns() ([]exportColumn, error) {
+	if len(opt.Columns) == 0 {
+		return exportColumns, nil
+	}
+	var columns []exportColumn
+	for _, name := range opt.Columns {
+		c, err := lookupExportColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// Validate checks the format and columns of the export.
+func (opt ExportDevicesOption) Validate() error {
+	if opt.Format != ExportCSV && opt.Format != ExportJSON {
+		return errors.Errorf("unsupported export format %q, must be %s or %s", opt.Format, ExportCSV, ExportJSON)
+	}
+	_, err := opt.columns()
+	return err
+}
+
+func (opt ExportDevicesOption) contentType() string {
+	if opt.Format == ExportCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// ExportDevices writes the devices matching the filters in opt to w.
+// Devices are written as they are read from the store.
+func (svc *DeviceService) ExportDevices(ctx context.Context, opt ExportDevicesOption, w io.Writer) error {
+	if err := opt.Validate(); err != nil {
+		return err
+	}
+	columns, _ := opt.columns()
+
+	var write func(Device) error
+	var flush func() error
+	switch opt.Format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = c.name
+		}
+		if err := cw.Write(header); err != nil {
+			return errors.Wrap(err, "write CSV header")
+		}
+		record := make([]string, len(columns))
+		write = func(dev Device) error {
+			for i, c := range columns {
+				record[i] = csvValue(c.value(dev))
+			}
+			return cw.Write(record)
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case ExportJSON:
+		bw := bufio.NewWriter(w)
+		write = func(dev Device) error {
+			return writeJSONLine(bw, columns, dev)
+		}
+		flush = bw.Flush
+	}
+
+	if err := svc.store.ForEach(ctx, opt.ListDevicesOption, write); err != nil {
+		return errors.Wrap(err, "export devices")
+	}
+	return errors.Wrap(flush(), "export devices")
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case Attributes:
+		if len(v) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return ""
+}
+
+// writeJSONLine writes the device as a JSON object with the keys in column
+// order, followed by a newline.
+func writeJSONLine(w *bufio.Writer, columns []exportColumn, dev Device) error {
+	w.WriteByte('{')
+	for i, c := range columns {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		key, _ := json.Marshal(c.name)
+		value, err := json.Marshal(c.value(dev))
+		if err != nil {
+			return errors.Wrapf(err, "marshal %s", c.name)
+		}
+		w.Write(key)
+		w.WriteByte(':')
+		w.Write(value)
+	}
+	w.WriteByte('}')
+	return w.WriteByte('\n')
+}
+
+type exportDevicesRequest struct {
+	ExportDevicesOption
+}
+
+// exportDevicesResponse streams the export. On the server the export is
+// written by write, and on the client the response body is copied to the
+// writer passed to ExportDevices.
+type exportDevicesResponse struct {
+	Err   error `json:"err,omitempty"`
+	opt   ExportDevicesOption
+	write func(io.Writer) error
+}
+
+func (r exportDevicesResponse) Failed() error { return r.Err }
+
+func decodeExportDevicesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req exportDevicesRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+// exportErrorTrailer is the HTTP trailer with the error which stopped an
+// export, because the status is already sent when the export fails.
+const exportErrorTrailer = "X-Export-Error"
+
+func encodeExportDevicesResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(exportDevicesResponse)
+	if resp.Err != nil {
+		httputil.ErrorEncoder(ctx, resp.Err, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", resp.opt.contentType())
+	w.Header().Set("Trailer", exportErrorTrailer)
+	w.WriteHeader(http.StatusOK)
+	// the status is already sent, so an error which truncates the export
+	// is sent in the trailer instead of being returned to the error encoder.
+	if err := resp.write(w); err != nil {
+		w.Header().Set(exportErrorTrailer, err.Error())
+	}
+	return nil
+}
+
+type exportWriterKey struct{}
+
+func decodeExportDevicesResponse(ctx context.Context, r *http.Response) (interface{}, error) {
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, httputil.JSONErrorDecoder(r)
+	}
+	w, ok := ctx.Value(exportWriterKey{}).(io.Writer)
+	if !ok {
+		return nil, errors.New("no writer for device export")
+	}
+	// the client cancels the request context when the endpoint returns,
+	// so the body is copied here instead of being returned.
+	if _, err := io.Copy(w, r.Body); err != nil {
+		return nil, errors.Wrap(err, "read device export")
+	}
+	if msg := r.Trailer.Get(exportErrorTrailer); msg != "" {
+		return nil, errors.Errorf("device export failed on the server: %s", msg)
+	}
+	return exportDevicesResponse{}, nil
+}
+
+func MakeExportDevicesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(exportDevicesRequest)
+		if err := req.Validate(); err != nil {
+			return exportDevicesResponse{Err: err}, nil
+		}
+		return exportDevicesResponse{
+			opt: req.ExportDevicesOption,
+			write: func(w io.Writer) error {
+				return svc.ExportDevices(ctx, req.ExportDevicesOption, w)
+			},
+		}, nil
+	}
+}
+
+func (e Endpoints) ExportDevices(ctx context.Context, opt ExportDevicesOption, w io.Writer) error {
+	ctx = context.WithValue(ctx, exportWriterKey{}, w)
+	response, err := e.ExportDevicesEndpoint(ctx, exportDevicesRequest{opt})
+	if err != nil {
+		return err
+	}
+	resp := response.(exportDevicesResponse)
+	if resp.write != nil {
+		return resp.write(w)
+	}
+	return resp.Err
+}
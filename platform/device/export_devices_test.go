@@ -0,0 +1,158 @@
+package device
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// exportStore implements the ForEach method of Store.
+type exportStore struct {
+	Store
+	devices []Device
+	err     error
+}
+
+func (s exportStore) ForEach(ctx context.Context, opt ListDevicesOption, fn func(Device) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, dev := range s.devices {
+		if !opt.Match(dev) {
+			continue
+		}
+		if err := fn(dev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var exportTestDevices = []Device{
+	{
+		UDID:         "UDID-1",
+		SerialNumber: "SERIAL1",
+		OSVersion:    "10.14.6",
+		Enrolled:     true,
+		LastSeen:     time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC),
+		Attributes:   Attributes{"owner": "jane"},
+		Notes:        "desk 4, \"loaner\"",
+	},
+	{UDID: "UDID-2", SerialNumber: "SERIAL2", OSVersion: "10.13.6"},
+}
+
+func TestExportDevices(t *testing.T) {
+	svc := New(exportStore{devices: exportTestDevices})
+	ctx := context.Background()
+
+	var tests = []struct {
+		name string
+		opt  ExportDevicesOption
+		want string
+	}{
+		{
+			name: "csv",
+			opt: ExportDevicesOption{
+				Format:  ExportCSV,
+				Columns: []string{"serial_number", "enrolled", "last_seen", "attributes.owner", "notes"},
+			},
+			want: "serial_number,enrolled,last_seen,attributes.owner,notes\n" +
+				"SERIAL1,true,2019-06-01T12:00:00Z,jane,\"desk 4, \"\"loaner\"\"\"\n" +
+				"SERIAL2,false,,,\n",
+		},
+		{
+			name: "json",
+			opt: ExportDevicesOption{
+				Format:  ExportJSON,
+				Columns: []string{"udid", "enrolled", "attributes"},
+			},
+			want: `{"udid":"UDID-1","enrolled":true,"attributes":{"owner":"jane"}}` + "\n" +
+				`{"udid":"UDID-2","enrolled":false,"attributes":null}` + "\n",
+		},
+		{
+			name: "filtered",
+			opt: ExportDevicesOption{
+				ListDevicesOption: ListDevicesOption{FilterOSVersionMax: "10.13.6"},
+				Format:            ExportCSV,
+				Columns:           []string{"udid"},
+			},
+			want: "udid\nUDID-2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := svc.ExportDevices(ctx, tt.opt, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if have := buf.String(); have != tt.want {
+				t.Errorf("have\n%s\nwant\n%s", have, tt.want)
+			}
+		})
+	}
+
+	if err := svc.ExportDevices(ctx, ExportDevicesOption{Format: "xml"}, new(bytes.Buffer)); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	err := svc.ExportDevices(ctx, ExportDevicesOption{Format: ExportCSV, Columns: []string{"token"}}, new(bytes.Buffer))
+	if err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestExportDevicesHTTP(t *testing.T) {
+	svc := New(exportStore{devices: exportTestDevices})
+	r := mux.NewRouter()
+	nop := func(next endpoint.Endpoint) endpoint.Endpoint { return next }
+	RegisterHTTPHandlers(r, MakeServerEndpoints(svc, nop))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client, err := NewHTTPClient(srv.URL, "secret", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	opt := ExportDevicesOption{Format: ExportCSV, Columns: []string{"udid", "os_version"}}
+	if err := client.ExportDevices(ctx, opt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := buf.String(), "udid,os_version\nUDID-1,10.14.6\nUDID-2,10.13.6\n"; have != want {
+		t.Errorf("have\n%s\nwant\n%s", have, want)
+	}
+
+	err = client.ExportDevices(ctx, ExportDevicesOption{Format: "xml"}, new(bytes.Buffer))
+	if err == nil || !strings.Contains(err.Error(), "unsupported export format") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestExportDevicesHTTPError(t *testing.T) {
+	svc := New(exportStore{err: errors.New("database closed")})
+	r := mux.NewRouter()
+	nop := func(next endpoint.Endpoint) endpoint.Endpoint { return next }
+	RegisterHTTPHandlers(r, MakeServerEndpoints(svc, nop))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client, err := NewHTTPClient(srv.URL, "secret", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := ExportDevicesOption{Format: ExportCSV, Columns: []string{"udid"}}
+	err = client.ExportDevices(context.Background(), opt, new(bytes.Buffer))
+	if err == nil || !strings.Contains(err.Error(), "database closed") {
+		t.Errorf("expected the export error from the trailer, got %v", err)
+	}
+}
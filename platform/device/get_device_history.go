@@ -0,0 +1,291 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/mdm"
+	mdmcmd "github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/pkg/httputil"
+	"github.com/micromdm/micromdm/platform/queue"
+)
+
+// CommandHistoryStore provides the queue state and the raw device responses
+// the command history is built from.
+type CommandHistoryStore interface {
+	DeviceCommand(udid string) (*queue.DeviceCommand, error)
+	CommandResponse(commandUUID string) ([]byte, error)
+}
+
+// Command status values reported in the history.
+const (
+	CommandStatusQueued       = "Queued"
+	CommandStatusSent         = "Sent"
+	CommandStatusNotNow       = "NotNow"
+	CommandStatusAcknowledged = "Acknowledged"
+	CommandStatusError        = "Error"
+)
+
+const defaultHistoryPerPage = 100
+
+type DeviceHistoryOption struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+
+	FilterRequestType []string `json:"filter_request_type"`
+	FilterStatus      []string `json:"filter_status"`
+
+	// IncludeResponse decodes the response body the device sent for
+	// each command, if one was recorded.
+	IncludeResponse bool `json:"include_response"`
+}
+
+type DeviceHistory struct {
+	UDID     string               `json:"udid"`
+	Total    int                  `json:"total"`
+	Commands []CommandHistoryItem `json:"commands"`
+}
+
+type CommandHistoryItem struct {
+	CommandUUID    string               `json:"command_uuid"`
+	RequestType    string               `json:"request_type"`
+	Status         string               `json:"status"`
+	QueuedAt       time.Time            `json:"queued_at"`
+	LastSentAt     time.Time            `json:"last_sent_at"`
+	AcknowledgedAt time.Time            `json:"acknowledged_at"`
+	TimesSent      int                  `json:"times_sent"`
+	ErrorChain     []mdm.ErrorChainItem `json:"error_chain,omitempty"`
+	Response       interface{}          `json:"response,omitempty"`
+
+	// ResponseError is set instead of Response if the recorded response
+	// could not be decoded.
+	ResponseError string `json:"response_error,omitempty"`
+}
+
+func (svc *DeviceService) GetDeviceHistory(ctx context.Context, udid string, opt DeviceHistoryOption) (*DeviceHistory, error) {
+	if svc.commands == nil {
+		return nil, errors.New("device command history is not available")
+	}
+	history := &DeviceHistory{UDID: udid}
+	dc, err := svc.commands.DeviceCommand(udid)
+	if err != nil {
+		if isNotFound(err) {
+			return history, nil
+		}
+		return nil, errors.Wrapf(err, "get command queue for udid %s", udid)
+	}
+
+	var items []CommandHistoryItem
+	add := func(commands []queue.Command, status func(queue.Command) string) {
+		for _, c := range commands {
+			item := commandHistoryItem(c, status(c))
+			if !matchesFilter(opt.FilterRequestType, item.RequestType) ||
+				!matchesFilter(opt.FilterStatus, item.Status) {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+	add(dc.Commands, func(c queue.Command) string {
+		if c.TimesSent == 0 {
+			return CommandStatusQueued
+		}
+		return CommandStatusSent
+	})
+	add(dc.NotNow, func(queue.Command) string { return CommandStatusNotNow })
+	add(dc.Completed, func(queue.Command) string { return CommandStatusAcknowledged })
+	add(dc.Failed, func(c queue.Command) string {
+		if c.LastStatus == "" {
+			return CommandStatusError
+		}
+		return c.LastStatus
+	})
+
+	// newest first
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].QueuedAt.After(items[j].QueuedAt)
+	})
+
+	history.Total = len(items)
+	items = paginate(items, opt.Page, opt.PerPage)
+
+	if opt.IncludeResponse {
+		for i := range items {
+			if err := svc.addResponse(&items[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	history.Commands = items
+	return history, nil
+}
+
+// addResponse decodes the recorded response of the command into item. A
+// response which fails to decode is reported on the item, so that it
+// doesn't hide the rest of the history.
+func (svc *DeviceService) addResponse(item *CommandHistoryItem) error {
+	if !mdmcmd.SupportsResponse(item.RequestType) {
+		return nil
+	}
+	raw, err := svc.commands.CommandResponse(item.CommandUUID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "get response for command %s", item.CommandUUID)
+	}
+	resp, err := mdmcmd.DecodeResponse(item.RequestType, raw)
+	if err != nil {
+		item.ResponseError = err.Error()
+		return nil
+	}
+	item.Response = resp
+	return nil
+}
+
+func commandHistoryItem(c queue.Command, status string) CommandHistoryItem {
+	item := CommandHistoryItem{
+		CommandUUID:    c.UUID,
+		Status:         status,
+		QueuedAt:       c.CreatedAt,
+		LastSentAt:     c.LastSentAt,
+		AcknowledgedAt: c.Acknowledged,
+		TimesSent:      c.TimesSent,
+		RequestType:    c.RequestType(),
+	}
+	if len(c.FailureMessage) > 0 {
+		// best effort, commands queued by older versions have no ErrorChain.
+		_ = json.Unmarshal(c.FailureMessage, &item.ErrorChain)
+	}
+	return item
+}
+
+func matchesFilter(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f == value {
+			return true
+		}
+	}
+	return false
+}
+
+// paginate returns the requested page of items. Pages start at 1.
+func paginate(items []CommandHistoryItem, page, perPage int) []CommandHistoryItem {
+	if perPage <= 0 {
+		perPage = defaultHistoryPerPage
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * perPage
+	if start >= len(items) {
+		return []CommandHistoryItem{}
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+type getDeviceHistoryRequest struct {
+	UDID string
+	Opts DeviceHistoryOption
+}
+
+type getDeviceHistoryResponse struct {
+	History *DeviceHistory `json:"history,omitempty"`
+	Err     error          `json:"err,omitempty"`
+}
+
+func (r getDeviceHistoryResponse) Failed() error { return r.Err }
+
+func decodeGetDeviceHistoryRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var errBadRoute = errors.New("bad route")
+	udid, ok := mux.Vars(r)["udid"]
+	if !ok {
+		return nil, errBadRoute
+	}
+	req := getDeviceHistoryRequest{UDID: udid}
+	q := r.URL.Query()
+	var err error
+	if v := q.Get("page"); v != "" {
+		if req.Opts.Page, err = strconv.Atoi(v); err != nil {
+			return nil, errors.Wrap(err, "parse page query parameter")
+		}
+	}
+	if v := q.Get("per_page"); v != "" {
+		if req.Opts.PerPage, err = strconv.Atoi(v); err != nil {
+			return nil, errors.Wrap(err, "parse per_page query parameter")
+		}
+	}
+	req.Opts.FilterRequestType = q["request_type"]
+	req.Opts.FilterStatus = q["status"]
+	if v := q.Get("include_response"); v != "" {
+		if req.Opts.IncludeResponse, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.Wrap(err, "parse include_response query parameter")
+		}
+	}
+	return req, nil
+}
+
+func encodeGetDeviceHistoryRequest(_ context.Context, r *http.Request, request interface{}) error {
+	req := request.(getDeviceHistoryRequest)
+	r.Method, r.URL.Path = "GET", "/v1/devices/"+req.UDID+"/history"
+	q := url.Values{}
+	if req.Opts.Page > 0 {
+		q.Set("page", strconv.Itoa(req.Opts.Page))
+	}
+	if req.Opts.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(req.Opts.PerPage))
+	}
+	for _, t := range req.Opts.FilterRequestType {
+		q.Add("request_type", t)
+	}
+	for _, s := range req.Opts.FilterStatus {
+		q.Add("status", s)
+	}
+	if req.Opts.IncludeResponse {
+		q.Set("include_response", "true")
+	}
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func decodeGetDeviceHistoryResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp getDeviceHistoryResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeGetDeviceHistoryEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getDeviceHistoryRequest)
+		history, err := svc.GetDeviceHistory(ctx, req.UDID, req.Opts)
+		return getDeviceHistoryResponse{
+			History: history,
+			Err:     err,
+		}, nil
+	}
+}
+
+func (e Endpoints) GetDeviceHistory(ctx context.Context, udid string, opts DeviceHistoryOption) (*DeviceHistory, error) {
+	request := getDeviceHistoryRequest{UDID: udid, Opts: opts}
+	response, err := e.GetDeviceHistoryEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return response.(getDeviceHistoryResponse).History, response.(getDeviceHistoryResponse).Err
+}
@@ -0,0 +1,173 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/groob/plist"
+
+	mdmcmd "github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/queue"
+)
+
+type mockCommandHistory struct {
+	dc        *queue.DeviceCommand
+	responses map[string][]byte
+}
+
+func (m *mockCommandHistory) DeviceCommand(udid string) (*queue.DeviceCommand, error) {
+	if m.dc == nil || m.dc.DeviceUDID != udid {
+		return nil, mockNotFound{}
+	}
+	return m.dc, nil
+}
+
+func (m *mockCommandHistory) CommandResponse(commandUUID string) ([]byte, error) {
+	raw, ok := m.responses[commandUUID]
+	if !ok {
+		return nil, mockNotFound{}
+	}
+	return raw, nil
+}
+
+type mockNotFound struct{}
+
+func (mockNotFound) Error() string  { return "not found" }
+func (mockNotFound) NotFound() bool { return true }
+
+func historyCommand(t *testing.T, uuid, requestType string, queuedAt time.Time) queue.Command {
+	payload, err := plist.Marshal(&mdmcmd.CommandPayload{
+		CommandUUID: uuid,
+		Command:     &mdmcmd.Command{RequestType: requestType},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queue.Command{UUID: uuid, Payload: payload, CreatedAt: queuedAt}
+}
+
+func TestGetDeviceHistory(t *testing.T) {
+	now := time.Now().UTC()
+	failed := historyCommand(t, "cmd-failed", "InstallProfile", now.Add(-3*time.Hour))
+	failed.LastStatus = CommandStatusError
+	failed.FailureMessage = []byte(`[{"error_code":4001,"error_domain":"MCProfileErrorDomain"}]`)
+
+	dc := &queue.DeviceCommand{
+		DeviceUDID: "UDID-FOO",
+		Commands:   []queue.Command{historyCommand(t, "cmd-queued", "DeviceLock", now)},
+		Completed:  []queue.Command{historyCommand(t, "cmd-acked", "SecurityInfo", now.Add(-1*time.Hour))},
+		Failed:     []queue.Command{failed},
+	}
+	store := &mockCommandHistory{
+		dc: dc,
+		responses: map[string][]byte{
+			"cmd-acked": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>SecurityInfo</key><dict><key>PasscodePresent</key><true/></dict>
+<key>Status</key><string>Acknowledged</string>
+</dict></plist>`),
+		},
+	}
+	svc := New(nil, WithCommandHistory(store))
+	ctx := context.Background()
+
+	history, err := svc.GetDeviceHistory(ctx, "UDID-FOO", DeviceHistoryOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := history.Total, 3; have != want {
+		t.Fatalf("have %d commands, want %d", have, want)
+	}
+	var order []string
+	for _, c := range history.Commands {
+		order = append(order, c.CommandUUID)
+	}
+	if have, want := fmt.Sprint(order), "[cmd-queued cmd-acked cmd-failed]"; have != want {
+		t.Errorf("have order %s, want %s", have, want)
+	}
+	if have, want := history.Commands[0].Status, CommandStatusQueued; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+	if have, want := len(history.Commands[2].ErrorChain), 1; have != want {
+		t.Fatalf("have %d ErrorChain items, want %d", have, want)
+	}
+	if have, want := history.Commands[2].ErrorChain[0].ErrorCode, 4001; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+
+	history, err = svc.GetDeviceHistory(ctx, "UDID-FOO", DeviceHistoryOption{
+		FilterStatus:    []string{CommandStatusAcknowledged},
+		IncludeResponse: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(history.Commands), 1; have != want {
+		t.Fatalf("have %d commands, want %d", have, want)
+	}
+	resp, ok := history.Commands[0].Response.(*mdmcmd.SecurityInfoResponse)
+	if !ok {
+		t.Fatalf("expected decoded SecurityInfo response, got %T", history.Commands[0].Response)
+	}
+	if !resp.SecurityInfo.PasscodePresent {
+		t.Error("expected PasscodePresent to be true")
+	}
+
+	history, err = svc.GetDeviceHistory(ctx, "UDID-FOO", DeviceHistoryOption{Page: 2, PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(history.Commands), 1; have != want {
+		t.Errorf("have %d commands on page 2, want %d", have, want)
+	}
+	if have, want := history.Total, 3; have != want {
+		t.Errorf("have total %d, want %d", have, want)
+	}
+
+	history, err = svc.GetDeviceHistory(ctx, "UDID-UNKNOWN", DeviceHistoryOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := history.Total, 0; have != want {
+		t.Errorf("have %d commands for unknown device, want %d", have, want)
+	}
+}
+
+func TestGetDeviceHistoryResponseError(t *testing.T) {
+	now := time.Now().UTC()
+	dc := &queue.DeviceCommand{
+		DeviceUDID: "UDID-FOO",
+		Completed: []queue.Command{
+			historyCommand(t, "cmd-good", "SecurityInfo", now),
+			historyCommand(t, "cmd-bad", "SecurityInfo", now.Add(-1*time.Hour)),
+		},
+	}
+	store := &mockCommandHistory{
+		dc: dc,
+		responses: map[string][]byte{
+			"cmd-good": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>SecurityInfo</key><dict><key>PasscodePresent</key><true/></dict>
+<key>Status</key><string>Acknowledged</string>
+</dict></plist>`),
+			"cmd-bad": []byte("not a plist"),
+		},
+	}
+	svc := New(nil, WithCommandHistory(store))
+
+	history, err := svc.GetDeviceHistory(context.Background(), "UDID-FOO", DeviceHistoryOption{IncludeResponse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(history.Commands), 2; have != want {
+		t.Fatalf("have %d commands, want %d", have, want)
+	}
+	if _, ok := history.Commands[0].Response.(*mdmcmd.SecurityInfoResponse); !ok {
+		t.Errorf("expected decoded SecurityInfo response, got %T", history.Commands[0].Response)
+	}
+	if bad := history.Commands[1]; bad.Response != nil || bad.ResponseError == "" {
+		t.Errorf("want a response error instead of a response, have %v, %q", bad.Response, bad.ResponseError)
+	}
+}
@@ -2,7 +2,10 @@ package device
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-kit/kit/endpoint"
@@ -10,22 +13,183 @@ import (
 	"github.com/micromdm/micromdm/pkg/httputil"
 )
 
+// ListDevicesOption filters and paginates the device list.
+// Filters are combined, a device must match all of the ones which are set.
 type ListDevicesOption struct {
+	// Page starts at 1. If PerPage is 0 all matching devices are returned.
 	Page    int `json:"page"`
 	PerPage int `json:"per_page"`
 
-	FilterSerial []string `json:"filter_serial"`
-	FilterUDID   []string `json:"filter_udid"`
+	FilterSerial           []string           `json:"filter_serial"`
+	FilterUDID             []string           `json:"filter_udid"`
+	FilterModel            []string           `json:"filter_model"` // matches Model, ModelName or ProductName
+	FilterDEPProfileStatus []DEPProfileStatus `json:"filter_dep_profile_status"`
+	FilterEnrolled         *bool              `json:"filter_enrolled,omitempty"`
+	FilterLifecycle        []LifecycleState   `json:"filter_lifecycle,omitempty"`
+
+	// Inclusive OS version range, either bound may be empty.
+	FilterOSVersionMin string `json:"filter_os_version_min"`
+	FilterOSVersionMax string `json:"filter_os_version_max"`
+
+	// Devices last seen within the window. A zero time is ignored.
+	FilterLastSeenAfter  time.Time `json:"filter_last_seen_after"`
+	FilterLastSeenBefore time.Time `json:"filter_last_seen_before"`
+
+	// Devices which have all of the custom attribute values.
+	FilterAttributes map[string]string `json:"filter_attributes,omitempty"`
+}
+
+// Match reports whether the device matches all the filters in opt.
+func (opt ListDevicesOption) Match(dev Device) bool {
+	if len(opt.FilterSerial) > 0 && !containsString(opt.FilterSerial, dev.SerialNumber) {
+		return false
+	}
+	if len(opt.FilterUDID) > 0 && !containsString(opt.FilterUDID, dev.UDID) {
+		return false
+	}
+	if len(opt.FilterModel) > 0 &&
+		!containsString(opt.FilterModel, dev.Model) &&
+		!containsString(opt.FilterModel, dev.ModelName) &&
+		!containsString(opt.FilterModel, dev.ProductName) {
+		return false
+	}
+	if len(opt.FilterDEPProfileStatus) > 0 {
+		var found bool
+		for _, status := range opt.FilterDEPProfileStatus {
+			if status == dev.DEPProfileStatus {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if opt.FilterEnrolled != nil && *opt.FilterEnrolled != dev.Enrolled {
+		return false
+	}
+	if len(opt.FilterLifecycle) > 0 {
+		var found bool
+		for _, state := range opt.FilterLifecycle {
+			if state == dev.Lifecycle {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if opt.FilterOSVersionMin != "" && CompareOSVersion(dev.OSVersion, opt.FilterOSVersionMin) < 0 {
+		return false
+	}
+	if opt.FilterOSVersionMax != "" && CompareOSVersion(dev.OSVersion, opt.FilterOSVersionMax) > 0 {
+		return false
+	}
+	if !opt.FilterLastSeenAfter.IsZero() && dev.LastSeen.Before(opt.FilterLastSeenAfter) {
+		return false
+	}
+	if !opt.FilterLastSeenBefore.IsZero() && dev.LastSeen.After(opt.FilterLastSeenBefore) {
+		return false
+	}
+	for key, value := range opt.FilterAttributes {
+		if v, ok := dev.Attributes[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Offset returns the index of the first device on the requested page.
+func (opt ListDevicesOption) Offset() int {
+	if opt.PerPage <= 0 || opt.Page <= 1 {
+		return 0
+	}
+	return (opt.Page - 1) * opt.PerPage
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareOSVersion compares two dotted version strings numerically,
+// returning -1, 0 or 1. Missing components are treated as 0, so
+// "10.14" and "10.14.0" are equal. Components which are not numbers are
+// treated as 0 too.
+func CompareOSVersion(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var va, vb uint64
+		if i < len(pa) {
+			va = osVersionPart(pa[i])
+		}
+		if i < len(pb) {
+			vb = osVersionPart(pb[i])
+		}
+		switch {
+		case va < vb:
+			return -1
+		case va > vb:
+			return 1
+		}
+	}
+	return 0
+}
+
+// OSVersionKey returns a key of the version which compares as a string
+// like CompareOSVersion compares versions. Each component is zero padded
+// to ten digits, and trailing zero components are removed. Stores which
+// can't compare versions with CompareOSVersion filter on the key.
+func OSVersionKey(version string) string {
+	var parts []string
+	for _, p := range strings.Split(version, ".") {
+		parts = append(parts, fmt.Sprintf("%010d", osVersionPart(p)))
+	}
+	for len(parts) > 0 && osVersionPart(parts[len(parts)-1]) == 0 {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, "")
+}
+
+// maxOSVersionPart is the largest version component with ten digits.
+const maxOSVersionPart = 9999999999
+
+// osVersionPart returns the value of a version component with at most ten
+// digits, or 0.
+func osVersionPart(s string) uint64 {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || len(s) > 10 || n > maxOSVersionPart {
+		return 0
+	}
+	return n
 }
 
 type DeviceDTO struct {
-	SerialNumber     string    `json:"serial_number"`
-	UDID             string    `json:"udid"`
-	EnrollmentStatus bool      `json:"enrollment_status"`
-	LastSeen         time.Time `json:"last_seen"`
+	SerialNumber     string           `json:"serial_number"`
+	UDID             string           `json:"udid"`
+	EnrollmentStatus bool             `json:"enrollment_status"`
+	LastSeen         time.Time        `json:"last_seen"`
+	Model            string           `json:"model,omitempty"`
+	ModelName        string           `json:"model_name,omitempty"`
+	ProductName      string           `json:"product_name,omitempty"`
+	OSVersion        string           `json:"os_version,omitempty"`
+	DEPProfileStatus DEPProfileStatus `json:"dep_profile_status,omitempty"`
+	Attributes       Attributes       `json:"attributes,omitempty"`
+	Notes            string           `json:"notes,omitempty"`
+	Lifecycle        LifecycleState   `json:"lifecycle,omitempty"`
+	LifecycleChanged time.Time        `json:"lifecycle_changed,omitempty"`
 }
 
-func (svc *DeviceService) ListDevices(ctx context.Context, opt ListDevicesOption) ([]DeviceDTO, error) {
+func (svc *DeviceService) ListDevices(ctx context.Context, opt ListDevicesOption) ([]DeviceDTO, int, error) {
+	total, err := svc.store.Count(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
 	devices, err := svc.store.List(ctx, opt)
 	var dto []DeviceDTO
 	for _, d := range devices {
@@ -34,14 +198,24 @@ func (svc *DeviceService) ListDevices(ctx context.Context, opt ListDevicesOption
 			UDID:             d.UDID,
 			EnrollmentStatus: d.Enrolled,
 			LastSeen:         d.LastSeen,
+			Model:            d.Model,
+			ModelName:        d.ModelName,
+			ProductName:      d.ProductName,
+			OSVersion:        d.OSVersion,
+			DEPProfileStatus: d.DEPProfileStatus,
+			Attributes:       d.Attributes,
+			Notes:            d.Notes,
+			Lifecycle:        d.Lifecycle,
+			LifecycleChanged: d.LifecycleChanged,
 		})
 	}
-	return dto, err
+	return dto, total, err
 }
 
 type getDevicesRequest struct{ Opts ListDevicesOption }
 type getDevicesResponse struct {
 	Devices []DeviceDTO `json:"devices"`
+	Total   int         `json:"total"`
 	Err     error       `json:"err,omitempty"`
 }
 
@@ -65,19 +239,21 @@ func decodeListDevicesResponse(_ context.Context, r *http.Response) (interface{}
 func MakeListDevicesEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(getDevicesRequest)
-		dto, err := svc.ListDevices(ctx, req.Opts)
+		dto, total, err := svc.ListDevices(ctx, req.Opts)
 		return getDevicesResponse{
 			Devices: dto,
+			Total:   total,
 			Err:     err,
 		}, nil
 	}
 }
 
-func (e Endpoints) ListDevices(ctx context.Context, opts ListDevicesOption) ([]DeviceDTO, error) {
+func (e Endpoints) ListDevices(ctx context.Context, opts ListDevicesOption) ([]DeviceDTO, int, error) {
 	request := getDevicesRequest{opts}
 	response, err := e.ListDevicesEndpoint(ctx, request.Opts)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return response.(getDevicesResponse).Devices, response.(getDevicesResponse).Err
+	resp := response.(getDevicesResponse)
+	return resp.Devices, resp.Total, resp.Err
 }
@@ -0,0 +1,19 @@
+package device
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOSVersionKey(t *testing.T) {
+	versions := []string{"", "0", "9", "10", "10.9", "10.13.6", "10.14", "10.14.0", "10.14.6", "10.14.10", "11.0.1", "12.beta", "12", "007"}
+	for _, a := range versions {
+		for _, b := range versions {
+			want := CompareOSVersion(a, b)
+			have := strings.Compare(OSVersionKey(a), OSVersionKey(b))
+			if have != want {
+				t.Errorf("%q and %q: keys compare %d, versions compare %d", a, b, have, want)
+			}
+		}
+	}
+}
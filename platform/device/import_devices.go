@@ -0,0 +1,216 @@
+package device
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+// ImportDevice pre-stages a device by serial number, usually with data
+// from purchasing before the device arrives. A placeholder record is
+// created for unknown serials, and merged with the device when it enrolls.
+type ImportDevice struct {
+	SerialNumber string `json:"serial_number"`
+	AssetTag     string `json:"asset_tag,omitempty"`
+	Description  string `json:"description,omitempty"`
+
+	// Attributes are merged with the existing attributes of the device.
+	// An attribute with an empty value is removed.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Notes replace the notes of the device if set.
+	Notes *string `json:"notes,omitempty"`
+}
+
+// Import actions.
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+)
+
+type ImportFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type ImportDeviceResult struct {
+	SerialNumber string              `json:"serial_number"`
+	Action       string              `json:"action"`
+	Changes      []ImportFieldChange `json:"changes,omitempty"`
+}
+
+type ImportDevicesResult struct {
+	DryRun  bool                 `json:"dry_run"`
+	Devices []ImportDeviceResult `json:"devices"`
+}
+
+// ImportDevices creates or updates the devices. With dryRun set the
+// changes are computed but nothing is saved.
+func (svc *DeviceService) ImportDevices(ctx context.Context, devices []ImportDevice, dryRun bool) (*ImportDevicesResult, error) {
+	seen := make(map[string]bool)
+	for _, imp := range devices {
+		if imp.SerialNumber == "" {
+			return nil, errors.New("imported device must have a serial number")
+		}
+		if seen[imp.SerialNumber] {
+			return nil, errors.Errorf("serial number %s is imported more than once", imp.SerialNumber)
+		}
+		seen[imp.SerialNumber] = true
+		for key := range imp.Attributes {
+			if err := validAttributeKey(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := &ImportDevicesResult{DryRun: dryRun}
+	for _, imp := range devices {
+		dev, err := svc.store.DeviceBySerial(ctx, imp.SerialNumber)
+		action := ImportUpdate
+		if isNotFound(err) {
+			action = ImportCreate
+			dev = &Device{
+				UUID:         uuid.NewV4().String(),
+				SerialNumber: imp.SerialNumber,
+				Lifecycle:    LifecycleActive,
+			}
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "get device with serial %s", imp.SerialNumber)
+		}
+
+		var changes []ImportFieldChange
+		if action == ImportUpdate && dev.UDID != "" && !dryRun {
+			// the device worker updates enrolled devices concurrently.
+			dev, err = svc.store.UpdateByUDID(ctx, dev.UDID, func(dev *Device) error {
+				if changes = imp.apply(dev); len(changes) == 0 {
+					return errUnchanged
+				}
+				return nil
+			})
+			if err != nil && errors.Cause(err) != errUnchanged {
+				return nil, errors.Wrapf(err, "save imported device %s", imp.SerialNumber)
+			}
+		} else {
+			changes = imp.apply(dev)
+		}
+		if action == ImportUpdate && len(changes) == 0 {
+			action = ImportUnchanged
+		}
+		result.Devices = append(result.Devices, ImportDeviceResult{
+			SerialNumber: imp.SerialNumber,
+			Action:       action,
+			Changes:      changes,
+		})
+		if dryRun || action == ImportUnchanged {
+			continue
+		}
+
+		if dev.UDID == "" {
+			if err := svc.store.Save(ctx, dev); err != nil {
+				return nil, errors.Wrapf(err, "save imported device %s", imp.SerialNumber)
+			}
+		}
+		if svc.publisher != nil {
+			if err := publishDeviceUpdated(ctx, svc.publisher, dev); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// apply updates dev with the imported fields and returns the changes.
+func (imp ImportDevice) apply(dev *Device) []ImportFieldChange {
+	var changes []ImportFieldChange
+	set := func(field string, have *string, want string) {
+		if *have == want {
+			return
+		}
+		changes = append(changes, ImportFieldChange{Field: field, Old: *have, New: want})
+		*have = want
+	}
+	if imp.AssetTag != "" {
+		set("asset_tag", &dev.AssetTag, imp.AssetTag)
+	}
+	if imp.Description != "" {
+		set("description", &dev.Description, imp.Description)
+	}
+	if imp.Notes != nil {
+		set("notes", &dev.Notes, *imp.Notes)
+	}
+
+	keys := make([]string, 0, len(imp.Attributes))
+	for key := range imp.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		have, want := dev.Attributes[key], imp.Attributes[key]
+		if have == want {
+			continue
+		}
+		changes = append(changes, ImportFieldChange{Field: "attributes." + key, Old: have, New: want})
+		if want == "" {
+			delete(dev.Attributes, key)
+			continue
+		}
+		if dev.Attributes == nil {
+			dev.Attributes = make(Attributes)
+		}
+		dev.Attributes[key] = want
+	}
+	return changes
+}
+
+type importDevicesRequest struct {
+	Devices []ImportDevice `json:"devices"`
+	DryRun  bool           `json:"dry_run"`
+}
+
+type importDevicesResponse struct {
+	Result *ImportDevicesResult `json:"result,omitempty"`
+	Err    error                `json:"err,omitempty"`
+}
+
+func (r importDevicesResponse) Failed() error { return r.Err }
+
+func decodeImportDevicesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req importDevicesRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeImportDevicesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp importDevicesResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeImportDevicesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(importDevicesRequest)
+		result, err := svc.ImportDevices(ctx, req.Devices, req.DryRun)
+		return importDevicesResponse{
+			Result: result,
+			Err:    err,
+		}, nil
+	}
+}
+
+func (e Endpoints) ImportDevices(ctx context.Context, devices []ImportDevice, dryRun bool) (*ImportDevicesResult, error) {
+	request := importDevicesRequest{Devices: devices, DryRun: dryRun}
+	response, err := e.ImportDevicesEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(importDevicesResponse)
+	return resp.Result, resp.Err
+}
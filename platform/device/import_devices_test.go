@@ -0,0 +1,121 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// memStore is an in-memory Store keyed by serial number, implementing the
+// methods used to update devices.
+type memStore struct {
+	Store
+	devices map[string]*Device
+}
+
+func (m memStore) DeviceBySerial(ctx context.Context, serial string) (*Device, error) {
+	dev, ok := m.devices[serial]
+	if !ok {
+		return nil, memNotFound{}
+	}
+	copied := *dev
+	return &copied, nil
+}
+
+func (m memStore) DeviceByUDID(ctx context.Context, udid string) (*Device, error) {
+	for _, dev := range m.devices {
+		if dev.UDID == udid {
+			copied := *dev
+			return &copied, nil
+		}
+	}
+	return nil, memNotFound{}
+}
+
+func (m memStore) Save(ctx context.Context, dev *Device) error {
+	m.devices[dev.SerialNumber] = dev
+	return nil
+}
+
+func (m memStore) UpdateByUDID(ctx context.Context, udid string, fn func(*Device) error) (*Device, error) {
+	dev, err := m.DeviceByUDID(ctx, udid)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(dev); err != nil {
+		return nil, err
+	}
+	return dev, m.Save(ctx, dev)
+}
+
+type memNotFound struct{}
+
+func (memNotFound) Error() string  { return "not found" }
+func (memNotFound) NotFound() bool { return true }
+
+func TestImportDevices(t *testing.T) {
+	ctx := context.Background()
+	store := memStore{devices: map[string]*Device{
+		"EXISTING":  {UUID: "1", SerialNumber: "EXISTING", AssetTag: "A1", Attributes: Attributes{"owner": "jane"}},
+		"UNCHANGED": {UUID: "2", SerialNumber: "UNCHANGED", AssetTag: "A2"},
+	}}
+	svc := New(store)
+	devices := []ImportDevice{
+		{SerialNumber: "NEW", AssetTag: "A3", Attributes: map[string]string{"owner": "john"}},
+		{SerialNumber: "EXISTING", AssetTag: "A4", Attributes: map[string]string{"owner": "", "site": "nyc"}},
+		{SerialNumber: "UNCHANGED", AssetTag: "A2"},
+	}
+
+	result, err := svc.ImportDevices(ctx, devices, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, d := range result.Devices {
+		actions = append(actions, d.SerialNumber+":"+d.Action)
+	}
+	if have, want := fmt.Sprint(actions), "[NEW:create EXISTING:update UNCHANGED:unchanged]"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+	if have, want := fmt.Sprint(result.Devices[1].Changes), "[{asset_tag A1 A4} {attributes.owner jane } {attributes.site  nyc}]"; have != want {
+		t.Errorf("have changes %s, want %s", have, want)
+	}
+	if _, ok := store.devices["NEW"]; ok || store.devices["EXISTING"].AssetTag != "A1" {
+		t.Fatal("dry run must not save devices")
+	}
+
+	if _, err := svc.ImportDevices(ctx, devices, false); err != nil {
+		t.Fatal(err)
+	}
+	created := store.devices["NEW"]
+	if created == nil || created.UUID == "" || created.AssetTag != "A3" || created.Attributes["owner"] != "john" {
+		t.Errorf("unexpected placeholder device %+v", created)
+	}
+	updated := store.devices["EXISTING"]
+	if have, want := fmt.Sprint(updated.Attributes), "map[site:nyc]"; updated.AssetTag != "A4" || have != want {
+		t.Errorf("unexpected updated device %+v", updated)
+	}
+
+	if _, err := svc.ImportDevices(ctx, []ImportDevice{{SerialNumber: "X"}, {SerialNumber: "X"}}, true); err == nil {
+		t.Error("expected error for duplicate serial numbers")
+	}
+}
+
+func TestImportEnrolledDevice(t *testing.T) {
+	store := racingStore{memStore{devices: map[string]*Device{
+		"C02ENROLLED": {UUID: "1", UDID: "UDID-1", SerialNumber: "C02ENROLLED", OSVersion: "10.14.6"},
+	}}}
+	svc := New(store)
+
+	_, err := svc.ImportDevices(context.Background(), []ImportDevice{{SerialNumber: "C02ENROLLED", AssetTag: "A1"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := store.devices["C02ENROLLED"]
+	if dev.AssetTag != "A1" {
+		t.Errorf("have asset tag %q, want A1", dev.AssetTag)
+	}
+	if dev.OSVersion != "10.15" {
+		t.Errorf("have os version %s, want the concurrent update kept", dev.OSVersion)
+	}
+}
@@ -1,16 +1,6 @@
-// Code generated by protoc-gen-go.
+// Code generated by protoc-gen-go. DO NOT EDIT.
 // source: device.proto
-// DO NOT EDIT!
 
-/*
-Package deviceproto is a generated protocol buffer package.
-
-It is generated from these files:
-	device.proto
-
-It has these top-level messages:
-	Device
-*/
 package deviceproto
 
 import proto "github.com/golang/protobuf/proto"
@@ -29,41 +19,67 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Device struct {
-	Uuid                   string `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
-	Udid                   string `protobuf:"bytes,2,opt,name=udid" json:"udid,omitempty"`
-	SerialNumber           string `protobuf:"bytes,3,opt,name=serial_number,json=serialNumber" json:"serial_number,omitempty"`
-	OsVersion              string `protobuf:"bytes,4,opt,name=os_version,json=osVersion" json:"os_version,omitempty"`
-	BuildVersion           string `protobuf:"bytes,5,opt,name=build_version,json=buildVersion" json:"build_version,omitempty"`
-	ProductName            string `protobuf:"bytes,6,opt,name=product_name,json=productName" json:"product_name,omitempty"`
-	Imei                   string `protobuf:"bytes,7,opt,name=imei" json:"imei,omitempty"`
-	Meid                   string `protobuf:"bytes,8,opt,name=meid" json:"meid,omitempty"`
-	Token                  string `protobuf:"bytes,9,opt,name=token" json:"token,omitempty"`
-	PushMagic              string `protobuf:"bytes,10,opt,name=push_magic,json=pushMagic" json:"push_magic,omitempty"`
-	MdmTopic               string `protobuf:"bytes,11,opt,name=mdm_topic,json=mdmTopic" json:"mdm_topic,omitempty"`
-	UnlockToken            string `protobuf:"bytes,12,opt,name=unlock_token,json=unlockToken" json:"unlock_token,omitempty"`
-	Enrolled               bool   `protobuf:"varint,13,opt,name=enrolled" json:"enrolled,omitempty"`
-	AwaitingConfiguration  bool   `protobuf:"varint,14,opt,name=awaiting_configuration,json=awaitingConfiguration" json:"awaiting_configuration,omitempty"`
-	DeviceName             string `protobuf:"bytes,15,opt,name=device_name,json=deviceName" json:"device_name,omitempty"`
-	Model                  string `protobuf:"bytes,16,opt,name=model" json:"model,omitempty"`
-	ModelName              string `protobuf:"bytes,17,opt,name=model_name,json=modelName" json:"model_name,omitempty"`
-	Description            string `protobuf:"bytes,18,opt,name=description" json:"description,omitempty"`
-	Color                  string `protobuf:"bytes,19,opt,name=color" json:"color,omitempty"`
-	AssetTag               string `protobuf:"bytes,20,opt,name=asset_tag,json=assetTag" json:"asset_tag,omitempty"`
-	DepDevice              bool   `protobuf:"varint,21,opt,name=dep_device,json=depDevice" json:"dep_device,omitempty"`
-	DepProfileStatus       string `protobuf:"bytes,22,opt,name=dep_profile_status,json=depProfileStatus" json:"dep_profile_status,omitempty"`
-	DepProfileUuid         string `protobuf:"bytes,23,opt,name=dep_profile_uuid,json=depProfileUuid" json:"dep_profile_uuid,omitempty"`
-	DepProfileAssignTime   int64  `protobuf:"varint,24,opt,name=dep_profile_assign_time,json=depProfileAssignTime" json:"dep_profile_assign_time,omitempty"`
-	DepProfilePushTime     int64  `protobuf:"varint,25,opt,name=dep_profile_push_time,json=depProfilePushTime" json:"dep_profile_push_time,omitempty"`
-	DepProfileAssignedDate int64  `protobuf:"varint,26,opt,name=dep_profile_assigned_date,json=depProfileAssignedDate" json:"dep_profile_assigned_date,omitempty"`
-	DepProfileAssignedBy   string `protobuf:"bytes,27,opt,name=dep_profile_assigned_by,json=depProfileAssignedBy" json:"dep_profile_assigned_by,omitempty"`
-	LastSeen               int64  `protobuf:"varint,28,opt,name=last_seen,json=lastSeen" json:"last_seen,omitempty"`
-	LastQueryResponse      []byte `protobuf:"bytes,29,opt,name=last_query_response,json=lastQueryResponse,proto3" json:"last_query_response,omitempty"`
-}
-
-func (m *Device) Reset()                    { *m = Device{} }
-func (m *Device) String() string            { return proto.CompactTextString(m) }
-func (*Device) ProtoMessage()               {}
-func (*Device) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+	Uuid                   string            `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Udid                   string            `protobuf:"bytes,2,opt,name=udid,proto3" json:"udid,omitempty"`
+	SerialNumber           string            `protobuf:"bytes,3,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	OsVersion              string            `protobuf:"bytes,4,opt,name=os_version,json=osVersion,proto3" json:"os_version,omitempty"`
+	BuildVersion           string            `protobuf:"bytes,5,opt,name=build_version,json=buildVersion,proto3" json:"build_version,omitempty"`
+	ProductName            string            `protobuf:"bytes,6,opt,name=product_name,json=productName,proto3" json:"product_name,omitempty"`
+	Imei                   string            `protobuf:"bytes,7,opt,name=imei,proto3" json:"imei,omitempty"`
+	Meid                   string            `protobuf:"bytes,8,opt,name=meid,proto3" json:"meid,omitempty"`
+	Token                  string            `protobuf:"bytes,9,opt,name=token,proto3" json:"token,omitempty"`
+	PushMagic              string            `protobuf:"bytes,10,opt,name=push_magic,json=pushMagic,proto3" json:"push_magic,omitempty"`
+	MdmTopic               string            `protobuf:"bytes,11,opt,name=mdm_topic,json=mdmTopic,proto3" json:"mdm_topic,omitempty"`
+	UnlockToken            string            `protobuf:"bytes,12,opt,name=unlock_token,json=unlockToken,proto3" json:"unlock_token,omitempty"`
+	Enrolled               bool              `protobuf:"varint,13,opt,name=enrolled,proto3" json:"enrolled,omitempty"`
+	AwaitingConfiguration  bool              `protobuf:"varint,14,opt,name=awaiting_configuration,json=awaitingConfiguration,proto3" json:"awaiting_configuration,omitempty"`
+	DeviceName             string            `protobuf:"bytes,15,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	Model                  string            `protobuf:"bytes,16,opt,name=model,proto3" json:"model,omitempty"`
+	ModelName              string            `protobuf:"bytes,17,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Description            string            `protobuf:"bytes,18,opt,name=description,proto3" json:"description,omitempty"`
+	Color                  string            `protobuf:"bytes,19,opt,name=color,proto3" json:"color,omitempty"`
+	AssetTag               string            `protobuf:"bytes,20,opt,name=asset_tag,json=assetTag,proto3" json:"asset_tag,omitempty"`
+	DepDevice              bool              `protobuf:"varint,21,opt,name=dep_device,json=depDevice,proto3" json:"dep_device,omitempty"`
+	DepProfileStatus       string            `protobuf:"bytes,22,opt,name=dep_profile_status,json=depProfileStatus,proto3" json:"dep_profile_status,omitempty"`
+	DepProfileUuid         string            `protobuf:"bytes,23,opt,name=dep_profile_uuid,json=depProfileUuid,proto3" json:"dep_profile_uuid,omitempty"`
+	DepProfileAssignTime   int64             `protobuf:"varint,24,opt,name=dep_profile_assign_time,json=depProfileAssignTime,proto3" json:"dep_profile_assign_time,omitempty"`
+	DepProfilePushTime     int64             `protobuf:"varint,25,opt,name=dep_profile_push_time,json=depProfilePushTime,proto3" json:"dep_profile_push_time,omitempty"`
+	DepProfileAssignedDate int64             `protobuf:"varint,26,opt,name=dep_profile_assigned_date,json=depProfileAssignedDate,proto3" json:"dep_profile_assigned_date,omitempty"`
+	DepProfileAssignedBy   string            `protobuf:"bytes,27,opt,name=dep_profile_assigned_by,json=depProfileAssignedBy,proto3" json:"dep_profile_assigned_by,omitempty"`
+	LastSeen               int64             `protobuf:"varint,28,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	LastQueryResponse      []byte            `protobuf:"bytes,29,opt,name=last_query_response,json=lastQueryResponse,proto3" json:"last_query_response,omitempty"`
+	Attributes             map[string]string `protobuf:"bytes,30,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Notes                  string            `protobuf:"bytes,31,opt,name=notes,proto3" json:"notes,omitempty"`
+	Lifecycle              string            `protobuf:"bytes,32,opt,name=lifecycle,proto3" json:"lifecycle,omitempty"`
+	LifecycleChanged       int64             `protobuf:"varint,33,opt,name=lifecycle_changed,json=lifecycleChanged,proto3" json:"lifecycle_changed,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}          `json:"-"`
+	XXX_unrecognized       []byte            `json:"-"`
+	XXX_sizecache          int32             `json:"-"`
+}
+
+func (m *Device) Reset()         { *m = Device{} }
+func (m *Device) String() string { return proto.CompactTextString(m) }
+func (*Device) ProtoMessage()    {}
+func (*Device) Descriptor() ([]byte, []int) {
+	return fileDescriptor_device_ef83007ddb4ed140, []int{0}
+}
+func (m *Device) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Device.Unmarshal(m, b)
+}
+func (m *Device) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Device.Marshal(b, m, deterministic)
+}
+func (dst *Device) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Device.Merge(dst, src)
+}
+func (m *Device) XXX_Size() int {
+	return xxx_messageInfo_Device.Size(m)
+}
+func (m *Device) XXX_DiscardUnknown() {
+	xxx_messageInfo_Device.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Device proto.InternalMessageInfo
 
 func (m *Device) GetUuid() string {
 	if m != nil {
@@ -268,47 +284,166 @@ func (m *Device) GetLastQueryResponse() []byte {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*Device)(nil), "deviceproto.Device")
+func (m *Device) GetAttributes() map[string]string {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+func (m *Device) GetNotes() string {
+	if m != nil {
+		return m.Notes
+	}
+	return ""
+}
+
+func (m *Device) GetLifecycle() string {
+	if m != nil {
+		return m.Lifecycle
+	}
+	return ""
 }
 
-func init() { proto.RegisterFile("device.proto", fileDescriptor0) }
-
-var fileDescriptor0 = []byte{
-	// 557 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x94, 0x41, 0x4f, 0x1b, 0x31,
-	0x10, 0x85, 0x95, 0x02, 0x21, 0x71, 0x02, 0x0d, 0x26, 0x09, 0x03, 0x14, 0x35, 0x6d, 0x2f, 0x39,
-	0x54, 0x48, 0x55, 0xc5, 0xa1, 0xc7, 0xb6, 0x5c, 0x8b, 0x68, 0x48, 0x7b, 0xb5, 0x9c, 0xf5, 0xb0,
-	0x58, 0xac, 0xed, 0xad, 0xed, 0xa5, 0xca, 0x2f, 0xef, 0xb5, 0xf2, 0x38, 0x90, 0xa8, 0xf4, 0xe6,
-	0xf9, 0xde, 0xbc, 0xf1, 0xcc, 0xac, 0xb5, 0xac, 0xaf, 0xf0, 0x41, 0x17, 0x78, 0x5e, 0x7b, 0x17,
-	0x1d, 0xef, 0xe5, 0x88, 0x82, 0xb7, 0x7f, 0x76, 0x59, 0xfb, 0x92, 0x62, 0xce, 0xd9, 0x76, 0xd3,
-	0x68, 0x05, 0xad, 0x49, 0x6b, 0xda, 0x9d, 0xd1, 0x99, 0x98, 0xd2, 0x0a, 0x5e, 0xac, 0x98, 0xd2,
-	0x8a, 0xbf, 0x63, 0x7b, 0x01, 0xbd, 0x96, 0x95, 0xb0, 0x8d, 0x59, 0xa0, 0x87, 0x2d, 0x12, 0xfb,
-	0x19, 0x5e, 0x11, 0xe3, 0x67, 0x8c, 0xb9, 0x20, 0x1e, 0xd0, 0x07, 0xed, 0x2c, 0x6c, 0x53, 0x46,
-	0xd7, 0x85, 0x9f, 0x19, 0xa4, 0x1a, 0x8b, 0x46, 0x57, 0xea, 0x29, 0x63, 0x27, 0xd7, 0x20, 0xf8,
-	0x98, 0xf4, 0x86, 0xf5, 0x6b, 0xef, 0x54, 0x53, 0x44, 0x61, 0xa5, 0x41, 0x68, 0x53, 0x4e, 0x6f,
-	0xc5, 0xae, 0xa4, 0xa1, 0x9e, 0xb5, 0x41, 0x0d, 0xbb, 0xb9, 0xbf, 0x74, 0x4e, 0xcc, 0xa0, 0x56,
-	0xd0, 0xc9, 0x2c, 0x9d, 0xf9, 0x90, 0xed, 0x44, 0x77, 0x8f, 0x16, 0xba, 0x04, 0x73, 0x90, 0x9a,
-	0xac, 0x9b, 0x70, 0x27, 0x8c, 0x2c, 0x75, 0x01, 0x2c, 0x37, 0x99, 0xc8, 0xb7, 0x04, 0xf8, 0x29,
-	0xeb, 0x1a, 0x65, 0x44, 0x74, 0xb5, 0x2e, 0xa0, 0x47, 0x6a, 0xc7, 0x28, 0x33, 0x4f, 0x71, 0x6a,
-	0xae, 0xb1, 0x95, 0x2b, 0xee, 0x45, 0x2e, 0xdc, 0xcf, 0xcd, 0x65, 0x36, 0xa7, 0xf2, 0x27, 0xac,
-	0x83, 0xd6, 0xbb, 0xaa, 0x42, 0x05, 0x7b, 0x93, 0xd6, 0xb4, 0x33, 0x7b, 0x8a, 0xf9, 0x05, 0x1b,
-	0xcb, 0xdf, 0x52, 0x47, 0x6d, 0x4b, 0x51, 0x38, 0x7b, 0xab, 0xcb, 0xc6, 0xcb, 0x98, 0x36, 0xb1,
-	0x4f, 0x99, 0xa3, 0x47, 0xf5, 0xeb, 0xa6, 0xc8, 0x5f, 0xb3, 0xd5, 0xd7, 0xcb, 0x1b, 0x79, 0x49,
-	0x97, 0xb2, 0x8c, 0x68, 0x21, 0x43, 0xb6, 0x63, 0x9c, 0xc2, 0x0a, 0x06, 0x79, 0x50, 0x0a, 0xd2,
-	0xa0, 0x74, 0xc8, 0xae, 0x83, 0x3c, 0x28, 0x11, 0x32, 0x4d, 0x52, 0xd5, 0x50, 0x78, 0x5d, 0x53,
-	0x07, 0x3c, 0x8f, 0xb2, 0x81, 0x52, 0xd9, 0xc2, 0x55, 0xce, 0xc3, 0x61, 0x2e, 0x4b, 0x41, 0x5a,
-	0x90, 0x0c, 0x01, 0xa3, 0x88, 0xb2, 0x84, 0x61, 0x5e, 0x10, 0x81, 0xb9, 0x2c, 0xd3, 0x9d, 0x0a,
-	0x6b, 0x91, 0x7b, 0x83, 0x11, 0x4d, 0xd5, 0x55, 0x58, 0xaf, 0x5e, 0xdb, 0x7b, 0xc6, 0x93, 0x5c,
-	0x7b, 0x77, 0xab, 0x2b, 0x14, 0x21, 0xca, 0xd8, 0x04, 0x18, 0x53, 0x91, 0x81, 0xc2, 0xfa, 0x3a,
-	0x0b, 0x37, 0xc4, 0xf9, 0x94, 0x0d, 0x36, 0xb3, 0xe9, 0x9d, 0x1e, 0x51, 0xee, 0xfe, 0x3a, 0xf7,
-	0x47, 0x7a, 0xb1, 0x17, 0xec, 0x68, 0x33, 0x53, 0x86, 0xa0, 0x4b, 0x2b, 0xa2, 0x36, 0x08, 0x30,
-	0x69, 0x4d, 0xb7, 0x66, 0xc3, 0xb5, 0xe1, 0x33, 0x89, 0x73, 0x6d, 0x90, 0x7f, 0x60, 0xa3, 0x4d,
-	0x1b, 0x3d, 0x0b, 0x32, 0x1d, 0x93, 0x89, 0xaf, 0x4d, 0xd7, 0x4d, 0xb8, 0x23, 0xcb, 0x27, 0x76,
-	0xfc, 0xfc, 0x26, 0x54, 0x42, 0xc9, 0x88, 0x70, 0x42, 0xb6, 0xf1, 0xbf, 0x77, 0xa1, 0xba, 0x94,
-	0x11, 0xff, 0xdf, 0x24, 0x2a, 0xb1, 0x58, 0xc2, 0x29, 0x4d, 0x35, 0x7c, 0x6e, 0xfc, 0xb2, 0x4c,
-	0xfb, 0xae, 0x64, 0x88, 0x22, 0x20, 0x5a, 0x78, 0x45, 0x37, 0x74, 0x12, 0xb8, 0x41, 0xb4, 0xfc,
-	0x9c, 0x1d, 0x92, 0xf8, 0xab, 0x41, 0xbf, 0x14, 0x1e, 0x43, 0xed, 0x6c, 0x40, 0x38, 0x9b, 0xb4,
-	0xa6, 0xfd, 0xd9, 0x41, 0x92, 0xbe, 0x27, 0x65, 0xb6, 0x12, 0x16, 0x6d, 0xfa, 0x01, 0x7c, 0xfc,
-	0x1b, 0x00, 0x00, 0xff, 0xff, 0x9b, 0x06, 0xcd, 0x66, 0x1d, 0x04, 0x00, 0x00,
+func (m *Device) GetLifecycleChanged() int64 {
+	if m != nil {
+		return m.LifecycleChanged
+	}
+	return 0
+}
+
+type LifecycleEvent struct {
+	Udid                 string   `protobuf:"bytes,1,opt,name=udid,proto3" json:"udid,omitempty"`
+	SerialNumber         string   `protobuf:"bytes,2,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	From                 string   `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To                   string   `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Time                 int64    `protobuf:"varint,5,opt,name=time,proto3" json:"time,omitempty"`
+	LastSeen             int64    `protobuf:"varint,6,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LifecycleEvent) Reset()         { *m = LifecycleEvent{} }
+func (m *LifecycleEvent) String() string { return proto.CompactTextString(m) }
+func (*LifecycleEvent) ProtoMessage()    {}
+func (*LifecycleEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_device_ef83007ddb4ed140, []int{1}
+}
+func (m *LifecycleEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LifecycleEvent.Unmarshal(m, b)
+}
+func (m *LifecycleEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LifecycleEvent.Marshal(b, m, deterministic)
+}
+func (dst *LifecycleEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LifecycleEvent.Merge(dst, src)
+}
+func (m *LifecycleEvent) XXX_Size() int {
+	return xxx_messageInfo_LifecycleEvent.Size(m)
+}
+func (m *LifecycleEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_LifecycleEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LifecycleEvent proto.InternalMessageInfo
+
+func (m *LifecycleEvent) GetUdid() string {
+	if m != nil {
+		return m.Udid
+	}
+	return ""
+}
+
+func (m *LifecycleEvent) GetSerialNumber() string {
+	if m != nil {
+		return m.SerialNumber
+	}
+	return ""
+}
+
+func (m *LifecycleEvent) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *LifecycleEvent) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *LifecycleEvent) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *LifecycleEvent) GetLastSeen() int64 {
+	if m != nil {
+		return m.LastSeen
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Device)(nil), "deviceproto.Device")
+	proto.RegisterMapType((map[string]string)(nil), "deviceproto.Device.AttributesEntry")
+	proto.RegisterType((*LifecycleEvent)(nil), "deviceproto.LifecycleEvent")
+}
+
+func init() { proto.RegisterFile("device.proto", fileDescriptor_device_ef83007ddb4ed140) }
+
+var fileDescriptor_device_ef83007ddb4ed140 = []byte{
+	// 724 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0x5f, 0x4f, 0x23, 0x37,
+	0x10, 0xd7, 0x26, 0x24, 0x4d, 0x9c, 0x10, 0x82, 0x09, 0x60, 0xfe, 0x95, 0x00, 0x2f, 0x91, 0x5a,
+	0x45, 0x6a, 0x2b, 0xa4, 0xb6, 0x52, 0x1f, 0x28, 0xf0, 0xd6, 0x22, 0x2e, 0xe4, 0xee, 0xd5, 0x72,
+	0xd6, 0x93, 0x60, 0xb1, 0x6b, 0xef, 0xad, 0xbd, 0x39, 0xe5, 0xcb, 0xdc, 0x27, 0xbb, 0x0f, 0x73,
+	0xf2, 0x78, 0x49, 0xc2, 0x1f, 0xdd, 0xdb, 0xcc, 0xef, 0x37, 0xbf, 0xf1, 0xcc, 0x78, 0x34, 0xa4,
+	0x2d, 0x61, 0xae, 0x62, 0x18, 0x66, 0xb9, 0x71, 0x86, 0xb6, 0x82, 0x87, 0xce, 0xf9, 0xb7, 0x26,
+	0xa9, 0xdf, 0xa0, 0x4f, 0x29, 0xd9, 0x28, 0x0a, 0x25, 0x59, 0xd4, 0x8f, 0x06, 0xcd, 0x11, 0xda,
+	0x88, 0x49, 0x25, 0x59, 0xa5, 0xc4, 0xa4, 0x92, 0xf4, 0x82, 0x6c, 0x5a, 0xc8, 0x95, 0x48, 0xb8,
+	0x2e, 0xd2, 0x09, 0xe4, 0xac, 0x8a, 0x64, 0x3b, 0x80, 0x77, 0x88, 0xd1, 0x13, 0x42, 0x8c, 0xe5,
+	0x73, 0xc8, 0xad, 0x32, 0x9a, 0x6d, 0x60, 0x44, 0xd3, 0xd8, 0x4f, 0x01, 0xf0, 0x39, 0x26, 0x85,
+	0x4a, 0xe4, 0x32, 0xa2, 0x16, 0x72, 0x20, 0xf8, 0x1c, 0x74, 0x46, 0xda, 0x59, 0x6e, 0x64, 0x11,
+	0x3b, 0xae, 0x45, 0x0a, 0xac, 0x8e, 0x31, 0xad, 0x12, 0xbb, 0x13, 0x29, 0xd6, 0xac, 0x52, 0x50,
+	0xec, 0xa7, 0x50, 0x9f, 0xb7, 0x3d, 0x96, 0x82, 0x92, 0xac, 0x11, 0x30, 0x6f, 0xd3, 0x1e, 0xa9,
+	0x39, 0xf3, 0x04, 0x9a, 0x35, 0x11, 0x0c, 0x8e, 0x2f, 0x32, 0x2b, 0xec, 0x23, 0x4f, 0xc5, 0x4c,
+	0xc5, 0x8c, 0x84, 0x22, 0x3d, 0xf2, 0xbf, 0x07, 0xe8, 0x11, 0x69, 0xa6, 0x32, 0xe5, 0xce, 0x64,
+	0x2a, 0x66, 0x2d, 0x64, 0x1b, 0xa9, 0x4c, 0xc7, 0xde, 0xf7, 0xc5, 0x15, 0x3a, 0x31, 0xf1, 0x13,
+	0x0f, 0x89, 0xdb, 0xa1, 0xb8, 0x80, 0x8d, 0x31, 0xfd, 0x21, 0x69, 0x80, 0xce, 0x4d, 0x92, 0x80,
+	0x64, 0x9b, 0xfd, 0x68, 0xd0, 0x18, 0x2d, 0x7d, 0x7a, 0x49, 0xf6, 0xc4, 0x17, 0xa1, 0x9c, 0xd2,
+	0x33, 0x1e, 0x1b, 0x3d, 0x55, 0xb3, 0x22, 0x17, 0xce, 0x4f, 0xa2, 0x83, 0x91, 0xbb, 0xcf, 0xec,
+	0xf5, 0x3a, 0x49, 0x4f, 0x49, 0xf9, 0x7b, 0x61, 0x22, 0x5b, 0xf8, 0x28, 0x09, 0x10, 0x0e, 0xa4,
+	0x47, 0x6a, 0xa9, 0x91, 0x90, 0xb0, 0x6e, 0x68, 0x14, 0x1d, 0xdf, 0x28, 0x1a, 0x41, 0xb5, 0x1d,
+	0x1a, 0x45, 0x04, 0x45, 0x7d, 0x9f, 0xd5, 0xc6, 0xb9, 0xca, 0xb0, 0x02, 0x1a, 0x5a, 0x59, 0x83,
+	0x7c, 0xda, 0xd8, 0x24, 0x26, 0x67, 0x3b, 0x21, 0x2d, 0x3a, 0x7e, 0x40, 0xc2, 0x5a, 0x70, 0xdc,
+	0x89, 0x19, 0xeb, 0x85, 0x01, 0x21, 0x30, 0x16, 0x33, 0xff, 0xa6, 0x84, 0x8c, 0x87, 0xda, 0xd8,
+	0x2e, 0x76, 0xd5, 0x94, 0x90, 0x95, 0xdb, 0xf6, 0x2b, 0xa1, 0x9e, 0xce, 0x72, 0x33, 0x55, 0x09,
+	0x70, 0xeb, 0x84, 0x2b, 0x2c, 0xdb, 0xc3, 0x24, 0x5d, 0x09, 0xd9, 0x7d, 0x20, 0x1e, 0x10, 0xa7,
+	0x03, 0xd2, 0x5d, 0x8f, 0xc6, 0x3d, 0xdd, 0xc7, 0xd8, 0xce, 0x2a, 0xf6, 0xa3, 0xdf, 0xd8, 0x4b,
+	0xb2, 0xbf, 0x1e, 0x29, 0xac, 0x55, 0x33, 0xcd, 0x9d, 0x4a, 0x81, 0xb1, 0x7e, 0x34, 0xa8, 0x8e,
+	0x7a, 0x2b, 0xc1, 0x15, 0x92, 0x63, 0x95, 0x02, 0xfd, 0x8d, 0xec, 0xae, 0xcb, 0x70, 0x2d, 0x50,
+	0x74, 0x80, 0x22, 0xba, 0x12, 0xdd, 0x17, 0xf6, 0x11, 0x25, 0x7f, 0x91, 0x83, 0xb7, 0x2f, 0x81,
+	0xe4, 0x52, 0x38, 0x60, 0x87, 0x28, 0xdb, 0x7b, 0xfd, 0x16, 0xc8, 0x1b, 0xe1, 0xe0, 0xfd, 0x22,
+	0x41, 0xf2, 0xc9, 0x82, 0x1d, 0x61, 0x57, 0xbd, 0xb7, 0xc2, 0x7f, 0x17, 0x7e, 0xde, 0x89, 0xb0,
+	0x8e, 0x5b, 0x00, 0xcd, 0x8e, 0xf1, 0x85, 0x86, 0x07, 0x1e, 0x00, 0x34, 0x1d, 0x92, 0x1d, 0x24,
+	0x3f, 0x17, 0x90, 0x2f, 0x78, 0x0e, 0x36, 0x33, 0xda, 0x02, 0x3b, 0xe9, 0x47, 0x83, 0xf6, 0x68,
+	0xdb, 0x53, 0x1f, 0x3c, 0x33, 0x2a, 0x09, 0x7a, 0x4d, 0x88, 0x70, 0x2e, 0x57, 0x93, 0xc2, 0x81,
+	0x65, 0x3f, 0xf7, 0xab, 0x83, 0xd6, 0xef, 0x17, 0xc3, 0xb5, 0xdb, 0x30, 0x0c, 0x3f, 0x35, 0xbc,
+	0x5a, 0x46, 0xdd, 0x6a, 0x97, 0x2f, 0x46, 0x6b, 0x32, 0xbf, 0x17, 0xda, 0x78, 0xfd, 0x69, 0xd8,
+	0x0b, 0x74, 0xe8, 0x31, 0x69, 0x26, 0x6a, 0x0a, 0xf1, 0x22, 0x4e, 0x80, 0xf5, 0x91, 0x59, 0x01,
+	0xf4, 0x17, 0xb2, 0xbd, 0x74, 0x78, 0xfc, 0x28, 0xf4, 0x0c, 0x24, 0x3b, 0xc3, 0x6e, 0xba, 0x4b,
+	0xe2, 0x3a, 0xe0, 0x87, 0xff, 0x90, 0xad, 0x57, 0xef, 0xd3, 0x2e, 0xa9, 0x3e, 0xc1, 0xa2, 0x3c,
+	0x53, 0xde, 0xf4, 0x55, 0xcc, 0x45, 0x52, 0x40, 0x79, 0xa6, 0x82, 0xf3, 0x77, 0xe5, 0xcf, 0xe8,
+	0xfc, 0x6b, 0x44, 0x3a, 0xff, 0x3d, 0xe7, 0xbc, 0x9d, 0x83, 0x76, 0xcb, 0x93, 0x16, 0xfd, 0xe8,
+	0xa4, 0x55, 0xde, 0x39, 0x69, 0x94, 0x6c, 0x4c, 0x73, 0x93, 0x96, 0xe7, 0x0e, 0x6d, 0xda, 0x21,
+	0x15, 0x67, 0xca, 0xf3, 0x56, 0x71, 0xc6, 0xc7, 0xe0, 0xd6, 0xd4, 0xb0, 0x1d, 0xb4, 0x5f, 0xfe,
+	0x5a, 0xfd, 0xe5, 0xaf, 0x4d, 0xea, 0x38, 0xea, 0x3f, 0xbe, 0x0f, 0x00, 0xd2, 0x65, 0x92, 0x8a,
+	0xa3, 0x05, 0x00, 0x00,
 }
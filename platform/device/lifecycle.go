@@ -0,0 +1,265 @@
+package device
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/apns"
+	"github.com/micromdm/micromdm/platform/device/internal/deviceproto"
+	"github.com/micromdm/micromdm/platform/pubsub"
+)
+
+// LifecycleChangedTopic is the topic of the LifecycleEvent published when
+// the lifecycle state of a device changes.
+const LifecycleChangedTopic = "mdm.DeviceLifecycleChanged"
+
+// LifecycleState is the lifecycle state of a device.
+type LifecycleState string
+
+// LifecycleState values.
+// Devices are active while they check in. The LifecycleMonitor marks them
+// stale, then missing, when they stop checking in. Retired and wiped are
+// set by administrators, or when a device unenrolls.
+const (
+	LifecycleActive  LifecycleState = "active"
+	LifecycleStale   LifecycleState = "stale"
+	LifecycleMissing LifecycleState = "missing"
+	LifecycleRetired LifecycleState = "retired"
+	LifecycleWiped   LifecycleState = "wiped"
+)
+
+// Valid reports whether s is a known lifecycle state.
+func (s LifecycleState) Valid() bool {
+	switch s {
+	case LifecycleActive, LifecycleStale, LifecycleMissing, LifecycleRetired, LifecycleWiped:
+		return true
+	}
+	return false
+}
+
+type LifecycleEvent struct {
+	UDID         string
+	SerialNumber string
+	From         LifecycleState
+	To           LifecycleState
+	Time         time.Time
+	LastSeen     time.Time
+}
+
+func MarshalLifecycleEvent(e *LifecycleEvent) ([]byte, error) {
+	return proto.Marshal(&deviceproto.LifecycleEvent{
+		Udid:         e.UDID,
+		SerialNumber: e.SerialNumber,
+		From:         string(e.From),
+		To:           string(e.To),
+		Time:         timeToNano(e.Time),
+		LastSeen:     timeToNano(e.LastSeen),
+	})
+}
+
+func UnmarshalLifecycleEvent(data []byte, e *LifecycleEvent) error {
+	var pb deviceproto.LifecycleEvent
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return errors.Wrap(err, "unmarshal proto to LifecycleEvent")
+	}
+	e.UDID = pb.GetUdid()
+	e.SerialNumber = pb.GetSerialNumber()
+	e.From = LifecycleState(pb.GetFrom())
+	e.To = LifecycleState(pb.GetTo())
+	e.Time = timeFromNano(pb.GetTime())
+	e.LastSeen = timeFromNano(pb.GetLastSeen())
+	return nil
+}
+
+// setLifecycle changes the lifecycle state of the device and returns the
+// event to publish, or nil if the state is unchanged.
+func setLifecycle(dev *Device, state LifecycleState, now time.Time) *LifecycleEvent {
+	from := dev.Lifecycle
+	if from == "" {
+		from = LifecycleActive
+	}
+	if from == state {
+		dev.Lifecycle = state
+		return nil
+	}
+	dev.Lifecycle = state
+	dev.LifecycleChanged = now
+	return &LifecycleEvent{
+		UDID:         dev.UDID,
+		SerialNumber: dev.SerialNumber,
+		From:         from,
+		To:           state,
+		Time:         now,
+		LastSeen:     dev.LastSeen,
+	}
+}
+
+func publishLifecycleChanged(ctx context.Context, pub pubsub.Publisher, event *LifecycleEvent) error {
+	if event == nil {
+		return nil
+	}
+	msg, err := MarshalLifecycleEvent(event)
+	if err != nil {
+		return errors.Wrap(err, "marshal lifecycle event")
+	}
+	err = pub.Publish(ctx, LifecycleChangedTopic, msg)
+	return errors.Wrap(err, "publish lifecycle event")
+}
+
+const defaultLifecycleCheckInterval = 15 * time.Minute
+
+// LifecycleConfig configures the LifecycleMonitor.
+type LifecycleConfig struct {
+	// Devices which have not checked in for StaleAfter are marked stale,
+	// and missing after MissingAfter. A zero duration disables the state.
+	StaleAfter   time.Duration
+	MissingAfter time.Duration
+
+	// PushStale sends an APNs push to a device when it becomes stale,
+	// to wake it up.
+	PushStale bool
+
+	// CheckInterval is how often devices are checked.
+	// Defaults to 15 minutes.
+	CheckInterval time.Duration
+}
+
+type Pusher interface {
+	Push(ctx context.Context, udid string, opts ...apns.PushOption) (string, error)
+}
+
+type LifecycleStore interface {
+	List(ctx context.Context, opt ListDevicesOption) ([]Device, error)
+	UpdateByUDID(ctx context.Context, udid string, fn func(*Device) error) (*Device, error)
+}
+
+// errUnchanged is returned by the update functions of devices which don't
+// need to be saved.
+var errUnchanged = errors.New("device unchanged")
+
+// LifecycleMonitor marks devices stale or missing when they stop checking in.
+type LifecycleMonitor struct {
+	config LifecycleConfig
+	store  LifecycleStore
+	pub    pubsub.Publisher
+	pusher Pusher
+	logger log.Logger
+	now    func() time.Time
+}
+
+// NewLifecycleMonitor creates a LifecycleMonitor. The pusher is only
+// used when config.PushStale is set.
+func NewLifecycleMonitor(config LifecycleConfig, store LifecycleStore, pub pubsub.Publisher, pusher Pusher, logger log.Logger) *LifecycleMonitor {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaultLifecycleCheckInterval
+	}
+	return &LifecycleMonitor{
+		config: config,
+		store:  store,
+		pub:    pub,
+		pusher: pusher,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Enabled reports whether a stale or missing threshold is configured.
+func (m *LifecycleMonitor) Enabled() bool {
+	return m.config.StaleAfter > 0 || m.config.MissingAfter > 0
+}
+
+func (m *LifecycleMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.check(ctx); err != nil {
+			level.Info(m.logger).Log(
+				"msg", "check device lifecycle",
+				"err", err,
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// state returns the lifecycle state for a device last seen at lastSeen.
+func (m *LifecycleMonitor) state(lastSeen time.Time) LifecycleState {
+	since := m.now().Sub(lastSeen)
+	switch {
+	case m.config.MissingAfter > 0 && since >= m.config.MissingAfter:
+		return LifecycleMissing
+	case m.config.StaleAfter > 0 && since >= m.config.StaleAfter:
+		return LifecycleStale
+	}
+	return LifecycleActive
+}
+
+func (m *LifecycleMonitor) check(ctx context.Context) error {
+	threshold := m.config.StaleAfter
+	if threshold <= 0 || (m.config.MissingAfter > 0 && m.config.MissingAfter < threshold) {
+		threshold = m.config.MissingAfter
+	}
+	enrolled := true
+	devices, err := m.store.List(ctx, ListDevicesOption{
+		FilterEnrolled:       &enrolled,
+		FilterLifecycle:      []LifecycleState{LifecycleActive, LifecycleStale},
+		FilterLastSeenBefore: m.now().Add(-threshold),
+	})
+	if err != nil {
+		return errors.Wrap(err, "list devices for lifecycle check")
+	}
+	for _, listed := range devices {
+		if listed.LastSeen.IsZero() {
+			continue
+		}
+		// the device is read again, because it may have checked in or
+		// changed since it was listed.
+		var event *LifecycleEvent
+		dev, err := m.store.UpdateByUDID(ctx, listed.UDID, func(dev *Device) error {
+			if dev.LastSeen.IsZero() || (dev.Lifecycle != LifecycleActive && dev.Lifecycle != LifecycleStale) {
+				return errUnchanged
+			}
+			if event = setLifecycle(dev, m.state(dev.LastSeen), m.now()); event == nil {
+				return errUnchanged
+			}
+			return nil
+		})
+		if errors.Cause(err) == errUnchanged || isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "save lifecycle for device %s", listed.UDID)
+		}
+		if err := publishDeviceUpdated(ctx, m.pub, dev); err != nil {
+			return err
+		}
+		if err := publishLifecycleChanged(ctx, m.pub, event); err != nil {
+			return err
+		}
+		level.Debug(m.logger).Log(
+			"msg", "device lifecycle changed",
+			"device_udid", dev.UDID,
+			"from", event.From,
+			"to", event.To,
+		)
+		if event.To == LifecycleStale && m.config.PushStale && m.pusher != nil {
+			if _, err := m.pusher.Push(ctx, dev.UDID); err != nil {
+				level.Info(m.logger).Log(
+					"msg", "push stale device",
+					"device_udid", dev.UDID,
+					"err", err,
+				)
+			}
+		}
+	}
+	return nil
+}
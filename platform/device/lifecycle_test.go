@@ -0,0 +1,182 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/micromdm/micromdm/platform/apns"
+	"github.com/micromdm/micromdm/platform/pubsub/inmem"
+)
+
+type mockLifecycleStore map[string]*Device
+
+func (m mockLifecycleStore) List(ctx context.Context, opt ListDevicesOption) ([]Device, error) {
+	var devices []Device
+	for _, dev := range m {
+		if opt.Match(*dev) {
+			devices = append(devices, *dev)
+		}
+	}
+	return devices, nil
+}
+
+func (m mockLifecycleStore) UpdateByUDID(ctx context.Context, udid string, fn func(*Device) error) (*Device, error) {
+	stored, ok := m[udid]
+	if !ok {
+		return nil, memNotFound{}
+	}
+	dev := *stored
+	if err := fn(&dev); err != nil {
+		return nil, err
+	}
+	m[udid] = &dev
+	return &dev, nil
+}
+
+type mockPusher []string
+
+func (m *mockPusher) Push(ctx context.Context, udid string, opts ...apns.PushOption) (string, error) {
+	*m = append(*m, udid)
+	return "", nil
+}
+
+func TestLifecycleMonitor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := mockLifecycleStore{
+		"active":  {UDID: "active", Enrolled: true, Lifecycle: LifecycleActive, LastSeen: now.Add(-time.Hour)},
+		"stale":   {UDID: "stale", Enrolled: true, Lifecycle: LifecycleActive, LastSeen: now.Add(-48 * time.Hour)},
+		"missing": {UDID: "missing", Enrolled: true, Lifecycle: LifecycleStale, LastSeen: now.Add(-60 * 24 * time.Hour)},
+		"retired": {UDID: "retired", Enrolled: true, Lifecycle: LifecycleRetired, LastSeen: now.Add(-60 * 24 * time.Hour)},
+	}
+	ps := inmem.NewPubSub()
+	events, err := ps.Subscribe(ctx, "test", LifecycleChangedTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pusher := new(mockPusher)
+	config := LifecycleConfig{
+		StaleAfter:   24 * time.Hour,
+		MissingAfter: 30 * 24 * time.Hour,
+		PushStale:    true,
+	}
+	monitor := NewLifecycleMonitor(config, store, ps, pusher, log.NewNopLogger())
+	monitor.now = func() time.Time { return now }
+
+	if err := monitor.check(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for udid, want := range map[string]LifecycleState{
+		"active":  LifecycleActive,
+		"stale":   LifecycleStale,
+		"missing": LifecycleMissing,
+		"retired": LifecycleRetired,
+	} {
+		if have := store[udid].Lifecycle; have != want {
+			t.Errorf("%s: have lifecycle %s, want %s", udid, have, want)
+		}
+	}
+	if have, want := fmt.Sprint(*pusher), "[stale]"; have != want {
+		t.Errorf("have pushes %s, want %s", have, want)
+	}
+
+	var changes []string
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-events:
+			var event LifecycleEvent
+			if err := UnmarshalLifecycleEvent(ev.Message, &event); err != nil {
+				t.Fatal(err)
+			}
+			changes = append(changes, fmt.Sprintf("%s:%s->%s", event.UDID, event.From, event.To))
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for lifecycle event")
+		}
+	}
+	sort.Strings(changes)
+	if have, want := fmt.Sprint(changes), "[missing:stale->missing stale:active->stale]"; have != want {
+		t.Errorf("have events %s, want %s", have, want)
+	}
+}
+
+func TestMarkSeen(t *testing.T) {
+	now := time.Now()
+	dev := &Device{UDID: "foo", Lifecycle: LifecycleMissing}
+	event := markSeen(dev, now)
+	if event == nil || event.From != LifecycleMissing || event.To != LifecycleActive {
+		t.Fatalf("expected missing->active event, got %+v", event)
+	}
+	if !dev.LastSeen.Equal(now) || !dev.LifecycleChanged.Equal(now) {
+		t.Errorf("expected last seen and lifecycle change times to be updated")
+	}
+
+	// retired devices stay retired until they enroll again.
+	dev.Lifecycle = LifecycleRetired
+	if event := markSeen(dev, now); event != nil || dev.Lifecycle != LifecycleRetired {
+		t.Errorf("expected retired device to stay retired, got %s", dev.Lifecycle)
+	}
+}
+
+// listedStore returns the devices as they were before they checked in.
+type listedStore struct {
+	mockLifecycleStore
+	listed []Device
+}
+
+func (s listedStore) List(ctx context.Context, opt ListDevicesOption) ([]Device, error) {
+	return s.listed, nil
+}
+
+func TestLifecycleCheckRereadsDevice(t *testing.T) {
+	now := time.Now()
+	stale := now.Add(-48 * time.Hour)
+	store := listedStore{
+		mockLifecycleStore: mockLifecycleStore{
+			"seen": {UDID: "seen", Enrolled: true, Lifecycle: LifecycleActive, LastSeen: now, Token: "new"},
+		},
+		listed: []Device{{UDID: "seen", Enrolled: true, Lifecycle: LifecycleActive, LastSeen: stale, Token: "old"}},
+	}
+	monitor := NewLifecycleMonitor(LifecycleConfig{StaleAfter: 24 * time.Hour}, store, inmem.NewPubSub(), nil, log.NewNopLogger())
+	monitor.now = func() time.Time { return now }
+	if err := monitor.check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	dev := store.mockLifecycleStore["seen"]
+	if dev.Lifecycle != LifecycleActive || !dev.LastSeen.Equal(now) || dev.Token != "new" {
+		t.Errorf("device which checked in after it was listed must be kept, have %+v", dev)
+	}
+}
+
+func TestSetDeviceLifecycle(t *testing.T) {
+	store := racingStore{memStore{devices: map[string]*Device{
+		"C02ENROLLED": {UUID: "1", UDID: "UDID-1", SerialNumber: "C02ENROLLED", OSVersion: "10.14.6", Lifecycle: LifecycleActive},
+		"C02DEP":      {UUID: "2", SerialNumber: "C02DEP", Lifecycle: LifecycleActive},
+	}}}
+	svc := New(store)
+
+	opt := SetLifecycleOptions{UDIDs: []string{"UDID-1"}, Serials: []string{"C02DEP"}, State: LifecycleRetired}
+	if err := svc.SetDeviceLifecycle(context.Background(), opt); err != nil {
+		t.Fatal(err)
+	}
+	enrolled := store.devices["C02ENROLLED"]
+	if enrolled.Lifecycle != LifecycleRetired || enrolled.OSVersion != "10.15" {
+		t.Errorf("want device retired with the concurrent update kept, have %+v", enrolled)
+	}
+	if have := store.devices["C02DEP"].Lifecycle; have != LifecycleRetired {
+		t.Errorf("have lifecycle %s for the device without a UDID, want %s", have, LifecycleRetired)
+	}
+
+	opt = SetLifecycleOptions{UDIDs: []string{"UDID-1", "UDID-NONE"}, State: LifecycleActive}
+	if err := svc.SetDeviceLifecycle(context.Background(), opt); err == nil {
+		t.Error("want error for an unknown device")
+	}
+	if have := store.devices["C02ENROLLED"].Lifecycle; have != LifecycleRetired {
+		t.Errorf("have lifecycle %s, want no device changed when one is unknown", have)
+	}
+}
@@ -46,41 +46,57 @@ func columns() []string {
 		"dep_profile_assigned_date",
 		"dep_profile_assigned_by",
 		"last_seen",
+		"attributes",
+		"notes",
+		"lifecycle",
+		"lifecycle_changed",
 	}
 }
 
 const tableName = "devices"
 
 func (d *Postgres) Save(ctx context.Context, device *device.Device) error {
+	return save(ctx, d.db, device)
+}
+
+func save(ctx context.Context, db sqlx.ExecerContext, dev *device.Device) error {
+	// the key of the version is only used to filter devices, and isn't
+	// read back.
+	osVersionKey := device.OSVersionKey(dev.OSVersion)
 	updateQuery, _, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
 		Update(tableName).
 		Prefix("ON CONFLICT (uuid) DO").
-		Set("uuid", device.UUID).
-		Set("udid", device.UDID).
-		Set("serial_number", device.SerialNumber).
-		Set("os_version", device.OSVersion).
-		Set("build_version", device.BuildVersion).
-		Set("product_name", device.ProductName).
-		Set("imei", device.IMEI).
-		Set("meid", device.MEID).
-		Set("push_magic", device.PushMagic).
-		Set("awaiting_configuration", device.AwaitingConfiguration).
-		Set("token", device.Token).
-		Set("unlock_token", device.UnlockToken).
-		Set("enrolled", device.Enrolled).
-		Set("description", device.Description).
-		Set("model", device.Model).
-		Set("model_name", device.ModelName).
-		Set("device_name", device.DeviceName).
-		Set("color", device.Color).
-		Set("asset_tag", device.AssetTag).
-		Set("dep_profile_status", device.DEPProfileStatus).
-		Set("dep_profile_uuid", device.DEPProfileUUID).
-		Set("dep_profile_assign_time", device.DEPProfileAssignTime).
-		Set("dep_profile_push_time", device.DEPProfilePushTime).
-		Set("dep_profile_assigned_date", device.DEPProfileAssignedDate).
-		Set("dep_profile_assigned_by", device.DEPProfileAssignedBy).
-		Set("last_seen", device.LastSeen).
+		Set("uuid", dev.UUID).
+		Set("udid", dev.UDID).
+		Set("serial_number", dev.SerialNumber).
+		Set("os_version", dev.OSVersion).
+		Set("os_version_key", osVersionKey).
+		Set("build_version", dev.BuildVersion).
+		Set("product_name", dev.ProductName).
+		Set("imei", dev.IMEI).
+		Set("meid", dev.MEID).
+		Set("push_magic", dev.PushMagic).
+		Set("awaiting_configuration", dev.AwaitingConfiguration).
+		Set("token", dev.Token).
+		Set("unlock_token", dev.UnlockToken).
+		Set("enrolled", dev.Enrolled).
+		Set("description", dev.Description).
+		Set("model", dev.Model).
+		Set("model_name", dev.ModelName).
+		Set("device_name", dev.DeviceName).
+		Set("color", dev.Color).
+		Set("asset_tag", dev.AssetTag).
+		Set("dep_profile_status", dev.DEPProfileStatus).
+		Set("dep_profile_uuid", dev.DEPProfileUUID).
+		Set("dep_profile_assign_time", dev.DEPProfileAssignTime).
+		Set("dep_profile_push_time", dev.DEPProfilePushTime).
+		Set("dep_profile_assigned_date", dev.DEPProfileAssignedDate).
+		Set("dep_profile_assigned_by", dev.DEPProfileAssignedBy).
+		Set("last_seen", dev.LastSeen).
+		Set("attributes", dev.Attributes).
+		Set("notes", dev.Notes).
+		Set("lifecycle", dev.Lifecycle).
+		Set("lifecycle_changed", dev.LifecycleChanged).
 		ToSql()
 	if err != nil {
 		return errors.Wrap(err, "building update query for device save")
@@ -89,34 +105,39 @@ func (d *Postgres) Save(ctx context.Context, device *device.Device) error {
 
 	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
 		Insert(tableName).
-		Columns(columns()...).
+		Columns(append(columns(), "os_version_key")...).
 		Values(
-			device.UUID,
-			device.UDID,
-			device.SerialNumber,
-			device.OSVersion,
-			device.BuildVersion,
-			device.ProductName,
-			device.IMEI,
-			device.MEID,
-			device.PushMagic,
-			device.AwaitingConfiguration,
-			device.Token,
-			device.UnlockToken,
-			device.Enrolled,
-			device.Description,
-			device.Model,
-			device.ModelName,
-			device.DeviceName,
-			device.Color,
-			device.AssetTag,
-			device.DEPProfileStatus,
-			device.DEPProfileUUID,
-			device.DEPProfileAssignTime,
-			device.DEPProfilePushTime,
-			device.DEPProfileAssignedDate,
-			device.DEPProfileAssignedBy,
-			device.LastSeen,
+			dev.UUID,
+			dev.UDID,
+			dev.SerialNumber,
+			dev.OSVersion,
+			dev.BuildVersion,
+			dev.ProductName,
+			dev.IMEI,
+			dev.MEID,
+			dev.PushMagic,
+			dev.AwaitingConfiguration,
+			dev.Token,
+			dev.UnlockToken,
+			dev.Enrolled,
+			dev.Description,
+			dev.Model,
+			dev.ModelName,
+			dev.DeviceName,
+			dev.Color,
+			dev.AssetTag,
+			dev.DEPProfileStatus,
+			dev.DEPProfileUUID,
+			dev.DEPProfileAssignTime,
+			dev.DEPProfilePushTime,
+			dev.DEPProfileAssignedDate,
+			dev.DEPProfileAssignedBy,
+			dev.LastSeen,
+			dev.Attributes,
+			dev.Notes,
+			dev.Lifecycle,
+			dev.LifecycleChanged,
+			osVersionKey,
 		).
 		Suffix(updateQuery).
 		ToSql()
@@ -124,10 +145,46 @@ func (d *Postgres) Save(ctx context.Context, device *device.Device) error {
 		return errors.Wrap(err, "building device save query")
 	}
 
-	_, err = d.db.ExecContext(ctx, query, args...)
+	_, err = db.ExecContext(ctx, query, args...)
 	return errors.Wrap(err, "exec device save in pg")
 }
 
+// UpdateByUDID locks the row of the device with udid, calls fn with the
+// device and saves it in a single transaction.
+func (d *Postgres) UpdateByUDID(ctx context.Context, udid string, fn func(*device.Device) error) (*device.Device, error) {
+	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(columns()...).
+		From(tableName).
+		Where(sq.Eq{"udid": udid}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building sql")
+	}
+
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin device update")
+	}
+	defer tx.Rollback()
+
+	var dev device.Device
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&dev)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return nil, deviceNotFoundErr{}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding device by udid for update")
+	}
+	if err := fn(&dev); err != nil {
+		return nil, err
+	}
+	if err := save(ctx, tx, &dev); err != nil {
+		return nil, err
+	}
+	return &dev, errors.Wrap(tx.Commit(), "commit device update")
+}
+
 func (d *Postgres) DeviceByUDID(ctx context.Context, udid string) (*device.Device, error) {
 	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
 		Select(columns()...).
@@ -164,11 +221,19 @@ func (d *Postgres) DeviceBySerial(ctx context.Context, serial string) (*device.D
 	return &dev, errors.Wrap(err, "finding device by serial")
 }
 
-func (d *Postgres) ListDevices(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error) {
-	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+// List returns the devices matching the filters in opt, ordered by uuid.
+func (d *Postgres) List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error) {
+	builder := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
 		Select(columns()...).
 		From(tableName).
-		ToSql()
+		OrderBy("uuid")
+	if where := filters(opt); len(where) > 0 {
+		builder = builder.Where(where)
+	}
+	if opt.PerPage > 0 {
+		builder = builder.Limit(uint64(opt.PerPage)).Offset(uint64(opt.Offset()))
+	}
+	query, args, err := builder.ToSql()
 	if err != nil {
 		return nil, errors.Wrap(err, "building sql")
 	}
@@ -177,6 +242,113 @@ func (d *Postgres) ListDevices(ctx context.Context, opt device.ListDevicesOption
 	return list, errors.Wrap(err, "list devices")
 }
 
+// ForEach calls fn with each device matching opt, ordered by uuid.
+// Rows are scanned one at a time.
+func (d *Postgres) ForEach(ctx context.Context, opt device.ListDevicesOption, fn func(device.Device) error) error {
+	builder := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select(columns()...).
+		From(tableName).
+		OrderBy("uuid")
+	if where := filters(opt); len(where) > 0 {
+		builder = builder.Where(where)
+	}
+	if opt.PerPage > 0 {
+		builder = builder.Limit(uint64(opt.PerPage)).Offset(uint64(opt.Offset()))
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building sql")
+	}
+	rows, err := d.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "query devices")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var dev device.Device
+		if err := rows.StructScan(&dev); err != nil {
+			return errors.Wrap(err, "scan device")
+		}
+		if err := fn(dev); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(rows.Err(), "iterate devices")
+}
+
+func (d *Postgres) ListDevices(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error) {
+	return d.List(ctx, opt)
+}
+
+// Count returns the number of devices matching the filters in opt.
+func (d *Postgres) Count(ctx context.Context, opt device.ListDevicesOption) (int, error) {
+	builder := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("COUNT(*)").
+		From(tableName)
+	if where := filters(opt); len(where) > 0 {
+		builder = builder.Where(where)
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "building sql")
+	}
+	var count int
+	err = d.db.QueryRowxContext(ctx, query, args...).Scan(&count)
+	return count, errors.Wrap(err, "count devices")
+}
+
+// filters translates the list options to a where clause which
+// matches device.ListDevicesOption.Match.
+func filters(opt device.ListDevicesOption) sq.And {
+	where := sq.And{}
+	if len(opt.FilterSerial) > 0 {
+		where = append(where, sq.Eq{"serial_number": opt.FilterSerial})
+	}
+	if len(opt.FilterUDID) > 0 {
+		where = append(where, sq.Eq{"udid": opt.FilterUDID})
+	}
+	if len(opt.FilterModel) > 0 {
+		where = append(where, sq.Or{
+			sq.Eq{"model": opt.FilterModel},
+			sq.Eq{"model_name": opt.FilterModel},
+			sq.Eq{"product_name": opt.FilterModel},
+		})
+	}
+	if len(opt.FilterDEPProfileStatus) > 0 {
+		var statuses []string
+		for _, s := range opt.FilterDEPProfileStatus {
+			statuses = append(statuses, string(s))
+		}
+		where = append(where, sq.Eq{"dep_profile_status": statuses})
+	}
+	if opt.FilterEnrolled != nil {
+		where = append(where, sq.Eq{"enrolled": *opt.FilterEnrolled})
+	}
+	if opt.FilterOSVersionMin != "" {
+		where = append(where, sq.GtOrEq{"os_version_key": device.OSVersionKey(opt.FilterOSVersionMin)})
+	}
+	if opt.FilterOSVersionMax != "" {
+		where = append(where, sq.LtOrEq{"os_version_key": device.OSVersionKey(opt.FilterOSVersionMax)})
+	}
+	if !opt.FilterLastSeenAfter.IsZero() {
+		where = append(where, sq.GtOrEq{"last_seen": opt.FilterLastSeenAfter})
+	}
+	if !opt.FilterLastSeenBefore.IsZero() {
+		where = append(where, sq.LtOrEq{"last_seen": opt.FilterLastSeenBefore})
+	}
+	if len(opt.FilterLifecycle) > 0 {
+		var states []string
+		for _, s := range opt.FilterLifecycle {
+			states = append(states, string(s))
+		}
+		where = append(where, sq.Eq{"lifecycle": states})
+	}
+	if len(opt.FilterAttributes) > 0 {
+		where = append(where, sq.Expr("attributes @> ?::jsonb", device.Attributes(opt.FilterAttributes)))
+	}
+	return where
+}
+
 func (d *Postgres) DeleteByUDID(ctx context.Context, udid string) error {
 	query, args, err := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
 		Delete(tableName).
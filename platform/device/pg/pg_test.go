@@ -2,6 +2,7 @@ package pg
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -71,3 +72,39 @@ func setup(t *testing.T) *Postgres {
 
 	return New(db)
 }
+
+func TestFilters(t *testing.T) {
+	enrolled := true
+	opt := device.ListDevicesOption{
+		FilterSerial:       []string{"C02XK1JAJHD3"},
+		FilterModel:        []string{"MacBookPro15,1"},
+		FilterEnrolled:     &enrolled,
+		FilterOSVersionMin: "10.14",
+		FilterAttributes:   map[string]string{"owner": "jane"},
+		FilterLifecycle:    []device.LifecycleState{device.LifecycleStale, device.LifecycleMissing},
+	}
+	query, args, err := filters(opt).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(args), 9; have != want {
+		t.Errorf("have %d args, want %d", have, want)
+	}
+	if !strings.Contains(query, "os_version_key >= ?") {
+		t.Errorf("expected os version comparison in query %q", query)
+	}
+	var hasKey bool
+	for _, arg := range args {
+		hasKey = hasKey || arg == device.OSVersionKey("10.14")
+	}
+	if !hasKey {
+		t.Errorf("expected the key of the os version in args %v", args)
+	}
+	if !strings.Contains(query, "attributes @>") {
+		t.Errorf("expected attributes containment in query %q", query)
+	}
+
+	if where := filters(device.ListDevicesOption{}); len(where) != 0 {
+		t.Errorf("expected no filters for empty options, got %d", len(where))
+	}
+}
@@ -0,0 +1,38 @@
+package device
+
+import (
+	"context"
+
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+// ProfileVariables returns the template variables of the device for
+// rendering profiles.
+func (d *Device) ProfileVariables() *profile.Variables {
+	return &profile.Variables{
+		UDID:         d.UDID,
+		SerialNumber: d.SerialNumber,
+		DeviceName:   d.DeviceName,
+		Model:        d.Model,
+		ModelName:    d.ModelName,
+		ProductName:  d.ProductName,
+		OSVersion:    d.OSVersion,
+		IMEI:         d.IMEI,
+		MEID:         d.MEID,
+		Attributes:   d.Attributes,
+	}
+}
+
+// ProfileVariablesFunc looks up the profile template variables of devices
+// in the store.
+func ProfileVariablesFunc(store interface {
+	DeviceByUDID(ctx context.Context, udid string) (*Device, error)
+}) profile.VariablesFunc {
+	return func(ctx context.Context, udid string) (*profile.Variables, error) {
+		dev, err := store.DeviceByUDID(ctx, udid)
+		if err != nil {
+			return nil, err
+		}
+		return dev.ProfileVariables(), nil
+	}
+}
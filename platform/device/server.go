@@ -9,20 +9,35 @@ import (
 )
 
 type Endpoints struct {
-	ListDevicesEndpoint   endpoint.Endpoint
-	RemoveDevicesEndpoint endpoint.Endpoint
+	ListDevicesEndpoint           endpoint.Endpoint
+	RemoveDevicesEndpoint         endpoint.Endpoint
+	GetDeviceHistoryEndpoint      endpoint.Endpoint
+	ApplyDeviceAttributesEndpoint endpoint.Endpoint
+	SetDeviceLifecycleEndpoint    endpoint.Endpoint
+	ExportDevicesEndpoint         endpoint.Endpoint
+	ImportDevicesEndpoint         endpoint.Endpoint
 }
 
 func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoint.Middleware) Endpoints {
 	return Endpoints{
-		ListDevicesEndpoint:   endpoint.Chain(outer, others...)(MakeListDevicesEndpoint(s)),
-		RemoveDevicesEndpoint: endpoint.Chain(outer, others...)(MakeRemoveDevicesEndpoint(s)),
+		ListDevicesEndpoint:           endpoint.Chain(outer, others...)(MakeListDevicesEndpoint(s)),
+		RemoveDevicesEndpoint:         endpoint.Chain(outer, others...)(MakeRemoveDevicesEndpoint(s)),
+		GetDeviceHistoryEndpoint:      endpoint.Chain(outer, others...)(MakeGetDeviceHistoryEndpoint(s)),
+		ApplyDeviceAttributesEndpoint: endpoint.Chain(outer, others...)(MakeApplyDeviceAttributesEndpoint(s)),
+		SetDeviceLifecycleEndpoint:    endpoint.Chain(outer, others...)(MakeSetDeviceLifecycleEndpoint(s)),
+		ExportDevicesEndpoint:         endpoint.Chain(outer, others...)(MakeExportDevicesEndpoint(s)),
+		ImportDevicesEndpoint:         endpoint.Chain(outer, others...)(MakeImportDevicesEndpoint(s)),
 	}
 }
 
 func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.ServerOption) {
 	// POST     /v1/devices		get a list of devices managed by the server
 	// DELETE  /v1/devices		remove one or more devices from the server
+	// GET     /v1/devices/:udid/history	get the command history of a device
+	// PUT     /v1/devices/attributes	set custom attributes and notes of one or more devices
+	// PUT     /v1/devices/lifecycle	set the lifecycle state of one or more devices
+	// POST    /v1/devices/export	stream the devices as CSV or newline delimited JSON
+	// POST    /v1/devices/import	create or update pre-staged devices by serial number
 
 	r.Methods("POST").Path("/v1/devices").Handler(httptransport.NewServer(
 		e.ListDevicesEndpoint,
@@ -37,4 +52,39 @@ func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.S
 		httputil.EncodeJSONResponse,
 		options...,
 	))
+
+	r.Methods("GET").Path("/v1/devices/{udid}/history").Handler(httptransport.NewServer(
+		e.GetDeviceHistoryEndpoint,
+		decodeGetDeviceHistoryRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("PUT").Path("/v1/devices/attributes").Handler(httptransport.NewServer(
+		e.ApplyDeviceAttributesEndpoint,
+		decodeApplyDeviceAttributesRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("PUT").Path("/v1/devices/lifecycle").Handler(httptransport.NewServer(
+		e.SetDeviceLifecycleEndpoint,
+		decodeSetDeviceLifecycleRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/devices/export").Handler(httptransport.NewServer(
+		e.ExportDevicesEndpoint,
+		decodeExportDevicesRequest,
+		encodeExportDevicesResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/devices/import").Handler(httptransport.NewServer(
+		e.ImportDevicesEndpoint,
+		decodeImportDevicesRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
 }
@@ -2,6 +2,9 @@ package device
 
 import (
 	"context"
+	"io"
+
+	"github.com/micromdm/micromdm/platform/pubsub"
 )
 
 type RemoveDevicesOptions struct {
@@ -10,20 +13,58 @@ type RemoveDevicesOptions struct {
 }
 
 type Service interface {
-	ListDevices(ctx context.Context, opt ListDevicesOption) ([]DeviceDTO, error)
+	ListDevices(ctx context.Context, opt ListDevicesOption) ([]DeviceDTO, int, error)
 	RemoveDevices(ctx context.Context, opt RemoveDevicesOptions) error
+	GetDeviceHistory(ctx context.Context, udid string, opt DeviceHistoryOption) (*DeviceHistory, error)
+	ApplyDeviceAttributes(ctx context.Context, updates []DeviceAttributes) (*ApplyAttributesResult, error)
+	SetDeviceLifecycle(ctx context.Context, opt SetLifecycleOptions) error
+	ExportDevices(ctx context.Context, opt ExportDevicesOption, w io.Writer) error
+	ImportDevices(ctx context.Context, devices []ImportDevice, dryRun bool) (*ImportDevicesResult, error)
 }
 
 type Store interface {
 	List(ctx context.Context, opt ListDevicesOption) ([]Device, error)
+	Count(ctx context.Context, opt ListDevicesOption) (int, error)
+	ForEach(ctx context.Context, opt ListDevicesOption, fn func(Device) error) error
+	Save(ctx context.Context, d *Device) error
+	// UpdateByUDID reads the device with udid, calls fn with it and saves
+	// the changed device, without any other update of the device in
+	// between.
+	UpdateByUDID(ctx context.Context, udid string, fn func(*Device) error) (*Device, error)
+	DeviceByUDID(ctx context.Context, udid string) (*Device, error)
+	DeviceBySerial(ctx context.Context, serial string) (*Device, error)
 	DeleteByUDID(ctx context.Context, udid string) error
 	DeleteBySerial(ctx context.Context, serial string) error
 }
 
 type DeviceService struct {
-	store Store
+	store     Store
+	commands  CommandHistoryStore
+	publisher pubsub.Publisher
+}
+
+type Option func(*DeviceService)
+
+// WithCommandHistory enables the device command history API,
+// backed by the command queue.
+func WithCommandHistory(commands CommandHistoryStore) Option {
+	return func(svc *DeviceService) {
+		svc.commands = commands
+	}
+}
+
+// WithPublisher publishes devices updated through the API
+// to DeviceUpdatedTopic, and lifecycle changes to LifecycleChangedTopic.
+func WithPublisher(pub pubsub.Publisher) Option {
+	return func(svc *DeviceService) {
+		svc.publisher = pub
+	}
 }
 
-func New(store Store) *DeviceService {
-	return &DeviceService{store: store}
+func New(store Store, opts ...Option) *DeviceService {
+	svc := &DeviceService{store: store}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
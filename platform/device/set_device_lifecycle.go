@@ -0,0 +1,117 @@
+package device
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+type SetLifecycleOptions struct {
+	UDIDs   []string       `json:"udids"`
+	Serials []string       `json:"serials"`
+	State   LifecycleState `json:"state"`
+}
+
+// SetDeviceLifecycle sets the lifecycle state of devices, usually to retire
+// a device or to record that it was wiped.
+func (svc *DeviceService) SetDeviceLifecycle(ctx context.Context, opt SetLifecycleOptions) error {
+	if !opt.State.Valid() {
+		return errors.Errorf("invalid lifecycle state %q", opt.State)
+	}
+
+	// devices are resolved first, so that an unknown device changes none.
+	// Devices selected by serial are updated by UDID if they enrolled.
+	var devices []*Device
+	for _, udid := range opt.UDIDs {
+		dev, err := svc.store.DeviceByUDID(ctx, udid)
+		if err != nil {
+			return errors.Wrapf(err, "get device with udid %s", udid)
+		}
+		devices = append(devices, dev)
+	}
+	for _, serial := range opt.Serials {
+		dev, err := svc.store.DeviceBySerial(ctx, serial)
+		if err != nil {
+			return errors.Wrapf(err, "get device with serial %s", serial)
+		}
+		devices = append(devices, dev)
+	}
+
+	for _, dev := range devices {
+		var event *LifecycleEvent
+		update := func(dev *Device) error {
+			if event = setLifecycle(dev, opt.State, time.Now()); event == nil {
+				return errUnchanged
+			}
+			return nil
+		}
+		var err error
+		if dev.UDID != "" {
+			// the device worker and the lifecycle monitor update
+			// enrolled devices concurrently.
+			dev, err = svc.store.UpdateByUDID(ctx, dev.UDID, update)
+		} else if err = update(dev); err == nil {
+			err = svc.store.Save(ctx, dev)
+		}
+		if errors.Cause(err) == errUnchanged {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "save device lifecycle")
+		}
+		if svc.publisher == nil {
+			continue
+		}
+		if err := publishDeviceUpdated(ctx, svc.publisher, dev); err != nil {
+			return err
+		}
+		if err := publishLifecycleChanged(ctx, svc.publisher, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type setDeviceLifecycleRequest struct{ Opts SetLifecycleOptions }
+
+type setDeviceLifecycleResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r setDeviceLifecycleResponse) Failed() error { return r.Err }
+
+func decodeSetDeviceLifecycleRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req setDeviceLifecycleRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeSetDeviceLifecycleResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp setDeviceLifecycleResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeSetDeviceLifecycleEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(setDeviceLifecycleRequest)
+		err = svc.SetDeviceLifecycle(ctx, req.Opts)
+		return setDeviceLifecycleResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+func (e Endpoints) SetDeviceLifecycle(ctx context.Context, opts SetLifecycleOptions) error {
+	request := setDeviceLifecycleRequest{Opts: opts}
+	resp, err := e.SetDeviceLifecycleEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	return resp.(setDeviceLifecycleResponse).Err
+}
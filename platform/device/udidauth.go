@@ -15,6 +15,10 @@ import (
 type UDIDCertAuthStore interface {
 	SaveUDIDCertHash(udid, certHash []byte) error
 	GetUDIDCertHash(udid []byte) ([]byte, error)
+
+	// SaveUDIDCert saves the DER encoded identity certificate of the
+	// device along with its hash.
+	SaveUDIDCert(udid, cert []byte) error
 }
 
 func UDIDCertAuthMiddleware(store UDIDCertAuthStore, logger log.Logger) mdm.Middleware {
@@ -40,7 +44,16 @@ func hashCertRaw(c []byte) []byte {
 	return retBytes
 }
 
-func (mw *udidCertAuthMiddleware) validateUDIDCertAuth(udid, certHash []byte) (bool, error) {
+// saveUDIDCert saves the identity certificate of the device and its hash.
+func (mw *udidCertAuthMiddleware) saveUDIDCert(udid, cert []byte) error {
+	if err := mw.store.SaveUDIDCertHash(udid, hashCertRaw(cert)); err != nil {
+		return err
+	}
+	return mw.store.SaveUDIDCert(udid, cert)
+}
+
+func (mw *udidCertAuthMiddleware) validateUDIDCertAuth(udid, cert []byte) (bool, error) {
+	certHash := hashCertRaw(cert)
 	dbCertHash, err := mw.store.GetUDIDCertHash(udid)
 	if err != nil && !isNotFound(err) {
 		return false, err
@@ -51,7 +64,7 @@ func (mw *udidCertAuthMiddleware) validateUDIDCertAuth(udid, certHash []byte) (b
 		// micromdm instances have stored udid-cert associations
 		// this can be an outright failure.
 		level.Info(mw.logger).Log("msg", "device cert hash not found, saving anyway", "udid", string(udid))
-		if err := mw.store.SaveUDIDCertHash(udid, certHash); err != nil {
+		if err := mw.saveUDIDCert(udid, cert); err != nil {
 			return false, err
 		}
 		return true, nil
@@ -73,7 +86,7 @@ func (mw *udidCertAuthMiddleware) Acknowledge(ctx context.Context, req mdm.Ackno
 	if err != nil {
 		return nil, errors.Wrap(err, "error retrieving device certificate")
 	}
-	matched, err := mw.validateUDIDCertAuth([]byte(req.Response.UDID), hashCertRaw(devcert.Raw))
+	matched, err := mw.validateUDIDCertAuth([]byte(req.Response.UDID), devcert.Raw)
 	if err != nil {
 		return nil, err
 	}
@@ -94,13 +107,13 @@ func (mw *udidCertAuthMiddleware) Checkin(ctx context.Context, req mdm.CheckinEv
 	}
 	switch req.Command.MessageType {
 	case "Authenticate":
-		// unconditionally save the cert hash on Authenticate message
-		if err := mw.store.SaveUDIDCertHash([]byte(req.Command.UDID), hashCertRaw(devcert.Raw)); err != nil {
+		// unconditionally save the cert and its hash on Authenticate message
+		if err := mw.saveUDIDCert([]byte(req.Command.UDID), devcert.Raw); err != nil {
 			return err
 		}
 		return mw.next.Checkin(ctx, req)
 	case "TokenUpdate", "CheckOut":
-		matched, err := mw.validateUDIDCertAuth([]byte(req.Command.UDID), hashCertRaw(devcert.Raw))
+		matched, err := mw.validateUDIDCertAuth([]byte(req.Command.UDID), devcert.Raw)
 		if err != nil {
 			return err
 		}
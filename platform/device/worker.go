@@ -10,12 +10,15 @@ import (
 	uuid "github.com/satori/go.uuid"
 
 	"github.com/micromdm/micromdm/mdm"
+	mdmcmd "github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/command"
 	"github.com/micromdm/micromdm/platform/dep/sync"
 	"github.com/micromdm/micromdm/platform/pubsub"
 )
 
 type DeviceWorkerStore interface {
 	Save(ctx context.Context, d *Device) error
+	UpdateByUDID(ctx context.Context, udid string, fn func(*Device) error) (*Device, error)
 	DeviceByUDID(ctx context.Context, udid string) (*Device, error)
 	DeviceBySerial(ctx context.Context, serial string) (*Device, error)
 }
@@ -24,13 +27,23 @@ type Worker struct {
 	db     DeviceWorkerStore
 	ps     pubsub.PublishSubscriber
 	logger log.Logger
+
+	// deviceInformation are the UUIDs of the DeviceInformation commands
+	// queued for devices, with the time they were queued. Only their
+	// responses update the device.
+	deviceInformation map[string]time.Time
 }
 
+// deviceInformationTTL is how long the worker waits for the response to a
+// DeviceInformation command.
+const deviceInformationTTL = 7 * 24 * time.Hour
+
 func NewWorker(db DeviceWorkerStore, ps pubsub.PublishSubscriber, logger log.Logger) *Worker {
 	return &Worker{
-		db:     db,
-		ps:     ps,
-		logger: logger,
+		db:                db,
+		ps:                ps,
+		logger:            logger,
+		deviceInformation: make(map[string]time.Time),
 	}
 }
 
@@ -56,6 +69,12 @@ func (w *Worker) Run(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrapf(err, "subscribing %s to %s", subscription, mdm.ConnectTopic)
 	}
+	commandEvents, err := w.ps.Subscribe(ctx, subscription, command.CommandTopic)
+	if err != nil {
+		return errors.Wrapf(err, "subscribing %s to %s", subscription, command.CommandTopic)
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
 
 	for {
 		var err error
@@ -72,6 +91,10 @@ func (w *Worker) Run(ctx context.Context) error {
 			err = w.updateFromDEPSync(ctx, ev.Message)
 		case ev := <-connectEvents:
 			err = w.updateFromAcknowledge(ctx, ev.Message)
+		case ev := <-commandEvents:
+			err = w.commandQueued(ev.Message)
+		case <-ticker.C:
+			w.expireDeviceInformation(time.Now())
 		}
 		if err != nil {
 			level.Info(w.logger).Log(
@@ -118,16 +141,21 @@ func (w *Worker) updateFromDEPSync(ctx context.Context, message []byte) error {
 
 		dev.SerialNumber = dd.SerialNumber
 		dev.Model = dd.Model
-		dev.Description = dd.Description
 		dev.Color = dd.Color
-		dev.AssetTag = dd.AssetTag
+		// keep an imported description and asset tag if DEP has none.
+		if dd.Description != "" {
+			dev.Description = dd.Description
+		}
+		if dd.AssetTag != "" {
+			dev.AssetTag = dd.AssetTag
+		}
 		dev.DEPProfileStatus = DEPProfileStatus(dd.ProfileStatus)
 		dev.DEPProfileUUID = dd.ProfileUUID
 		dev.DEPProfileAssignTime = dd.ProfileAssignTime
 		dev.DEPProfileAssignedDate = dd.DeviceAssignedDate
 		dev.DEPProfileAssignedBy = dd.DeviceAssignedBy
 
-		if err := w.db.Save(ctx, dev); err != nil {
+		if err := w.save(ctx, dev); err != nil {
 			return errors.Wrap(err, "save device %s from DEP sync")
 		}
 	}
@@ -135,6 +163,29 @@ func (w *Worker) updateFromDEPSync(ctx context.Context, message []byte) error {
 	return nil
 }
 
+// commandQueued records the UUIDs of DeviceInformation commands.
+func (w *Worker) commandQueued(message []byte) error {
+	var ev command.Event
+	if err := command.UnmarshalEvent(message, &ev); err != nil {
+		return errors.Wrap(err, "unmarshal command event")
+	}
+	if ev.Payload == nil || ev.Payload.Command == nil || ev.Payload.Command.RequestType != "DeviceInformation" {
+		return nil
+	}
+	w.deviceInformation[ev.Payload.CommandUUID] = time.Now()
+	return nil
+}
+
+// expireDeviceInformation forgets the DeviceInformation commands which were
+// not answered within deviceInformationTTL.
+func (w *Worker) expireDeviceInformation(now time.Time) {
+	for id, queued := range w.deviceInformation {
+		if now.Sub(queued) >= deviceInformationTTL {
+			delete(w.deviceInformation, id)
+		}
+	}
+}
+
 func (w *Worker) updateFromAcknowledge(ctx context.Context, message []byte) error {
 	var ev mdm.AcknowledgeEvent
 	if err := mdm.UnmarshalAcknowledgeEvent(message, &ev); err != nil {
@@ -145,14 +196,26 @@ func (w *Worker) updateFromAcknowledge(ctx context.Context, message []byte) erro
 		return nil
 	}
 
-	dev, err := w.db.DeviceByUDID(ctx, ev.Response.UDID)
-	if err != nil {
-		return errors.Wrapf(err, "retrieve device with udid %s", ev.Response.UDID)
+	_, deviceInformation := w.deviceInformation[ev.Response.CommandUUID]
+	if ev.Response.Status != "NotNow" {
+		delete(w.deviceInformation, ev.Response.CommandUUID)
 	}
-	dev.LastSeen = time.Now()
 
-	err = w.db.Save(ctx, dev)
-	return errors.Wrapf(err, "saving updated device for acknowledge event")
+	var lifecycle *LifecycleEvent
+	dev, err := w.db.UpdateByUDID(ctx, ev.Response.UDID, func(dev *Device) error {
+		lifecycle = markSeen(dev, time.Now())
+		if deviceInformation && ev.Response.Status == "Acknowledged" {
+			return updateFromQueryResponses(dev, ev.Raw)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "update device with udid %s for acknowledge event", ev.Response.UDID)
+	}
+	if err := publishDeviceUpdated(ctx, w.ps, dev); err != nil {
+		return err
+	}
+	return publishLifecycleChanged(ctx, w.ps, lifecycle)
 
 }
 
@@ -166,16 +229,21 @@ func (w *Worker) updateFromCheckout(ctx context.Context, message []byte) error {
 		return nil
 	}
 
-	dev, err := w.db.DeviceByUDID(ctx, ev.Command.UDID)
+	var lifecycle *LifecycleEvent
+	dev, err := w.db.UpdateByUDID(ctx, ev.Command.UDID, func(dev *Device) error {
+		now := time.Now()
+		dev.Enrolled = false
+		dev.LastSeen = now
+		lifecycle = setLifecycle(dev, LifecycleRetired, now)
+		return nil
+	})
 	if err != nil {
-		return errors.Wrapf(err, "retrieve device with udid %s", ev.Command.UDID)
+		return errors.Wrapf(err, "update device with udid %s for checkout event", ev.Command.UDID)
 	}
-
-	dev.Enrolled = false
-	dev.LastSeen = time.Now()
-
-	err = w.db.Save(ctx, dev)
-	return errors.Wrapf(err, "saving updated device for checkout event")
+	if err := publishDeviceUpdated(ctx, w.ps, dev); err != nil {
+		return err
+	}
+	return publishLifecycleChanged(ctx, w.ps, lifecycle)
 
 }
 
@@ -198,13 +266,16 @@ func (w *Worker) updateFromTokenUpdate(ctx context.Context, message []byte) erro
 	dev.PushMagic = ev.Command.PushMagic
 	dev.UnlockToken = ev.Command.UnlockToken.String()
 	dev.AwaitingConfiguration = ev.Command.AwaitingConfiguration
-	dev.LastSeen = time.Now()
+	lifecycle := markSeen(dev, time.Now())
 	// first TokenUpdate event will have the enrollment status set to false.
 	newlyEnrolled := !dev.Enrolled
 	dev.Enrolled = true
-	if err := w.db.Save(ctx, dev); err != nil {
+	if err := w.save(ctx, dev); err != nil {
 		return errors.Wrapf(err, "saving updated device for Token event udid=%s", ev.Command.UDID)
 	}
+	if err := publishLifecycleChanged(ctx, w.ps, lifecycle); err != nil {
+		return err
+	}
 
 	if newlyEnrolled {
 		// notify subscribers of a successful enrollment
@@ -235,6 +306,13 @@ func (w *Worker) updateFromAuthenticate(ctx context.Context, message []byte) err
 			"msg", "re-enrolling device",
 			"serial", ev.Command.SerialNumber,
 		)
+	} else if device.UUID != "" {
+		// the device was pre-staged by serial, through an import or DEP sync.
+		// The placeholder is merged with the enrolling device.
+		level.Debug(w.logger).Log(
+			"msg", "enrolling pre-staged device",
+			"serial", ev.Command.SerialNumber,
+		)
 	} else {
 		level.Debug(w.logger).Log(
 			"msg", "enrolling new device",
@@ -256,8 +334,70 @@ func (w *Worker) updateFromAuthenticate(ctx context.Context, message []byte) err
 	device.Model = ev.Command.Model
 	device.ModelName = ev.Command.ModelName
 	device.LastSeen = time.Now()
-	err = w.db.Save(ctx, device)
-	return errors.Wrapf(err, "saving updated device for authenticate event")
+	// an enrolling device is active, even if it was retired or wiped.
+	lifecycle := setLifecycle(device, LifecycleActive, device.LastSeen)
+	if err := w.save(ctx, device); err != nil {
+		return errors.Wrapf(err, "saving updated device for authenticate event")
+	}
+	return publishLifecycleChanged(ctx, w.ps, lifecycle)
+}
+
+// updateFromQueryResponses updates the device with the inventory returned
+// by a DeviceInformation command.
+func updateFromQueryResponses(dev *Device, raw []byte) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	resp, err := mdmcmd.DecodeResponse("DeviceInformation", raw)
+	if err != nil {
+		return errors.Wrap(err, "decode DeviceInformation response")
+	}
+	qr := resp.(*mdmcmd.DeviceInformationResponse).QueryResponses
+	for _, f := range []struct {
+		field *string
+		value string
+	}{
+		{&dev.DeviceName, qr.DeviceName},
+		{&dev.OSVersion, qr.OSVersion},
+		{&dev.BuildVersion, qr.BuildVersion},
+		{&dev.ModelName, qr.ModelName},
+		{&dev.Model, qr.Model},
+		{&dev.ProductName, qr.ProductName},
+		{&dev.IMEI, qr.IMEI},
+		{&dev.MEID, qr.MEID},
+	} {
+		if f.value != "" {
+			*f.field = f.value
+		}
+	}
+	return nil
+}
+
+// markSeen records a check-in from the device. Stale and missing devices
+// become active again.
+func markSeen(dev *Device, now time.Time) *LifecycleEvent {
+	dev.LastSeen = now
+	if dev.Lifecycle == LifecycleStale || dev.Lifecycle == LifecycleMissing {
+		return setLifecycle(dev, LifecycleActive, now)
+	}
+	return nil
+}
+
+// save stores the device and notifies subscribers of DeviceUpdatedTopic.
+func (w *Worker) save(ctx context.Context, dev *Device) error {
+	if err := w.db.Save(ctx, dev); err != nil {
+		return err
+	}
+	return publishDeviceUpdated(ctx, w.ps, dev)
+}
+
+func publishDeviceUpdated(ctx context.Context, pub pubsub.Publisher, dev *Device) error {
+	msg, err := MarshalDevice(dev)
+	if err != nil {
+		return errors.Wrap(err, "marshal updated device")
+	}
+	err = pub.Publish(ctx, DeviceUpdatedTopic, msg)
+	return errors.Wrap(err, "publish device update")
 }
 
 func getOrCreateDevice(ctx context.Context, db DeviceWorkerStore, serial, udid string) (dev *Device, reenrolling bool, err error) {
@@ -290,7 +430,7 @@ func getOrCreateDeviceBySerial(ctx context.Context, db DeviceWorkerStore, serial
 		return nil, errors.Wrapf(err, "retrieve device with serial number %s", serial)
 	}
 
-	dev := new(Device)
+	dev := &Device{Lifecycle: LifecycleActive}
 	return dev, nil
 }
 
@@ -0,0 +1,158 @@
+package device
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/micromdm/micromdm/dep"
+	"github.com/micromdm/micromdm/mdm"
+	mdmcmd "github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/command"
+	"github.com/micromdm/micromdm/platform/dep/sync"
+	"github.com/micromdm/micromdm/platform/pubsub/inmem"
+)
+
+func TestUpdateFromQueryResponses(t *testing.T) {
+	dev := &Device{DeviceName: "old-name", OSVersion: "10.13.6", SerialNumber: "C02FOOBAR"}
+	raw := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>QueryResponses</key><dict>
+<key>DeviceName</key><string>new-name</string>
+<key>OSVersion</key><string>10.14.6</string>
+</dict>
+<key>Status</key><string>Acknowledged</string>
+</dict></plist>`)
+	if err := updateFromQueryResponses(dev, raw); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := dev.DeviceName, "new-name"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+	if have, want := dev.OSVersion, "10.14.6"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+	if have, want := dev.SerialNumber, "C02FOOBAR"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+}
+
+func TestUpdateFromAcknowledge(t *testing.T) {
+	ctx := context.Background()
+	store := memStore{devices: map[string]*Device{
+		"C02FOOBAR": {UDID: "UDID-FOO", SerialNumber: "C02FOOBAR", OSVersion: "10.13.6"},
+	}}
+	w := NewWorker(store, inmem.NewPubSub(), log.NewNopLogger())
+	raw := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>QueryResponses</key><dict><key>OSVersion</key><string>10.14.6</string></dict>
+<key>Status</key><string>Acknowledged</string>
+</dict></plist>`)
+	acknowledge := func(commandUUID string) {
+		t.Helper()
+		msg, err := mdm.MarshalAcknowledgeEvent(&mdm.AcknowledgeEvent{
+			Response: mdm.Response{UDID: "UDID-FOO", Status: "Acknowledged", CommandUUID: commandUUID},
+			Raw:      raw,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.updateFromAcknowledge(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// responses to other commands are not DeviceInformation responses.
+	acknowledge("other")
+	if have := store.devices["C02FOOBAR"].OSVersion; have != "10.13.6" {
+		t.Errorf("have os version %s after an unknown command, want 10.13.6", have)
+	}
+
+	payload, err := mdmcmd.NewCommandPayload(&mdmcmd.CommandRequest{
+		UDID:    "UDID-FOO",
+		Command: &mdmcmd.Command{RequestType: "DeviceInformation", DeviceInformation: &mdmcmd.DeviceInformation{Queries: []string{"OSVersion"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := command.MarshalEvent(command.NewEvent(payload, "UDID-FOO"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.commandQueued(msg); err != nil {
+		t.Fatal(err)
+	}
+	acknowledge(payload.CommandUUID)
+	if have := store.devices["C02FOOBAR"].OSVersion; have != "10.14.6" {
+		t.Errorf("have os version %s after DeviceInformation, want 10.14.6", have)
+	}
+	if len(w.deviceInformation) != 0 {
+		t.Error("acknowledged DeviceInformation command must be forgotten")
+	}
+}
+
+func TestAuthenticateMergesPlaceholder(t *testing.T) {
+	ctx := context.Background()
+	store := memStore{devices: map[string]*Device{
+		"C02FOOBAR": {
+			UUID:         "placeholder",
+			SerialNumber: "C02FOOBAR",
+			AssetTag:     "A1001",
+			Attributes:   Attributes{"assigned_user": "jane"},
+		},
+	}}
+	w := NewWorker(store, inmem.NewPubSub(), log.NewNopLogger())
+
+	ev := mdm.CheckinEvent{Command: mdm.CheckinCommand{MessageType: "Authenticate", UDID: "UDID-FOO"}}
+	ev.Command.SerialNumber = "C02FOOBAR"
+	ev.Command.OSVersion = "10.14.6"
+	msg, err := mdm.MarshalCheckinEvent(&ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.updateFromAuthenticate(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	dev := store.devices["C02FOOBAR"]
+	if have, want := dev.UUID, "placeholder"; have != want {
+		t.Errorf("have uuid %s, want %s", have, want)
+	}
+	if dev.UDID != "UDID-FOO" || dev.OSVersion != "10.14.6" {
+		t.Errorf("expected device to be updated from Authenticate, got %+v", dev)
+	}
+	if dev.AssetTag != "A1001" || dev.Attributes["assigned_user"] != "jane" {
+		t.Errorf("expected pre-staged fields to be kept, got %+v", dev)
+	}
+}
+
+func TestDEPSyncKeepsImportedFields(t *testing.T) {
+	store := memStore{devices: map[string]*Device{
+		"C02FOOBAR": {
+			UUID:         "imported",
+			SerialNumber: "C02FOOBAR",
+			Description:  "Jane's laptop",
+			AssetTag:     "A1001",
+		},
+	}}
+	w := NewWorker(store, inmem.NewPubSub(), log.NewNopLogger())
+
+	msg, err := sync.MarshalEvent(sync.NewEvent([]dep.Device{
+		{SerialNumber: "C02FOOBAR", Model: "MacBook Pro", OpType: "modified"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.updateFromDEPSync(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	dev := store.devices["C02FOOBAR"]
+	if dev.Model != "MacBook Pro" {
+		t.Errorf("have model %q, want the model from DEP", dev.Model)
+	}
+	if dev.Description != "Jane's laptop" || dev.AssetTag != "A1001" {
+		t.Errorf("expected imported description and asset tag to be kept, got %+v", dev)
+	}
+}
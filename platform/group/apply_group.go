@@ -0,0 +1,88 @@
+package group
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+// ApplyGroup creates a group or replaces the members of an existing group
+// with the same name. A new group is assigned a UUID if it doesn't have one.
+// The members of a dynamic group are evaluated from its query instead.
+func (svc *GroupService) ApplyGroup(ctx context.Context, g *Group) error {
+	if g == nil || g.Name == "" {
+		return errors.New("group must have a name")
+	}
+	svc.mtx.Lock()
+	defer svc.mtx.Unlock()
+	if g.UUID == "" {
+		existing, err := svc.store.GroupByName(g.Name)
+		switch {
+		case err == nil:
+			g.UUID = existing.UUID
+		case IsNotFound(err):
+			g.UUID = uuid.NewV4().String()
+		default:
+			return errors.Wrapf(err, "get group %s", g.Name)
+		}
+	}
+	if g.IsDynamic() {
+		if _, err := ParseQuery(g.Query); err != nil {
+			return err
+		}
+		if svc.evaluator != nil {
+			return svc.evaluator.applyGroup(ctx, g)
+		}
+		g.UDIDs, g.Serials = nil, nil
+		return svc.store.Save(g)
+	}
+	g.UDIDs = appendUnique(nil, g.UDIDs...)
+	g.Serials = appendUnique(nil, g.Serials...)
+	return svc.store.Save(g)
+}
+
+type applyGroupRequest struct {
+	Group *Group `json:"group"`
+}
+
+type applyGroupResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r applyGroupResponse) Failed() error { return r.Err }
+
+func decodeApplyGroupRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req applyGroupRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeApplyGroupResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp applyGroupResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeApplyGroupEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(applyGroupRequest)
+		err = svc.ApplyGroup(ctx, req.Group)
+		return applyGroupResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+func (e Endpoints) ApplyGroup(ctx context.Context, g *Group) error {
+	request := applyGroupRequest{Group: g}
+	resp, err := e.ApplyGroupEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	return resp.(applyGroupResponse).Err
+}
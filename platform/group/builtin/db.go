@@ -0,0 +1,162 @@
+package builtin
+
+import (
+	"fmt"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/group"
+)
+
+const (
+	GroupBucket      = "mdm.Groups"
+	groupIndexBucket = "mdm.GroupIdx"
+)
+
+type DB struct {
+	*bolt.DB
+}
+
+func NewDB(db *bolt.DB) (*DB, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(groupIndexBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(GroupBucket))
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s bucket", GroupBucket)
+	}
+	datastore := &DB{
+		DB: db,
+	}
+	return datastore, nil
+}
+
+func (db *DB) List() ([]group.Group, error) {
+	var groups []group.Group
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(GroupBucket))
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var g group.Group
+			if err := group.UnmarshalGroup(v, &g); err != nil {
+				return err
+			}
+			groups = append(groups, g)
+		}
+		return nil
+	})
+	return groups, err
+}
+
+func (db *DB) Save(g *group.Group) error {
+	if err := g.Verify(); err != nil {
+		return err
+	}
+	existing, err := db.GroupByName(g.Name)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if err == nil && g.UUID != existing.UUID {
+		return fmt.Errorf("Group not saved: same name %s exists", g.Name)
+	}
+	tx, err := db.DB.Begin(true)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+	bkt := tx.Bucket([]byte(GroupBucket))
+	if bkt == nil {
+		return fmt.Errorf("bucket %q not found!", GroupBucket)
+	}
+	groupproto, err := group.MarshalGroup(g)
+	if err != nil {
+		return errors.Wrap(err, "marshalling group")
+	}
+	idxBucket := tx.Bucket([]byte(groupIndexBucket))
+	if idxBucket == nil {
+		return fmt.Errorf("bucket %q not found!", groupIndexBucket)
+	}
+
+	// a group can be renamed by saving it with the same UUID,
+	// in which case the old name is removed from the index.
+	if old := bkt.Get([]byte(g.UUID)); old != nil {
+		var prev group.Group
+		if err := group.UnmarshalGroup(old, &prev); err != nil {
+			return errors.Wrap(err, "unmarshal existing group")
+		}
+		if prev.Name != g.Name {
+			if err := idxBucket.Delete([]byte(prev.Name)); err != nil {
+				return errors.Wrap(err, "delete group idx from boltdb")
+			}
+		}
+	}
+
+	if err := idxBucket.Put([]byte(g.Name), []byte(g.UUID)); err != nil {
+		return errors.Wrap(err, "put group idx to boltdb")
+	}
+	if err := bkt.Put([]byte(g.UUID), groupproto); err != nil {
+		return errors.Wrap(err, "put group to boltdb")
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GroupByName(name string) (*group.Group, error) {
+	var g group.Group
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(GroupBucket))
+		ib := tx.Bucket([]byte(groupIndexBucket))
+		idx := ib.Get([]byte(name))
+		if idx == nil {
+			return &notFound{"Group", fmt.Sprintf("name %s", name)}
+		}
+		v := b.Get(idx)
+		if v == nil {
+			return &notFound{"Group", fmt.Sprintf("uuid %s", string(idx))}
+		}
+		return group.UnmarshalGroup(v, &g)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (db *DB) Delete(name string) error {
+	g, err := db.GroupByName(name)
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(GroupBucket))
+		i := tx.Bucket([]byte(groupIndexBucket))
+		if err := i.Delete([]byte(g.Name)); err != nil {
+			return err
+		}
+		return b.Delete([]byte(g.UUID))
+	})
+}
+
+type notFound struct {
+	ResourceType string
+	Message      string
+}
+
+func (e *notFound) Error() string {
+	return fmt.Sprintf("not found: %s %s", e.ResourceType, e.Message)
+}
+
+func (e *notFound) NotFound() bool {
+	return true
+}
+
+func isNotFound(err error) bool {
+	if _, ok := err.(*notFound); ok {
+		return true
+	}
+	return false
+}
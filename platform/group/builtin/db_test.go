@@ -0,0 +1,80 @@
+package builtin
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/micromdm/micromdm/platform/group"
+)
+
+func TestSave(t *testing.T) {
+	db := setupDB(t)
+	g := &group.Group{
+		UUID:    "a-b-c-d",
+		Name:    "lab",
+		UDIDs:   []string{"UDID-FOO"},
+		Serials: []string{"C02FOOBAR"},
+	}
+	if err := db.Save(g); err != nil {
+		t.Fatalf("saving group in datastore: %s", err)
+	}
+
+	byName, err := db.GroupByName("lab")
+	if err != nil {
+		t.Fatalf("getting group by name: %s", err)
+	}
+	if have, want := byName.UUID, g.UUID; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+	if !byName.HasDevice("", "C02FOOBAR") {
+		t.Errorf("expected serial C02FOOBAR to be a member of %s", byName.Name)
+	}
+
+	// a different group with the same name must not replace it.
+	if err := db.Save(&group.Group{UUID: "e-f-g-h", Name: "lab"}); err == nil {
+		t.Error("expected error saving group with duplicate name")
+	}
+
+	// renaming the group removes the old name.
+	g.Name = "classroom"
+	if err := db.Save(g); err != nil {
+		t.Fatalf("renaming group: %s", err)
+	}
+	if _, err := db.GroupByName("lab"); !isNotFound(err) {
+		t.Errorf("expected not found error for old group name, got %v", err)
+	}
+
+	groups, err := db.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(groups), 1; have != want {
+		t.Fatalf("have %d groups, want %d", have, want)
+	}
+
+	if err := db.Delete("classroom"); err != nil {
+		t.Fatalf("deleting group: %s", err)
+	}
+	if _, err := db.GroupByName("classroom"); !group.IsNotFound(err) {
+		t.Errorf("expected not found error after delete, got %v", err)
+	}
+}
+
+func setupDB(t *testing.T) *DB {
+	f, _ := ioutil.TempFile("", "bolt-")
+	f.Close()
+	os.Remove(f.Name())
+
+	db, err := bolt.Open(f.Name(), 0777, nil)
+	if err != nil {
+		t.Fatalf("couldn't open bolt, err %s\n", err)
+	}
+	groupDB, err := NewDB(db)
+	if err != nil {
+		t.Fatalf("couldn't create group DB, err %s\n", err)
+	}
+	return groupDB
+}
@@ -0,0 +1,81 @@
+package group
+
+import (
+	"net/url"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransport.ClientOption) (Service, error) {
+	u, err := url.Parse(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var applyGroupEndpoint endpoint.Endpoint
+	{
+		applyGroupEndpoint = httptransport.NewClient(
+			"PUT",
+			httputil.CopyURL(u, "/v1/groups"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeApplyGroupResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var getGroupsEndpoint endpoint.Endpoint
+	{
+		getGroupsEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/groups"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeGetGroupsResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var removeGroupsEndpoint endpoint.Endpoint
+	{
+		removeGroupsEndpoint = httptransport.NewClient(
+			"DELETE",
+			httputil.CopyURL(u, "/v1/groups"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeRemoveGroupsResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var addGroupMembersEndpoint endpoint.Endpoint
+	{
+		addGroupMembersEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, ""), // empty path, modified by the encodeRequest func
+			httputil.EncodeRequestWithToken(token, encodeGroupMembersRequest),
+			decodeGroupMembersResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var removeGroupMembersEndpoint endpoint.Endpoint
+	{
+		removeGroupMembersEndpoint = httptransport.NewClient(
+			"DELETE",
+			httputil.CopyURL(u, ""), // empty path, modified by the encodeRequest func
+			httputil.EncodeRequestWithToken(token, encodeGroupMembersRequest),
+			decodeGroupMembersResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	return Endpoints{
+		ApplyGroupEndpoint:         applyGroupEndpoint,
+		GetGroupsEndpoint:          getGroupsEndpoint,
+		RemoveGroupsEndpoint:       removeGroupsEndpoint,
+		AddGroupMembersEndpoint:    addGroupMembersEndpoint,
+		RemoveGroupMembersEndpoint: removeGroupMembersEndpoint,
+	}, nil
+}
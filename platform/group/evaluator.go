@@ -0,0 +1,239 @@
+package group
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/pubsub"
+)
+
+type DeviceStore interface {
+	List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error)
+	DeviceByUDID(ctx context.Context, udid string) (*device.Device, error)
+}
+
+// Evaluator keeps the members of dynamic groups up to date. A device is
+// re-evaluated every time it's updated, and a MembershipEvent is published
+// when it joins or leaves a group.
+type Evaluator struct {
+	store   Store
+	devices DeviceStore
+	pubsub  pubsub.PublishSubscriber
+	logger  log.Logger
+
+	// serializes updates to groups. The GroupService created WithEvaluator
+	// shares the lock.
+	mtx sync.Mutex
+}
+
+func NewEvaluator(store Store, devices DeviceStore, ps pubsub.PublishSubscriber, logger log.Logger) *Evaluator {
+	return &Evaluator{
+		store:   store,
+		devices: devices,
+		pubsub:  ps,
+		logger:  logger,
+	}
+}
+
+func (e *Evaluator) Run(ctx context.Context) error {
+	const subscription = "group_evaluator"
+	deviceEvents, err := e.pubsub.Subscribe(ctx, subscription, device.DeviceUpdatedTopic)
+	if err != nil {
+		return errors.Wrapf(err, "subscribing %s to %s", subscription, device.DeviceUpdatedTopic)
+	}
+
+	// devices may have changed while the server was not running.
+	if err := e.evaluateAll(ctx); err != nil {
+		level.Info(e.logger).Log(
+			"msg", "evaluate dynamic groups",
+			"err", err,
+		)
+	}
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-deviceEvents:
+			var dev device.Device
+			if err = device.UnmarshalDevice(ev.Message, &dev); err != nil {
+				err = errors.Wrap(err, "unmarshal device")
+				break
+			}
+			err = e.EvaluateDevice(ctx, dev.UDID)
+		}
+		if err != nil {
+			level.Info(e.logger).Log(
+				"msg", "evaluate dynamic groups for device",
+				"err", err,
+			)
+		}
+	}
+}
+
+// EvaluateDevice updates the membership of a single device in every
+// dynamic group. The device is read from the store, because the updates of
+// a device may be published out of order.
+func (e *Evaluator) EvaluateDevice(ctx context.Context, udid string) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	dev, err := e.devices.DeviceByUDID(ctx, udid)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get device %s", udid)
+	}
+	groups, err := e.store.List()
+	if err != nil {
+		return errors.Wrap(err, "list groups")
+	}
+	for _, g := range groups {
+		if !g.IsDynamic() {
+			continue
+		}
+		query, err := ParseQuery(g.Query)
+		if err != nil {
+			return errors.Wrapf(err, "parse query for group %s", g.Name)
+		}
+
+		wasMember := g.HasDevice(dev.UDID, dev.SerialNumber)
+		isMember := query.Match(*dev)
+		before := len(g.UDIDs) + len(g.Serials)
+		if isMember {
+			g.AddMembers(deviceMembers(*dev))
+		} else {
+			g.RemoveMembers(deviceMembers(*dev))
+		}
+		if before == len(g.UDIDs)+len(g.Serials) {
+			continue
+		}
+
+		if err := e.store.Save(&g); err != nil {
+			return errors.Wrapf(err, "save group %s", g.Name)
+		}
+		if wasMember != isMember {
+			if err := e.publish(ctx, &g, dev.UDID, dev.SerialNumber, isMember); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyGroup saves a dynamic group and evaluates its members. The members
+// of the group are computed, so the UDIDs and serials of g are replaced
+// with the current members. It is called by the GroupService, which holds
+// the lock.
+func (e *Evaluator) applyGroup(ctx context.Context, g *Group) error {
+	g.UDIDs, g.Serials = nil, nil
+	existing, err := e.store.GroupByName(g.Name)
+	if err != nil && !IsNotFound(err) {
+		return errors.Wrapf(err, "get group %s", g.Name)
+	}
+	if err == nil && existing.UUID == g.UUID {
+		g.UDIDs, g.Serials = existing.UDIDs, existing.Serials
+	}
+	if err := e.store.Save(g); err != nil {
+		return err
+	}
+	return e.evaluateGroup(ctx, g)
+}
+
+func (e *Evaluator) evaluateAll(ctx context.Context) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	groups, err := e.store.List()
+	if err != nil {
+		return errors.Wrap(err, "list groups")
+	}
+	for _, g := range groups {
+		if !g.IsDynamic() {
+			continue
+		}
+		if err := e.evaluateGroup(ctx, &g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateGroup recomputes all the members of a dynamic group.
+func (e *Evaluator) evaluateGroup(ctx context.Context, g *Group) error {
+	query, err := ParseQuery(g.Query)
+	if err != nil {
+		return errors.Wrapf(err, "parse query for group %s", g.Name)
+	}
+	devices, err := e.devices.List(ctx, device.ListDevicesOption{})
+	if err != nil {
+		return errors.Wrap(err, "list devices")
+	}
+
+	type change struct {
+		udid, serial string
+		joined       bool
+	}
+	var changes []change
+	previous := Group{UDIDs: g.UDIDs, Serials: g.Serials}
+	g.UDIDs, g.Serials = nil, nil
+	for _, dev := range devices {
+		wasMember := previous.HasDevice(dev.UDID, dev.SerialNumber)
+		isMember := query.Match(dev)
+		if isMember {
+			g.AddMembers(deviceMembers(dev))
+		}
+		if wasMember != isMember {
+			changes = append(changes, change{dev.UDID, dev.SerialNumber, isMember})
+		}
+		previous.RemoveMembers(deviceMembers(dev))
+	}
+
+	// whatever is left in previous are the members which no longer exist.
+	for _, udid := range previous.UDIDs {
+		changes = append(changes, change{udid: udid})
+	}
+	for _, serial := range previous.Serials {
+		changes = append(changes, change{serial: serial})
+	}
+
+	if err := e.store.Save(g); err != nil {
+		return errors.Wrapf(err, "save group %s", g.Name)
+	}
+	for _, c := range changes {
+		if err := e.publish(ctx, g, c.udid, c.serial, c.joined); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) publish(ctx context.Context, g *Group, udid, serial string, joined bool) error {
+	event := NewMembershipEvent(g, udid, serial, joined)
+	msg, err := MarshalMembershipEvent(event)
+	if err != nil {
+		return errors.Wrap(err, "marshal group membership event")
+	}
+	err = e.pubsub.Publish(ctx, MembershipChangedTopic, msg)
+	return errors.Wrapf(err, "publish membership event for group %s", g.Name)
+}
+
+// deviceMembers returns the identifiers used for the device in a
+// dynamic group.
+func deviceMembers(dev device.Device) Members {
+	var m Members
+	if dev.UDID != "" {
+		m.UDIDs = []string{dev.UDID}
+	}
+	if dev.SerialNumber != "" {
+		m.Serials = []string{dev.SerialNumber}
+	}
+	return m
+}
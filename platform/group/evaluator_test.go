@@ -0,0 +1,103 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/pubsub"
+	"github.com/micromdm/micromdm/platform/pubsub/inmem"
+)
+
+type mockDevices []device.Device
+
+func (m mockDevices) List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error) {
+	return m, nil
+}
+
+func (m mockDevices) DeviceByUDID(ctx context.Context, udid string) (*device.Device, error) {
+	for _, dev := range m {
+		if dev.UDID == udid {
+			return &dev, nil
+		}
+	}
+	return nil, mockNotFound{}
+}
+
+func TestEvaluator(t *testing.T) {
+	ctx := context.Background()
+	store := make(mockStore)
+	devices := mockDevices{
+		{UDID: "UDID-A", SerialNumber: "SERIAL-A", OSVersion: "10.13.6"},
+		{UDID: "UDID-B", SerialNumber: "SERIAL-B", OSVersion: "10.14.6"},
+	}
+	ps := inmem.NewPubSub()
+	events, err := ps.Subscribe(ctx, "test", MembershipChangedTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluator := NewEvaluator(store, devices, ps, log.NewNopLogger())
+	svc := New(store, WithEvaluator(evaluator))
+
+	if err := svc.ApplyGroup(ctx, &Group{Name: "outdated", Query: "os_version < 10.14"}); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := fmt.Sprint(store["outdated"].UDIDs), "[UDID-A]"; have != want {
+		t.Errorf("have members %s, want %s", have, want)
+	}
+	expectEvent(t, events, "UDID-A", true)
+
+	// updating device A removes it from the group, and B joins.
+	devices[0].OSVersion = "10.14.6"
+	err = evaluator.EvaluateDevice(ctx, "UDID-A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, "UDID-A", false)
+	devices[1].OSVersion = "10.12"
+	err = evaluator.EvaluateDevice(ctx, "UDID-B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, "UDID-B", true)
+	if have, want := fmt.Sprint(store["outdated"].UDIDs), "[UDID-B]"; have != want {
+		t.Errorf("have members %s, want %s", have, want)
+	}
+
+	// no event is published if the membership doesn't change.
+	devices[1].OSVersion = "10.12.1"
+	err = evaluator.EvaluateDevice(ctx, "UDID-B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected membership event %s", ev.Message)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	err = svc.AddGroupMembers(ctx, "outdated", Members{UDIDs: []string{"UDID-C"}})
+	if err == nil {
+		t.Error("expected error adding members to a dynamic group")
+	}
+}
+
+func expectEvent(t *testing.T, events <-chan pubsub.Event, udid string, joined bool) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		var event MembershipEvent
+		if err := UnmarshalMembershipEvent(ev.Message, &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.UDID != udid || event.Joined != joined {
+			t.Errorf("have event for %s joined=%v, want %s joined=%v", event.UDID, event.Joined, udid, joined)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for membership event for %s", udid)
+	}
+}
@@ -0,0 +1,68 @@
+package group
+
+import (
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/micromdm/micromdm/platform/group/internal/groupproto"
+)
+
+// MembershipChangedTopic is the topic of the events published when a device
+// joins or leaves a dynamic group.
+const MembershipChangedTopic = "mdm.GroupMembershipChanged"
+
+// MembershipEvent describes a device which joined or left a group.
+type MembershipEvent struct {
+	ID           string
+	Time         time.Time
+	GroupUUID    string
+	GroupName    string
+	UDID         string
+	SerialNumber string
+	Joined       bool
+}
+
+func NewMembershipEvent(g *Group, udid, serial string, joined bool) *MembershipEvent {
+	event := MembershipEvent{
+		ID:           uuid.NewV4().String(),
+		Time:         time.Now().UTC(),
+		GroupUUID:    g.UUID,
+		GroupName:    g.Name,
+		UDID:         udid,
+		SerialNumber: serial,
+		Joined:       joined,
+	}
+	return &event
+}
+
+// MarshalMembershipEvent serializes an event to a protocol buffer wire format.
+func MarshalMembershipEvent(e *MembershipEvent) ([]byte, error) {
+	return proto.Marshal(&groupproto.MembershipEvent{
+		Id:           e.ID,
+		Time:         e.Time.UnixNano(),
+		GroupUuid:    e.GroupUUID,
+		GroupName:    e.GroupName,
+		Udid:         e.UDID,
+		SerialNumber: e.SerialNumber,
+		Joined:       e.Joined,
+	})
+}
+
+// UnmarshalMembershipEvent parses a protocol buffer representation of data
+// into the event.
+func UnmarshalMembershipEvent(data []byte, e *MembershipEvent) error {
+	var pb groupproto.MembershipEvent
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	e.ID = pb.GetId()
+	e.Time = time.Unix(0, pb.GetTime()).UTC()
+	e.GroupUUID = pb.GetGroupUuid()
+	e.GroupName = pb.GetGroupName()
+	e.UDID = pb.GetUdid()
+	e.SerialNumber = pb.GetSerialNumber()
+	e.Joined = pb.GetJoined()
+	return nil
+}
@@ -0,0 +1,78 @@
+package group
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+func (svc *GroupService) GetGroups(ctx context.Context, opt GetGroupsOption) ([]Group, error) {
+	var groups []Group
+	if opt.FilterName != "" {
+		g, err := svc.store.GroupByName(opt.FilterName)
+		if err != nil {
+			return nil, err
+		}
+		groups = []Group{*g}
+	} else {
+		var err error
+		groups, err = svc.store.List()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.FilterUDID == "" && opt.FilterSerial == "" {
+		return groups, nil
+	}
+	var filtered []Group
+	for _, g := range groups {
+		if g.HasDevice(opt.FilterUDID, opt.FilterSerial) {
+			filtered = append(filtered, g)
+		}
+	}
+	return filtered, nil
+}
+
+type getGroupsRequest struct{ Opts GetGroupsOption }
+type getGroupsResponse struct {
+	Groups []Group `json:"groups"`
+	Err    error   `json:"err,omitempty"`
+}
+
+func (r getGroupsResponse) Failed() error { return r.Err }
+
+func decodeGetGroupsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var opts GetGroupsOption
+	err := httputil.DecodeJSONRequest(r, &opts)
+	return getGroupsRequest{Opts: opts}, err
+}
+
+func decodeGetGroupsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp getGroupsResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeGetGroupsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getGroupsRequest)
+		groups, err := svc.GetGroups(ctx, req.Opts)
+		return getGroupsResponse{
+			Groups: groups,
+			Err:    err,
+		}, nil
+	}
+}
+
+func (e Endpoints) GetGroups(ctx context.Context, opt GetGroupsOption) ([]Group, error) {
+	request := getGroupsRequest{opt}
+	response, err := e.GetGroupsEndpoint(ctx, request.Opts)
+	if err != nil {
+		return nil, err
+	}
+	return response.(getGroupsResponse).Groups, response.(getGroupsResponse).Err
+}
@@ -0,0 +1,116 @@
+package group
+
+import (
+	"errors"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/micromdm/micromdm/platform/group/internal/groupproto"
+)
+
+// Group is a named list of devices. Devices can be added to a static group
+// by UDID, by serial number, or both. Blueprints, commands and reports can
+// target the members of a group instead of a single device.
+//
+// A group with a Query is dynamic: its members are the devices matching
+// the query, and are kept up to date as devices change.
+type Group struct {
+	UUID    string   `json:"uuid"`
+	Name    string   `json:"name"`
+	UDIDs   []string `json:"udids,omitempty"`
+	Serials []string `json:"serials,omitempty"`
+	Query   string   `json:"query,omitempty"`
+}
+
+// Members is a list of devices to add to or remove from a group.
+type Members struct {
+	UDIDs   []string `json:"udids,omitempty"`
+	Serials []string `json:"serials,omitempty"`
+}
+
+func (g *Group) Verify() error {
+	if g.Name == "" || g.UUID == "" {
+		return errors.New("Group must have Name and UUID")
+	}
+	if g.Query != "" {
+		if _, err := ParseQuery(g.Query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsDynamic reports whether the group members are computed from a query.
+func (g *Group) IsDynamic() bool {
+	return g.Query != ""
+}
+
+// HasDevice reports whether a device with the UDID or the serial number
+// is a member of the group.
+func (g *Group) HasDevice(udid, serial string) bool {
+	return (udid != "" && contains(g.UDIDs, udid)) ||
+		(serial != "" && contains(g.Serials, serial))
+}
+
+// AddMembers adds the devices which are not already members of the group.
+func (g *Group) AddMembers(m Members) {
+	g.UDIDs = appendUnique(g.UDIDs, m.UDIDs...)
+	g.Serials = appendUnique(g.Serials, m.Serials...)
+}
+
+// RemoveMembers removes the devices from the group.
+func (g *Group) RemoveMembers(m Members) {
+	g.UDIDs = without(g.UDIDs, m.UDIDs)
+	g.Serials = without(g.Serials, m.Serials)
+}
+
+func MarshalGroup(g *Group) ([]byte, error) {
+	protogroup := groupproto.Group{
+		Uuid:    g.UUID,
+		Name:    g.Name,
+		Udids:   g.UDIDs,
+		Serials: g.Serials,
+		Query:   g.Query,
+	}
+	return proto.Marshal(&protogroup)
+}
+
+func UnmarshalGroup(data []byte, g *Group) error {
+	var pb groupproto.Group
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	g.UUID = pb.GetUuid()
+	g.Name = pb.GetName()
+	g.UDIDs = pb.GetUdids()
+	g.Serials = pb.GetSerials()
+	g.Query = pb.GetQuery()
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if v != "" && !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func without(list []string, remove []string) []string {
+	var out []string
+	for _, v := range list {
+		if !contains(remove, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package group
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+func (svc *GroupService) AddGroupMembers(ctx context.Context, name string, members Members) error {
+	return svc.updateMembers(name, func(g *Group) { g.AddMembers(members) })
+}
+
+func (svc *GroupService) RemoveGroupMembers(ctx context.Context, name string, members Members) error {
+	return svc.updateMembers(name, func(g *Group) { g.RemoveMembers(members) })
+}
+
+func (svc *GroupService) updateMembers(name string, update func(*Group)) error {
+	svc.mtx.Lock()
+	defer svc.mtx.Unlock()
+	g, err := svc.store.GroupByName(name)
+	if err != nil {
+		return err
+	}
+	if g.IsDynamic() {
+		return errors.Errorf("members of dynamic group %s are evaluated from its query", name)
+	}
+	update(g)
+	return svc.store.Save(g)
+}
+
+type groupMembersRequest struct {
+	Name    string  `json:"-"`
+	Members Members `json:"members"`
+}
+
+type groupMembersResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r groupMembersResponse) Failed() error { return r.Err }
+
+func decodeGroupMembersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	name, ok := mux.Vars(r)["name"]
+	if !ok {
+		return nil, errors.New("bad route")
+	}
+	req := groupMembersRequest{Name: name}
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func encodeGroupMembersRequest(ctx context.Context, r *http.Request, request interface{}) error {
+	req := request.(groupMembersRequest)
+	r.URL.Path = "/v1/groups/" + req.Name + "/members"
+	return httptransport.EncodeJSONRequest(ctx, r, request)
+}
+
+func decodeGroupMembersResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp groupMembersResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeAddGroupMembersEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(groupMembersRequest)
+		err = svc.AddGroupMembers(ctx, req.Name, req.Members)
+		return groupMembersResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+func MakeRemoveGroupMembersEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(groupMembersRequest)
+		err = svc.RemoveGroupMembers(ctx, req.Name, req.Members)
+		return groupMembersResponse{
+			Err: err,
+		}, nil
+	}
+}
+
+func (e Endpoints) AddGroupMembers(ctx context.Context, name string, members Members) error {
+	request := groupMembersRequest{Name: name, Members: members}
+	resp, err := e.AddGroupMembersEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	return resp.(groupMembersResponse).Err
+}
+
+func (e Endpoints) RemoveGroupMembers(ctx context.Context, name string, members Members) error {
+	request := groupMembersRequest{Name: name, Members: members}
+	resp, err := e.RemoveGroupMembersEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	return resp.(groupMembersResponse).Err
+}
@@ -0,0 +1,3 @@
+package groupproto
+
+//go:generate protoc --go_out=. group.proto
@@ -0,0 +1,201 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: group.proto
+
+package groupproto
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type Group struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Udids                []string `protobuf:"bytes,3,rep,name=udids,proto3" json:"udids,omitempty"`
+	Serials              []string `protobuf:"bytes,4,rep,name=serials,proto3" json:"serials,omitempty"`
+	Query                string   `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Group) Reset()         { *m = Group{} }
+func (m *Group) String() string { return proto.CompactTextString(m) }
+func (*Group) ProtoMessage()    {}
+func (*Group) Descriptor() ([]byte, []int) {
+	return fileDescriptor_group_b8f93991970b987b, []int{0}
+}
+func (m *Group) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Group.Unmarshal(m, b)
+}
+func (m *Group) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Group.Marshal(b, m, deterministic)
+}
+func (dst *Group) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Group.Merge(dst, src)
+}
+func (m *Group) XXX_Size() int {
+	return xxx_messageInfo_Group.Size(m)
+}
+func (m *Group) XXX_DiscardUnknown() {
+	xxx_messageInfo_Group.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Group proto.InternalMessageInfo
+
+func (m *Group) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *Group) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Group) GetUdids() []string {
+	if m != nil {
+		return m.Udids
+	}
+	return nil
+}
+
+func (m *Group) GetSerials() []string {
+	if m != nil {
+		return m.Serials
+	}
+	return nil
+}
+
+func (m *Group) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+type MembershipEvent struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time                 int64    `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	GroupUuid            string   `protobuf:"bytes,3,opt,name=group_uuid,json=groupUuid,proto3" json:"group_uuid,omitempty"`
+	GroupName            string   `protobuf:"bytes,4,opt,name=group_name,json=groupName,proto3" json:"group_name,omitempty"`
+	Udid                 string   `protobuf:"bytes,5,opt,name=udid,proto3" json:"udid,omitempty"`
+	SerialNumber         string   `protobuf:"bytes,6,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	Joined               bool     `protobuf:"varint,7,opt,name=joined,proto3" json:"joined,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MembershipEvent) Reset()         { *m = MembershipEvent{} }
+func (m *MembershipEvent) String() string { return proto.CompactTextString(m) }
+func (*MembershipEvent) ProtoMessage()    {}
+func (*MembershipEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_group_b8f93991970b987b, []int{1}
+}
+func (m *MembershipEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MembershipEvent.Unmarshal(m, b)
+}
+func (m *MembershipEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MembershipEvent.Marshal(b, m, deterministic)
+}
+func (dst *MembershipEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MembershipEvent.Merge(dst, src)
+}
+func (m *MembershipEvent) XXX_Size() int {
+	return xxx_messageInfo_MembershipEvent.Size(m)
+}
+func (m *MembershipEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_MembershipEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MembershipEvent proto.InternalMessageInfo
+
+func (m *MembershipEvent) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *MembershipEvent) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *MembershipEvent) GetGroupUuid() string {
+	if m != nil {
+		return m.GroupUuid
+	}
+	return ""
+}
+
+func (m *MembershipEvent) GetGroupName() string {
+	if m != nil {
+		return m.GroupName
+	}
+	return ""
+}
+
+func (m *MembershipEvent) GetUdid() string {
+	if m != nil {
+		return m.Udid
+	}
+	return ""
+}
+
+func (m *MembershipEvent) GetSerialNumber() string {
+	if m != nil {
+		return m.SerialNumber
+	}
+	return ""
+}
+
+func (m *MembershipEvent) GetJoined() bool {
+	if m != nil {
+		return m.Joined
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*Group)(nil), "groupproto.Group")
+	proto.RegisterType((*MembershipEvent)(nil), "groupproto.MembershipEvent")
+}
+
+func init() { proto.RegisterFile("group.proto", fileDescriptor_group_b8f93991970b987b) }
+
+var fileDescriptor_group_b8f93991970b987b = []byte{
+	// 232 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x90, 0xcf, 0x4a, 0xc4, 0x30,
+	0x10, 0xc6, 0x69, 0xd3, 0x76, 0xed, 0xf8, 0x0f, 0x06, 0x91, 0xb9, 0x08, 0x65, 0xbd, 0xf4, 0xe4,
+	0xc5, 0x67, 0x10, 0x4f, 0xee, 0x21, 0xe0, 0x79, 0xd9, 0x25, 0x41, 0x23, 0x6e, 0x52, 0x93, 0x46,
+	0xf0, 0xfd, 0x7c, 0x30, 0x99, 0x49, 0x5d, 0xf6, 0xf6, 0x7d, 0xbf, 0x19, 0x3a, 0xbf, 0x06, 0xce,
+	0xdf, 0x62, 0xc8, 0xd3, 0xc3, 0x14, 0xc3, 0x1c, 0x10, 0xa4, 0x48, 0x5e, 0x67, 0x68, 0x9f, 0xb9,
+	0x21, 0x42, 0x93, 0xb3, 0x33, 0x54, 0x0d, 0xd5, 0xd8, 0x6b, 0xc9, 0xcc, 0xfc, 0xee, 0x60, 0xa9,
+	0x2e, 0x8c, 0x33, 0xde, 0x40, 0x9b, 0x8d, 0x33, 0x89, 0xd4, 0xa0, 0xc6, 0x5e, 0x97, 0x82, 0x04,
+	0xab, 0x64, 0xa3, 0xdb, 0x7d, 0x26, 0x6a, 0x84, 0xff, 0x57, 0xde, 0xff, 0xca, 0x36, 0xfe, 0x50,
+	0x2b, 0x1f, 0x29, 0x65, 0xfd, 0x5b, 0xc1, 0xf5, 0x8b, 0x3d, 0xec, 0x6d, 0x4c, 0xef, 0x6e, 0x7a,
+	0xfa, 0xb6, 0x7e, 0xc6, 0x2b, 0xa8, 0x8f, 0xf7, 0xeb, 0x72, 0x7d, 0x76, 0xcb, 0x75, 0xa5, 0x25,
+	0xe3, 0x1d, 0x14, 0xf9, 0xad, 0xb8, 0x2a, 0xd9, 0xed, 0x85, 0xbc, 0xb2, 0xf0, 0x71, 0x2c, 0xda,
+	0xcd, 0xc9, 0x78, 0xc3, 0xee, 0xfc, 0x8f, 0xc6, 0x99, 0x45, 0x45, 0x32, 0xde, 0xc3, 0x65, 0x51,
+	0xdd, 0xfa, 0xcc, 0x3e, 0xd4, 0xc9, 0xf0, 0xa2, 0xc0, 0x8d, 0x30, 0xbc, 0x85, 0xee, 0x23, 0x38,
+	0x6f, 0x0d, 0xad, 0x86, 0x6a, 0x3c, 0xd3, 0x4b, 0xdb, 0x77, 0xf2, 0x88, 0x8f, 0x7f, 0x03, 0x00,
+	0x6e, 0xd6, 0xf4, 0x7b, 0x5f, 0x01, 0x00, 0x00,
+}
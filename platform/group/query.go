@@ -0,0 +1,330 @@
+package group
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/platform/device"
+)
+
+// Query is a predicate over device attributes which defines the members of
+// a dynamic group. Queries compare device fields to values and combine the
+// comparisons with AND, OR, NOT and parentheses:
+//
+//	model_name = "MacBook Pro" AND os_version < 10.15
+//	dep_profile_status = "assigned" OR NOT enrolled = true
+//
+// Custom device attributes are selected with the attributes. prefix, for
+// example attributes.cost_center = "1234".
+//
+// Supported operators are =, !=, <, <=, >, >= and CONTAINS. os_version is
+// compared numerically by version component, boolean fields only support
+// = and !=, and all other fields are compared as strings.
+type Query struct {
+	src  string
+	root queryNode
+}
+
+// ParseQuery parses the query string s.
+func ParseQuery(s string) (*Query, error) {
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errors.Errorf("query: unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &Query{src: s, root: root}, nil
+}
+
+// Match reports whether the device satisfies the query.
+func (q *Query) Match(dev device.Device) bool {
+	return q.root.match(dev)
+}
+
+func (q *Query) String() string {
+	return q.src
+}
+
+type fieldKind int
+
+const (
+	stringField fieldKind = iota
+	versionField
+	boolField
+)
+
+type queryField struct {
+	kind  fieldKind
+	value func(device.Device) string
+}
+
+// queryFields are the device fields which can be used in a query.
+var queryFields = map[string]queryField{
+	"uuid":                    {stringField, func(d device.Device) string { return d.UUID }},
+	"udid":                    {stringField, func(d device.Device) string { return d.UDID }},
+	"serial_number":           {stringField, func(d device.Device) string { return d.SerialNumber }},
+	"os_version":              {versionField, func(d device.Device) string { return d.OSVersion }},
+	"build_version":           {stringField, func(d device.Device) string { return d.BuildVersion }},
+	"product_name":            {stringField, func(d device.Device) string { return d.ProductName }},
+	"imei":                    {stringField, func(d device.Device) string { return d.IMEI }},
+	"meid":                    {stringField, func(d device.Device) string { return d.MEID }},
+	"model":                   {stringField, func(d device.Device) string { return d.Model }},
+	"model_name":              {stringField, func(d device.Device) string { return d.ModelName }},
+	"device_name":             {stringField, func(d device.Device) string { return d.DeviceName }},
+	"description":             {stringField, func(d device.Device) string { return d.Description }},
+	"color":                   {stringField, func(d device.Device) string { return d.Color }},
+	"asset_tag":               {stringField, func(d device.Device) string { return d.AssetTag }},
+	"dep_profile_status":      {stringField, func(d device.Device) string { return string(d.DEPProfileStatus) }},
+	"dep_profile_uuid":        {stringField, func(d device.Device) string { return d.DEPProfileUUID }},
+	"dep_profile_assigned_by": {stringField, func(d device.Device) string { return d.DEPProfileAssignedBy }},
+	"lifecycle":               {stringField, func(d device.Device) string { return string(d.Lifecycle) }},
+	"enrolled":                {boolField, func(d device.Device) string { return strconv.FormatBool(d.Enrolled) }},
+	"awaiting_configuration":  {boolField, func(d device.Device) string { return strconv.FormatBool(d.AwaitingConfiguration) }},
+}
+
+// attributePrefix selects a custom device attribute, as in attributes.owner.
+const attributePrefix = "attributes."
+
+func attributeField(key string) queryField {
+	return queryField{stringField, func(d device.Device) string { return d.Attributes[key] }}
+}
+
+type queryNode interface {
+	match(device.Device) bool
+}
+
+type andNode struct{ left, right queryNode }
+
+func (n andNode) match(d device.Device) bool { return n.left.match(d) && n.right.match(d) }
+
+type orNode struct{ left, right queryNode }
+
+func (n orNode) match(d device.Device) bool { return n.left.match(d) || n.right.match(d) }
+
+type notNode struct{ expr queryNode }
+
+func (n notNode) match(d device.Device) bool { return !n.expr.match(d) }
+
+type compareNode struct {
+	field queryField
+	op    string
+	value string
+}
+
+func (n compareNode) match(d device.Device) bool {
+	have := n.field.value(d)
+	if n.op == "CONTAINS" {
+		return strings.Contains(strings.ToLower(have), strings.ToLower(n.value))
+	}
+	var cmp int
+	switch n.field.kind {
+	case versionField:
+		cmp = device.CompareOSVersion(have, n.value)
+	default:
+		cmp = strings.Compare(have, n.value)
+	}
+	switch n.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lexQuery(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, errors.Errorf("query: unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{tokString, s[i+1 : i+1+end], i})
+			i += end + 2
+		case strings.ContainsRune("=!<>", c):
+			op := string(c)
+			if i+1 < len(s) && s[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, errors.Errorf("query: unexpected ! at position %d, use !=", i)
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		default:
+			start := i
+			for i < len(s) && !unicode.IsSpace(rune(s[i])) && !strings.ContainsRune("()\"=!<>", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, s[start:i], start})
+		}
+	}
+	tokens = append(tokens, token{tokEOF, "end of query", len(s)})
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *queryParser) peek() token { return p.tokens[p.pos] }
+
+func (p *queryParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) keyword(kw string) bool {
+	tok := p.peek()
+	if tok.kind == tokIdent && strings.EqualFold(tok.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.keyword("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errors.Errorf("query: expected ) at position %d, got %q", closing.pos, closing.text)
+		}
+		return expr, nil
+	case tokIdent:
+		return p.parseComparison(tok)
+	default:
+		return nil, errors.Errorf("query: expected field name at position %d, got %q", tok.pos, tok.text)
+	}
+}
+
+func (p *queryParser) parseComparison(name token) (queryNode, error) {
+	field, ok := queryFields[strings.ToLower(name.text)]
+	if key := strings.TrimPrefix(name.text, attributePrefix); key != name.text && key != "" {
+		field, ok = attributeField(key), true
+	}
+	if !ok {
+		return nil, errors.Errorf("query: unknown field %q at position %d", name.text, name.pos)
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == tokOp:
+	case op.kind == tokIdent && strings.EqualFold(op.text, "CONTAINS"):
+		op.text = "CONTAINS"
+	default:
+		return nil, errors.Errorf("query: expected operator after %s at position %d, got %q", name.text, op.pos, op.text)
+	}
+
+	value := p.next()
+	if value.kind != tokIdent && value.kind != tokString {
+		return nil, errors.Errorf("query: expected value after %s %s at position %d, got %q", name.text, op.text, value.pos, value.text)
+	}
+
+	if field.kind == boolField {
+		if op.text != "=" && op.text != "!=" {
+			return nil, errors.Errorf("query: operator %s is not supported for boolean field %s", op.text, name.text)
+		}
+		b, err := strconv.ParseBool(value.text)
+		if err != nil {
+			return nil, errors.Errorf("query: field %s must be compared to true or false, got %q", name.text, value.text)
+		}
+		value.text = strconv.FormatBool(b)
+	}
+
+	return compareNode{field: field, op: op.text, value: value.text}, nil
+}
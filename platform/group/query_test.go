@@ -0,0 +1,70 @@
+package group
+
+import (
+	"testing"
+
+	"github.com/micromdm/micromdm/platform/device"
+)
+
+func TestQuery(t *testing.T) {
+	macbook := device.Device{
+		UDID:             "UDID-FOO",
+		SerialNumber:     "C02FOOBAR",
+		ModelName:        "MacBook Pro",
+		OSVersion:        "10.14.6",
+		DEPProfileStatus: device.ASSIGNED,
+		Enrolled:         true,
+		Attributes:       device.Attributes{"cost_center": "1234"},
+	}
+
+	var tests = []struct {
+		query string
+		match bool
+	}{
+		{`model_name = "MacBook Pro"`, true},
+		{`model_name = "MacBook Pro" AND os_version < 10.15`, true},
+		{`model_name = "MacBook Pro" AND os_version >= 10.15`, false},
+		{`os_version > 10.9`, true},
+		{`dep_profile_status = assigned`, true},
+		{`dep_profile_status != assigned OR enrolled = false`, false},
+		{`NOT enrolled = false`, true},
+		{`(model_name = iMac OR model_name = "MacBook Pro") and enrolled = TRUE`, true},
+		{`model_name contains macbook`, true},
+		{`serial_number = C02FOOBAR AND NOT (udid = UDID-FOO)`, false},
+		{`attributes.cost_center = "1234"`, true},
+		{`attributes.owner = jane`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have, want := q.Match(macbook), tt.match; have != want {
+				t.Errorf("have %v, want %v", have, want)
+			}
+		})
+	}
+}
+
+func TestParseQuery_errors(t *testing.T) {
+	var tests = []string{
+		``,
+		`model_name`,
+		`model_name =`,
+		`unknown_field = foo`,
+		`enrolled = maybe`,
+		`enrolled < true`,
+		`model_name = "MacBook Pro`,
+		`(model_name = iMac`,
+		`model_name = iMac OR`,
+		`model_name ! iMac`,
+		`model_name = iMac enrolled = true`,
+		`attributes. = foo`,
+	}
+	for _, query := range tests {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("expected error parsing query %q", query)
+		}
+	}
+}
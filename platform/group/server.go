@@ -0,0 +1,70 @@
+package group
+
+import (
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+type Endpoints struct {
+	ApplyGroupEndpoint         endpoint.Endpoint
+	GetGroupsEndpoint          endpoint.Endpoint
+	RemoveGroupsEndpoint       endpoint.Endpoint
+	AddGroupMembersEndpoint    endpoint.Endpoint
+	RemoveGroupMembersEndpoint endpoint.Endpoint
+}
+
+func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ApplyGroupEndpoint:         endpoint.Chain(outer, others...)(MakeApplyGroupEndpoint(s)),
+		GetGroupsEndpoint:          endpoint.Chain(outer, others...)(MakeGetGroupsEndpoint(s)),
+		RemoveGroupsEndpoint:       endpoint.Chain(outer, others...)(MakeRemoveGroupsEndpoint(s)),
+		AddGroupMembersEndpoint:    endpoint.Chain(outer, others...)(MakeAddGroupMembersEndpoint(s)),
+		RemoveGroupMembersEndpoint: endpoint.Chain(outer, others...)(MakeRemoveGroupMembersEndpoint(s)),
+	}
+}
+
+func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.ServerOption) {
+	// PUT     /v1/groups					create or replace a group on the server
+	// POST    /v1/groups					get a list of groups managed by the server
+	// DELETE  /v1/groups					remove one or more groups from the server
+	// POST    /v1/groups/{name}/members	add devices to a group
+	// DELETE  /v1/groups/{name}/members	remove devices from a group
+
+	r.Methods("PUT").Path("/v1/groups").Handler(httptransport.NewServer(
+		e.ApplyGroupEndpoint,
+		decodeApplyGroupRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/groups").Handler(httptransport.NewServer(
+		e.GetGroupsEndpoint,
+		decodeGetGroupsRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("DELETE").Path("/v1/groups").Handler(httptransport.NewServer(
+		e.RemoveGroupsEndpoint,
+		decodeRemoveGroupsRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/groups/{name}/members").Handler(httptransport.NewServer(
+		e.AddGroupMembersEndpoint,
+		decodeGroupMembersRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("DELETE").Path("/v1/groups/{name}/members").Handler(httptransport.NewServer(
+		e.RemoveGroupMembersEndpoint,
+		decodeGroupMembersRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+}
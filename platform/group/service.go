@@ -0,0 +1,69 @@
+package group
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type GetGroupsOption struct {
+	FilterName string `json:"filter_name"`
+
+	// FilterUDID and FilterSerial only return the groups
+	// which have the device as a member.
+	FilterUDID   string `json:"filter_udid"`
+	FilterSerial string `json:"filter_serial"`
+}
+
+type Service interface {
+	ApplyGroup(ctx context.Context, g *Group) error
+	GetGroups(ctx context.Context, opt GetGroupsOption) ([]Group, error)
+	RemoveGroups(ctx context.Context, names []string) error
+	AddGroupMembers(ctx context.Context, name string, members Members) error
+	RemoveGroupMembers(ctx context.Context, name string, members Members) error
+}
+
+type Store interface {
+	Save(*Group) error
+	GroupByName(name string) (*Group, error)
+	List() ([]Group, error)
+	Delete(string) error
+}
+
+type GroupService struct {
+	store     Store
+	evaluator *Evaluator
+
+	// serializes membership updates, which read and write the whole group.
+	// It is the lock of the evaluator if the service has one.
+	mtx *sync.Mutex
+}
+
+type Option func(*GroupService)
+
+// WithEvaluator evaluates the members of dynamic groups when they are applied.
+func WithEvaluator(e *Evaluator) Option {
+	return func(svc *GroupService) {
+		svc.evaluator = e
+		svc.mtx = &e.mtx
+	}
+}
+
+func New(store Store, opts ...Option) *GroupService {
+	svc := &GroupService{store: store, mtx: new(sync.Mutex)}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+func IsNotFound(err error) bool {
+	type notFoundError interface {
+		error
+		NotFound() bool
+	}
+
+	_, ok := errors.Cause(err).(notFoundError)
+	return ok
+}
@@ -0,0 +1,101 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type mockStore map[string]Group
+
+func (m mockStore) Save(g *Group) error {
+	m[g.Name] = *g
+	return nil
+}
+
+func (m mockStore) GroupByName(name string) (*Group, error) {
+	g, ok := m[name]
+	if !ok {
+		return nil, mockNotFound{}
+	}
+	return &g, nil
+}
+
+func (m mockStore) List() ([]Group, error) {
+	var groups []Group
+	for _, g := range m {
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func (m mockStore) Delete(name string) error {
+	delete(m, name)
+	return nil
+}
+
+type mockNotFound struct{}
+
+func (mockNotFound) Error() string  { return "not found" }
+func (mockNotFound) NotFound() bool { return true }
+
+func TestGroupMembers(t *testing.T) {
+	store := make(mockStore)
+	svc := New(store)
+	ctx := context.Background()
+
+	g := &Group{Name: "lab", UDIDs: []string{"UDID-A", "UDID-A"}}
+	if err := svc.ApplyGroup(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	if g.UUID == "" {
+		t.Fatal("expected a UUID to be assigned to the new group")
+	}
+	uuid := g.UUID
+
+	// applying the group again by name keeps the UUID.
+	if err := svc.ApplyGroup(ctx, &Group{Name: "lab", UDIDs: []string{"UDID-A"}}); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := store["lab"].UUID, uuid; have != want {
+		t.Errorf("have uuid %s, want %s", have, want)
+	}
+
+	err := svc.AddGroupMembers(ctx, "lab", Members{
+		UDIDs:   []string{"UDID-A", "UDID-B"},
+		Serials: []string{"C02FOOBAR"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := fmt.Sprint(store["lab"].UDIDs), "[UDID-A UDID-B]"; have != want {
+		t.Errorf("have members %s, want %s", have, want)
+	}
+
+	groups, err := svc.GetGroups(ctx, GetGroupsOption{FilterSerial: "C02FOOBAR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(groups), 1; have != want {
+		t.Fatalf("have %d groups for serial, want %d", have, want)
+	}
+
+	if err := svc.RemoveGroupMembers(ctx, "lab", Members{UDIDs: []string{"UDID-A"}}); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := fmt.Sprint(store["lab"].UDIDs), "[UDID-B]"; have != want {
+		t.Errorf("have members %s, want %s", have, want)
+	}
+
+	groups, err = svc.GetGroups(ctx, GetGroupsOption{FilterUDID: "UDID-A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(groups), 0; have != want {
+		t.Errorf("have %d groups for removed udid, want %d", have, want)
+	}
+
+	if err := svc.AddGroupMembers(ctx, "unknown", Members{UDIDs: []string{"UDID-A"}}); !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+// Package inventory periodically queues inventory commands for enrolled devices.
+package inventory
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/command"
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/pubsub"
+	"github.com/micromdm/micromdm/platform/queue"
+)
+
+// Inventory request types which can be scheduled.
+const (
+	DeviceInformation        = "DeviceInformation"
+	InstalledApplicationList = "InstalledApplicationList"
+	ProfileList              = "ProfileList"
+	SecurityInfo             = "SecurityInfo"
+)
+
+// DeviceInformationQueries are the queries sent with every scheduled
+// DeviceInformation command.
+var DeviceInformationQueries = []string{
+	"UDID",
+	"DeviceName",
+	"OSVersion",
+	"BuildVersion",
+	"ModelName",
+	"Model",
+	"ProductName",
+	"SerialNumber",
+	"DeviceCapacity",
+	"AvailableDeviceCapacity",
+	"BatteryLevel",
+	"IMEI",
+	"MEID",
+	"IsSupervised",
+	"IsDeviceLocatorServiceEnabled",
+	"IsActivationLockEnabled",
+	"IsCloudBackupEnabled",
+	"LastCloudBackupDate",
+	"LocalHostName",
+	"HostName",
+	"WiFiMAC",
+	"BluetoothMAC",
+	"EthernetMACs",
+	"OSUpdateSettings",
+}
+
+const defaultCheckInterval = 5 * time.Minute
+
+// Config configures the inventory scheduler.
+type Config struct {
+	// Intervals maps an inventory request type to how often it is sent
+	// to each device. Request types with a zero interval are not scheduled.
+	Intervals map[string]time.Duration
+
+	// Jitter is the maximum delay added to each device's schedule,
+	// to avoid queueing commands for the whole fleet at once.
+	Jitter time.Duration
+
+	// CheckInterval is how often the scheduler looks for devices
+	// which are due for inventory. Defaults to 5 minutes.
+	CheckInterval time.Duration
+}
+
+type DeviceStore interface {
+	List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error)
+}
+
+type QueueStore interface {
+	DeviceCommand(udid string) (*queue.DeviceCommand, error)
+}
+
+// Scheduler queues inventory commands on a fixed interval per request type.
+type Scheduler struct {
+	config  Config
+	devices DeviceStore
+	queue   QueueStore
+	cmdsvc  command.Service
+	sub     pubsub.Subscriber
+	logger  log.Logger
+
+	started time.Time
+	now     func() time.Time
+
+	mtx    sync.Mutex
+	queued map[string]pendingCommand // by udid and request type
+}
+
+// pendingCommand is a command queued by the scheduler which is not yet in
+// the device queue.
+type pendingCommand struct {
+	uuid string
+	at   time.Time
+}
+
+func NewScheduler(
+	config Config,
+	devices DeviceStore,
+	q QueueStore,
+	cmdsvc command.Service,
+	sub pubsub.Subscriber,
+	logger log.Logger,
+) *Scheduler {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaultCheckInterval
+	}
+	return &Scheduler{
+		config:  config,
+		devices: devices,
+		queue:   q,
+		cmdsvc:  cmdsvc,
+		sub:     sub,
+		logger:  logger,
+		now:     time.Now,
+		queued:  make(map[string]pendingCommand),
+	}
+}
+
+// Enabled reports whether at least one inventory request type is scheduled.
+func (s *Scheduler) Enabled() bool {
+	return len(s.requestTypes()) > 0
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	const subscription = "inventory_scheduler"
+	enrolledEvents, err := s.sub.Subscribe(ctx, subscription, device.DeviceEnrolledTopic)
+	if err != nil {
+		return errors.Wrapf(err, "subscribing %s to %s", subscription, device.DeviceEnrolledTopic)
+	}
+
+	s.started = s.now()
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-enrolledEvents:
+			err = s.handleEnrollment(ctx, ev.Message)
+		case <-ticker.C:
+			err = s.queueDueCommands(ctx)
+		}
+		if err != nil {
+			level.Info(s.logger).Log(
+				"msg", "schedule inventory commands",
+				"err", err,
+			)
+		}
+	}
+}
+
+// handleEnrollment queues every scheduled inventory command as soon as a
+// device enrolls for the first time.
+func (s *Scheduler) handleEnrollment(ctx context.Context, message []byte) error {
+	var ev mdmsvc.CheckinEvent
+	if err := mdmsvc.UnmarshalCheckinEvent(message, &ev); err != nil {
+		return errors.Wrap(err, "unmarshal checkin event")
+	}
+	if ev.Command.UserID != "" || ev.Command.EnrollmentID != "" {
+		return nil
+	}
+	udid := ev.Command.UDID
+	pending, _, err := s.commandState(udid)
+	if err != nil {
+		return err
+	}
+	for _, requestType := range s.requestTypes() {
+		if pending[requestType] {
+			continue
+		}
+		if err := s.queueCommand(ctx, udid, requestType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) queueDueCommands(ctx context.Context) error {
+	requestTypes := s.requestTypes()
+	if len(requestTypes) == 0 {
+		return nil
+	}
+	devices, err := s.devices.List(ctx, device.ListDevicesOption{})
+	if err != nil {
+		return errors.Wrap(err, "list devices for inventory")
+	}
+	now := s.now()
+	for _, dev := range devices {
+		if !dev.Enrolled || dev.UDID == "" {
+			continue
+		}
+		pending, last, err := s.commandState(dev.UDID)
+		if err != nil {
+			level.Info(s.logger).Log(
+				"msg", "get command queue for inventory",
+				"device_udid", dev.UDID,
+				"err", err,
+			)
+			continue
+		}
+		for _, requestType := range requestTypes {
+			if pending[requestType] {
+				continue
+			}
+			if now.Before(s.nextRun(dev.UDID, requestType, last[requestType])) {
+				continue
+			}
+			if err := s.queueCommand(ctx, dev.UDID, requestType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nextRun returns the time a request type is due for a device.
+// Devices which never received the command are spread over the jitter
+// window following the scheduler start. After that, the command is due an
+// interval after it was last queued, which keeps the device's place in the
+// schedule.
+func (s *Scheduler) nextRun(udid, requestType string, last time.Time) time.Time {
+	if last.IsZero() {
+		return s.started.Add(s.jitter(udid, requestType))
+	}
+	return last.Add(s.config.Intervals[requestType])
+}
+
+// jitter returns a stable offset for the device and request type,
+// so that a device keeps the same place in the schedule.
+func (s *Scheduler) jitter(udid, requestType string) time.Duration {
+	if s.config.Jitter <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(udid + requestType))
+	return time.Duration(h.Sum64() % uint64(s.config.Jitter))
+}
+
+// commandState returns the request types that are still pending in the
+// device queue and the last time each request type was queued.
+func (s *Scheduler) commandState(udid string) (map[string]bool, map[string]time.Time, error) {
+	pending := make(map[string]bool)
+	last := make(map[string]time.Time)
+
+	dc, err := s.queue.DeviceCommand(udid)
+	if err != nil && !isNotFound(err) {
+		return nil, nil, errors.Wrapf(err, "get command queue for udid %s", udid)
+	}
+	inQueue := make(map[string]bool)
+	if dc != nil {
+		for _, list := range [][]queue.Command{dc.Commands, dc.NotNow} {
+			for _, c := range list {
+				pending[c.RequestType()] = true
+			}
+		}
+		for _, list := range [][]queue.Command{dc.Commands, dc.NotNow, dc.Completed, dc.Failed} {
+			for _, c := range list {
+				inQueue[c.UUID] = true
+				requestType := c.RequestType()
+				if c.CreatedAt.After(last[requestType]) {
+					last[requestType] = c.CreatedAt
+				}
+			}
+		}
+	}
+
+	// commands are added to the queue asynchronously, so also consider
+	// the commands queued by the scheduler which are not in the queue yet.
+	// A command which never reaches the queue is forgotten after its
+	// interval, so that it is queued again.
+	now := s.now()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, requestType := range s.requestTypes() {
+		key := udid + requestType
+		qc, ok := s.queued[key]
+		if !ok {
+			continue
+		}
+		if inQueue[qc.uuid] || now.Sub(qc.at) >= s.config.Intervals[requestType] {
+			delete(s.queued, key)
+			continue
+		}
+		pending[requestType] = true
+		if qc.at.After(last[requestType]) {
+			last[requestType] = qc.at
+		}
+	}
+	return pending, last, nil
+}
+
+func (s *Scheduler) queueCommand(ctx context.Context, udid, requestType string) error {
+	request := &mdm.CommandRequest{
+		UDID:    udid,
+		Command: &mdm.Command{RequestType: requestType},
+	}
+	switch requestType {
+	case DeviceInformation:
+		request.Command.DeviceInformation = &mdm.DeviceInformation{
+			Queries: DeviceInformationQueries,
+		}
+	case InstalledApplicationList:
+		request.Command.InstalledApplicationList = &mdm.InstalledApplicationList{}
+	}
+	queuedAt := s.now()
+	payload, err := s.cmdsvc.NewCommand(ctx, request)
+	if err != nil {
+		return errors.Wrapf(err, "queue %s command for udid %s", requestType, udid)
+	}
+
+	s.mtx.Lock()
+	s.queued[udid+requestType] = pendingCommand{uuid: payload.CommandUUID, at: queuedAt}
+	s.mtx.Unlock()
+
+	level.Debug(s.logger).Log(
+		"msg", "queued inventory command",
+		"request_type", requestType,
+		"device_udid", udid,
+	)
+	return nil
+}
+
+func (s *Scheduler) requestTypes() []string {
+	var types []string
+	for _, requestType := range []string{DeviceInformation, InstalledApplicationList, ProfileList, SecurityInfo} {
+		if s.config.Intervals[requestType] > 0 {
+			types = append(types, requestType)
+		}
+	}
+	return types
+}
+
+func isNotFound(err error) bool {
+	type notFoundErr interface {
+		error
+		NotFound() bool
+	}
+
+	_, ok := errors.Cause(err).(notFoundErr)
+	return ok
+}
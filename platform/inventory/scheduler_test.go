@@ -0,0 +1,186 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/groob/plist"
+
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/queue"
+)
+
+type mockDevices []device.Device
+
+func (m mockDevices) List(ctx context.Context, opt device.ListDevicesOption) ([]device.Device, error) {
+	return m, nil
+}
+
+type mockQueue map[string]*queue.DeviceCommand
+
+func (m mockQueue) DeviceCommand(udid string) (*queue.DeviceCommand, error) {
+	dc, ok := m[udid]
+	if !ok {
+		return nil, notFound{}
+	}
+	return dc, nil
+}
+
+type notFound struct{}
+
+func (notFound) Error() string  { return "not found" }
+func (notFound) NotFound() bool { return true }
+
+type mockCommands struct {
+	requests []*mdm.CommandRequest
+}
+
+func (m *mockCommands) NewCommand(ctx context.Context, req *mdm.CommandRequest) (*mdm.CommandPayload, error) {
+	m.requests = append(m.requests, req)
+	return mdm.NewCommandPayload(req)
+}
+
+func queuedCommand(t *testing.T, requestType string, createdAt time.Time) queue.Command {
+	payload, err := plist.Marshal(&mdm.CommandPayload{
+		CommandUUID: requestType,
+		Command:     &mdm.Command{RequestType: requestType},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queue.Command{UUID: requestType, Payload: payload, CreatedAt: createdAt}
+}
+
+func TestQueueDueCommands(t *testing.T) {
+	now := time.Now()
+	devices := mockDevices{
+		{UDID: "never-inventoried", Enrolled: true},
+		{UDID: "recently-inventoried", Enrolled: true},
+		{UDID: "pending", Enrolled: true},
+		{UDID: "unenrolled", Enrolled: false},
+	}
+	q := mockQueue{
+		"recently-inventoried": {
+			DeviceUDID: "recently-inventoried",
+			Completed:  []queue.Command{queuedCommand(t, SecurityInfo, now.Add(-time.Hour))},
+		},
+		"pending": {
+			DeviceUDID: "pending",
+			Commands:   []queue.Command{queuedCommand(t, SecurityInfo, now.Add(-48*time.Hour))},
+		},
+	}
+	cmds := new(mockCommands)
+	config := Config{Intervals: map[string]time.Duration{SecurityInfo: 24 * time.Hour}}
+	s := NewScheduler(config, devices, q, cmds, nil, log.NewNopLogger())
+	s.started = now.Add(-time.Minute)
+
+	if err := s.queueDueCommands(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(cmds.requests), 1; have != want {
+		t.Fatalf("have %d queued commands, want %d", have, want)
+	}
+	if have, want := cmds.requests[0].UDID, "never-inventoried"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+
+	// the command was queued, so it must not be queued again
+	// before the queue has picked it up.
+	if err := s.queueDueCommands(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(cmds.requests), 1; have != want {
+		t.Errorf("have %d queued commands after second run, want %d", have, want)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	s := NewScheduler(Config{Jitter: time.Hour}, nil, nil, nil, nil, log.NewNopLogger())
+	for _, udid := range []string{"a", "b", "c", "d"} {
+		j := s.jitter(udid, DeviceInformation)
+		if j < 0 || j >= time.Hour {
+			t.Errorf("jitter %s out of range for udid %s", j, udid)
+		}
+		if j != s.jitter(udid, DeviceInformation) {
+			t.Errorf("expected stable jitter for udid %s", udid)
+		}
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	config := Config{Jitter: time.Hour, Intervals: map[string]time.Duration{DeviceInformation: 24 * time.Hour}}
+	s := NewScheduler(config, nil, nil, nil, nil, log.NewNopLogger())
+	offset := s.jitter("a", DeviceInformation)
+	if have, want := s.nextRun("a", DeviceInformation, time.Time{}), s.started.Add(offset); !have.Equal(want) {
+		t.Errorf("have first run %s, want %s", have, want)
+	}
+	// the offset is only applied to the first run, so the period stays
+	// the interval.
+	last := s.started.Add(offset)
+	if have, want := s.nextRun("a", DeviceInformation, last), last.Add(24*time.Hour); !have.Equal(want) {
+		t.Errorf("have next run %s, want %s", have, want)
+	}
+}
+
+// storingCommands adds each command to the device queue before NewCommand
+// returns, with the creation time the queue would have set.
+type storingCommands struct {
+	queue     mockQueue
+	createdAt time.Time
+	requests  int
+}
+
+func (m *storingCommands) NewCommand(ctx context.Context, req *mdm.CommandRequest) (*mdm.CommandPayload, error) {
+	payload, err := mdm.NewCommandPayload(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := plist.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	m.requests++
+	dc := m.queue[req.UDID]
+	dc.Commands = append(dc.Commands, queue.Command{UUID: payload.CommandUUID, Payload: data, CreatedAt: m.createdAt})
+	return payload, nil
+}
+
+func TestQueuedCommandStoredFirst(t *testing.T) {
+	now := time.Now()
+	dc := &queue.DeviceCommand{DeviceUDID: "UDID-1"}
+	q := mockQueue{"UDID-1": dc}
+	cmds := &storingCommands{queue: q, createdAt: now.Add(-time.Second)}
+	config := Config{Intervals: map[string]time.Duration{SecurityInfo: 24 * time.Hour}}
+	s := NewScheduler(config, mockDevices{{UDID: "UDID-1", Enrolled: true}}, q, cmds, nil, log.NewNopLogger())
+	s.now = func() time.Time { return now }
+	s.started = now.Add(-time.Minute)
+
+	if err := s.queueDueCommands(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cmds.requests != 1 {
+		t.Fatalf("have %d queued commands, want 1", cmds.requests)
+	}
+
+	// the device acknowledges the command.
+	dc.Completed, dc.Commands = dc.Commands, nil
+	pending, _, err := s.commandState("UDID-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending[SecurityInfo] {
+		t.Error("want completed command not pending")
+	}
+
+	// the command is due again an interval after it was queued.
+	s.now = func() time.Time { return now.Add(24 * time.Hour) }
+	if err := s.queueDueCommands(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cmds.requests != 2 {
+		t.Errorf("have %d queued commands after the interval, want 2", cmds.requests)
+	}
+}
@@ -5,11 +5,40 @@ import (
 	"net/http"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
 	"github.com/micromdm/micromdm/pkg/httputil"
 )
 
-func (svc *ProfileService) ApplyProfile(ctx context.Context, p *Profile) error {
-	return svc.store.Save(p)
+// ApplyProfile saves the profile, and returns the lint warnings of the
+// profile. Profiles with lint errors are rejected.
+func (svc *ProfileService) ApplyProfile(ctx context.Context, p *Profile) ([]LintProblem, error) {
+	var warnings []LintProblem
+	if p != nil {
+		problems := p.Mobileconfig.Lint()
+		if err := lintErrors(problems); err != nil {
+			return nil, err
+		}
+		warnings = problems
+	}
+	if p != nil && (p.Mobileconfig.IsTemplate() || p.Encrypt) && p.Mobileconfig.IsSigned() {
+		if err := svc.checkSigner(); err != nil {
+			return nil, errors.Wrap(err, "signed templates and encrypted profiles are signed again for each device")
+		}
+	}
+	if err := svc.store.Save(p); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// checkSigner returns an error if the server can't sign profiles.
+func (svc *ProfileService) checkSigner() error {
+	if svc.signer == nil {
+		return errors.New("server has no signing identity")
+	}
+	_, err := svc.signer.Sign(Mobileconfig(validationProfile))
+	return err
 }
 
 type applyProfileRequest struct {
@@ -17,7 +46,8 @@ type applyProfileRequest struct {
 }
 
 type applyProfileResponse struct {
-	Err error `json:"err,omitempty"`
+	Warnings []LintProblem `json:"warnings,omitempty"`
+	Err      error         `json:"err,omitempty"`
 }
 
 func (r applyProfileResponse) Failed() error { return r.Err }
@@ -37,18 +67,20 @@ func decodeApplyProfileResponse(_ context.Context, r *http.Response) (interface{
 func MakeApplyProfileEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(applyProfileRequest)
-		err = svc.ApplyProfile(ctx, req.Profile)
+		warnings, err := svc.ApplyProfile(ctx, req.Profile)
 		return applyProfileResponse{
-			Err: err,
+			Warnings: warnings,
+			Err:      err,
 		}, nil
 	}
 }
 
-func (e Endpoints) ApplyProfile(ctx context.Context, p *Profile) error {
+func (e Endpoints) ApplyProfile(ctx context.Context, p *Profile) ([]LintProblem, error) {
 	request := applyProfileRequest{Profile: p}
 	resp, err := e.ApplyProfileEndpoint(ctx, request)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return resp.(applyProfileResponse).Err
+	response := resp.(applyProfileResponse)
+	return response.Warnings, response.Err
 }
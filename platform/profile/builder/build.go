@@ -0,0 +1,240 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/groob/plist"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/micromdm/micromdm/mdm/enroll"
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+// Request describes a profile built from typed payloads.
+type Request struct {
+	Identifier        string `json:"id"`
+	DisplayName       string `json:"display_name,omitempty"`
+	Description       string `json:"description,omitempty"`
+	Organization      string `json:"organization,omitempty"`
+	Scope             string `json:"scope,omitempty"`
+	RemovalDisallowed bool   `json:"removal_disallowed,omitempty"`
+
+	// Sign signs the profile with the signing identity of the server, and
+	// Encrypt encrypts it to the identity certificate of each device.
+	Sign    bool `json:"sign,omitempty"`
+	Encrypt bool `json:"encrypt,omitempty"`
+
+	Payloads []Payload `json:"payloads"`
+}
+
+// Payload is one of the typed payloads of a profile. Exactly one of the
+// fields must be set. The PayloadType, PayloadUUID, PayloadVersion,
+// PayloadIdentifier and PayloadDisplayName of the payload are set when
+// they are empty.
+type Payload struct {
+	WiFi           *enroll.WiFiPayload           `json:"wifi,omitempty"`
+	Restrictions   *enroll.RestrictionsPayload   `json:"restrictions,omitempty"`
+	Passcode       *enroll.PasscodePayload       `json:"passcode,omitempty"`
+	Certificate    *enroll.CertificatePayload    `json:"certificate,omitempty"`
+	PKCS12         *enroll.PKCS12Payload         `json:"pkcs12,omitempty"`
+	SCEP           *enroll.SCEPPayload           `json:"scep,omitempty"`
+	LoginWindow    *enroll.LoginWindowPayload    `json:"loginwindow,omitempty"`
+	CustomSettings *enroll.CustomSettingsPayload `json:"custom_settings,omitempty"`
+}
+
+// typed returns the payload which is set, with its kind, its common keys
+// and its default PayloadType.
+func (p Payload) typed() (kind string, common *enroll.Payload, value interface{}, payloadType string, err error) {
+	var set []string
+	if p.WiFi != nil {
+		set = append(set, "wifi")
+		kind, common, value, payloadType = "wifi", &p.WiFi.Payload, p.WiFi, enroll.WiFiPayloadType
+	}
+	if p.Restrictions != nil {
+		set = append(set, "restrictions")
+		kind, common, value, payloadType = "restrictions", &p.Restrictions.Payload, p.Restrictions, enroll.RestrictionsPayloadType
+	}
+	if p.Passcode != nil {
+		set = append(set, "passcode")
+		kind, common, value, payloadType = "passcode", &p.Passcode.Payload, p.Passcode, enroll.PasscodePayloadType
+	}
+	if p.Certificate != nil {
+		set = append(set, "certificate")
+		kind, common, value, payloadType = "certificate", &p.Certificate.Payload, p.Certificate, enroll.CertificatePayloadType
+	}
+	if p.PKCS12 != nil {
+		set = append(set, "pkcs12")
+		kind, common, value, payloadType = "pkcs12", &p.PKCS12.Payload, p.PKCS12, enroll.PKCS12PayloadType
+	}
+	if p.SCEP != nil {
+		set = append(set, "scep")
+		kind, common, value, payloadType = "scep", &p.SCEP.Payload, p.SCEP, enroll.SCEPPayloadType
+	}
+	if p.LoginWindow != nil {
+		set = append(set, "loginwindow")
+		kind, common, value, payloadType = "loginwindow", &p.LoginWindow.Payload, p.LoginWindow, enroll.LoginWindowPayloadType
+	}
+	if p.CustomSettings != nil {
+		set = append(set, "custom_settings")
+		kind, common, value, payloadType = "custom-settings", &p.CustomSettings.Payload, p.CustomSettings, enroll.CustomSettingsPayloadType
+	}
+	switch len(set) {
+	case 0:
+		return "", nil, nil, "", errors.New("payload has no type")
+	case 1:
+		return kind, common, value, payloadType, nil
+	default:
+		return "", nil, nil, "", errors.Errorf("payload has more than one type: %s", strings.Join(set, ", "))
+	}
+}
+
+// Build returns the mobileconfig of the profile described by req. It
+// doesn't sign or lint the profile.
+func Build(req Request) (profile.Mobileconfig, error) {
+	if req.Identifier == "" {
+		return nil, errors.New("profile must have an identifier")
+	}
+	if len(req.Payloads) == 0 {
+		return nil, errors.New("profile must have at least one payload")
+	}
+
+	p := enroll.NewProfile()
+	p.PayloadIdentifier = req.Identifier
+	p.PayloadDisplayName = req.DisplayName
+	p.PayloadDescription = req.Description
+	p.PayloadOrganization = req.Organization
+	p.PayloadScope = req.Scope
+	p.PayloadRemovalDisallowed = req.RemovalDisallowed
+	if p.PayloadDisplayName == "" {
+		p.PayloadDisplayName = req.Identifier
+	}
+
+	identifiers := make(map[string]bool)
+	for i, payload := range req.Payloads {
+		kind, common, value, payloadType, err := payload.typed()
+		if err != nil {
+			return nil, errors.Wrapf(err, "payload %d", i)
+		}
+		if common.PayloadType == "" {
+			common.PayloadType = payloadType
+		}
+		if common.PayloadVersion == 0 {
+			common.PayloadVersion = 1
+		}
+		if common.PayloadUUID == "" {
+			common.PayloadUUID = uuid.NewV4().String()
+		}
+		if common.PayloadDisplayName == "" {
+			common.PayloadDisplayName = p.PayloadDisplayName
+		}
+		if common.PayloadIdentifier == "" {
+			common.PayloadIdentifier = uniqueIdentifier(identifiers, req.Identifier+"."+kind)
+		}
+		identifiers[common.PayloadIdentifier] = true
+
+		// the plist encoder doesn't encode pointers in an interface.
+		p.PayloadContent = append(p.PayloadContent, reflect.ValueOf(value).Elem().Interface())
+	}
+
+	mc, err := plist.MarshalIndent(p, "  ")
+	return profile.Mobileconfig(mc), errors.Wrap(err, "marshal profile")
+}
+
+// uniqueIdentifier returns id, or id with a number appended if another
+// payload already uses it.
+func uniqueIdentifier(used map[string]bool, id string) string {
+	if !used[id] {
+		return id
+	}
+	for n := 2; ; n++ {
+		if next := fmt.Sprintf("%s.%d", id, n); !used[next] {
+			return next
+		}
+	}
+}
+
+// Template returns an example request for a profile with a payload of
+// kind, as a starting point for building a profile.
+func Template(kind string) (*Request, error) {
+	template, ok := templates[kind]
+	if !ok {
+		return nil, errors.Errorf("unknown profile template %q, valid templates are %s",
+			kind, strings.Join(TemplateNames(), ", "))
+	}
+	return &Request{
+		Identifier:   "com.example." + kind,
+		DisplayName:  template.displayName,
+		Organization: "Example Inc.",
+		Scope:        "System",
+		Payloads:     []Payload{template.payload},
+	}, nil
+}
+
+// TemplateNames returns the kinds of payloads Template has examples of.
+func TemplateNames() []string {
+	var names []string
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newBool(b bool) *bool { return &b }
+
+var templates = map[string]struct {
+	displayName string
+	payload     Payload
+}{
+	"wifi": {"Wi-Fi", Payload{WiFi: &enroll.WiFiPayload{
+		SSID:           "Example",
+		AutoJoin:       newBool(true),
+		EncryptionType: "WPA2",
+		Password:       "changeme",
+	}}},
+	"restrictions": {"Restrictions", Payload{Restrictions: &enroll.RestrictionsPayload{
+		AllowAirDrop:         newBool(false),
+		AllowCamera:          newBool(true),
+		ForceEncryptedBackup: newBool(true),
+	}}},
+	"passcode": {"Passcode", Payload{Passcode: &enroll.PasscodePayload{
+		AllowSimple:       newBool(false),
+		ForcePIN:          newBool(true),
+		MinLength:         8,
+		MaxInactivity:     15,
+		MaxFailedAttempts: 10,
+	}}},
+	"certificate": {"Root Certificate", Payload{Certificate: &enroll.CertificatePayload{
+		Payload:                    enroll.Payload{PayloadType: enroll.RootCertificatePayloadType},
+		PayloadCertificateFileName: "root.cer",
+	}}},
+	"pkcs12": {"Identity", Payload{PKCS12: &enroll.PKCS12Payload{
+		PayloadCertificateFileName: "identity.p12",
+		Password:                   "changeme",
+	}}},
+	"scep": {"SCEP", Payload{SCEP: &enroll.SCEPPayload{
+		PayloadContent: enroll.SCEPPayloadContent{
+			Challenge: "changeme",
+			Keysize:   2048,
+			KeyType:   "RSA",
+			KeyUsage:  5,
+			Subject:   [][][]string{{{"CN", "{{.SerialNumber}}"}}},
+			URL:       "https://scep.example.com/scep",
+		},
+	}}},
+	"loginwindow": {"Login Window", Payload{LoginWindow: &enroll.LoginWindowPayload{
+		ShowFullName:    true,
+		LoginwindowText: "Property of Example Inc.",
+	}}},
+	"custom-settings": {"Custom Settings", Payload{CustomSettings: &enroll.CustomSettingsPayload{
+		PayloadContent: map[string]enroll.CustomSettingsDomain{
+			"com.example.app": enroll.NewCustomSettingsDomain(map[string]interface{}{
+				"ServerURL": "https://app.example.com",
+			}),
+		},
+	}}},
+}
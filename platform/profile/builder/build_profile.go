@@ -0,0 +1,82 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+// BuildProfile builds the profile described by req, signs it if requested
+// and saves it, like an uploaded profile.
+func (svc *BuilderService) BuildProfile(ctx context.Context, req Request) (*profile.Profile, error) {
+	mc, err := Build(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Sign {
+		if svc.signer == nil {
+			return nil, errors.New("server has no signing identity")
+		}
+		if mc, err = svc.signer.Sign(mc); err != nil {
+			return nil, err
+		}
+	}
+	p := &profile.Profile{
+		Identifier:   req.Identifier,
+		Mobileconfig: mc,
+		Encrypt:      req.Encrypt,
+	}
+	if _, err := svc.profiles.ApplyProfile(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+type buildProfileRequest struct {
+	Request
+}
+
+type buildProfileResponse struct {
+	Profile *profile.Profile `json:"profile,omitempty"`
+	Err     error            `json:"err,omitempty"`
+}
+
+func (r buildProfileResponse) Failed() error { return r.Err }
+
+func decodeBuildProfileRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req buildProfileRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeBuildProfileResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp buildProfileResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeBuildProfileEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(buildProfileRequest)
+		p, err := svc.BuildProfile(ctx, req.Request)
+		return buildProfileResponse{
+			Profile: p,
+			Err:     err,
+		}, nil
+	}
+}
+
+func (e Endpoints) BuildProfile(ctx context.Context, req Request) (*profile.Profile, error) {
+	request := buildProfileRequest{Request: req}
+	resp, err := e.BuildProfileEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := resp.(buildProfileResponse)
+	return response.Profile, response.Err
+}
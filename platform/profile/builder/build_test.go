@@ -0,0 +1,138 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/groob/plist"
+
+	"github.com/micromdm/micromdm/mdm/enroll"
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+func TestBuildTemplates(t *testing.T) {
+	for _, kind := range TemplateNames() {
+		t.Run(kind, func(t *testing.T) {
+			req, err := Template(kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mc, err := Build(*req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, problem := range mc.Lint() {
+				t.Errorf("lint: %s", problem)
+			}
+			id, err := mc.GetPayloadIdentifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != req.Identifier {
+				t.Errorf("have PayloadIdentifier %s, want %s", id, req.Identifier)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	req := Request{
+		Identifier:  "com.example.wifi",
+		DisplayName: "Wi-Fi",
+		Payloads: []Payload{
+			{WiFi: &enroll.WiFiPayload{SSID: "corp", EncryptionType: "WPA2", Password: "secret"}},
+			{WiFi: &enroll.WiFiPayload{SSID: "guest", HiddenNetwork: true}},
+		},
+	}
+	mc, err := Build(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var profile struct {
+		PayloadDisplayName string
+		PayloadContent     []map[string]interface{}
+	}
+	if err := plist.Unmarshal(mc, &profile); err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.PayloadContent) != 2 {
+		t.Fatalf("have %d payloads, want 2", len(profile.PayloadContent))
+	}
+	wifi := profile.PayloadContent[0]
+	if wifi["PayloadType"] != enroll.WiFiPayloadType || wifi["SSID_STR"] != "corp" || wifi["Password"] != "secret" {
+		t.Errorf("unexpected wifi payload %v", wifi)
+	}
+	if have := profile.PayloadContent[1]["PayloadIdentifier"]; have != "com.example.wifi.wifi.2" {
+		t.Errorf("have identifier %v for the second payload, want com.example.wifi.wifi.2", have)
+	}
+	if _, ok := profile.PayloadContent[1]["Password"]; ok {
+		t.Error("empty Password must be omitted")
+	}
+	if have := profile.PayloadContent[1]["HIDDEN_NETWORK"]; have != true {
+		t.Errorf("have HIDDEN_NETWORK %v for the second payload, want true", have)
+	}
+}
+
+type testSecrets map[string]string
+
+func (s testSecrets) Secret(ctx context.Context, name string) (string, error) {
+	return s[name], nil
+}
+
+func TestBuildSecret(t *testing.T) {
+	req := Request{
+		Identifier: "com.example.wifi",
+		Payloads: []Payload{
+			{WiFi: &enroll.WiFiPayload{SSID: "corp", EncryptionType: "WPA2", Password: `{{secret "wifi/corp"}}`}},
+		},
+	}
+	mc, err := Build(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mc.HasSecrets() {
+		t.Fatal("profile with a secret reference must have secrets")
+	}
+	if err := (&profile.Profile{Identifier: req.Identifier, Mobileconfig: mc}).Validate(); err != nil {
+		t.Fatalf("built profile with a secret reference: %s", err)
+	}
+
+	variables := func(ctx context.Context, udid string) (*profile.Variables, error) {
+		return &profile.Variables{}, nil
+	}
+	r := profile.NewRenderer(variables, nil, profile.WithSecrets(testSecrets{"wifi/corp": "p\"ss"}))
+	rendered, err := r.Render(context.Background(), "udid", mc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wifi struct {
+		PayloadContent []struct{ Password string }
+	}
+	if err := plist.Unmarshal(rendered, &wifi); err != nil {
+		t.Fatal(err)
+	}
+	if len(wifi.PayloadContent) != 1 || wifi.PayloadContent[0].Password != `p"ss` {
+		t.Errorf("have payloads %+v, want the password resolved", wifi.PayloadContent)
+	}
+}
+
+func TestBuildInvalidPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload Payload
+		want    string
+	}{
+		{"no type", Payload{}, "payload has no type"},
+		{"two types", Payload{WiFi: &enroll.WiFiPayload{}, Passcode: &enroll.PasscodePayload{}}, "more than one type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(Request{Identifier: "com.example", Payloads: []Payload{tt.payload}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("have err %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
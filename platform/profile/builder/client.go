@@ -0,0 +1,32 @@
+package builder
+
+import (
+	"net/url"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransport.ClientOption) (Service, error) {
+	u, err := url.Parse(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var buildProfileEndpoint endpoint.Endpoint
+	{
+		buildProfileEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/profiles/build"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeBuildProfileResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	return Endpoints{
+		BuildProfileEndpoint: buildProfileEndpoint,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package builder
+
+import (
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+type Endpoints struct {
+	BuildProfileEndpoint endpoint.Endpoint
+}
+
+func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoint.Middleware) Endpoints {
+	return Endpoints{
+		BuildProfileEndpoint: endpoint.Chain(outer, others...)(MakeBuildProfileEndpoint(s)),
+	}
+}
+
+func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.ServerOption) {
+	// POST    /v1/profiles/build	build a profile from typed payloads and save it
+
+	r.Methods("POST").Path("/v1/profiles/build").Handler(httptransport.NewServer(
+		e.BuildProfileEndpoint,
+		decodeBuildProfileRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+}
@@ -0,0 +1,35 @@
+// Package builder builds profiles from typed payloads, and saves them
+// with the profile service.
+package builder
+
+import (
+	"context"
+
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+type Service interface {
+	BuildProfile(ctx context.Context, req Request) (*profile.Profile, error)
+}
+
+type Option func(*BuilderService)
+
+// WithSigner signs the profiles built with Sign.
+func WithSigner(signer profile.Signer) Option {
+	return func(svc *BuilderService) {
+		svc.signer = signer
+	}
+}
+
+func New(profiles profile.Service, opts ...Option) *BuilderService {
+	svc := &BuilderService{profiles: profiles}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+type BuilderService struct {
+	profiles profile.Service
+	signer   profile.Signer
+}
@@ -3,6 +3,7 @@ package builtin
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/micromdm/micromdm/platform/profile"
@@ -11,6 +12,15 @@ import (
 
 const (
 	ProfileBucket = "mdm.Profile"
+
+	// DeviceProfileBucket has a bucket of device profiles for each profile
+	// identifier, keyed by device UDID. Device profiles are kept when a
+	// profile is deleted, to track its removal from the devices.
+	DeviceProfileBucket = "mdm.DeviceProfile"
+
+	// deviceProfileCommandBucket maps the UDID and command UUID of pending
+	// InstallProfile commands to the profile identifier.
+	deviceProfileCommandBucket = "mdm.DeviceProfileCommand"
 )
 
 type DB struct {
@@ -19,7 +29,15 @@ type DB struct {
 
 func NewDB(db *bolt.DB) (*DB, error) {
 	err := db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(ProfileBucket))
+		_, err := tx.CreateBucketIfNotExists([]byte(DeviceProfileBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(deviceProfileCommandBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(ProfileBucket))
 		return err
 	})
 	if err != nil {
@@ -58,10 +76,14 @@ func (db *DB) Save(p *profile.Profile) error {
 	if err != nil {
 		return errors.Wrap(err, "begin transaction")
 	}
+	defer tx.Rollback()
 	bkt := tx.Bucket([]byte(ProfileBucket))
 	if bkt == nil {
 		return fmt.Errorf("bucket %q not found!", ProfileBucket)
 	}
+	if err := setVersion(bkt, p); err != nil {
+		return err
+	}
 	pproto, err := profile.MarshalProfile(p)
 	if err != nil {
 		return errors.Wrap(err, "marshalling profile")
@@ -72,6 +94,30 @@ func (db *DB) Save(p *profile.Profile) error {
 	return tx.Commit()
 }
 
+// setVersion sets the hash of the profile, and increments the version of
+// the stored profile if the hash changed. The upload time is the time of
+// the upload of the current version.
+func setVersion(bkt *bolt.Bucket, p *profile.Profile) error {
+	p.Hash = p.Mobileconfig.Hash()
+	v := bkt.Get([]byte(p.Identifier))
+	if v == nil {
+		p.Version = 1
+		p.Uploaded = time.Now().UTC()
+		return nil
+	}
+	var stored profile.Profile
+	if err := profile.UnmarshalProfile(v, &stored); err != nil {
+		return errors.Wrap(err, "unmarshal stored profile")
+	}
+	p.Version = stored.Version
+	p.Uploaded = stored.Uploaded
+	if stored.Hash != p.Hash {
+		p.Version++
+		p.Uploaded = time.Now().UTC()
+	}
+	return nil
+}
+
 func (db *DB) ProfileById(ctx context.Context, id string) (*profile.Profile, error) {
 	var p profile.Profile
 	err := db.View(func(tx *bolt.Tx) error {
@@ -97,6 +143,91 @@ func (db *DB) Delete(id string) error {
 	return err
 }
 
+func commandKey(udid, commandUUID string) []byte {
+	return []byte(udid + "/" + commandUUID)
+}
+
+func (db *DB) SaveDeviceProfile(dp *profile.DeviceProfile) error {
+	data, err := profile.MarshalDeviceProfile(dp)
+	if err != nil {
+		return errors.Wrap(err, "marshalling device profile")
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		devices, err := tx.Bucket([]byte(DeviceProfileBucket)).CreateBucketIfNotExists([]byte(dp.Identifier))
+		if err != nil {
+			return errors.Wrapf(err, "create device profile bucket for profile %s", dp.Identifier)
+		}
+		commands := tx.Bucket([]byte(deviceProfileCommandBucket))
+		if v := devices.Get([]byte(dp.UDID)); v != nil {
+			var prev profile.DeviceProfile
+			if err := profile.UnmarshalDeviceProfile(v, &prev); err != nil {
+				return err
+			}
+			if prev.CommandUUID != "" && prev.CommandUUID != dp.CommandUUID {
+				if err := commands.Delete(commandKey(prev.UDID, prev.CommandUUID)); err != nil {
+					return err
+				}
+			}
+		}
+		if dp.CommandUUID != "" {
+			if err := commands.Put(commandKey(dp.UDID, dp.CommandUUID), []byte(dp.Identifier)); err != nil {
+				return errors.Wrap(err, "put device profile command to boltdb")
+			}
+		}
+		return errors.Wrap(devices.Put([]byte(dp.UDID), data), "put device profile to boltdb")
+	})
+}
+
+func (db *DB) DeviceProfile(udid, id string) (*profile.DeviceProfile, error) {
+	var dp profile.DeviceProfile
+	err := db.View(func(tx *bolt.Tx) error {
+		return deviceProfile(tx, udid, id, &dp)
+	})
+	return &dp, err
+}
+
+func deviceProfile(tx *bolt.Tx, udid, id string, dp *profile.DeviceProfile) error {
+	var v []byte
+	if devices := tx.Bucket([]byte(DeviceProfileBucket)).Bucket([]byte(id)); devices != nil {
+		v = devices.Get([]byte(udid))
+	}
+	if v == nil {
+		return &notFound{"DeviceProfile", fmt.Sprintf("profile %s udid %s", id, udid)}
+	}
+	return profile.UnmarshalDeviceProfile(v, dp)
+}
+
+func (db *DB) DeviceProfileByCommand(udid, commandUUID string) (*profile.DeviceProfile, error) {
+	var dp profile.DeviceProfile
+	err := db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket([]byte(deviceProfileCommandBucket)).Get(commandKey(udid, commandUUID))
+		if id == nil {
+			return &notFound{"DeviceProfile", fmt.Sprintf("udid %s command %s", udid, commandUUID)}
+		}
+		return deviceProfile(tx, udid, string(id), &dp)
+	})
+	return &dp, err
+}
+
+func (db *DB) DeviceProfiles(id string) ([]profile.DeviceProfile, error) {
+	var list []profile.DeviceProfile
+	err := db.View(func(tx *bolt.Tx) error {
+		devices := tx.Bucket([]byte(DeviceProfileBucket)).Bucket([]byte(id))
+		if devices == nil {
+			return nil
+		}
+		return devices.ForEach(func(k, v []byte) error {
+			var dp profile.DeviceProfile
+			if err := profile.UnmarshalDeviceProfile(v, &dp); err != nil {
+				return err
+			}
+			list = append(list, dp)
+			return nil
+		})
+	})
+	return list, err
+}
+
 type notFound struct {
 	ResourceType string
 	Message      string
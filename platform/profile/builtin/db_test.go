@@ -0,0 +1,244 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/profile"
+)
+
+func setupDB(t *testing.T) *DB {
+	f, _ := ioutil.TempFile("", "bolt-")
+	f.Close()
+	os.Remove(f.Name())
+
+	db, err := bolt.Open(f.Name(), 0777, nil)
+	if err != nil {
+		t.Fatalf("couldn't open bolt, err %s\n", err)
+	}
+	profileDB, err := NewDB(db)
+	if err != nil {
+		t.Fatalf("couldn't create profile DB, err %s\n", err)
+	}
+	return profileDB
+}
+
+func testProfile(id, content string) *profile.Profile {
+	return &profile.Profile{
+		Identifier: id,
+		Mobileconfig: profile.Mobileconfig(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>PayloadIdentifier</key><string>%s</string><key>PayloadDisplayName</key><string>%s</string></dict></plist>`, id, content)),
+	}
+}
+
+func TestVersion(t *testing.T) {
+	db := setupDB(t)
+	var (
+		uploaded time.Time
+		version  int
+	)
+	for _, tt := range []struct {
+		content string
+		version int
+	}{
+		{"v1", 1},
+		{"v1", 1},
+		{"v2", 2},
+		{"v3", 3},
+	} {
+		p := testProfile("com.example.wifi", tt.content)
+		if err := db.Save(p); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := db.ProfileById(context.Background(), p.Identifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Version != tt.version || stored.Hash != p.Mobileconfig.Hash() {
+			t.Errorf("saved %s: have version %d hash %s, want version %d", tt.content, stored.Version, stored.Hash, tt.version)
+		}
+		// the upload time changes only with the version.
+		if have, want := !stored.Uploaded.Equal(uploaded), stored.Version != version; have != want {
+			t.Errorf("saved %s: have upload time changed %v, want %v", tt.content, have, want)
+		}
+		uploaded, version = stored.Uploaded, stored.Version
+	}
+}
+
+func TestDeviceProfileByCommand(t *testing.T) {
+	db := setupDB(t)
+	dp := &profile.DeviceProfile{
+		UDID:        "UDID-1",
+		Identifier:  "com.example.wifi",
+		Status:      profile.DeviceProfilePending,
+		CommandUUID: "command-1",
+		SentVersion: 1,
+	}
+	if err := db.SaveDeviceProfile(dp); err != nil {
+		t.Fatal(err)
+	}
+	found, err := db.DeviceProfileByCommand("UDID-1", "command-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Identifier != dp.Identifier || found.SentVersion != 1 {
+		t.Errorf("have %+v, want %+v", found, dp)
+	}
+
+	dp.CommandUUID = "command-2"
+	dp.SentVersion = 2
+	if err := db.SaveDeviceProfile(dp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DeviceProfileByCommand("UDID-1", "command-1"); !profile.IsNotFound(err) {
+		t.Errorf("replaced command must not be found, have err %v", err)
+	}
+	if _, err := db.DeviceProfileByCommand("UDID-2", "command-2"); !profile.IsNotFound(err) {
+		t.Errorf("command of another device must not be found, have err %v", err)
+	}
+
+	if err := db.Save(testProfile(dp.Identifier, "v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(dp.Identifier); err != nil {
+		t.Fatal(err)
+	}
+	if devices, err := db.DeviceProfiles(dp.Identifier); err != nil || len(devices) != 1 {
+		t.Errorf("have device profiles %v, err %v, want them kept after the profile was deleted", devices, err)
+	}
+}
+
+type commandService struct {
+	tracker *profile.Tracker
+	queued  []string
+}
+
+func (svc *commandService) NewCommand(ctx context.Context, req *mdm.CommandRequest) (*mdm.CommandPayload, error) {
+	uuid := fmt.Sprintf("command-%d", len(svc.queued))
+	svc.queued = append(svc.queued, req.UDID)
+	payload := &mdm.CommandPayload{CommandUUID: uuid, Command: req.Command}
+	return payload, svc.tracker.CommandQueued(ctx, req.UDID, payload)
+}
+
+func TestRollout(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	cmds := &commandService{tracker: profile.NewTracker(db, nil, nil)}
+	svc := profile.New(db, profile.WithCommandService(cmds))
+
+	p := testProfile("com.example.wifi", "v1")
+	if err := db.Save(p); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		udid := fmt.Sprintf("UDID-%d", i)
+		payload := &mdm.CommandPayload{
+			CommandUUID: "install-" + udid,
+			Command: &mdm.Command{
+				RequestType:    "InstallProfile",
+				InstallProfile: &mdm.InstallProfile{Payload: p.Mobileconfig},
+			},
+		}
+		if err := cmds.tracker.CommandQueued(ctx, udid, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rollout, err := svc.RolloutProfile(ctx, profile.RolloutRequest{Identifier: p.Identifier})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rollout.Devices != 10 || rollout.Current != 10 || len(rollout.Queued) != 0 {
+		t.Errorf("devices with the current version must not be sent the profile again, have %+v", rollout)
+	}
+
+	if err := db.Save(testProfile(p.Identifier, "v2")); err != nil {
+		t.Fatal(err)
+	}
+	for _, stage := range []struct {
+		percent int
+		queued  int
+	}{
+		{25, 3},
+		{25, 0},
+		{50, 2},
+		{100, 5},
+	} {
+		rollout, err := svc.RolloutProfile(ctx, profile.RolloutRequest{Identifier: p.Identifier, Percent: stage.percent})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rollout.Version != 2 || len(rollout.Queued) != stage.queued {
+			t.Errorf("rollout to %d%%: have %+v, want %d queued", stage.percent, rollout, stage.queued)
+		}
+	}
+	if len(cmds.queued) != 10 {
+		t.Errorf("have %d devices sent version 2, want 10", len(cmds.queued))
+	}
+	devices, err := db.DeviceProfiles(p.Identifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dp := range devices {
+		if dp.SentVersion != 2 || dp.Status != profile.DeviceProfilePending {
+			t.Errorf("have %+v, want version 2 pending", dp)
+		}
+	}
+}
+
+func TestRemoveFromDevices(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	cmds := &commandService{tracker: profile.NewTracker(db, nil, nil)}
+	svc := profile.New(db, profile.WithCommandService(cmds))
+
+	p := testProfile("com.example.wifi", "v1")
+	if err := db.Save(p); err != nil {
+		t.Fatal(err)
+	}
+	for udid, status := range map[string]string{
+		"UDID-1": profile.DeviceProfileAcknowledged,
+		"UDID-2": profile.DeviceProfilePending,
+		"UDID-3": profile.DeviceProfileError,
+		"UDID-4": profile.DeviceProfileRemoved,
+	} {
+		dp := &profile.DeviceProfile{UDID: udid, Identifier: p.Identifier, Status: status, SentVersion: 1}
+		if err := db.SaveDeviceProfile(dp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removing, err := svc.RemoveProfiles(ctx, []string{p.Identifier}, profile.RemoveProfilesOption{FromDevices: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removing) != 2 || len(cmds.queued) != 2 {
+		t.Fatalf("have %v removing, want the profile removed from UDID-1 and UDID-2", removing)
+	}
+	if _, err := db.ProfileById(ctx, p.Identifier); !profile.IsNotFound(err) {
+		t.Errorf("profile must be deleted, have err %v", err)
+	}
+	devices, err := svc.GetDeviceProfiles(ctx, profile.GetDeviceProfilesOption{
+		Identifier: p.Identifier,
+		Status:     profile.DeviceProfileRemoving,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Errorf("have %v, want 2 devices removing the profile", devices)
+	}
+	for _, dp := range devices {
+		found, err := db.DeviceProfileByCommand(dp.UDID, dp.CommandUUID)
+		if err != nil || found.Status != profile.DeviceProfileRemoving {
+			t.Errorf("removal of %s must be tracked by command, have %v, err %v", dp.UDID, found, err)
+		}
+	}
+}
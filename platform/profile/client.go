@@ -26,6 +26,39 @@ func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransp
 		).Endpoint()
 	}
 
+	var lintProfileEndpoint endpoint.Endpoint
+	{
+		lintProfileEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/profiles/lint"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeLintProfileResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var rolloutProfileEndpoint endpoint.Endpoint
+	{
+		rolloutProfileEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/profiles/rollout"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeRolloutProfileResponse,
+			opts...,
+		).Endpoint()
+	}
+
+	var getDeviceProfilesEndpoint endpoint.Endpoint
+	{
+		getDeviceProfilesEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/profiles/devices"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeGetDeviceProfilesResponse,
+			opts...,
+		).Endpoint()
+	}
+
 	var getProfilesEndpoint endpoint.Endpoint
 	{
 		getProfilesEndpoint = httptransport.NewClient(
@@ -37,6 +70,17 @@ func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransp
 		).Endpoint()
 	}
 
+	var queryProfilesEndpoint endpoint.Endpoint
+	{
+		queryProfilesEndpoint = httptransport.NewClient(
+			"POST",
+			httputil.CopyURL(u, "/v1/profiles/query"),
+			httputil.EncodeRequestWithToken(token, httptransport.EncodeJSONRequest),
+			decodeQueryProfilesResponse,
+			opts...,
+		).Endpoint()
+	}
+
 	var removeProfilesEndpoint endpoint.Endpoint
 	{
 		removeProfilesEndpoint = httptransport.NewClient(
@@ -49,8 +93,12 @@ func NewHTTPClient(instance, token string, logger log.Logger, opts ...httptransp
 	}
 
 	return Endpoints{
-		ApplyProfileEndpoint:   applyProfileEndpoint,
-		GetProfilesEndpoint:    getProfilesEndpoint,
-		RemoveProfilesEndpoint: removeProfilesEndpoint,
+		ApplyProfileEndpoint:      applyProfileEndpoint,
+		LintProfileEndpoint:       lintProfileEndpoint,
+		RolloutProfileEndpoint:    rolloutProfileEndpoint,
+		GetDeviceProfilesEndpoint: getDeviceProfilesEndpoint,
+		GetProfilesEndpoint:       getProfilesEndpoint,
+		QueryProfilesEndpoint:     queryProfilesEndpoint,
+		RemoveProfilesEndpoint:    removeProfilesEndpoint,
 	}, nil
 }
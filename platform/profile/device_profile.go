@@ -0,0 +1,100 @@
+package profile
+
+import (
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/micromdm/micromdm/platform/profile/internal/profileproto"
+)
+
+// Statuses of a DeviceProfile. The first three are the statuses of an
+// InstallProfile command, the others of a RemoveProfile command.
+const (
+	DeviceProfilePending      = "Pending"
+	DeviceProfileAcknowledged = "Acknowledged"
+	DeviceProfileError        = "Error"
+	DeviceProfileRemoving     = "Removing"
+	DeviceProfileRemoved      = "Removed"
+	DeviceProfileRemoveFailed = "RemoveFailed"
+)
+
+// DeviceProfile is the version of a profile a device was last sent in an
+// InstallProfile command, and the last version the device acknowledged.
+// Devices which reported the profile in a ProfileList response without
+// being sent it have an Acknowledged time, but no versions.
+type DeviceProfile struct {
+	UDID                string    `json:"udid"`
+	Identifier          string    `json:"identifier"`
+	Status              string    `json:"status"`
+	CommandUUID         string    `json:"command_uuid,omitempty"`
+	SentVersion         int       `json:"sent_version"`
+	Sent                time.Time `json:"sent"`
+	AcknowledgedVersion int       `json:"acknowledged_version,omitempty"`
+	Acknowledged        time.Time `json:"acknowledged,omitempty"`
+}
+
+// Installed reports whether the device is known to have the profile
+// installed, or is about to install it.
+func (dp DeviceProfile) Installed() bool {
+	switch dp.Status {
+	case DeviceProfilePending, DeviceProfileAcknowledged, DeviceProfileRemoveFailed:
+		return true
+	case DeviceProfileError:
+		return !dp.Acknowledged.IsZero()
+	}
+	return false
+}
+
+// removal reports whether the profile was removed from the device, or a
+// removal was attempted.
+func (dp DeviceProfile) removal() bool {
+	switch dp.Status {
+	case DeviceProfileRemoving, DeviceProfileRemoved, DeviceProfileRemoveFailed:
+		return true
+	}
+	return false
+}
+
+func MarshalDeviceProfile(dp *DeviceProfile) ([]byte, error) {
+	return proto.Marshal(&profileproto.DeviceProfile{
+		Udid:                dp.UDID,
+		Identifier:          dp.Identifier,
+		Status:              dp.Status,
+		CommandUuid:         dp.CommandUUID,
+		SentVersion:         int64(dp.SentVersion),
+		Sent:                timeToNano(dp.Sent),
+		AcknowledgedVersion: int64(dp.AcknowledgedVersion),
+		Acknowledged:        timeToNano(dp.Acknowledged),
+	})
+}
+
+func UnmarshalDeviceProfile(data []byte, dp *DeviceProfile) error {
+	var pb profileproto.DeviceProfile
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+	dp.UDID = pb.GetUdid()
+	dp.Identifier = pb.GetIdentifier()
+	dp.Status = pb.GetStatus()
+	dp.CommandUUID = pb.GetCommandUuid()
+	dp.SentVersion = int(pb.GetSentVersion())
+	dp.Sent = timeFromNano(pb.GetSent())
+	dp.AcknowledgedVersion = int(pb.GetAcknowledgedVersion())
+	dp.Acknowledged = timeFromNano(pb.GetAcknowledged())
+	return nil
+}
+
+func timeToNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func timeFromNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
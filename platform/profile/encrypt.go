@@ -0,0 +1,59 @@
+package profile
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/groob/plist"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/crypto/profileutil"
+)
+
+// CertificateFunc looks up the identity certificate of a device.
+type CertificateFunc func(ctx context.Context, udid string) (*x509.Certificate, error)
+
+// EncryptPayloadContent replaces the PayloadContent of an unsigned profile
+// with EncryptedPayloadContent, the PayloadContent array encrypted to the
+// certificate of a device.
+func (mc Mobileconfig) EncryptPayloadContent(cert *x509.Certificate) (Mobileconfig, error) {
+	var p map[string]interface{}
+	if err := plist.Unmarshal(mc, &p); err != nil {
+		return nil, errors.Wrap(err, "unmarshal profile to encrypt")
+	}
+	content, ok := p["PayloadContent"]
+	if !ok {
+		return nil, errors.New("profile has no PayloadContent to encrypt")
+	}
+	plaintext, err := plist.Marshal(content)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal PayloadContent")
+	}
+	encrypted, err := profileutil.Encrypt(plaintext, cert)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypt PayloadContent")
+	}
+	delete(p, "PayloadContent")
+	p["EncryptedPayloadContent"] = encrypted
+	out, err := plist.MarshalIndent(p, "\t")
+	return out, errors.Wrap(err, "marshal encrypted profile")
+}
+
+// validateEncrypt checks that a profile stored with Encrypt has content
+// to encrypt.
+func validateEncrypt(mc Mobileconfig) error {
+	content, _, err := mc.content()
+	if err != nil {
+		return err
+	}
+	var p struct {
+		PayloadContent []interface{}
+	}
+	if err := plist.Unmarshal(content, &p); err != nil {
+		return errors.Wrap(err, "unmarshal profile to encrypt")
+	}
+	if len(p.PayloadContent) == 0 {
+		return errors.New("encrypted profile must have a PayloadContent array")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package profile
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+type GetDeviceProfilesOption struct {
+	Identifier string `json:"id"`
+
+	// Status only returns the devices with the profile in this status,
+	// such as RemoveFailed.
+	Status string `json:"status,omitempty"`
+}
+
+// GetDeviceProfiles returns the devices which were sent a profile, or
+// had it removed, and the status of the last command for each.
+func (svc *ProfileService) GetDeviceProfiles(ctx context.Context, opt GetDeviceProfilesOption) ([]DeviceProfile, error) {
+	devices, err := svc.store.DeviceProfiles(opt.Identifier)
+	if err != nil || opt.Status == "" {
+		return devices, err
+	}
+	var filtered []DeviceProfile
+	for _, dp := range devices {
+		if dp.Status == opt.Status {
+			filtered = append(filtered, dp)
+		}
+	}
+	return filtered, nil
+}
+
+type getDeviceProfilesRequest struct {
+	GetDeviceProfilesOption
+}
+
+type getDeviceProfilesResponse struct {
+	Devices []DeviceProfile `json:"devices,omitempty"`
+	Err     error           `json:"err,omitempty"`
+}
+
+func (r getDeviceProfilesResponse) Failed() error { return r.Err }
+
+func decodeGetDeviceProfilesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req getDeviceProfilesRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeGetDeviceProfilesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp getDeviceProfilesResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeGetDeviceProfilesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getDeviceProfilesRequest)
+		devices, err := svc.GetDeviceProfiles(ctx, req.GetDeviceProfilesOption)
+		return getDeviceProfilesResponse{
+			Devices: devices,
+			Err:     err,
+		}, nil
+	}
+}
+
+func (e Endpoints) GetDeviceProfiles(ctx context.Context, opt GetDeviceProfilesOption) ([]DeviceProfile, error) {
+	request := getDeviceProfilesRequest{GetDeviceProfilesOption: opt}
+	resp, err := e.GetDeviceProfilesEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := resp.(getDeviceProfilesResponse)
+	return response.Devices, response.Err
+}
@@ -10,15 +10,25 @@ import (
 )
 
 func (svc *ProfileService) GetProfiles(ctx context.Context, opt GetProfilesOption) ([]Profile, error) {
+	var profiles []Profile
 	if opt.Identifier != "" {
 		foundProf, err := svc.store.ProfileById(ctx, opt.Identifier)
 		if err != nil {
 			return nil, err
 		}
-		return []Profile{*foundProf}, nil
+		profiles = []Profile{*foundProf}
 	} else {
-		return svc.store.List()
+		var err error
+		profiles, err = svc.store.List()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range profiles {
+		// a profile which can't be parsed is still listed, without metadata.
+		profiles[i].Metadata, _ = profiles[i].ParseMetadata()
 	}
+	return profiles, nil
 }
 
 type getProfilesRequest struct{ Opts GetProfilesOption }
@@ -1,16 +1,6 @@
-// Code generated by protoc-gen-go.
+// Code generated by protoc-gen-go. DO NOT EDIT.
 // source: profile.proto
-// DO NOT EDIT!
 
-/*
-Package profileproto is a generated protocol buffer package.
-
-It is generated from these files:
-	profile.proto
-
-It has these top-level messages:
-	Profile
-*/
 package profileproto
 
 import proto "github.com/golang/protobuf/proto"
@@ -29,14 +19,40 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Profile struct {
-	Id           string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
-	Mobileconfig []byte `protobuf:"bytes,2,opt,name=mobileconfig,proto3" json:"mobileconfig,omitempty"`
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Mobileconfig         []byte   `protobuf:"bytes,2,opt,name=mobileconfig,proto3" json:"mobileconfig,omitempty"`
+	Encrypt              bool     `protobuf:"varint,3,opt,name=encrypt,proto3" json:"encrypt,omitempty"`
+	Version              int64    `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Hash                 string   `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`
+	Uploaded             int64    `protobuf:"varint,6,opt,name=uploaded,proto3" json:"uploaded,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Profile) Reset()         { *m = Profile{} }
+func (m *Profile) String() string { return proto.CompactTextString(m) }
+func (*Profile) ProtoMessage()    {}
+func (*Profile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_profile_4b931d114dc99521, []int{0}
+}
+func (m *Profile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Profile.Unmarshal(m, b)
+}
+func (m *Profile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Profile.Marshal(b, m, deterministic)
+}
+func (dst *Profile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Profile.Merge(dst, src)
+}
+func (m *Profile) XXX_Size() int {
+	return xxx_messageInfo_Profile.Size(m)
+}
+func (m *Profile) XXX_DiscardUnknown() {
+	xxx_messageInfo_Profile.DiscardUnknown(m)
 }
 
-func (m *Profile) Reset()                    { *m = Profile{} }
-func (m *Profile) String() string            { return proto.CompactTextString(m) }
-func (*Profile) ProtoMessage()               {}
-func (*Profile) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+var xxx_messageInfo_Profile proto.InternalMessageInfo
 
 func (m *Profile) GetId() string {
 	if m != nil {
@@ -52,19 +68,153 @@ func (m *Profile) GetMobileconfig() []byte {
 	return nil
 }
 
+func (m *Profile) GetEncrypt() bool {
+	if m != nil {
+		return m.Encrypt
+	}
+	return false
+}
+
+func (m *Profile) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Profile) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *Profile) GetUploaded() int64 {
+	if m != nil {
+		return m.Uploaded
+	}
+	return 0
+}
+
+type DeviceProfile struct {
+	Udid                 string   `protobuf:"bytes,1,opt,name=udid,proto3" json:"udid,omitempty"`
+	Identifier           string   `protobuf:"bytes,2,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Status               string   `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	CommandUuid          string   `protobuf:"bytes,4,opt,name=command_uuid,json=commandUuid,proto3" json:"command_uuid,omitempty"`
+	SentVersion          int64    `protobuf:"varint,5,opt,name=sent_version,json=sentVersion,proto3" json:"sent_version,omitempty"`
+	Sent                 int64    `protobuf:"varint,6,opt,name=sent,proto3" json:"sent,omitempty"`
+	AcknowledgedVersion  int64    `protobuf:"varint,7,opt,name=acknowledged_version,json=acknowledgedVersion,proto3" json:"acknowledged_version,omitempty"`
+	Acknowledged         int64    `protobuf:"varint,8,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeviceProfile) Reset()         { *m = DeviceProfile{} }
+func (m *DeviceProfile) String() string { return proto.CompactTextString(m) }
+func (*DeviceProfile) ProtoMessage()    {}
+func (*DeviceProfile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_profile_4b931d114dc99521, []int{1}
+}
+func (m *DeviceProfile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeviceProfile.Unmarshal(m, b)
+}
+func (m *DeviceProfile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeviceProfile.Marshal(b, m, deterministic)
+}
+func (dst *DeviceProfile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeviceProfile.Merge(dst, src)
+}
+func (m *DeviceProfile) XXX_Size() int {
+	return xxx_messageInfo_DeviceProfile.Size(m)
+}
+func (m *DeviceProfile) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeviceProfile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeviceProfile proto.InternalMessageInfo
+
+func (m *DeviceProfile) GetUdid() string {
+	if m != nil {
+		return m.Udid
+	}
+	return ""
+}
+
+func (m *DeviceProfile) GetIdentifier() string {
+	if m != nil {
+		return m.Identifier
+	}
+	return ""
+}
+
+func (m *DeviceProfile) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *DeviceProfile) GetCommandUuid() string {
+	if m != nil {
+		return m.CommandUuid
+	}
+	return ""
+}
+
+func (m *DeviceProfile) GetSentVersion() int64 {
+	if m != nil {
+		return m.SentVersion
+	}
+	return 0
+}
+
+func (m *DeviceProfile) GetSent() int64 {
+	if m != nil {
+		return m.Sent
+	}
+	return 0
+}
+
+func (m *DeviceProfile) GetAcknowledgedVersion() int64 {
+	if m != nil {
+		return m.AcknowledgedVersion
+	}
+	return 0
+}
+
+func (m *DeviceProfile) GetAcknowledged() int64 {
+	if m != nil {
+		return m.Acknowledged
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Profile)(nil), "profileproto.Profile")
+	proto.RegisterType((*DeviceProfile)(nil), "profileproto.DeviceProfile")
 }
 
-func init() { proto.RegisterFile("profile.proto", fileDescriptor0) }
+func init() { proto.RegisterFile("profile.proto", fileDescriptor_profile_4b931d114dc99521) }
 
-var fileDescriptor0 = []byte{
-	// 97 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x2d, 0x28, 0xca, 0x4f,
-	0xcb, 0xcc, 0x49, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x81, 0x72, 0xc1, 0x3c, 0x25,
-	0x5b, 0x2e, 0xf6, 0x00, 0x08, 0x5f, 0x88, 0x8f, 0x8b, 0x29, 0x33, 0x45, 0x82, 0x51, 0x81, 0x51,
-	0x83, 0x33, 0x88, 0x29, 0x33, 0x45, 0x48, 0x89, 0x8b, 0x27, 0x37, 0x3f, 0x29, 0x33, 0x27, 0x35,
-	0x39, 0x3f, 0x2f, 0x2d, 0x33, 0x5d, 0x82, 0x49, 0x81, 0x51, 0x83, 0x27, 0x08, 0x45, 0x2c, 0x89,
-	0x0d, 0x6c, 0x8a, 0x31, 0x20, 0x00, 0x00, 0xff, 0xff, 0xea, 0x33, 0x2f, 0xfa, 0x64, 0x00, 0x00,
-	0x00,
+var fileDescriptor_profile_4b931d114dc99521 = []byte{
+	// 286 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x91, 0xcf, 0x4e, 0x83, 0x40,
+	0x10, 0xc6, 0x03, 0xfd, 0xcb, 0x94, 0x7a, 0x18, 0x8d, 0xd9, 0x78, 0x30, 0xc8, 0x89, 0x93, 0x89,
+	0xf1, 0x15, 0x7c, 0x00, 0xb3, 0x89, 0x5e, 0x1b, 0xca, 0x4e, 0xdb, 0x89, 0x74, 0x97, 0x00, 0x5b,
+	0xe3, 0x3b, 0xf8, 0x12, 0xbe, 0xa9, 0x61, 0x84, 0x4a, 0x6f, 0xf3, 0xfd, 0xe6, 0x03, 0x7e, 0x13,
+	0x60, 0x5d, 0xd5, 0x6e, 0xc7, 0x25, 0x3d, 0x56, 0xb5, 0x6b, 0x1d, 0xc6, 0x7d, 0x94, 0x94, 0xfe,
+	0x04, 0xb0, 0x78, 0xfd, 0x03, 0x78, 0x05, 0x21, 0x1b, 0x15, 0x24, 0x41, 0x16, 0xe9, 0x90, 0x0d,
+	0xa6, 0x10, 0x1f, 0xdd, 0x96, 0x4b, 0x2a, 0x9c, 0xdd, 0xf1, 0x5e, 0x85, 0x49, 0x90, 0xc5, 0xfa,
+	0x82, 0xa1, 0x82, 0x05, 0xd9, 0xa2, 0xfe, 0xaa, 0x5a, 0x35, 0x49, 0x82, 0x6c, 0xa9, 0x87, 0xd8,
+	0x6d, 0x4e, 0x54, 0x37, 0xec, 0xac, 0x9a, 0x26, 0x41, 0x36, 0xd1, 0x43, 0x44, 0x84, 0xe9, 0x21,
+	0x6f, 0x0e, 0x6a, 0x26, 0x5f, 0x92, 0x19, 0xef, 0x60, 0xe9, 0xab, 0xd2, 0xe5, 0x86, 0x8c, 0x9a,
+	0x4b, 0xfd, 0x9c, 0xd3, 0xef, 0x10, 0xd6, 0x2f, 0x74, 0xe2, 0x82, 0x06, 0x53, 0x84, 0xa9, 0x37,
+	0x67, 0x57, 0x99, 0xf1, 0x1e, 0x80, 0x0d, 0xd9, 0x96, 0x77, 0x4c, 0xb5, 0xb8, 0x46, 0x7a, 0x44,
+	0xf0, 0x16, 0xe6, 0x4d, 0x9b, 0xb7, 0xbe, 0x11, 0xd1, 0x48, 0xf7, 0x09, 0x1f, 0x20, 0x2e, 0xdc,
+	0xf1, 0x98, 0x5b, 0xb3, 0xf1, 0x9e, 0x8d, 0xc8, 0x46, 0x7a, 0xd5, 0xb3, 0x37, 0xcf, 0xa6, 0xab,
+	0x34, 0x64, 0xdb, 0xcd, 0x70, 0xcf, 0x4c, 0x04, 0x57, 0x1d, 0x7b, 0xff, 0xbf, 0xa9, 0x8b, 0xbd,
+	0xbb, 0xcc, 0xf8, 0x04, 0x37, 0x79, 0xf1, 0x61, 0xdd, 0x67, 0x49, 0x66, 0x4f, 0xe6, 0xfc, 0xf8,
+	0x42, 0x3a, 0xd7, 0xe3, 0xdd, 0xf0, 0x9a, 0x14, 0xe2, 0x31, 0x56, 0x4b, 0xa9, 0x5e, 0xb0, 0xed,
+	0x5c, 0xfe, 0xdc, 0xf3, 0xef, 0x00, 0x0e, 0x2f, 0xed, 0x67, 0xd8, 0x01, 0x00, 0x00,
 }
@@ -0,0 +1,304 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fullsailor/pkcs7"
+	"github.com/groob/plist"
+)
+
+// Lint severities. Profiles with errors are rejected on upload, because
+// devices are certain to fail to install them. Warnings are likely
+// mistakes, which some devices or payload variants accept.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintProblem is a problem found in a profile by Lint.
+type LintProblem struct {
+	Severity string `json:"severity"`
+
+	// Payload is the PayloadIdentifier of the payload with the problem,
+	// or empty for a problem with the profile itself.
+	Payload string `json:"payload,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p LintProblem) String() string {
+	if p.Payload == "" {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Payload, p.Message)
+}
+
+// LintErrors is returned when a profile with lint errors is uploaded.
+type LintErrors []LintProblem
+
+func (e LintErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, p := range e {
+		msgs[i] = p.String()
+	}
+	return "profile failed lint: " + strings.Join(msgs, "; ")
+}
+
+// lintErrors returns the errors in problems as LintErrors, or nil.
+func lintErrors(problems []LintProblem) error {
+	var errs LintErrors
+	for _, p := range problems {
+		if p.Severity == LintError {
+			errs = append(errs, p)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// certificateExpiryWarning is how long before the signing certificate of
+// a profile expires that Lint starts to warn about it.
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+// requiredKeys are the keys every profile and payload must have.
+var requiredKeys = []string{"PayloadIdentifier", "PayloadType", "PayloadUUID", "PayloadVersion"}
+
+// payloadKeys are the known payload types, with the keys each requires.
+var payloadKeys = map[string][]string{
+	"com.apple.ManagedClient.preferences":           {"PayloadContent"},
+	"com.apple.MCX":                                 nil,
+	"com.apple.MCX.FileVault2":                      nil,
+	"com.apple.MCX.TimeMachine":                     nil,
+	"com.apple.SoftwareUpdate":                      nil,
+	"com.apple.TCC.configuration-profile-policy":    {"Services"},
+	"com.apple.airplay":                             nil,
+	"com.apple.airprint":                            nil,
+	"com.apple.app.lock":                            {"App"},
+	"com.apple.applicationaccess":                   nil,
+	"com.apple.applicationaccess.new":               nil,
+	"com.apple.associated-domains":                  nil,
+	"com.apple.caldav.account":                      {"CalDAVHostName"},
+	"com.apple.carddav.account":                     {"CardDAVHostName"},
+	"com.apple.cellular":                            nil,
+	"com.apple.configurationprofile.identification": nil,
+	"com.apple.desktop":                             nil,
+	"com.apple.dnsSettings.managed":                 {"DNSSettings"},
+	"com.apple.dock":                                nil,
+	"com.apple.eas.account":                         nil,
+	"com.apple.education":                           nil,
+	"com.apple.extensiblesso":                       {"ExtensionIdentifier", "Type"},
+	"com.apple.finder":                              nil,
+	"com.apple.firstactiveethernet.managed":         nil,
+	"com.apple.font":                                {"Font"},
+	"com.apple.globalethernet.managed":              nil,
+	"com.apple.homescreenlayout":                    nil,
+	"com.apple.ldap.account":                        {"LDAPAccountHostName"},
+	"com.apple.loginwindow":                         nil,
+	"com.apple.mail.managed":                        {"EmailAccountType", "IncomingMailServerHostName", "IncomingMailServerAuthentication", "OutgoingMailServerHostName", "OutgoingMailServerAuthentication"},
+	"com.apple.mcxprinting":                         nil,
+	"com.apple.mdm":                                 {"IdentityCertificateUUID", "ServerURL", "Topic"},
+	"com.apple.mobiledevice.passwordpolicy":         nil,
+	"com.apple.notificationsettings":                {"NotificationSettings"},
+	"com.apple.profileRemovalPassword":              {"RemovalPassword"},
+	"com.apple.proxy.http.global":                   {"ProxyType"},
+	"com.apple.screensaver":                         nil,
+	"com.apple.security.acme":                       {"ClientIdentifier", "DirectoryURL", "KeySize", "KeyType"},
+	"com.apple.security.certificatetransparency":    nil,
+	"com.apple.security.firewall":                   nil,
+	"com.apple.security.pem":                        {"PayloadContent"},
+	"com.apple.security.pkcs1":                      {"PayloadContent"},
+	"com.apple.security.pkcs12":                     {"PayloadContent"},
+	"com.apple.security.root":                       {"PayloadContent"},
+	"com.apple.security.scep":                       {"PayloadContent"},
+	"com.apple.security.smartcard":                  nil,
+	"com.apple.shareddeviceconfiguration":           nil,
+	"com.apple.subscribedcalendar.account":          {"SubCalAccountHostName"},
+	"com.apple.syspolicy.kernel-extension-policy":   nil,
+	"com.apple.system-extension-policy":             nil,
+	"com.apple.systempolicy.control":                nil,
+	"com.apple.systempolicy.managed":                nil,
+	"com.apple.universalaccess":                     nil,
+	"com.apple.vpn.managed":                         {"UserDefinedName", "VPNType"},
+	"com.apple.vpn.managed.applayer":                {"UserDefinedName", "VPNType", "VPNUUID"},
+	"com.apple.webClip.managed":                     {"Label", "URL"},
+	"com.apple.webcontent-filter":                   {"FilterType"},
+	"com.apple.wifi.managed":                        {"SSID_STR"},
+}
+
+// Lint parses the whole mobileconfig, unwrapping it first if it is
+// signed, and reports problems which make devices reject the profile as
+// errors and likely mistakes as warnings. Templates are linted as
+// rendered with example values.
+func (mc Mobileconfig) Lint() []LintProblem {
+	return lint(mc, time.Now())
+}
+
+type linter struct {
+	problems    []LintProblem
+	uuids       map[string]string
+	identifiers map[string]bool
+}
+
+func (l *linter) report(severity, payload, format string, args ...interface{}) {
+	l.problems = append(l.problems, LintProblem{
+		Severity: severity,
+		Payload:  payload,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func lint(mc Mobileconfig, now time.Time) []LintProblem {
+	l := &linter{uuids: make(map[string]string), identifiers: make(map[string]bool)}
+	content, signed, err := mc.content()
+	if err != nil {
+		l.report(LintError, "", "%s", err)
+		return l.problems
+	}
+	if signed {
+		l.lintSignature(mc, now)
+	}
+	if isTemplate(content) {
+		if content, err = renderTemplate(content, validationVariables, false, nil); err != nil {
+			l.report(LintError, "", "%s", err)
+			return l.problems
+		}
+	}
+
+	var profile map[string]interface{}
+	if err := plist.Unmarshal(content, &profile); err != nil {
+		l.report(LintError, "", "profile is not a property list dictionary: %s", err)
+		return l.problems
+	}
+	l.lintProfile(profile)
+	return l.problems
+}
+
+func (l *linter) lintSignature(mc Mobileconfig, now time.Time) {
+	p7, err := pkcs7.Parse(mc)
+	if err != nil {
+		l.report(LintError, "", "parse profile signature: %s", err)
+		return
+	}
+	if err := p7.Verify(); err != nil {
+		l.report(LintError, "", "profile signature is not valid: %s", err)
+		return
+	}
+	cert := p7.GetOnlySigner()
+	if cert == nil {
+		l.report(LintError, "", "profile must have exactly one signer")
+		return
+	}
+	name := cert.Subject.CommonName
+	switch {
+	// devices install profiles signed by a certificate which is not
+	// valid as unverified.
+	case now.Before(cert.NotBefore):
+		l.report(LintWarning, "", "signing certificate %q is not valid until %s", name, cert.NotBefore.Format(time.RFC3339))
+	case now.After(cert.NotAfter):
+		l.report(LintWarning, "", "signing certificate %q expired on %s", name, cert.NotAfter.Format(time.RFC3339))
+	case now.Add(certificateExpiryWarning).After(cert.NotAfter):
+		l.report(LintWarning, "", "signing certificate %q expires on %s", name, cert.NotAfter.Format(time.RFC3339))
+	}
+}
+
+func (l *linter) lintProfile(profile map[string]interface{}) {
+	l.lintCommonKeys("", profile)
+	if typ, ok := profile["PayloadType"].(string); ok && typ != "Configuration" {
+		l.report(LintError, "", "profile PayloadType must be Configuration, not %q", typ)
+	}
+	if scope, ok := profile["PayloadScope"]; ok && scope != "System" && scope != "User" {
+		l.report(LintError, "", "PayloadScope must be System or User, not %v", scope)
+	}
+	if _, ok := profile["PayloadDisplayName"]; !ok {
+		l.report(LintWarning, "", "profile has no PayloadDisplayName")
+	}
+
+	if _, ok := profile["EncryptedPayloadContent"]; ok {
+		// the payloads can only be read by the device.
+		return
+	}
+	content, ok := profile["PayloadContent"]
+	if !ok {
+		l.report(LintWarning, "", "profile has no PayloadContent")
+		return
+	}
+	payloads, ok := content.([]interface{})
+	if !ok {
+		l.report(LintError, "", "PayloadContent must be an array of payloads")
+		return
+	}
+	for i, p := range payloads {
+		payload, ok := p.(map[string]interface{})
+		if !ok {
+			l.report(LintError, "", "payload %d is not a dictionary", i)
+			continue
+		}
+		l.lintPayload(i, payload)
+	}
+}
+
+func (l *linter) lintPayload(i int, payload map[string]interface{}) {
+	name, _ := payload["PayloadIdentifier"].(string)
+	if name == "" {
+		name = fmt.Sprintf("payload %d", i)
+	}
+	l.lintCommonKeys(name, payload)
+
+	typ, ok := payload["PayloadType"].(string)
+	if !ok {
+		return
+	}
+	keys, known := payloadKeys[typ]
+	switch {
+	case typ == "Configuration":
+		l.report(LintError, name, "payload must not have the PayloadType of a profile")
+	case !known:
+		l.report(LintWarning, name, "unknown PayloadType %q", typ)
+	default:
+		// the keys of a payload type depend on its variant, such as
+		// Hotspot 2.0 Wi-Fi networks without an SSID, so a missing
+		// key is only a warning.
+		l.lintRequiredKeys(LintWarning, name, payload, keys)
+	}
+}
+
+func (l *linter) lintRequiredKeys(severity, name string, dict map[string]interface{}, keys []string) {
+	for _, key := range keys {
+		if v, ok := dict[key]; !ok || v == "" {
+			l.report(severity, name, "missing required key %s", key)
+		}
+	}
+}
+
+// lintCommonKeys checks the keys of the profile and each of its payloads,
+// and reports UUIDs and identifiers used more than once in the profile.
+func (l *linter) lintCommonKeys(name string, dict map[string]interface{}) {
+	l.lintRequiredKeys(LintError, name, dict, requiredKeys)
+	if v, ok := dict["PayloadVersion"]; ok && fmt.Sprint(v) != "1" {
+		l.report(LintWarning, name, "PayloadVersion should be 1, not %v", v)
+	}
+	if uuid, ok := dict["PayloadUUID"].(string); ok && uuid != "" {
+		uuid = strings.ToUpper(uuid)
+		if other, dup := l.uuids[uuid]; dup {
+			l.report(LintError, name, "PayloadUUID %s is also used by %s", uuid, describe(other))
+		} else {
+			l.uuids[uuid] = name
+		}
+	}
+	if id, ok := dict["PayloadIdentifier"].(string); ok && id != "" {
+		if l.identifiers[id] {
+			l.report(LintWarning, name, "PayloadIdentifier is used by more than one payload")
+		}
+		l.identifiers[id] = true
+	}
+}
+
+func describe(name string) string {
+	if name == "" {
+		return "the profile"
+	}
+	return name
+}
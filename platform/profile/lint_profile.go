@@ -0,0 +1,58 @@
+package profile
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+func (svc *ProfileService) LintProfile(ctx context.Context, mc Mobileconfig) ([]LintProblem, error) {
+	return mc.Lint(), nil
+}
+
+type lintProfileRequest struct {
+	Mobileconfig Mobileconfig `json:"mobileconfig"`
+}
+
+type lintProfileResponse struct {
+	Problems []LintProblem `json:"problems,omitempty"`
+	Err      error         `json:"err,omitempty"`
+}
+
+func (r lintProfileResponse) Failed() error { return r.Err }
+
+func decodeLintProfileRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req lintProfileRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeLintProfileResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp lintProfileResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeLintProfileEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(lintProfileRequest)
+		problems, err := svc.LintProfile(ctx, req.Mobileconfig)
+		return lintProfileResponse{
+			Problems: problems,
+			Err:      err,
+		}, nil
+	}
+}
+
+func (e Endpoints) LintProfile(ctx context.Context, mc Mobileconfig) ([]LintProblem, error) {
+	request := lintProfileRequest{Mobileconfig: mc}
+	resp, err := e.LintProfileEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := resp.(lintProfileResponse)
+	return response.Problems, response.Err
+}
@@ -0,0 +1,174 @@
+package profile
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micromdm/micromdm/pkg/crypto"
+	"github.com/micromdm/micromdm/pkg/crypto/profileutil"
+)
+
+func lintProfile(scope string, payloads ...string) Mobileconfig {
+	return Mobileconfig(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>PayloadContent</key>
+	<array>` + strings.Join(payloads, "") + `</array>
+	<key>PayloadDisplayName</key>
+	<string>Lint</string>
+	<key>PayloadIdentifier</key>
+	<string>com.example.lint</string>
+	<key>PayloadScope</key>
+	<string>` + scope + `</string>
+	<key>PayloadType</key>
+	<string>Configuration</string>
+	<key>PayloadUUID</key>
+	<string>2B3E3E0C-5A3B-4F43-8A3A-8C4D0B2C1D00</string>
+	<key>PayloadVersion</key>
+	<integer>1</integer>
+</dict>
+</plist>`)
+}
+
+func lintPayload(typ, id, uuid, keys string) string {
+	return `<dict>` + keys + `
+		<key>PayloadIdentifier</key>
+		<string>` + id + `</string>
+		<key>PayloadType</key>
+		<string>` + typ + `</string>
+		<key>PayloadUUID</key>
+		<string>` + uuid + `</string>
+		<key>PayloadVersion</key>
+		<integer>1</integer>
+	</dict>`
+}
+
+var wifiPayload = lintPayload("com.apple.wifi.managed", "com.example.lint.wifi", "7C1B1E8A-8A3D-4C2B-9D4E-1F5A6B7C8D01",
+	`<key>SSID_STR</key><string>corp</string>`)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		mc   Mobileconfig
+		want []string
+	}{
+		{
+			name: "valid",
+			mc:   lintProfile("System", wifiPayload),
+		},
+		{
+			name: "duplicate uuid",
+			mc: lintProfile("System", wifiPayload, lintPayload("com.apple.dock", "com.example.lint.dock",
+				"7c1b1e8a-8a3d-4c2b-9d4e-1f5a6b7c8d01", "")),
+			want: []string{"error: com.example.lint.dock: PayloadUUID 7C1B1E8A-8A3D-4C2B-9D4E-1F5A6B7C8D01 is also used by com.example.lint.wifi"},
+		},
+		{
+			name: "duplicate identifier",
+			mc: lintProfile("System", wifiPayload, lintPayload("com.apple.dock", "com.example.lint.wifi",
+				"5E0F6C1A-0B1C-4D2E-8F3A-4B5C6D7E8F02", "")),
+			want: []string{"warning: com.example.lint.wifi: PayloadIdentifier is used by more than one payload"},
+		},
+		{
+			name: "invalid scope",
+			mc:   lintProfile("Computer", wifiPayload),
+			want: []string{"error: PayloadScope must be System or User, not Computer"},
+		},
+		{
+			name: "unknown payload type",
+			mc: lintProfile("User", lintPayload("com.example.custom", "com.example.lint.custom",
+				"5E0F6C1A-0B1C-4D2E-8F3A-4B5C6D7E8F02", "")),
+			want: []string{`warning: com.example.lint.custom: unknown PayloadType "com.example.custom"`},
+		},
+		{
+			name: "missing required key",
+			mc: lintProfile("System", lintPayload("com.apple.wifi.managed", "com.example.lint.wifi",
+				"5E0F6C1A-0B1C-4D2E-8F3A-4B5C6D7E8F02", "")),
+			want: []string{"warning: com.example.lint.wifi: missing required key SSID_STR"},
+		},
+		{
+			name: "not a property list",
+			mc:   Mobileconfig("<?xml version=\"1.0\"?><plist><array></array></plist>"),
+			want: []string{"error: profile is not a property list dictionary"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := tt.mc.Lint()
+			if len(problems) != len(tt.want) {
+				t.Fatalf("have problems %v, want %v", problems, tt.want)
+			}
+			for i, want := range tt.want {
+				if have := problems[i].String(); !strings.HasPrefix(have, want) {
+					t.Errorf("have problem %q, want %q", have, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLintSignature(t *testing.T) {
+	key, cert, err := crypto.SimpleSelfSignedRSAKeypair("lint", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := profileutil.Sign(key, cert, lintProfile("System", wifiPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if problems := lint(signed, time.Now()); len(problems) != 1 || problems[0].Severity != LintWarning {
+		t.Errorf("want expiry warning for a certificate valid for 10 days, have %v", problems)
+	}
+	expired := lint(signed, time.Now().Add(11*24*time.Hour))
+	if len(expired) != 1 || expired[0].Severity != LintWarning || !strings.Contains(expired[0].Message, "expired") {
+		t.Errorf("want warning for an expired signing certificate, have %v", expired)
+	}
+
+	tampered := Mobileconfig(strings.Replace(string(signed), "corp", "evil", 1))
+	if err := lintErrors(tampered.Lint()); err == nil || !strings.Contains(err.Error(), "signature is not valid") {
+		t.Errorf("want invalid signature error, have %v", err)
+	}
+}
+
+// saveStore records the profiles saved by ApplyProfile.
+type saveStore struct {
+	Store
+	saved []*Profile
+}
+
+func (s *saveStore) Save(p *Profile) error {
+	s.saved = append(s.saved, p)
+	return nil
+}
+
+func TestApplyProfileWarnings(t *testing.T) {
+	store := &saveStore{}
+	svc := New(store)
+
+	p := &Profile{
+		Identifier: "com.example.lint",
+		Mobileconfig: lintProfile("User", lintPayload("com.example.custom", "com.example.lint.custom",
+			"5E0F6C1A-0B1C-4D2E-8F3A-4B5C6D7E8F02", "")),
+	}
+	warnings, err := svc.ApplyProfile(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0].Severity != LintWarning {
+		t.Errorf("want one lint warning, have %v", warnings)
+	}
+	if len(store.saved) != 1 {
+		t.Errorf("want profile with warnings saved, have %d saved", len(store.saved))
+	}
+
+	p.Mobileconfig = lintProfile("Computer", wifiPayload)
+	if _, err := svc.ApplyProfile(context.Background(), p); err == nil {
+		t.Error("want error for a profile with lint errors")
+	}
+	if len(store.saved) != 1 {
+		t.Errorf("want profile with errors rejected, have %d saved", len(store.saved))
+	}
+}
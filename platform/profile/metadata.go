@@ -0,0 +1,93 @@
+package profile
+
+import (
+	"time"
+
+	"github.com/fullsailor/pkcs7"
+	"github.com/groob/plist"
+	"github.com/pkg/errors"
+)
+
+// Metadata describes a profile without its content, parsed from the
+// mobileconfig when profiles are listed or queried.
+type Metadata struct {
+	Identifier   string    `json:"identifier"`
+	Version      int       `json:"version,omitempty"`
+	Uploaded     time.Time `json:"uploaded,omitempty"`
+	DisplayName  string    `json:"display_name,omitempty"`
+	Organization string    `json:"organization,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+
+	// PayloadCount is the number of payloads in the profile, and
+	// PayloadTypes the number of payloads of each type.
+	PayloadCount int            `json:"payload_count"`
+	PayloadTypes map[string]int `json:"payload_types,omitempty"`
+
+	// Signer is the subject of the certificate the profile was signed with
+	// when it was uploaded.
+	Signer   string `json:"signer,omitempty"`
+	Template bool   `json:"template,omitempty"`
+	Encrypt  bool   `json:"encrypt,omitempty"`
+}
+
+// HasPayloadType reports whether the profile has a payload of the type.
+func (m *Metadata) HasPayloadType(payloadType string) bool {
+	return m.PayloadTypes[payloadType] > 0
+}
+
+type profileMetadata struct {
+	PayloadDisplayName  string
+	PayloadOrganization string
+	PayloadDescription  string
+	PayloadScope        string
+	PayloadContent      []struct {
+		PayloadType string
+	}
+}
+
+// ParseMetadata parses the metadata of the profile from its Mobileconfig.
+func (p *Profile) ParseMetadata() (*Metadata, error) {
+	content, signed, err := p.Mobileconfig.content()
+	if err != nil {
+		return nil, err
+	}
+	m := &Metadata{
+		Identifier: p.Identifier,
+		Version:    p.Version,
+		Uploaded:   p.Uploaded,
+		Encrypt:    p.Encrypt,
+	}
+	if signed {
+		p7, err := pkcs7.Parse(p.Mobileconfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse signed profile")
+		}
+		if cert := p7.GetOnlySigner(); cert != nil {
+			m.Signer = cert.Subject.String()
+		}
+	}
+	if isTemplate(content) {
+		m.Template = true
+		if content, err = renderTemplate(content, validationVariables, false, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	var pm profileMetadata
+	if err := plist.Unmarshal(content, &pm); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal profile %s", p.Identifier)
+	}
+	m.DisplayName = pm.PayloadDisplayName
+	m.Organization = pm.PayloadOrganization
+	m.Description = pm.PayloadDescription
+	m.Scope = pm.PayloadScope
+	m.PayloadCount = len(pm.PayloadContent)
+	for _, payload := range pm.PayloadContent {
+		if m.PayloadTypes == nil {
+			m.PayloadTypes = make(map[string]int)
+		}
+		m.PayloadTypes[payload.PayloadType]++
+	}
+	return m, nil
+}
@@ -0,0 +1,52 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/micromdm/micromdm/pkg/crypto"
+	"github.com/micromdm/micromdm/pkg/crypto/profileutil"
+)
+
+func TestParseMetadata(t *testing.T) {
+	dock := lintPayload("com.apple.dock", "com.example.lint.dock", "5E0F6C1A-0B1C-4D2E-8F3A-4B5C6D7E8F02", "")
+	p := &Profile{
+		Identifier:   "com.example.lint",
+		Version:      2,
+		Mobileconfig: lintProfile("User", wifiPayload, dock),
+	}
+	m, err := p.ParseMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Identifier != p.Identifier || m.Version != 2 {
+		t.Errorf("have identifier %s version %d, want %s version 2", m.Identifier, m.Version, p.Identifier)
+	}
+	if m.DisplayName != "Lint" || m.Scope != "User" {
+		t.Errorf("have display name %q scope %q, want Lint and User", m.DisplayName, m.Scope)
+	}
+	if m.PayloadCount != 2 || !m.HasPayloadType("com.apple.wifi.managed") || !m.HasPayloadType("com.apple.dock") {
+		t.Errorf("have %d payloads of types %v, want a wifi and a dock payload", m.PayloadCount, m.PayloadTypes)
+	}
+	if m.HasPayloadType("com.apple.vpn.managed") {
+		t.Error("profile has no vpn payload")
+	}
+	if m.Signer != "" || m.Template {
+		t.Errorf("have signer %q template %v for an unsigned profile", m.Signer, m.Template)
+	}
+
+	key, cert, err := crypto.SimpleSelfSignedRSAKeypair("metadata", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := profileutil.Sign(key, cert, lintProfile("System", wifiPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Mobileconfig = signed
+	if m, err = p.ParseMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Signer != cert.Subject.String() || m.PayloadTypes["com.apple.wifi.managed"] != 1 {
+		t.Errorf("have signer %q payload types %v, want signer %q and one wifi payload", m.Signer, m.PayloadTypes, cert.Subject)
+	}
+}
@@ -1,6 +1,10 @@
 package profile
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
 	"github.com/pkg/errors"
 
 	"github.com/fullsailor/pkcs7"
@@ -41,9 +45,38 @@ func (mc *Mobileconfig) GetPayloadIdentifier() (string, error) {
 	return pId.PayloadIdentifier, err
 }
 
+// IsSigned reports whether the mobileconfig is signed.
+func (mc Mobileconfig) IsSigned() bool {
+	_, signed, err := mc.content()
+	return err == nil && signed
+}
+
 type Profile struct {
 	Identifier   string
 	Mobileconfig Mobileconfig
+
+	// Encrypt encrypts the PayloadContent of the profile to the identity
+	// certificate of each device it is installed on.
+	Encrypt bool `json:",omitempty"`
+
+	// Version is incremented by the store each time the profile is saved
+	// with a different Hash.
+	Version int    `json:",omitempty"`
+	Hash    string `json:",omitempty"`
+
+	// Uploaded is set by the store when a new version of the profile is
+	// saved.
+	Uploaded time.Time `json:",omitempty"`
+
+	// Metadata is parsed from the Mobileconfig when profiles are listed.
+	// It is not stored.
+	Metadata *Metadata `json:",omitempty"`
+}
+
+// Hash returns the hex encoded SHA-256 hash of the mobileconfig.
+func (mc Mobileconfig) Hash() string {
+	sum := sha256.Sum256(mc)
+	return hex.EncodeToString(sum[:])
 }
 
 // Validate checks the internal consistency and validity of a Profile structure
@@ -61,6 +94,14 @@ func (p *Profile) Validate() error {
 	if payloadId != p.Identifier {
 		return errors.New("payload Identifier does not match Profile")
 	}
+	if p.Encrypt {
+		if err := validateEncrypt(p.Mobileconfig); err != nil {
+			return err
+		}
+	}
+	if p.Mobileconfig.IsTemplate() {
+		return validateTemplate(p.Mobileconfig, p.Identifier)
+	}
 	return nil
 }
 
@@ -68,6 +109,10 @@ func MarshalProfile(p *Profile) ([]byte, error) {
 	protobp := profileproto.Profile{
 		Id:           p.Identifier,
 		Mobileconfig: p.Mobileconfig,
+		Encrypt:      p.Encrypt,
+		Version:      int64(p.Version),
+		Hash:         p.Hash,
+		Uploaded:     timeToNano(p.Uploaded),
 	}
 	return proto.Marshal(&protobp)
 }
@@ -79,5 +124,9 @@ func UnmarshalProfile(data []byte, p *Profile) error {
 	}
 	p.Identifier = pb.GetId()
 	p.Mobileconfig = pb.GetMobileconfig()
+	p.Encrypt = pb.GetEncrypt()
+	p.Version = int(pb.GetVersion())
+	p.Hash = pb.GetHash()
+	p.Uploaded = timeFromNano(pb.GetUploaded())
 	return nil
 }
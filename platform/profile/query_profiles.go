@@ -0,0 +1,84 @@
+package profile
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+type QueryProfilesOption struct {
+	// PayloadType only returns the profiles with a payload of this type,
+	// such as com.apple.wifi.managed.
+	PayloadType string `json:"payload_type,omitempty"`
+}
+
+// QueryProfiles returns the metadata of the profiles which match opt,
+// without their content.
+func (svc *ProfileService) QueryProfiles(ctx context.Context, opt QueryProfilesOption) ([]Metadata, error) {
+	profiles, err := svc.store.List()
+	if err != nil {
+		return nil, err
+	}
+	var found []Metadata
+	for _, p := range profiles {
+		m, err := p.ParseMetadata()
+		if err != nil {
+			if opt.PayloadType != "" {
+				continue
+			}
+			m = &Metadata{Identifier: p.Identifier, Version: p.Version, Uploaded: p.Uploaded, Encrypt: p.Encrypt}
+		}
+		if opt.PayloadType != "" && !m.HasPayloadType(opt.PayloadType) {
+			continue
+		}
+		found = append(found, *m)
+	}
+	return found, nil
+}
+
+type queryProfilesRequest struct {
+	QueryProfilesOption
+}
+
+type queryProfilesResponse struct {
+	Profiles []Metadata `json:"profiles"`
+	Err      error      `json:"err,omitempty"`
+}
+
+func (r queryProfilesResponse) Failed() error { return r.Err }
+
+func decodeQueryProfilesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req queryProfilesRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeQueryProfilesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp queryProfilesResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeQueryProfilesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(queryProfilesRequest)
+		profiles, err := svc.QueryProfiles(ctx, req.QueryProfilesOption)
+		return queryProfilesResponse{
+			Profiles: profiles,
+			Err:      err,
+		}, nil
+	}
+}
+
+func (e Endpoints) QueryProfiles(ctx context.Context, opt QueryProfilesOption) ([]Metadata, error) {
+	request := queryProfilesRequest{QueryProfilesOption: opt}
+	resp, err := e.QueryProfilesEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := resp.(queryProfilesResponse)
+	return response.Profiles, response.Err
+}
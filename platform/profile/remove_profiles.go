@@ -5,25 +5,79 @@ import (
 	"net/http"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/mdm/mdm"
 	"github.com/micromdm/micromdm/pkg/httputil"
 )
 
-func (svc *ProfileService) RemoveProfiles(ctx context.Context, ids []string) error {
+type RemoveProfilesOption struct {
+	// FromDevices queues RemoveProfile for every device known to have the
+	// profiles installed, before they are deleted from the server. The
+	// result of each removal is reported by GetDeviceProfiles.
+	FromDevices bool `json:"from_devices,omitempty"`
+}
+
+// RemoveProfiles deletes profiles from the server, and returns the devices
+// they are being removed from.
+func (svc *ProfileService) RemoveProfiles(ctx context.Context, ids []string, opt RemoveProfilesOption) ([]DeviceProfile, error) {
+	if opt.FromDevices && svc.commands == nil {
+		return nil, errors.New("removing profiles from devices is not enabled on this server")
+	}
+	var removing []DeviceProfile
 	for _, id := range ids {
+		if opt.FromDevices {
+			queued, err := svc.removeFromDevices(ctx, id)
+			removing = append(removing, queued...)
+			if err != nil {
+				return removing, err
+			}
+		}
 		err := svc.store.Delete(id)
 		if err != nil {
-			return err
+			return removing, err
+		}
+	}
+	return removing, nil
+}
+
+func (svc *ProfileService) removeFromDevices(ctx context.Context, id string) ([]DeviceProfile, error) {
+	devices, err := svc.store.DeviceProfiles(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get devices with profile %s", id)
+	}
+	var queued []DeviceProfile
+	for _, dp := range devices {
+		if !dp.Installed() {
+			continue
+		}
+		payload, err := svc.commands.NewCommand(ctx, &mdm.CommandRequest{
+			UDID: dp.UDID,
+			Command: &mdm.Command{
+				RequestType: "RemoveProfile",
+				RemoveProfile: &mdm.RemoveProfile{
+					Identifier: id,
+				},
+			},
+		})
+		if err != nil {
+			return queued, errors.Wrapf(err, "queue RemoveProfile for udid %s", dp.UDID)
 		}
+		dp.Status = DeviceProfileRemoving
+		dp.CommandUUID = payload.CommandUUID
+		queued = append(queued, dp)
 	}
-	return nil
+	return queued, nil
 }
 
 type removeProfileRequest struct {
 	Identifiers []string `json:"ids"`
+	RemoveProfilesOption
 }
 
 type removeProfileResponse struct {
-	Err error `json:"err,omitempty"`
+	Removing []DeviceProfile `json:"removing,omitempty"`
+	Err      error           `json:"err,omitempty"`
 }
 
 func (r removeProfileResponse) Failed() error { return r.Err }
@@ -43,18 +97,20 @@ func decodeRemoveProfileResponse(_ context.Context, r *http.Response) (interface
 func MakeRemoveProfilesEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(removeProfileRequest)
-		err = svc.RemoveProfiles(ctx, req.Identifiers)
+		removing, err := svc.RemoveProfiles(ctx, req.Identifiers, req.RemoveProfilesOption)
 		return removeProfileResponse{
-			Err: err,
+			Removing: removing,
+			Err:      err,
 		}, nil
 	}
 }
 
-func (e Endpoints) RemoveProfiles(ctx context.Context, ids []string) error {
-	request := removeProfileRequest{Identifiers: ids}
+func (e Endpoints) RemoveProfiles(ctx context.Context, ids []string, opt RemoveProfilesOption) ([]DeviceProfile, error) {
+	request := removeProfileRequest{Identifiers: ids, RemoveProfilesOption: opt}
 	resp, err := e.RemoveProfilesEndpoint(ctx, request)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return resp.(removeProfileResponse).Err
+	response := resp.(removeProfileResponse)
+	return response.Removing, response.Err
 }
@@ -0,0 +1,157 @@
+package profile
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"sort"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/pkg/httputil"
+)
+
+// CommandService queues MDM commands.
+type CommandService interface {
+	NewCommand(context.Context, *mdm.CommandRequest) (*mdm.CommandPayload, error)
+}
+
+// RolloutRequest rolls out the current version of a profile to the devices
+// which were sent an earlier version.
+type RolloutRequest struct {
+	Identifier string `json:"id"`
+
+	// Percent of the devices with the profile which should be sent the
+	// current version once the rollout is queued, to stage a rollout in
+	// steps such as 10, 50 and 100. Zero rolls out to all devices.
+	Percent int `json:"percent,omitempty"`
+}
+
+// Rollout is the result of a RolloutRequest.
+type Rollout struct {
+	Identifier string `json:"id"`
+	Version    int    `json:"version"`
+
+	// Devices is the number of devices which were sent the profile and
+	// did not have it removed, and Current the number of them sent the
+	// current version, including the devices in Queued.
+	Devices int      `json:"devices"`
+	Current int      `json:"current"`
+	Queued  []string `json:"queued,omitempty"`
+}
+
+func (svc *ProfileService) RolloutProfile(ctx context.Context, req RolloutRequest) (*Rollout, error) {
+	if svc.commands == nil {
+		return nil, errors.New("profile rollout is not enabled on this server")
+	}
+	if req.Percent < 0 || req.Percent > 100 {
+		return nil, errors.Errorf("rollout percent must be between 0 and 100, not %d", req.Percent)
+	}
+	if req.Percent == 0 {
+		req.Percent = 100
+	}
+	p, err := svc.store.ProfileById(ctx, req.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := svc.store.DeviceProfiles(req.Identifier)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get devices with profile %s", req.Identifier)
+	}
+
+	rollout := &Rollout{Identifier: p.Identifier, Version: p.Version}
+	var outdated []string
+	for _, dp := range devices {
+		if dp.removal() {
+			continue
+		}
+		rollout.Devices++
+		if dp.SentVersion == p.Version && dp.Status != DeviceProfileError {
+			rollout.Current++
+			continue
+		}
+		outdated = append(outdated, dp.UDID)
+	}
+	sortRollout(p.Identifier, outdated)
+
+	target := (rollout.Devices*req.Percent + 99) / 100
+	for _, udid := range outdated {
+		if rollout.Current >= target {
+			break
+		}
+		_, err := svc.commands.NewCommand(ctx, &mdm.CommandRequest{
+			UDID: udid,
+			Command: &mdm.Command{
+				RequestType: "InstallProfile",
+				InstallProfile: &mdm.InstallProfile{
+					Payload: p.Mobileconfig,
+				},
+			},
+		})
+		if err != nil {
+			return rollout, errors.Wrapf(err, "queue InstallProfile for udid %s", udid)
+		}
+		rollout.Current++
+		rollout.Queued = append(rollout.Queued, udid)
+	}
+	return rollout, nil
+}
+
+// sortRollout orders devices by a hash of their UDID and the profile, so
+// that each stage of a rollout picks devices in a stable order which
+// doesn't depend on how the UDIDs were assigned.
+func sortRollout(id string, udids []string) {
+	key := func(udid string) string {
+		sum := sha256.Sum256([]byte(id + "/" + udid))
+		return string(sum[:])
+	}
+	sort.Slice(udids, func(i, j int) bool {
+		return key(udids[i]) < key(udids[j])
+	})
+}
+
+type rolloutProfileRequest struct {
+	RolloutRequest
+}
+
+type rolloutProfileResponse struct {
+	Rollout *Rollout `json:"rollout,omitempty"`
+	Err     error    `json:"err,omitempty"`
+}
+
+func (r rolloutProfileResponse) Failed() error { return r.Err }
+
+func decodeRolloutProfileRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req rolloutProfileRequest
+	err := httputil.DecodeJSONRequest(r, &req)
+	return req, err
+}
+
+func decodeRolloutProfileResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var resp rolloutProfileResponse
+	err := httputil.DecodeJSONResponse(r, &resp)
+	return resp, err
+}
+
+func MakeRolloutProfileEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(rolloutProfileRequest)
+		rollout, err := svc.RolloutProfile(ctx, req.RolloutRequest)
+		return rolloutProfileResponse{
+			Rollout: rollout,
+			Err:     err,
+		}, nil
+	}
+}
+
+func (e Endpoints) RolloutProfile(ctx context.Context, req RolloutRequest) (*Rollout, error) {
+	request := rolloutProfileRequest{RolloutRequest: req}
+	resp, err := e.RolloutProfileEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := resp.(rolloutProfileResponse)
+	return response.Rollout, response.Err
+}
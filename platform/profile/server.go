@@ -8,16 +8,24 @@ import (
 )
 
 type Endpoints struct {
-	ApplyProfileEndpoint   endpoint.Endpoint
-	GetProfilesEndpoint    endpoint.Endpoint
-	RemoveProfilesEndpoint endpoint.Endpoint
+	ApplyProfileEndpoint      endpoint.Endpoint
+	LintProfileEndpoint       endpoint.Endpoint
+	RolloutProfileEndpoint    endpoint.Endpoint
+	GetDeviceProfilesEndpoint endpoint.Endpoint
+	GetProfilesEndpoint       endpoint.Endpoint
+	QueryProfilesEndpoint     endpoint.Endpoint
+	RemoveProfilesEndpoint    endpoint.Endpoint
 }
 
 func MakeServerEndpoints(s Service, outer endpoint.Middleware, others ...endpoint.Middleware) Endpoints {
 	return Endpoints{
-		ApplyProfileEndpoint:   endpoint.Chain(outer, others...)(MakeApplyProfileEndpoint(s)),
-		GetProfilesEndpoint:    endpoint.Chain(outer, others...)(MakeGetProfilesEndpoint(s)),
-		RemoveProfilesEndpoint: endpoint.Chain(outer, others...)(MakeRemoveProfilesEndpoint(s)),
+		ApplyProfileEndpoint:      endpoint.Chain(outer, others...)(MakeApplyProfileEndpoint(s)),
+		LintProfileEndpoint:       endpoint.Chain(outer, others...)(MakeLintProfileEndpoint(s)),
+		RolloutProfileEndpoint:    endpoint.Chain(outer, others...)(MakeRolloutProfileEndpoint(s)),
+		GetDeviceProfilesEndpoint: endpoint.Chain(outer, others...)(MakeGetDeviceProfilesEndpoint(s)),
+		GetProfilesEndpoint:       endpoint.Chain(outer, others...)(MakeGetProfilesEndpoint(s)),
+		QueryProfilesEndpoint:     endpoint.Chain(outer, others...)(MakeQueryProfilesEndpoint(s)),
+		RemoveProfilesEndpoint:    endpoint.Chain(outer, others...)(MakeRemoveProfilesEndpoint(s)),
 	}
 }
 
@@ -25,6 +33,10 @@ func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.S
 	// POST    /v1/profiles		get a list of profiles managed by the server
 	// PUT     /v1/profiles		create or replace a profile on the server
 	// DELETE  /v1/profiles		remove one or more profiles from the server
+	// POST    /v1/profiles/query	get the metadata of the profiles, optionally filtered by payload type
+	// POST    /v1/profiles/lint	report the problems found in a profile
+	// POST    /v1/profiles/rollout	send the current version of a profile to devices with an older version
+	// POST    /v1/profiles/devices	get the devices a profile was sent to or removed from
 
 	r.Methods("POST").Path("/v1/profiles").Handler(httptransport.NewServer(
 		e.GetProfilesEndpoint,
@@ -40,6 +52,34 @@ func RegisterHTTPHandlers(r *mux.Router, e Endpoints, options ...httptransport.S
 		options...,
 	))
 
+	r.Methods("POST").Path("/v1/profiles/query").Handler(httptransport.NewServer(
+		e.QueryProfilesEndpoint,
+		decodeQueryProfilesRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/profiles/lint").Handler(httptransport.NewServer(
+		e.LintProfileEndpoint,
+		decodeLintProfileRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/profiles/rollout").Handler(httptransport.NewServer(
+		e.RolloutProfileEndpoint,
+		decodeRolloutProfileRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/v1/profiles/devices").Handler(httptransport.NewServer(
+		e.GetDeviceProfilesEndpoint,
+		decodeGetDeviceProfilesRequest,
+		httputil.EncodeJSONResponse,
+		options...,
+	))
+
 	r.Methods("DELETE").Path("/v1/profiles").Handler(httptransport.NewServer(
 		e.RemoveProfilesEndpoint,
 		decodeRemoveProfilesRequest,
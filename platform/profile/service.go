@@ -5,9 +5,13 @@ import (
 )
 
 type Service interface {
-	ApplyProfile(ctx context.Context, p *Profile) error
+	ApplyProfile(ctx context.Context, p *Profile) ([]LintProblem, error)
+	LintProfile(ctx context.Context, mc Mobileconfig) ([]LintProblem, error)
 	GetProfiles(ctx context.Context, opt GetProfilesOption) ([]Profile, error)
-	RemoveProfiles(ctx context.Context, ids []string) error
+	QueryProfiles(ctx context.Context, opt QueryProfilesOption) ([]Metadata, error)
+	RemoveProfiles(ctx context.Context, ids []string, opt RemoveProfilesOption) ([]DeviceProfile, error)
+	GetDeviceProfiles(ctx context.Context, opt GetDeviceProfilesOption) ([]DeviceProfile, error)
+	RolloutProfile(ctx context.Context, req RolloutRequest) (*Rollout, error)
 }
 
 type GetProfilesOption struct {
@@ -19,14 +23,43 @@ type Store interface {
 	Save(p *Profile) error
 	List() ([]Profile, error)
 	Delete(id string) error
+
+	SaveDeviceProfile(dp *DeviceProfile) error
+	DeviceProfile(udid, id string) (*DeviceProfile, error)
+	DeviceProfileByCommand(udid, commandUUID string) (*DeviceProfile, error)
+	DeviceProfiles(id string) ([]DeviceProfile, error)
+}
+
+type Option func(*ProfileService)
+
+// WithSigner lets signed profile templates be uploaded, which are signed
+// again with the identity of the server after rendering.
+func WithSigner(signer Signer) Option {
+	return func(svc *ProfileService) {
+		svc.signer = signer
+	}
 }
 
-func New(store Store) *ProfileService {
-	return &ProfileService{store: store}
+// WithCommandService enables rollouts and removing profiles from devices,
+// which queue InstallProfile and RemoveProfile commands with cmds.
+func WithCommandService(cmds CommandService) Option {
+	return func(svc *ProfileService) {
+		svc.commands = cmds
+	}
+}
+
+func New(store Store, opts ...Option) *ProfileService {
+	svc := &ProfileService{store: store}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
 type ProfileService struct {
-	store Store
+	store    Store
+	signer   Signer
+	commands CommandService
 }
 
 func IsNotFound(err error) bool {
@@ -0,0 +1,341 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"html"
+	"regexp"
+	"text/template"
+
+	"github.com/fullsailor/pkcs7"
+	"github.com/groob/plist"
+	"github.com/pkg/errors"
+
+	"github.com/micromdm/micromdm/pkg/secrets"
+)
+
+// Variables are the device values a templated profile is rendered with.
+// A profile is a template when it contains a placeholder of a variable
+// such as {{.SerialNumber}}, or of a function below. Other text in braces
+// does not make a profile a template. Custom device attributes are
+// available as
+// {{attr "email"}}, which fails to render when the attribute is not set.
+// Secrets such as passwords are available as {{secret "wifi/corp"}}, and
+// are only resolved when an InstallProfile command is created.
+type Variables struct {
+	UDID         string
+	SerialNumber string
+	DeviceName   string
+	Model        string
+	ModelName    string
+	ProductName  string
+	OSVersion    string
+	IMEI         string
+	MEID         string
+	Attributes   map[string]string
+}
+
+// VariablesFunc looks up the template variables of a device.
+type VariablesFunc func(ctx context.Context, udid string) (*Variables, error)
+
+// Signer signs profiles with the identity of the server.
+type Signer interface {
+	Sign(Mobileconfig) (Mobileconfig, error)
+}
+
+// validationVariables render a template when it is uploaded, to report
+// syntax errors and templates which don't render a valid profile.
+var validationVariables = Variables{
+	UDID:         "00000000-0000-0000-0000-000000000000",
+	SerialNumber: "C02000000000",
+	DeviceName:   "DeviceName",
+	Model:        "Model",
+	ModelName:    "ModelName",
+	ProductName:  "ProductName",
+	OSVersion:    "10.14",
+	IMEI:         "IMEI",
+	MEID:         "MEID",
+}
+
+// templatePlaceholder matches the start of the placeholders of Variables,
+// attributes and secrets. The quotes of plist encoders are escaped.
+var templatePlaceholder = regexp.MustCompile(
+	`{{-?\s*(\.(UDID|SerialNumber|DeviceName|Model|ModelName|ProductName|OSVersion|IMEI|MEID|Attributes)\b|(attr|secret)\s+("|&#34;|&quot;))`)
+
+// isTemplate reports whether the unsigned content of a mobileconfig has
+// placeholders.
+func isTemplate(content []byte) bool {
+	return templatePlaceholder.Match(content)
+}
+
+// validationProfile is signed to check the signing identity of the server.
+const validationProfile = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>PayloadIdentifier</key><string>validation</string></dict></plist>`
+
+// content returns the unsigned content of the mobileconfig.
+func (mc Mobileconfig) content() (content Mobileconfig, signed bool, err error) {
+	if len(mc) > 5 && string(mc[0:5]) != "<?xml" {
+		p7, err := pkcs7.Parse(mc)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "Mobileconfig is not XML nor PKCS7 parseable")
+		}
+		return p7.Content, true, nil
+	}
+	return mc, false, nil
+}
+
+// IsTemplate reports whether the mobileconfig has placeholders which are
+// rendered for each device.
+func (mc Mobileconfig) IsTemplate() bool {
+	content, _, err := mc.content()
+	return err == nil && isTemplate(content)
+}
+
+var secretReference = regexp.MustCompile(`{{-?\s*secret\s`)
+
+// HasSecrets reports whether the mobileconfig is a template which
+// references secrets. Profiles rendered from it contain the values of the
+// secrets, and must not be returned by the API.
+func (mc Mobileconfig) HasSecrets() bool {
+	content, _, err := mc.content()
+	return err == nil && secretReference.Match(content)
+}
+
+// secretFunc resolves the value of a secret for a profile template.
+type secretFunc func(name string) (string, error)
+
+// renderTemplate renders content with vars. Templates are checked with
+// strict false, which renders attributes and secrets as placeholders.
+// Otherwise secrets are resolved with secret, which may be nil if the
+// server has no secrets provider.
+func renderTemplate(content []byte, vars Variables, strict bool, secret secretFunc) (Mobileconfig, error) {
+	secretValue := func(name string) (string, error) {
+		if err := secrets.ValidateName(name); err != nil {
+			return "", err
+		}
+		if !strict {
+			return "secret", nil
+		}
+		if secret == nil {
+			return "", errors.Errorf("profile references secret %q, but the server has no secrets provider", name)
+		}
+		v, err := secret(name)
+		if err != nil {
+			return "", err
+		}
+		return escapeXML(v), nil
+	}
+	attr := func(key string) (string, error) {
+		if !strict {
+			return "attribute", nil
+		}
+		v, ok := vars.Attributes[key]
+		if !ok {
+			return "", errors.Errorf("device has no attribute %q", key)
+		}
+		return escapeXML(v), nil
+	}
+	tmpl, err := template.New("profile").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"attr": attr, "secret": secretValue}).
+		Parse(string(unescapeActions(content)))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse profile template")
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars.escaped()); err != nil {
+		return nil, errors.Wrap(err, "render profile template")
+	}
+	return Mobileconfig(buf.Bytes()), nil
+}
+
+var templateAction = regexp.MustCompile(`(?s){{.*?}}`)
+
+// unescapeActions replaces the XML character references in the actions of
+// a template with the characters. Plist encoders escape the quotes of
+// actions such as {{secret "wifi/corp"}} as &#34; or &quot;.
+func unescapeActions(content []byte) []byte {
+	return templateAction.ReplaceAllFunc(content, func(action []byte) []byte {
+		if !bytes.ContainsRune(action, '&') {
+			return action
+		}
+		return []byte(html.UnescapeString(string(action)))
+	})
+}
+
+// escaped returns the variables escaped for the XML of a profile.
+func (v Variables) escaped() Variables {
+	e := Variables{
+		UDID:         escapeXML(v.UDID),
+		SerialNumber: escapeXML(v.SerialNumber),
+		DeviceName:   escapeXML(v.DeviceName),
+		Model:        escapeXML(v.Model),
+		ModelName:    escapeXML(v.ModelName),
+		ProductName:  escapeXML(v.ProductName),
+		OSVersion:    escapeXML(v.OSVersion),
+		IMEI:         escapeXML(v.IMEI),
+		MEID:         escapeXML(v.MEID),
+		Attributes:   make(map[string]string, len(v.Attributes)),
+	}
+	for key, value := range v.Attributes {
+		e.Attributes[key] = escapeXML(value)
+	}
+	return e
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// validateTemplate renders a template with validationVariables and checks
+// that the result is a profile with the same PayloadIdentifier.
+func validateTemplate(mc Mobileconfig, identifier string) error {
+	content, _, err := mc.content()
+	if err != nil {
+		return err
+	}
+	rendered, err := renderTemplate(content, validationVariables, false, nil)
+	if err != nil {
+		return err
+	}
+	var pId payloadIdentifier
+	if err := plist.Unmarshal(rendered, &pId); err != nil {
+		return errors.Wrap(err, "rendered profile template is not a valid profile")
+	}
+	if pId.PayloadIdentifier != identifier {
+		return errors.New("PayloadIdentifier of a profile template must not have placeholders")
+	}
+	return nil
+}
+
+// Renderer prepares the profiles of InstallProfile commands for the device
+// the command is sent to. Templates are rendered, and profiles stored with
+// Encrypt are encrypted when encryption is enabled.
+type Renderer struct {
+	variables VariablesFunc
+	signer    Signer
+	secrets   secrets.Provider
+
+	profiles     profileStore
+	certificates CertificateFunc
+}
+
+type profileStore interface {
+	ProfileById(ctx context.Context, id string) (*Profile, error)
+}
+
+// RendererOption configures a Renderer.
+type RendererOption func(*Renderer)
+
+// WithEncryption encrypts the profiles stored with Encrypt in the store to
+// the device identity certificates returned by certificates.
+func WithEncryption(store profileStore, certificates CertificateFunc) RendererOption {
+	return func(r *Renderer) {
+		r.profiles = store
+		r.certificates = certificates
+	}
+}
+
+// WithSecrets resolves the secrets referenced by templates with provider.
+// Without a provider, rendering a template which references a secret fails.
+func WithSecrets(provider secrets.Provider) RendererOption {
+	return func(r *Renderer) {
+		r.secrets = provider
+	}
+}
+
+// NewRenderer creates a Renderer. The signer signs the profiles which were
+// signed before they were rendered or encrypted, and may be nil if the
+// server has no identity.
+func NewRenderer(variables VariablesFunc, signer Signer, opts ...RendererOption) *Renderer {
+	r := &Renderer{variables: variables, signer: signer}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Render returns payload prepared for the device, or payload unchanged if
+// it is neither a template nor encrypted.
+func (r *Renderer) Render(ctx context.Context, udid string, payload []byte) ([]byte, error) {
+	content, signed, err := Mobileconfig(payload).content()
+	if err != nil {
+		// not a profile the server can change.
+		return payload, nil
+	}
+	var changed bool
+	if isTemplate(content) {
+		vars, err := r.variables(ctx, udid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get profile template variables for udid %s", udid)
+		}
+		secret := func(name string) (string, error) {
+			return r.secret(ctx, name)
+		}
+		if content, err = renderTemplate(content, *vars, true, secret); err != nil {
+			return nil, errors.Wrapf(err, "render profile for udid %s", udid)
+		}
+		changed = true
+	}
+
+	encrypt, err := r.encrypt(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	if encrypt {
+		cert, err := r.certificates(ctx, udid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get identity certificate of udid %s", udid)
+		}
+		if content, err = content.EncryptPayloadContent(cert); err != nil {
+			return nil, errors.Wrapf(err, "encrypt profile for udid %s", udid)
+		}
+		changed = true
+	}
+
+	switch {
+	case !changed:
+		return payload, nil
+	case !signed:
+		return content, nil
+	case r.signer == nil:
+		return nil, errors.New("signed profile must be signed again for the device, but the server has no signing identity")
+	}
+	signedContent, err := r.signer.Sign(content)
+	return signedContent, errors.Wrap(err, "sign profile")
+}
+
+// secret resolves a secret referenced by a profile template. The error
+// names the secret, but never includes its value.
+func (r *Renderer) secret(ctx context.Context, name string) (string, error) {
+	if r.secrets == nil {
+		return "", errors.Errorf("profile references secret %q, but the server has no secrets provider", name)
+	}
+	value, err := r.secrets.Secret(ctx, name)
+	if secrets.IsNotFound(err) {
+		return "", errors.Errorf("secret %q not found", name)
+	}
+	return value, errors.Wrapf(err, "resolve secret %q", name)
+}
+
+// encrypt reports whether the profile is stored with Encrypt.
+func (r *Renderer) encrypt(ctx context.Context, content Mobileconfig) (bool, error) {
+	if r.profiles == nil {
+		return false, nil
+	}
+	id, err := content.GetPayloadIdentifier()
+	if err != nil {
+		return false, nil
+	}
+	p, err := r.profiles.ProfileById(ctx, id)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "get profile %s", id)
+	}
+	return p.Encrypt, nil
+}
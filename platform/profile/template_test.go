@@ -0,0 +1,162 @@
+package profile
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/micromdm/micromdm/pkg/secrets"
+)
+
+const templateProfile = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>PayloadContent</key>
+	<array>
+		<dict>
+			<key>EmailAddress</key>
+			<string>{{attr "email"}}</string>
+			<key>ComputerName</key>
+			<string>mac-{{.SerialNumber}}</string>
+		</dict>
+	</array>
+	<key>PayloadIdentifier</key>
+	<string>com.example.exchange</string>
+</dict>
+</plist>`
+
+func TestRenderTemplate(t *testing.T) {
+	mc := Mobileconfig(templateProfile)
+	if !mc.IsTemplate() {
+		t.Fatal("profile with placeholders is not a template")
+	}
+
+	vars := &Variables{
+		SerialNumber: "C02ABC",
+		Attributes:   map[string]string{"email": "j&j@example.com"},
+	}
+	variables := func(ctx context.Context, udid string) (*Variables, error) {
+		return vars, nil
+	}
+	ctx := context.Background()
+	r := NewRenderer(variables, nil)
+	payload, err := r.Render(ctx, "udid", mc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := Mobileconfig(payload)
+	for _, want := range []string{"<string>mac-C02ABC</string>", "<string>j&amp;j@example.com</string>"} {
+		if !strings.Contains(string(rendered), want) {
+			t.Errorf("rendered profile does not contain %s:\n%s", want, rendered)
+		}
+	}
+	if id, err := rendered.GetPayloadIdentifier(); err != nil || id != "com.example.exchange" {
+		t.Errorf("have identifier %q, err %v", id, err)
+	}
+
+	vars.Attributes = nil
+	if _, err := r.Render(ctx, "udid", mc); err == nil {
+		t.Error("rendered a template with a missing device attribute")
+	}
+}
+
+func TestRenderEscapedActions(t *testing.T) {
+	// actions written by a plist encoder have escaped quotes.
+	mc := Mobileconfig(strings.Replace(templateProfile, `{{attr "email"}}`, `{{attr &#34;email&#34;}} &amp; {{attr &quot;name&quot;}}`, 1))
+	variables := func(ctx context.Context, udid string) (*Variables, error) {
+		return &Variables{Attributes: map[string]string{"email": "j@example.com", "name": "J"}}, nil
+	}
+	rendered, err := NewRenderer(variables, nil).Render(context.Background(), "udid", mc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<string>j@example.com &amp; J</string>"; !strings.Contains(string(rendered), want) {
+		t.Errorf("rendered profile does not contain %s:\n%s", want, rendered)
+	}
+}
+
+func TestLiteralBraces(t *testing.T) {
+	mc := Mobileconfig(strings.Replace(templateProfile, `{{attr "email"}}`, `{{ not a placeholder`, 1))
+	mc = Mobileconfig(strings.Replace(string(mc), `{{.SerialNumber}}`, `{{.Serial}}`, 1))
+	if mc.IsTemplate() {
+		t.Fatal("profile without placeholders must not be a template")
+	}
+	if err := (&Profile{Identifier: "com.example.exchange", Mobileconfig: mc}).Validate(); err != nil {
+		t.Errorf("profile with literal braces: %s", err)
+	}
+	variables := func(ctx context.Context, udid string) (*Variables, error) {
+		return &Variables{}, nil
+	}
+	rendered, err := NewRenderer(variables, nil).Render(context.Background(), "udid", mc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rendered) != string(mc) {
+		t.Errorf("profile without placeholders must be sent unchanged:\n%s", rendered)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	p := &Profile{Identifier: "com.example.exchange", Mobileconfig: Mobileconfig(templateProfile)}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("valid template: %s", err)
+	}
+
+	p.Mobileconfig = Mobileconfig(strings.Replace(templateProfile, "{{.SerialNumber}}", "{{.SerialNumber", 1))
+	if err := p.Validate(); err == nil {
+		t.Error("template with a syntax error passed validation")
+	}
+
+	p.Mobileconfig = Mobileconfig(strings.Replace(templateProfile, "{{.SerialNumber}}", "{{.Serial}}", 1))
+	if err := p.Validate(); err == nil {
+		t.Error("template with an unknown variable passed validation")
+	}
+
+	p.Identifier = "com.example.{{.UDID}}"
+	p.Mobileconfig = Mobileconfig(strings.Replace(templateProfile, "com.example.exchange", p.Identifier, 1))
+	if err := p.Validate(); err == nil {
+		t.Error("template with a templated PayloadIdentifier passed validation")
+	}
+}
+
+type testSecrets map[string]string
+
+func (s testSecrets) Secret(ctx context.Context, name string) (string, error) {
+	if v, ok := s[name]; ok {
+		return v, nil
+	}
+	return secrets.Multi{}.Secret(ctx, name)
+}
+
+func TestRenderSecrets(t *testing.T) {
+	mc := Mobileconfig(strings.Replace(templateProfile, `{{attr "email"}}`, `{{secret "wifi/corp"}}`, 1))
+	if !mc.HasSecrets() || Mobileconfig(templateProfile).HasSecrets() {
+		t.Fatal("HasSecrets must only report the profile which references a secret")
+	}
+	if err := (&Profile{Identifier: "com.example.exchange", Mobileconfig: mc}).Validate(); err != nil {
+		t.Fatalf("valid template with a secret: %s", err)
+	}
+
+	variables := func(ctx context.Context, udid string) (*Variables, error) {
+		return &Variables{SerialNumber: "C02ABC"}, nil
+	}
+	ctx := context.Background()
+	r := NewRenderer(variables, nil, WithSecrets(testSecrets{"wifi/corp": "p<ss"}))
+	rendered, err := r.Render(ctx, "udid", mc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(rendered), "<string>p&lt;ss</string>") {
+		t.Errorf("rendered profile does not contain the escaped secret:\n%s", rendered)
+	}
+
+	r = NewRenderer(variables, nil, WithSecrets(testSecrets{}))
+	if _, err := r.Render(ctx, "udid", mc); err == nil || !strings.Contains(err.Error(), `secret "wifi/corp" not found`) {
+		t.Errorf("want secret not found error, have %v", err)
+	}
+	r = NewRenderer(variables, nil)
+	if _, err := r.Render(ctx, "udid", mc); err == nil || !strings.Contains(err.Error(), "no secrets provider") {
+		t.Errorf("want no secrets provider error, have %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package profile
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/mdm/mdm"
+	"github.com/micromdm/micromdm/platform/pubsub"
+)
+
+// Tracker records the version of a profile each device is sent, the
+// version each device acknowledged installing, and the removal of profiles
+// from devices. The command service calls CommandQueued for every command
+// it queues, and Run records the responses of the devices. Profiles
+// reported in ProfileList responses are recorded as installed.
+type Tracker struct {
+	store  Store
+	sub    pubsub.Subscriber
+	logger log.Logger
+	now    func() time.Time
+
+	mtx sync.Mutex
+	// profileLists are the UUIDs of the ProfileList commands queued for
+	// devices, with the time they were queued. Only their responses are
+	// decoded as profile lists.
+	profileLists map[string]time.Time
+}
+
+// profileListTTL is how long the tracker waits for the response to a
+// ProfileList command.
+const profileListTTL = 7 * 24 * time.Hour
+
+func NewTracker(store Store, sub pubsub.Subscriber, logger log.Logger) *Tracker {
+	return &Tracker{
+		store:        store,
+		sub:          sub,
+		logger:       logger,
+		now:          time.Now,
+		profileLists: make(map[string]time.Time),
+	}
+}
+
+// CommandQueued records the InstallProfile, RemoveProfile and ProfileList
+// commands queued for a device. The profile of an InstallProfile command is
+// the profile as requested, before it is rendered or signed for the device.
+func (t *Tracker) CommandQueued(ctx context.Context, udid string, payload *mdm.CommandPayload) error {
+	if payload == nil || payload.Command == nil {
+		return nil
+	}
+	switch {
+	case payload.Command.RequestType == "ProfileList":
+		t.mtx.Lock()
+		t.profileLists[payload.CommandUUID] = t.now()
+		t.mtx.Unlock()
+		return nil
+	case payload.Command.InstallProfile != nil:
+		return t.installQueued(ctx, udid, payload.CommandUUID, payload.Command.InstallProfile.Payload)
+	case payload.Command.RemoveProfile != nil:
+		return t.removeQueued(udid, payload.CommandUUID, payload.Command.RemoveProfile.Identifier)
+	}
+	return nil
+}
+
+// installQueued records that the current version of the profile in
+// payload was queued for the device. Profiles which are not stored on the
+// server are not tracked, and a profile which differs from the stored
+// version is recorded with an unknown version.
+func (t *Tracker) installQueued(ctx context.Context, udid, commandUUID string, payload []byte) error {
+	mc := Mobileconfig(payload)
+	id, err := mc.GetPayloadIdentifier()
+	if err != nil {
+		return errors.Wrap(err, "get identifier of sent profile")
+	}
+	p, err := t.store.ProfileById(ctx, id)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get sent profile %s", id)
+	}
+
+	dp, err := t.deviceProfile(udid, id)
+	if err != nil {
+		return err
+	}
+	dp.Status = DeviceProfilePending
+	dp.CommandUUID = commandUUID
+	dp.SentVersion = 0
+	if mc.Hash() == p.Hash {
+		dp.SentVersion = p.Version
+	}
+	dp.Sent = t.now().UTC()
+	return errors.Wrap(t.store.SaveDeviceProfile(dp), "save sent device profile")
+}
+
+// profileListQueued reports whether commandUUID is a ProfileList command
+// queued for a device. The command is forgotten unless the device answered
+// NotNow, because it will be sent again.
+func (t *Tracker) profileListQueued(commandUUID, status string) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	_, ok := t.profileLists[commandUUID]
+	if status != "NotNow" {
+		delete(t.profileLists, commandUUID)
+	}
+	return ok
+}
+
+// expireProfileLists forgets the ProfileList commands which were not
+// answered within profileListTTL.
+func (t *Tracker) expireProfileLists(now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for id, queued := range t.profileLists {
+		if now.Sub(queued) >= profileListTTL {
+			delete(t.profileLists, id)
+		}
+	}
+}
+
+// removeQueued records that the profile is being removed from the device.
+// The removal is tracked even if the profile is not stored on the server,
+// because it is usually deleted right after the removal is queued.
+func (t *Tracker) removeQueued(udid, commandUUID, id string) error {
+	dp, err := t.deviceProfile(udid, id)
+	if err != nil {
+		return err
+	}
+	dp.Status = DeviceProfileRemoving
+	dp.CommandUUID = commandUUID
+	return errors.Wrap(t.store.SaveDeviceProfile(dp), "save removed device profile")
+}
+
+func (t *Tracker) deviceProfile(udid, id string) (*DeviceProfile, error) {
+	dp, err := t.store.DeviceProfile(udid, id)
+	if IsNotFound(err) {
+		return &DeviceProfile{UDID: udid, Identifier: id}, nil
+	}
+	return dp, errors.Wrapf(err, "get profile %s of udid %s", id, udid)
+}
+
+func (t *Tracker) Run(ctx context.Context) error {
+	const subscription = "profile_tracker"
+	connectEvents, err := t.sub.Subscribe(ctx, subscription, mdmsvc.ConnectTopic)
+	if err != nil {
+		return errors.Wrapf(err, "subscribing %s to %s", subscription, mdmsvc.ConnectTopic)
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			t.expireProfileLists(t.now())
+		case ev := <-connectEvents:
+			if err := t.handleAcknowledge(ctx, ev.Message); err != nil {
+				level.Info(t.logger).Log(
+					"msg", "track profile acknowledgement",
+					"err", err,
+				)
+			}
+		}
+	}
+}
+
+func (t *Tracker) handleAcknowledge(ctx context.Context, message []byte) error {
+	var ev mdmsvc.AcknowledgeEvent
+	if err := mdmsvc.UnmarshalAcknowledgeEvent(message, &ev); err != nil {
+		return errors.Wrap(err, "unmarshal acknowledge event")
+	}
+	if ev.Response.Status == "Idle" {
+		return nil
+	}
+	if t.profileListQueued(ev.Response.CommandUUID, ev.Response.Status) {
+		if ev.Response.Status == "Acknowledged" {
+			return t.handleProfileList(ctx, ev.Response.UDID, ev.Raw)
+		}
+		return nil
+	}
+	if ev.Response.Status == "NotNow" {
+		return nil
+	}
+	dp, err := t.store.DeviceProfileByCommand(ev.Response.UDID, ev.Response.CommandUUID)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get device profile for command %s", ev.Response.CommandUUID)
+	}
+
+	dp.CommandUUID = ""
+	acknowledged := ev.Response.Status == "Acknowledged"
+	switch {
+	case dp.Status == DeviceProfileRemoving && acknowledged:
+		dp.Status = DeviceProfileRemoved
+	case dp.Status == DeviceProfileRemoving:
+		dp.Status = DeviceProfileRemoveFailed
+	case acknowledged:
+		dp.Status = DeviceProfileAcknowledged
+		dp.AcknowledgedVersion = dp.SentVersion
+		dp.Acknowledged = t.now().UTC()
+	default:
+		dp.Status = DeviceProfileError
+	}
+	return errors.Wrapf(t.store.SaveDeviceProfile(dp), "save %s device profile", dp.Status)
+}
+
+// handleProfileList records the profiles stored on the server which a
+// device reports as installed, but which it is not known to have.
+func (t *Tracker) handleProfileList(ctx context.Context, udid string, raw []byte) error {
+	resp, err := mdm.DecodeResponse("ProfileList", raw)
+	if err != nil {
+		return err
+	}
+	for _, item := range resp.(*mdm.ProfileListResponse).ProfileList {
+		if _, err := t.store.ProfileById(ctx, item.PayloadIdentifier); err != nil {
+			continue
+		}
+		dp, err := t.deviceProfile(udid, item.PayloadIdentifier)
+		if err != nil {
+			return err
+		}
+		if dp.Installed() {
+			continue
+		}
+		dp.Status = DeviceProfileAcknowledged
+		dp.CommandUUID = ""
+		dp.SentVersion = dp.AcknowledgedVersion
+		dp.Acknowledged = t.now().UTC()
+		if err := t.store.SaveDeviceProfile(dp); err != nil {
+			return errors.Wrap(err, "save installed device profile")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package profile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mdmsvc "github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/mdm/mdm"
+)
+
+type notFoundErr struct{}
+
+func (notFoundErr) Error() string  { return "not found" }
+func (notFoundErr) NotFound() bool { return true }
+
+// trackerStore keeps one profile and the device profiles in memory.
+type trackerStore struct {
+	Store
+	profile *Profile
+	devices map[string]DeviceProfile
+}
+
+func (s *trackerStore) ProfileById(ctx context.Context, id string) (*Profile, error) {
+	if s.profile == nil || s.profile.Identifier != id {
+		return nil, notFoundErr{}
+	}
+	return s.profile, nil
+}
+
+func (s *trackerStore) SaveDeviceProfile(dp *DeviceProfile) error {
+	s.devices[dp.UDID] = *dp
+	return nil
+}
+
+func (s *trackerStore) DeviceProfile(udid, id string) (*DeviceProfile, error) {
+	dp, ok := s.devices[udid]
+	if !ok {
+		return nil, notFoundErr{}
+	}
+	return &dp, nil
+}
+
+func (s *trackerStore) DeviceProfileByCommand(udid, commandUUID string) (*DeviceProfile, error) {
+	dp, ok := s.devices[udid]
+	if !ok || dp.CommandUUID != commandUUID {
+		return nil, notFoundErr{}
+	}
+	return &dp, nil
+}
+
+func acknowledge(t *testing.T, tr *Tracker, udid, commandUUID, status string, raw []byte) {
+	t.Helper()
+	msg, err := mdmsvc.MarshalAcknowledgeEvent(&mdmsvc.AcknowledgeEvent{
+		Time:     time.Now(),
+		Response: mdmsvc.Response{UDID: udid, CommandUUID: commandUUID, Status: status},
+		Raw:      raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.handleAcknowledge(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func queue(t *testing.T, tr *Tracker, udid, commandUUID string, cmd *mdm.Command) {
+	t.Helper()
+	payload := &mdm.CommandPayload{CommandUUID: commandUUID, Command: cmd}
+	if err := tr.CommandQueued(context.Background(), udid, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func installProfile(mc Mobileconfig) *mdm.Command {
+	return &mdm.Command{RequestType: "InstallProfile", InstallProfile: &mdm.InstallProfile{Payload: mc}}
+}
+
+func TestTracker(t *testing.T) {
+	mc := Mobileconfig(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>PayloadIdentifier</key><string>com.example.wifi</string></dict></plist>`)
+	store := &trackerStore{
+		profile: &Profile{Identifier: "com.example.wifi", Mobileconfig: mc, Version: 3, Hash: mc.Hash()},
+		devices: make(map[string]DeviceProfile),
+	}
+	tr := NewTracker(store, nil, nil)
+
+	queue(t, tr, "UDID-1", "command-1", installProfile(mc))
+	acknowledge(t, tr, "UDID-1", "command-1", "NotNow", nil)
+	if dp := store.devices["UDID-1"]; dp.Status != DeviceProfilePending || dp.SentVersion != 3 {
+		t.Errorf("have %+v, want version 3 pending", dp)
+	}
+	acknowledge(t, tr, "UDID-1", "command-1", "Acknowledged", nil)
+	if dp := store.devices["UDID-1"]; dp.Status != DeviceProfileAcknowledged || dp.AcknowledgedVersion != 3 || dp.CommandUUID != "" {
+		t.Errorf("have %+v, want version 3 acknowledged", dp)
+	}
+
+	store.profile.Version = 4
+	queue(t, tr, "UDID-1", "command-2", installProfile(mc))
+	acknowledge(t, tr, "UDID-1", "command-2", "Error", nil)
+	if dp := store.devices["UDID-1"]; dp.Status != DeviceProfileError || dp.SentVersion != 4 || dp.AcknowledgedVersion != 3 {
+		t.Errorf("have %+v, want version 4 failed and version 3 acknowledged", dp)
+	}
+
+	// a profile which isn't the stored version has an unknown version.
+	changed := Mobileconfig(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>PayloadIdentifier</key><string>com.example.wifi</string><key>PayloadVersion</key><integer>2</integer></dict></plist>`)
+	queue(t, tr, "UDID-3", "command-6", installProfile(changed))
+	if dp := store.devices["UDID-3"]; dp.Status != DeviceProfilePending || dp.SentVersion != 0 {
+		t.Errorf("have %+v, want unknown version pending", dp)
+	}
+
+	other := Mobileconfig(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>PayloadIdentifier</key><string>com.example.other</string></dict></plist>`)
+	queue(t, tr, "UDID-2", "command-3", installProfile(other))
+	if _, ok := store.devices["UDID-2"]; ok {
+		t.Error("profiles which are not stored on the server must not be tracked")
+	}
+
+	remove := &mdm.Command{RequestType: "RemoveProfile", RemoveProfile: &mdm.RemoveProfile{Identifier: "com.example.wifi"}}
+	queue(t, tr, "UDID-1", "command-4", remove)
+	acknowledge(t, tr, "UDID-1", "command-4", "Error", nil)
+	if dp := store.devices["UDID-1"]; dp.Status != DeviceProfileRemoveFailed || !dp.Installed() {
+		t.Errorf("have %+v, want failed removal", dp)
+	}
+	queue(t, tr, "UDID-1", "command-5", remove)
+	acknowledge(t, tr, "UDID-1", "command-5", "Acknowledged", nil)
+	if dp := store.devices["UDID-1"]; dp.Status != DeviceProfileRemoved || dp.Installed() {
+		t.Errorf("have %+v, want profile removed", dp)
+	}
+}
+
+func TestTrackerProfileList(t *testing.T) {
+	store := &trackerStore{
+		profile: &Profile{Identifier: "com.example.wifi", Version: 2},
+		devices: map[string]DeviceProfile{
+			"UDID-2": {UDID: "UDID-2", Identifier: "com.example.wifi", Status: DeviceProfileAcknowledged, SentVersion: 2, AcknowledgedVersion: 2},
+		},
+	}
+	tr := NewTracker(store, nil, nil)
+	raw := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+	<key>ProfileList</key>
+	<array>
+		<dict><key>PayloadIdentifier</key><string>com.example.wifi</string></dict>
+		<dict><key>PayloadIdentifier</key><string>com.example.other</string></dict>
+	</array>
+	<key>Status</key><string>Acknowledged</string>
+</dict></plist>`)
+
+	// responses to commands which were not queued as ProfileList are ignored.
+	acknowledge(t, tr, "UDID-1", "other-1", "Acknowledged", raw)
+	if len(store.devices) != 1 {
+		t.Fatalf("have %d device profiles, want the response to an unknown command ignored", len(store.devices))
+	}
+
+	profileList := &mdm.Command{RequestType: "ProfileList"}
+	queue(t, tr, "UDID-1", "list-1", profileList)
+	queue(t, tr, "UDID-2", "list-2", profileList)
+	acknowledge(t, tr, "UDID-1", "list-1", "NotNow", nil)
+	acknowledge(t, tr, "UDID-1", "list-1", "Acknowledged", raw)
+	acknowledge(t, tr, "UDID-2", "list-2", "Acknowledged", raw)
+	if len(tr.profileLists) != 0 {
+		t.Error("acknowledged ProfileList commands must be forgotten")
+	}
+	if dp := store.devices["UDID-1"]; !dp.Installed() || dp.SentVersion != 0 {
+		t.Errorf("have %+v, want profile of unknown version installed", dp)
+	}
+	if dp := store.devices["UDID-2"]; dp.SentVersion != 2 || dp.AcknowledgedVersion != 2 {
+		t.Errorf("have %+v, want known version kept", dp)
+	}
+	if len(store.devices) != 2 {
+		t.Errorf("have %d device profiles, want profiles which are not stored on the server ignored", len(store.devices))
+	}
+}
+
+func TestTrackerExpireProfileLists(t *testing.T) {
+	tr := NewTracker(&trackerStore{}, nil, nil)
+	now := time.Now()
+	tr.now = func() time.Time { return now }
+	queue(t, tr, "UDID-1", "list-1", &mdm.Command{RequestType: "ProfileList"})
+
+	tr.expireProfileLists(now.Add(profileListTTL - time.Minute))
+	if len(tr.profileLists) != 1 {
+		t.Fatal("want ProfileList command kept before the TTL")
+	}
+	tr.expireProfileLists(now.Add(profileListTTL))
+	if len(tr.profileLists) != 0 {
+		t.Error("want ProfileList command expired after the TTL")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gogo/protobuf/proto"
+	"github.com/groob/plist"
 	"github.com/pkg/errors"
 
 	"github.com/micromdm/micromdm/platform/queue/internal/devicecommandproto"
@@ -23,6 +24,20 @@ type Command struct {
 	FailureMessage []byte
 }
 
+// RequestType returns the MDM RequestType of the queued command payload.
+// An empty string is returned if the payload can not be decoded.
+func (c Command) RequestType() string {
+	var payload struct {
+		Command struct {
+			RequestType string
+		}
+	}
+	if err := plist.Unmarshal(c.Payload, &payload); err != nil {
+		return ""
+	}
+	return payload.Command.RequestType
+}
+
 type DeviceCommand struct {
 	DeviceUDID string
 	Commands   []Command
@@ -36,67 +51,10 @@ type DeviceCommand struct {
 func MarshalDeviceCommand(c *DeviceCommand) ([]byte, error) {
 	protoc := devicecommandproto.DeviceCommand{
 		DeviceUdid: c.DeviceUDID,
-	}
-
-	// TODO add helper here to reduce copy/pasted boilerplate.
-	for _, command := range c.Commands {
-		protoc.Commands = append(protoc.Commands, &devicecommandproto.Command{
-			Uuid:         command.UUID,
-			Payload:      command.Payload,
-			CreatedAt:    command.CreatedAt.UnixNano(),
-			LastSentAt:   command.LastSentAt.UnixNano(),
-			Acknowledged: command.Acknowledged.UnixNano(),
-
-			TimesSent: int64(command.TimesSent),
-
-			LastStatus:     command.LastStatus,
-			FailureMessage: command.FailureMessage,
-		})
-	}
-
-	for _, command := range c.Completed {
-		protoc.Completed = append(protoc.Completed, &devicecommandproto.Command{
-			Uuid:         command.UUID,
-			Payload:      command.Payload,
-			CreatedAt:    command.CreatedAt.UnixNano(),
-			LastSentAt:   command.LastSentAt.UnixNano(),
-			Acknowledged: command.Acknowledged.UnixNano(),
-
-			TimesSent: int64(command.TimesSent),
-
-			LastStatus:     command.LastStatus,
-			FailureMessage: command.FailureMessage,
-		})
-	}
-
-	for _, command := range c.Failed {
-		protoc.Failed = append(protoc.Failed, &devicecommandproto.Command{
-			Uuid:         command.UUID,
-			Payload:      command.Payload,
-			CreatedAt:    command.CreatedAt.UnixNano(),
-			LastSentAt:   command.LastSentAt.UnixNano(),
-			Acknowledged: command.Acknowledged.UnixNano(),
-
-			TimesSent: int64(command.TimesSent),
-
-			LastStatus:     command.LastStatus,
-			FailureMessage: command.FailureMessage,
-		})
-	}
-
-	for _, command := range c.NotNow {
-		protoc.NotNow = append(protoc.NotNow, &devicecommandproto.Command{
-			Uuid:         command.UUID,
-			Payload:      command.Payload,
-			CreatedAt:    command.CreatedAt.UnixNano(),
-			LastSentAt:   command.LastSentAt.UnixNano(),
-			Acknowledged: command.Acknowledged.UnixNano(),
-
-			TimesSent: int64(command.TimesSent),
-
-			LastStatus:     command.LastStatus,
-			FailureMessage: command.FailureMessage,
-		})
+		Commands:   commandsToProto(c.Commands),
+		Completed:  commandsToProto(c.Completed),
+		Failed:     commandsToProto(c.Failed),
+		NotNow:     commandsToProto(c.NotNow),
 	}
 	return proto.Marshal(&protoc)
 }
@@ -107,68 +65,63 @@ func UnmarshalDeviceCommand(data []byte, c *DeviceCommand) error {
 		return errors.Wrap(err, "unmarshal proto to DeviceCommand")
 	}
 	c.DeviceUDID = pb.GetDeviceUdid()
-	protoCommands := pb.GetCommands()
-	protoCommandsCompleted := pb.GetCompleted()
-	protoCommandsFailed := pb.GetFailed()
-	protoCommandsNotNow := pb.GetNotNow()
-	for _, command := range protoCommands {
-		c.Commands = append(c.Commands, Command{
-			UUID:         command.GetUuid(),
-			Payload:      command.GetPayload(),
-			CreatedAt:    time.Unix(0, command.GetCreatedAt()).UTC(),
-			LastSentAt:   time.Unix(0, command.GetLastSentAt()).UTC(),
-			Acknowledged: time.Unix(0, command.GetAcknowledged()).UTC(),
-
-			TimesSent: int(command.TimesSent),
-
-			LastStatus:     command.LastStatus,
-			FailureMessage: command.FailureMessage,
-		})
-	}
+	c.Commands = commandsFromProto(pb.GetCommands())
+	c.Completed = commandsFromProto(pb.GetCompleted())
+	c.Failed = commandsFromProto(pb.GetFailed())
+	c.NotNow = commandsFromProto(pb.GetNotNow())
+	return nil
+}
 
-	for _, command := range protoCommandsCompleted {
-		c.Completed = append(c.Completed, Command{
-			UUID:         command.GetUuid(),
-			Payload:      command.GetPayload(),
-			CreatedAt:    time.Unix(0, command.GetCreatedAt()).UTC(),
-			LastSentAt:   time.Unix(0, command.GetLastSentAt()).UTC(),
-			Acknowledged: time.Unix(0, command.GetAcknowledged()).UTC(),
+func commandsToProto(commands []Command) []*devicecommandproto.Command {
+	var pb []*devicecommandproto.Command
+	for _, command := range commands {
+		pb = append(pb, &devicecommandproto.Command{
+			Uuid:         command.UUID,
+			Payload:      command.Payload,
+			CreatedAt:    timeToNano(command.CreatedAt),
+			LastSentAt:   timeToNano(command.LastSentAt),
+			Acknowledged: timeToNano(command.Acknowledged),
 
-			TimesSent: int(command.TimesSent),
+			TimesSent: int64(command.TimesSent),
 
 			LastStatus:     command.LastStatus,
 			FailureMessage: command.FailureMessage,
 		})
 	}
+	return pb
+}
 
-	for _, command := range protoCommandsFailed {
-		c.Failed = append(c.Failed, Command{
+func commandsFromProto(pb []*devicecommandproto.Command) []Command {
+	var commands []Command
+	for _, command := range pb {
+		commands = append(commands, Command{
 			UUID:         command.GetUuid(),
 			Payload:      command.GetPayload(),
-			CreatedAt:    time.Unix(0, command.GetCreatedAt()).UTC(),
-			LastSentAt:   time.Unix(0, command.GetLastSentAt()).UTC(),
-			Acknowledged: time.Unix(0, command.GetAcknowledged()).UTC(),
+			CreatedAt:    nanoToTime(command.GetCreatedAt()),
+			LastSentAt:   nanoToTime(command.GetLastSentAt()),
+			Acknowledged: nanoToTime(command.GetAcknowledged()),
 
-			TimesSent: int(command.TimesSent),
+			TimesSent: int(command.GetTimesSent()),
 
-			LastStatus:     command.LastStatus,
-			FailureMessage: command.FailureMessage,
+			LastStatus:     command.GetLastStatus(),
+			FailureMessage: command.GetFailureMessage(),
 		})
 	}
+	return commands
+}
 
-	for _, command := range protoCommandsNotNow {
-		c.NotNow = append(c.NotNow, Command{
-			UUID:         command.GetUuid(),
-			Payload:      command.GetPayload(),
-			CreatedAt:    time.Unix(0, command.GetCreatedAt()).UTC(),
-			LastSentAt:   time.Unix(0, command.GetLastSentAt()).UTC(),
-			Acknowledged: time.Unix(0, command.GetAcknowledged()).UTC(),
-
-			TimesSent: int(command.TimesSent),
+// timeToNano converts t to unix nanoseconds, keeping the zero time as 0
+// so that unset timestamps survive a round trip through the proto.
+func timeToNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
 
-			LastStatus:     command.LastStatus,
-			FailureMessage: command.FailureMessage,
-		})
+func nanoToTime(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
 	}
-	return nil
+	return time.Unix(0, n).UTC()
 }
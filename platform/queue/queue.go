@@ -3,6 +3,8 @@ package queue
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -18,14 +20,23 @@ import (
 )
 
 const (
-	DeviceCommandBucket = "mdm.DeviceCommands"
+	DeviceCommandBucket   = "mdm.DeviceCommands"
+	CommandResponseBucket = "mdm.CommandResponses"
+
+	// CommandResponseTimeBucket indexes the command responses by the time
+	// they were saved, so that old responses can be pruned.
+	CommandResponseTimeBucket = "mdm.CommandResponseTimes"
 
 	CommandQueuedTopic = "mdm.CommandQueued"
 )
 
+// DefaultResponseRetention is how long command responses are kept.
+const DefaultResponseRetention = 90 * 24 * time.Hour
+
 type Store struct {
 	*bolt.DB
-	logger log.Logger
+	logger            log.Logger
+	responseRetention time.Duration
 }
 
 type Option func(*Store)
@@ -36,6 +47,15 @@ func WithLogger(logger log.Logger) Option {
 	}
 }
 
+// WithResponseRetention sets how long the raw responses of commands are
+// kept for the command history. The default is 90 days. Responses are
+// kept forever if d is 0.
+func WithResponseRetention(d time.Duration) Option {
+	return func(s *Store) {
+		s.responseRetention = d
+	}
+}
+
 func (db *Store) Next(ctx context.Context, resp mdm.Response) ([]byte, error) {
 	cmd, err := db.nextCommand(ctx, resp)
 	if err != nil {
@@ -68,6 +88,7 @@ func (db *Store) nextCommand(ctx context.Context, resp mdm.Response) (*Command,
 		return nil, errors.Wrapf(err, "get device command from queue, udid: %s", resp.UDID)
 	}
 
+	now := time.Now().UTC()
 	var cmd *Command
 	switch resp.Status {
 	case "NotNow":
@@ -78,6 +99,7 @@ func (db *Store) nextCommand(ctx context.Context, resp mdm.Response) (*Command,
 		if x == nil {
 			break
 		}
+		x.LastStatus = resp.Status
 		dc.NotNow = append(dc.NotNow, *x)
 
 	case "Acknowledged":
@@ -87,7 +109,8 @@ func (db *Store) nextCommand(ctx context.Context, resp mdm.Response) (*Command,
 		if x == nil {
 			break
 		}
-		x.Acknowledged = time.Now().UTC()
+		x.Acknowledged = now
+		x.LastStatus = resp.Status
 		dc.Completed = append(dc.Completed, *x)
 	case "Error":
 		// move to failed, send next
@@ -96,6 +119,9 @@ func (db *Store) nextCommand(ctx context.Context, resp mdm.Response) (*Command,
 		if x == nil { // must've already bin ackd
 			break
 		}
+		x.Acknowledged = now
+		x.LastStatus = resp.Status
+		x.FailureMessage = marshalErrorChain(resp.ErrorChain)
 		dc.Failed = append(dc.Failed, *x)
 
 	case "CommandFormatError":
@@ -105,6 +131,9 @@ func (db *Store) nextCommand(ctx context.Context, resp mdm.Response) (*Command,
 		if x == nil {
 			break
 		}
+		x.Acknowledged = now
+		x.LastStatus = resp.Status
+		x.FailureMessage = marshalErrorChain(resp.ErrorChain)
 		dc.Failed = append(dc.Failed, *x)
 
 	case "Idle":
@@ -119,13 +148,13 @@ func (db *Store) nextCommand(ctx context.Context, resp mdm.Response) (*Command,
 	// If the regular queue is empty, send a command that got
 	// refused with NotNow before.
 	cmd, dc.Commands = popFirst(dc.Commands)
+	if cmd == nil && resp.Status != "NotNow" {
+		cmd, dc.NotNow = popFirst(dc.NotNow)
+	}
 	if cmd != nil {
+		cmd.LastSentAt = now
+		cmd.TimesSent++
 		dc.Commands = append(dc.Commands, *cmd)
-	} else if resp.Status != "NotNow" {
-		cmd, dc.NotNow = popFirst(dc.NotNow)
-		if cmd != nil {
-			dc.Commands = append(dc.Commands, *cmd)
-		}
 	}
 
 	if err := db.Save(dc); err != nil {
@@ -144,6 +173,19 @@ func popFirst(all []Command) (*Command, []Command) {
 	return &first, all
 }
 
+// marshalErrorChain encodes the ErrorChain reported by the device so that it
+// can be kept with the failed command.
+func marshalErrorChain(chain []mdm.ErrorChainItem) []byte {
+	if len(chain) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(chain)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
 func cut(all []Command, uuid string) (*Command, []Command) {
 	for i, cmd := range all {
 		if cmd.UUID == uuid {
@@ -155,15 +197,11 @@ func cut(all []Command, uuid string) (*Command, []Command) {
 }
 
 func NewQueue(db *bolt.DB, pubsub pubsub.PublishSubscriber, opts ...Option) (*Store, error) {
-	err := db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(DeviceCommandBucket))
-		return err
-	})
-	if err != nil {
-		return nil, errors.Wrapf(err, "creating %s bucket", DeviceCommandBucket)
+	if err := createBuckets(db, time.Now().UTC()); err != nil {
+		return nil, err
 	}
 
-	datastore := &Store{DB: db, logger: log.NewNopLogger()}
+	datastore := &Store{DB: db, logger: log.NewNopLogger(), responseRetention: DefaultResponseRetention}
 	for _, fn := range opts {
 		fn(datastore)
 	}
@@ -172,9 +210,40 @@ func NewQueue(db *bolt.DB, pubsub pubsub.PublishSubscriber, opts ...Option) (*St
 		return nil, err
 	}
 
+	if err := datastore.pollResponses(pubsub); err != nil {
+		return nil, err
+	}
+
 	return datastore, nil
 }
 
+// createBuckets creates the buckets of the queue. Responses saved before
+// the time index existed are indexed at now.
+func createBuckets(db *bolt.DB, now time.Time) error {
+	for _, bucket := range []string{DeviceCommandBucket, CommandResponseBucket} {
+		err := db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+			return err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "creating %s bucket", bucket)
+		}
+	}
+	err := db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(CommandResponseTimeBucket)) != nil {
+			return nil
+		}
+		idx, err := tx.CreateBucket([]byte(CommandResponseTimeBucket))
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(CommandResponseBucket)).ForEach(func(k, v []byte) error {
+			return idx.Put(responseTimeKey(now, string(k)), k)
+		})
+	})
+	return errors.Wrapf(err, "creating %s bucket", CommandResponseTimeBucket)
+}
+
 func (db *Store) Save(cmd *DeviceCommand) error {
 	tx, err := db.DB.Begin(true)
 	if err != nil {
@@ -211,6 +280,73 @@ func (db *Store) DeviceCommand(udid string) (*DeviceCommand, error) {
 	return &dev, nil
 }
 
+// CommandResponse returns the raw response the device sent for a command.
+func (db *Store) CommandResponse(commandUUID string) ([]byte, error) {
+	var raw []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(CommandResponseBucket))
+		v := b.Get([]byte(commandUUID))
+		if v == nil {
+			return &notFound{"CommandResponse", fmt.Sprintf("command_uuid %s", commandUUID)}
+		}
+		raw = append(raw, v...)
+		return nil
+	})
+	return raw, err
+}
+
+// saveCommandResponse saves the response of a command, and removes the
+// responses older than the response retention, if any.
+func (db *Store) saveCommandResponse(commandUUID string, raw []byte, now time.Time) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(CommandResponseBucket))
+		if bkt == nil {
+			return fmt.Errorf("bucket %q not found!", CommandResponseBucket)
+		}
+		idx := tx.Bucket([]byte(CommandResponseTimeBucket))
+		if idx == nil {
+			return fmt.Errorf("bucket %q not found!", CommandResponseTimeBucket)
+		}
+		if err := bkt.Put([]byte(commandUUID), raw); err != nil {
+			return errors.Wrap(err, "put CommandResponse to boltdb")
+		}
+		if err := idx.Put(responseTimeKey(now, commandUUID), []byte(commandUUID)); err != nil {
+			return errors.Wrap(err, "put CommandResponse time to boltdb")
+		}
+		if db.responseRetention <= 0 {
+			return nil
+		}
+		return pruneCommandResponses(bkt, idx, now.Add(-db.responseRetention))
+	})
+}
+
+// responseTimeKey is the key of a response in the time index. Keys sort by
+// the time the response was saved.
+func responseTimeKey(t time.Time, commandUUID string) []byte {
+	key := make([]byte, 8, 8+len(commandUUID))
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return append(key, commandUUID...)
+}
+
+// pruneCommandResponses removes the responses saved before cutoff.
+func pruneCommandResponses(bkt, idx *bolt.Bucket, cutoff time.Time) error {
+	end := responseTimeKey(cutoff, "")
+	var expired [][]byte
+	c := idx.Cursor()
+	for k, _ := c.First(); k != nil && string(k) < string(end); k, _ = c.Next() {
+		expired = append(expired, k)
+	}
+	for _, k := range expired {
+		if err := bkt.Delete(idx.Get(k)); err != nil {
+			return errors.Wrap(err, "delete expired CommandResponse")
+		}
+		if err := idx.Delete(k); err != nil {
+			return errors.Wrap(err, "delete expired CommandResponse time")
+		}
+	}
+	return nil
+}
+
 type notFound struct {
 	ResourceType string
 	Message      string
@@ -220,6 +356,10 @@ func (e *notFound) Error() string {
 	return fmt.Sprintf("not found: %s %s", e.ResourceType, e.Message)
 }
 
+func (e *notFound) NotFound() bool {
+	return true
+}
+
 func (db *Store) pollCommands(pubsub pubsub.PublishSubscriber) error {
 	commandEvents, err := pubsub.Subscribe(context.TODO(), "command-queue", command.CommandTopic)
 	if err != nil {
@@ -248,8 +388,9 @@ func (db *Store) pollCommands(pubsub pubsub.PublishSubscriber) error {
 					continue
 				}
 				newCmd := Command{
-					UUID:    ev.Payload.CommandUUID,
-					Payload: newPayload,
+					UUID:      ev.Payload.CommandUUID,
+					Payload:   newPayload,
+					CreatedAt: time.Now().UTC(),
 				}
 				cmd.Commands = append(cmd.Commands, newCmd)
 				if err := db.Save(cmd); err != nil {
@@ -283,6 +424,41 @@ func (db *Store) pollCommands(pubsub pubsub.PublishSubscriber) error {
 	return nil
 }
 
+// pollResponses keeps the raw device response for every command result, so
+// that the command history can show what the device reported.
+func (db *Store) pollResponses(pubsub pubsub.PublishSubscriber) error {
+	connectEvents, err := pubsub.Subscribe(context.TODO(), "command-queue", mdm.ConnectTopic)
+	if err != nil {
+		return errors.Wrapf(err,
+			"subscribing push to %s topic", mdm.ConnectTopic)
+	}
+	go func() {
+		for {
+			select {
+			case event := <-connectEvents:
+				var ev mdm.AcknowledgeEvent
+				if err := mdm.UnmarshalAcknowledgeEvent(event.Message, &ev); err != nil {
+					level.Info(db.logger).Log("msg", "unmarshal acknowledge event in queue", "err", err)
+					continue
+				}
+				switch ev.Response.Status {
+				case "Acknowledged", "Error", "CommandFormatError":
+				default:
+					continue
+				}
+				if ev.Response.CommandUUID == "" || len(ev.Raw) == 0 {
+					continue
+				}
+				if err := db.saveCommandResponse(ev.Response.CommandUUID, ev.Raw, time.Now().UTC()); err != nil {
+					level.Info(db.logger).Log("msg", "save command response in db", "err", err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
 func isNotFound(err error) bool {
 	if _, ok := err.(*notFound); ok {
 		return true
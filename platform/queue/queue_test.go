@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/go-kit/kit/log"
@@ -155,6 +156,121 @@ func TestNext_zeroCommands(t *testing.T) {
 
 }
 
+func TestNext_tracksCommandState(t *testing.T) {
+	store, teardown := setupDB(t)
+	defer teardown()
+
+	dc := &DeviceCommand{DeviceUDID: "TestDevice"}
+	dc.Commands = append(dc.Commands, Command{UUID: "xCmd"})
+	dc.Commands = append(dc.Commands, Command{UUID: "yCmd"})
+	if err := store.Save(dc); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	cmd, err := store.nextCommand(ctx, mdm.Response{UDID: dc.DeviceUDID, Status: "Idle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.TimesSent != 1 || cmd.LastSentAt.IsZero() {
+		t.Errorf("expected sent command to be recorded, got TimesSent %d, LastSentAt %s", cmd.TimesSent, cmd.LastSentAt)
+	}
+
+	if _, err := store.nextCommand(ctx, mdm.Response{UDID: dc.DeviceUDID, CommandUUID: "xCmd", Status: "Acknowledged"}); err != nil {
+		t.Fatal(err)
+	}
+	chain := []mdm.ErrorChainItem{{ErrorCode: 12021, ErrorDomain: "MCMDMErrorDomain"}}
+	if _, err := store.nextCommand(ctx, mdm.Response{UDID: dc.DeviceUDID, CommandUUID: "yCmd", Status: "Error", ErrorChain: chain}); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := store.DeviceCommand(dc.DeviceUDID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(saved.Completed), 1; have != want {
+		t.Fatalf("have %d completed commands, want %d", have, want)
+	}
+	if have, want := saved.Completed[0].LastStatus, "Acknowledged"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+	if saved.Completed[0].Acknowledged.IsZero() {
+		t.Error("expected acknowledged time to be set")
+	}
+	if have, want := len(saved.Failed), 1; have != want {
+		t.Fatalf("have %d failed commands, want %d", have, want)
+	}
+	if have, want := saved.Failed[0].LastStatus, "Error"; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+	if len(saved.Failed[0].FailureMessage) == 0 {
+		t.Error("expected ErrorChain to be stored with the failed command")
+	}
+}
+
+func TestCommandResponseRetention(t *testing.T) {
+	store, teardown := setupDB(t)
+	defer teardown()
+	store.responseRetention = time.Hour
+
+	start := time.Now()
+	saves := []struct {
+		uuid string
+		at   time.Time
+	}{
+		{"old", start},
+		{"recent", start.Add(30 * time.Minute)},
+		{"new", start.Add(time.Hour + time.Minute)},
+	}
+	for _, s := range saves {
+		if err := store.saveCommandResponse(s.uuid, []byte(s.uuid), s.at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := store.CommandResponse("old"); !isNotFound(err) {
+		t.Errorf("want response older than the retention pruned, have err %v", err)
+	}
+	for _, uuid := range []string{"recent", "new"} {
+		raw, err := store.CommandResponse(uuid)
+		if err != nil {
+			t.Fatalf("get response %s: %s", uuid, err)
+		}
+		if string(raw) != uuid {
+			t.Errorf("have response %q, want %q", raw, uuid)
+		}
+	}
+
+	var indexed int
+	err := store.View(func(tx *bolt.Tx) error {
+		indexed = tx.Bucket([]byte(CommandResponseTimeBucket)).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := indexed, 2; have != want {
+		t.Errorf("have %d indexed responses, want %d", have, want)
+	}
+}
+
+func TestCommandResponseRetentionDisabled(t *testing.T) {
+	store, teardown := setupDB(t)
+	defer teardown()
+	store.responseRetention = 0
+
+	start := time.Now()
+	if err := store.saveCommandResponse("old", []byte("old"), start); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.saveCommandResponse("new", []byte("new"), start.Add(365*24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CommandResponse("old"); err != nil {
+		t.Errorf("want response kept without a retention, have err %v", err)
+	}
+}
+
 func setupDB(t *testing.T) (*Store, func()) {
 	f, _ := ioutil.TempFile("", "bolt-")
 	teardown := func() {
@@ -166,13 +282,9 @@ func setupDB(t *testing.T) (*Store, func()) {
 	if err != nil {
 		t.Fatalf("couldn't open bolt, err %s\n", err)
 	}
-	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(DeviceCommandBucket))
-		return err
-	})
-	if err != nil {
+	if err := createBuckets(db, time.Now()); err != nil {
 		t.Fatal(err)
 	}
-	store := &Store{DB: db, logger: log.NewNopLogger()}
+	store := &Store{DB: db, logger: log.NewNopLogger(), responseRetention: DefaultResponseRetention}
 	return store, teardown
 }
@@ -1,14 +1,20 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
 
+	"github.com/boltdb/bolt"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	boltdepot "github.com/micromdm/scep/depot/bolt"
 	"github.com/pkg/errors"
 
 	"github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/platform/device"
+	"github.com/micromdm/micromdm/platform/profile"
 )
 
 type ScepVerifyDepot interface {
@@ -64,3 +70,78 @@ func (mw *verifyCertificateMiddleware) Checkin(ctx context.Context, req mdm.Chec
 	}
 	return mw.next.Checkin(ctx, req)
 }
+
+// IdentityCertStore stores the identity certificate of each device, and
+// its hash.
+type IdentityCertStore interface {
+	device.UDIDCertAuthStore
+	GetUDIDCert(udid []byte) ([]byte, error)
+}
+
+// DeviceIdentityCertificate returns the identity certificate the
+// UDIDCertAuthMiddleware saved when the device checked in. The certificate
+// of a device enrolled before certificates were saved is looked up in the
+// SCEP depot by its hash once, and saved.
+func DeviceIdentityCertificate(store IdentityCertStore, depot *boltdepot.Depot) profile.CertificateFunc {
+	return func(ctx context.Context, udid string) (*x509.Certificate, error) {
+		der, err := store.GetUDIDCert([]byte(udid))
+		if err == nil {
+			cert, err := x509.ParseCertificate(der)
+			return cert, errors.Wrapf(err, "parse certificate of udid %s", udid)
+		}
+		if !isNotFound(err) {
+			return nil, errors.Wrap(err, "get device certificate")
+		}
+
+		hash, err := store.GetUDIDCertHash([]byte(udid))
+		if err != nil {
+			return nil, errors.Wrap(err, "get device certificate hash")
+		}
+		cert, err := depotCertificate(depot, hash)
+		if err != nil {
+			return nil, err
+		}
+		if cert == nil {
+			return nil, errors.Errorf("no certificate for udid %s in scep depot", udid)
+		}
+		if err := store.SaveUDIDCert([]byte(udid), cert.Raw); err != nil {
+			return nil, errors.Wrap(err, "save device certificate")
+		}
+		return cert, nil
+	}
+}
+
+// depotCertificate returns the certificate with the SHA-256 hash in the
+// SCEP depot, or nil.
+func depotCertificate(depot *boltdepot.Depot, hash []byte) (*x509.Certificate, error) {
+	var cert *x509.Certificate
+	err := depot.View(func(tx *bolt.Tx) error {
+		// TODO: "scep_certificates" is internal const in micromdm/scep
+		bkt := tx.Bucket([]byte("scep_certificates"))
+		if bkt == nil {
+			return errors.New("scep_certificates bucket not found")
+		}
+		return bkt.ForEach(func(k, v []byte) error {
+			if cert != nil {
+				return nil
+			}
+			sum := sha256.Sum256(v)
+			if !bytes.Equal(sum[:], hash) {
+				return nil
+			}
+			var err error
+			cert, err = x509.ParseCertificate(append([]byte(nil), v...))
+			return err
+		})
+	})
+	return cert, errors.Wrap(err, "find device certificate in scep depot")
+}
+
+func isNotFound(err error) bool {
+	type notFoundError interface {
+		error
+		NotFound() bool
+	}
+	e, ok := errors.Cause(err).(notFoundError)
+	return ok && e.NotFound()
+}
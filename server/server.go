@@ -18,6 +18,7 @@ import (
 	"github.com/micromdm/micromdm/dep"
 	"github.com/micromdm/micromdm/mdm"
 	"github.com/micromdm/micromdm/mdm/enroll"
+	"github.com/micromdm/micromdm/pkg/secrets"
 	"github.com/micromdm/micromdm/platform/apns"
 	apnsbuiltin "github.com/micromdm/micromdm/platform/apns/builtin"
 	"github.com/micromdm/micromdm/platform/command"
@@ -49,10 +50,20 @@ type Server struct {
 	SCEPDepot          *boltdepot.Depot
 	ProfileDB          profile.Store
 	ConfigDB           config.Store
+	ProfileSigner      *config.ProfileSigner
 	RemoveDB           block.Store
 	CommandWebhookURL  string
 	DEPClient          *dep.Client
 	SyncDB             *syncbuiltin.DB
+	CommandQueue       *queue.Store
+
+	// CommandResponseRetention is how long the responses of commands are
+	// kept for the device command history. Kept forever if 0.
+	CommandResponseRetention time.Duration
+
+	// Secrets resolves the secrets referenced by profile templates when
+	// InstallProfile commands are created. Optional.
+	Secrets secrets.Provider
 
 	APNSPushService apns.Service
 	CommandService  command.Service
@@ -89,23 +100,23 @@ func (c *Server) Setup(logger log.Logger) error {
 		return err
 	}
 
-	if err := c.setupCommandService(); err != nil {
+	if err := c.setupProfileDB(); err != nil {
 		return err
 	}
 
-	if err := c.setupWebhooks(logger); err != nil {
+	if err := c.setupCommandService(logger); err != nil {
 		return err
 	}
 
-	if err := c.setupCommandQueue(logger); err != nil {
+	if err := c.setupWebhooks(logger); err != nil {
 		return err
 	}
 
-	if err := c.setupDepClient(); err != nil {
+	if err := c.setupCommandQueue(logger); err != nil {
 		return err
 	}
 
-	if err := c.setupProfileDB(); err != nil {
+	if err := c.setupDepClient(); err != nil {
 		return err
 	}
 
@@ -148,8 +159,25 @@ func (c *Server) setupRemoveService() error {
 	return nil
 }
 
-func (c *Server) setupCommandService() error {
-	commandService, err := command.New(c.PubClient)
+func (c *Server) setupCommandService(logger log.Logger) error {
+	devDB, err := devicebuiltin.NewDB(c.DB)
+	if err != nil {
+		return errors.Wrap(err, "new device db")
+	}
+	renderer := profile.NewRenderer(
+		device.ProfileVariablesFunc(devDB),
+		c.ProfileSigner,
+		profile.WithEncryption(c.ProfileDB, DeviceIdentityCertificate(devDB, c.SCEPDepot)),
+		profile.WithSecrets(c.Secrets),
+	)
+	tracker := profile.NewTracker(c.ProfileDB, c.PubClient, log.With(logger, "component", "profile_tracker"))
+	go tracker.Run(context.Background())
+	commandService, err := command.New(c.PubClient,
+		command.WithProfileRenderer(renderer),
+		command.WithProfileSigner(c.ProfileSigner),
+		command.WithProfileTracker(tracker),
+		command.WithLogger(log.With(logger, "component", "command")),
+	)
 	if err != nil {
 		return err
 	}
@@ -158,10 +186,14 @@ func (c *Server) setupCommandService() error {
 }
 
 func (c *Server) setupCommandQueue(logger log.Logger) error {
-	q, err := queue.NewQueue(c.DB, c.PubClient, queue.WithLogger(logger))
+	q, err := queue.NewQueue(c.DB, c.PubClient,
+		queue.WithLogger(logger),
+		queue.WithResponseRetention(c.CommandResponseRetention),
+	)
 	if err != nil {
 		return err
 	}
+	c.CommandQueue = q
 	devDB, err := devicebuiltin.NewDB(c.DB)
 	if err != nil {
 		return errors.Wrap(err, "new device db")
@@ -207,6 +239,7 @@ func (c *Server) setupConfigStore() error {
 	}
 	c.ConfigDB = db
 	c.ConfigService = config.New(db)
+	c.ProfileSigner = config.NewProfileSigner(db)
 
 	return nil
 }
@@ -247,6 +280,7 @@ func (c *Server) setupEnrollmentService() error {
 		c.TLSCertPath,
 		SCEPCertificateSubject,
 		c.ProfileDB,
+		enroll.WithProfileSigner(c.ProfileSigner),
 	)
 	return errors.Wrap(err, "setting up enrollment service")
 }
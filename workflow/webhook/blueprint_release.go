@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/micromdm/micromdm/platform/blueprint"
+)
+
+// BlueprintReleaseEvent is sent when a device is held in Setup Assistant,
+// because a command required by its blueprints failed or timed out.
+type BlueprintReleaseEvent struct {
+	UDID     string                     `json:"udid"`
+	State    string                     `json:"state"`
+	Reason   string                     `json:"reason,omitempty"`
+	Started  time.Time                  `json:"started"`
+	Commands []blueprint.TrackedCommand `json:"commands,omitempty"`
+}
+
+func blueprintReleaseEvent(topic string, data []byte) (*Event, error) {
+	var rel blueprint.DeviceRelease
+	if err := blueprint.UnmarshalDeviceRelease(data, &rel); err != nil {
+		return nil, errors.Wrap(err, "unmarshal device release for webhook")
+	}
+	webhookEvent := Event{
+		Topic:     topic,
+		EventID:   uuid.NewV4().String(),
+		CreatedAt: rel.Updated,
+
+		BlueprintReleaseEvent: &BlueprintReleaseEvent{
+			UDID:     rel.UDID,
+			State:    string(rel.State),
+			Reason:   rel.Reason,
+			Started:  rel.Started,
+			Commands: rel.Commands,
+		},
+	}
+	return &webhookEvent, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/micromdm/micromdm/mdm"
+	"github.com/micromdm/micromdm/platform/blueprint"
 	"github.com/micromdm/micromdm/platform/pubsub"
 )
 
@@ -18,8 +19,9 @@ type Event struct {
 	EventID   string    `json:"event_id"`
 	CreatedAt time.Time `json:"created_at"`
 
-	AcknowledgeEvent *AcknowledgeEvent `json:"acknowledge_event,omitempty"`
-	CheckinEvent     *CheckinEvent     `json:"checkin_event,omitempty"`
+	AcknowledgeEvent      *AcknowledgeEvent      `json:"acknowledge_event,omitempty"`
+	CheckinEvent          *CheckinEvent          `json:"checkin_event,omitempty"`
+	BlueprintReleaseEvent *BlueprintReleaseEvent `json:"blueprint_release_event,omitempty"`
 }
 
 type Worker struct {
@@ -81,6 +83,11 @@ func (w *Worker) Run(ctx context.Context) error {
 		return errors.Wrapf(err, "subscribe %s to %s", subscription, mdm.CheckoutTopic)
 	}
 
+	releaseHeldEvents, err := w.sub.Subscribe(ctx, subscription, blueprint.ReleaseHeldTopic)
+	if err != nil {
+		return errors.Wrapf(err, "subscribe %s to %s", subscription, blueprint.ReleaseHeldTopic)
+	}
+
 	for {
 		var (
 			event *Event
@@ -97,6 +104,8 @@ func (w *Worker) Run(ctx context.Context) error {
 			event, err = checkinEvent(ev.Topic, ev.Message)
 		case ev := <-checkoutEvents:
 			event, err = checkinEvent(ev.Topic, ev.Message)
+		case ev := <-releaseHeldEvents:
+			event, err = blueprintReleaseEvent(ev.Topic, ev.Message)
 		}
 
 		if err != nil {
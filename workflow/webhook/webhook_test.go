@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/micromdm/micromdm/platform/blueprint"
+	"github.com/micromdm/micromdm/platform/pubsub/inmem"
+)
+
+func TestBlueprintReleaseHeld(t *testing.T) {
+	events := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decode webhook event: %s", err)
+		}
+		select {
+		case events <- ev:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubsub := inmem.NewPubSub()
+	worker := New(srv.URL, pubsub)
+	go worker.Run(ctx)
+
+	now := time.Now().UTC()
+	rel := &blueprint.DeviceRelease{
+		UDID:    "UDID-1",
+		State:   blueprint.ReleaseHeld,
+		Reason:  "InstallProfile command timed out",
+		Started: now.Add(-time.Hour),
+		Updated: now,
+		Commands: []blueprint.TrackedCommand{
+			{CommandUUID: "cmd-1", RequestType: "InstallProfile", Status: blueprint.CommandPending},
+		},
+	}
+	data, err := blueprint.MarshalDeviceRelease(rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the worker subscribes asynchronously, so publish until the event arrives.
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-ticker.C:
+			if err := pubsub.Publish(ctx, blueprint.ReleaseHeldTopic, data); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		case <-timeout:
+			t.Fatal("timed out waiting for webhook event")
+		case ev := <-events:
+			if have, want := ev.Topic, blueprint.ReleaseHeldTopic; have != want {
+				t.Errorf("have topic %q, want %q", have, want)
+			}
+			if ev.BlueprintReleaseEvent == nil {
+				t.Fatal("missing blueprint_release_event")
+			}
+			if have, want := ev.BlueprintReleaseEvent.UDID, rel.UDID; have != want {
+				t.Errorf("have udid %q, want %q", have, want)
+			}
+			if have, want := ev.BlueprintReleaseEvent.State, "held"; have != want {
+				t.Errorf("have state %q, want %q", have, want)
+			}
+			if have, want := len(ev.BlueprintReleaseEvent.Commands), 1; have != want {
+				t.Errorf("have %d commands, want %d", have, want)
+			}
+		}
+		return
+	}
+}